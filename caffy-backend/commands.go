@@ -0,0 +1,245 @@
+package main
+
+import (
+	"caffy-backend/services"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+)
+
+// runCommand : 서버 대신 실행할 관리 명령 (명령을 처리했으면 true)
+// 사용법:
+//
+//	go run . gc [--dry-run]
+//	go run . import-catalog [--dry-run] [--format csv|json] <파일>
+//	go run . export-catalog [--format csv|json] [--out 파일]
+//	go run . create-admin --email 이메일 --password 비밀번호 [--nickname 닉네임]
+//	go run . benchmark [--limit N] [--verified-only] [--out 결과.json] [--baseline 이전결과.json] <데이터셋 폴더>
+//	go run . export-dataset [--split 0.8,0.1,0.1] [--seed N] [--min-images N] [--dry-run] <내보낼 폴더>
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "gc":
+		runImageGCCommand(args[1:])
+	case "import-catalog":
+		runImportCatalogCommand(args[1:])
+	case "export-catalog":
+		runExportCatalogCommand(args[1:])
+	case "create-admin":
+		runCreateAdminCommand(args[1:])
+	case "benchmark":
+		runBenchmarkCommand(args[1:])
+	case "export-dataset":
+		runExportDatasetCommand(args[1:])
+	default:
+		log.Fatalf("❌ 알 수 없는 명령: %s (사용 가능: gc, import-catalog, export-catalog, create-admin, benchmark, export-dataset)", args[0])
+	}
+	return true
+}
+
+// runImageGCCommand : 이미지 정리 작업 1회 실행 후 리포트 출력
+func runImageGCCommand(args []string) {
+	flags := flag.NewFlagSet("gc", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "삭제하지 않고 정리 대상만 출력")
+	flags.Parse(args)
+
+	report, err := services.RunImageGC(*dryRun)
+	if err != nil {
+		log.Fatalf("❌ 이미지 정리 실패: %v", err)
+	}
+	printReport(report)
+}
+
+// runImportCatalogCommand : 카탈로그 파일 가져오기 후 리포트 출력 (실패한 음료가 있으면 종료 코드 1)
+func runImportCatalogCommand(args []string) {
+	flags := flag.NewFlagSet("import-catalog", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "저장하지 않고 검증 결과와 변경 내용만 출력")
+	format := flags.String("format", "", "파일 형식 (csv, json - 비우면 확장자로 판별)")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		log.Fatalf("❌ 사용법: import-catalog [--dry-run] [--format csv|json] <파일>")
+	}
+
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = services.CatalogFormatFromName(path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("❌ 파일을 열 수 없습니다: %v", err)
+	}
+	defer file.Close()
+
+	items, err := services.ParseCatalog(file, services.CatalogFormatFromName(*format))
+	if err != nil {
+		log.Fatalf("❌ 카탈로그 읽기 실패: %v", err)
+	}
+	report, err := services.ImportCatalog(items, services.CatalogImportOptions{DryRun: *dryRun})
+	if err != nil {
+		log.Fatalf("❌ 카탈로그 가져오기 실패: %v", err)
+	}
+	printReport(report)
+
+	if report.Failed > 0 {
+		file.Close()
+		os.Exit(1)
+	}
+}
+
+// runExportCatalogCommand : 카탈로그를 파일(또는 표준 출력)로 내보내기
+func runExportCatalogCommand(args []string) {
+	flags := flag.NewFlagSet("export-catalog", flag.ExitOnError)
+	format := flags.String("format", "", "파일 형식 (csv, json - 비우면 --out 확장자, 그것도 없으면 json)")
+	out := flags.String("out", "", "저장할 파일 (비우면 표준 출력)")
+	flags.Parse(args)
+
+	if *format == "" {
+		*format = services.CatalogFormatFromName(*out)
+	}
+	if *format == "" {
+		*format = services.CatalogFormatJSON
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("❌ 파일을 만들 수 없습니다: %v", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	count, err := services.ExportCatalog(w, services.CatalogFormatFromName(*format))
+	if err != nil {
+		log.Fatalf("❌ 카탈로그 내보내기 실패: %v", err)
+	}
+	log.Printf("📦 음료 %d개 내보내기 완료", count)
+}
+
+// runCreateAdminCommand : 관리자 계정 만들기 (이미 가입한 이메일이면 admin으로 승격)
+func runCreateAdminCommand(args []string) {
+	flags := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	email := flags.String("email", "", "관리자 이메일")
+	password := flags.String("password", "", "비밀번호 (6자 이상, 이미 가입한 이메일이면 무시)")
+	nickname := flags.String("nickname", "", "닉네임 (비우면 이메일 앞부분)")
+	flags.Parse(args)
+
+	user, created, err := services.CreateAdmin(*email, *password, *nickname)
+	if err != nil {
+		log.Fatalf("❌ 관리자 만들기 실패: %v", err)
+	}
+	if created {
+		log.Printf("👑 관리자 계정 생성: %s (ID %d)", user.Email, user.ID)
+	} else {
+		log.Printf("👑 기존 사용자를 관리자로 변경: %s (ID %d)", user.Email, user.ID)
+	}
+}
+
+// runBenchmarkCommand : 라벨이 달린 이미지 세트로 인식 체인을 평가하고 결과를 JSON으로 저장
+// 데이터셋 폴더는 caffy-ai 형식 (images/ + labels.json)
+func runBenchmarkCommand(args []string) {
+	flags := flag.NewFlagSet("benchmark", flag.ExitOnError)
+	limit := flags.Int("limit", 0, "평가할 최대 이미지 수 (0이면 전부)")
+	verifiedOnly := flags.Bool("verified-only", false, "검증된 라벨만 평가")
+	out := flags.String("out", "", "결과를 저장할 파일 (비우면 표준 출력)")
+	baseline := flags.String("baseline", "", "비교할 이전 결과 파일")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		log.Fatalf("❌ 사용법: benchmark [--limit N] [--verified-only] [--out 결과.json] [--baseline 이전결과.json] <데이터셋 폴더>")
+	}
+
+	// 실행 전에 읽어 두어 잘못된 경로로 프로바이더 비용을 쓰지 않도록 함
+	var previous *services.BenchmarkReport
+	if *baseline != "" {
+		var err error
+		if previous, err = services.LoadBenchmarkReport(*baseline); err != nil {
+			log.Fatalf("❌ 이전 결과를 읽을 수 없습니다: %v", err)
+		}
+	}
+
+	dataset, err := services.LoadBenchmarkDataset(flags.Arg(0), *verifiedOnly)
+	if err != nil {
+		log.Fatalf("❌ 데이터셋 읽기 실패: %v", err)
+	}
+	if len(dataset.Items) == 0 {
+		log.Fatalf("❌ 평가할 이미지가 없습니다 (건너뜀 %d개)", len(dataset.Skipped))
+	}
+
+	report := services.RunBenchmark(dataset, services.BenchmarkOptions{Limit: *limit})
+	if previous != nil {
+		report.Comparison = services.CompareBenchmarkReports(previous, *baseline, report)
+	}
+
+	log.Printf("📊 이미지 %d개 (실패 %d, 건너뜀 %d) | 이름 정확도 %.1f%% | 별칭 포함 %.1f%% | 카페인 평균 오차 %.1fmg | 평균 %.0fms | $%.4f",
+		report.Total, report.Failed, len(report.Skipped),
+		report.NameAccuracy*100, report.AliasAccuracy*100, report.CaffeineError.Mean, report.LatencyMS.Mean, report.CostUSD)
+	for _, stats := range report.ProviderStats {
+		log.Printf("   %s: 호출 %d, 응답 %d, 실패 %d, 건너뜀 %d | p50 %.0fms, p95 %.0fms | 토큰 %d/%d | $%.4f",
+			stats.Provider, stats.Calls, stats.Answered, stats.Failed, stats.Skipped,
+			stats.LatencyMS.Median, stats.LatencyMS.P95, stats.InputTokens, stats.OutputTokens, stats.CostUSD)
+	}
+	if c := report.Comparison; c != nil {
+		log.Printf("🔀 %s 대비: 이름 정확도 %+.1f%%p, 별칭 포함 %+.1f%%p, 카페인 오차 %+.1fmg, 지연 %+.0fms, 비용 $%+.4f | 개선 %d, 악화 %d",
+			c.Baseline, c.NameAccuracyDelta*100, c.AliasAccuracyDelta*100, c.CaffeineMAEDelta, c.LatencyMeanDelta, c.CostUSDDelta, len(c.Fixed), len(c.Regressed))
+	}
+
+	if *out == "" {
+		printReport(report)
+		return
+	}
+	file, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("❌ 파일을 만들 수 없습니다: %v", err)
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatalf("❌ 결과 저장 실패: %v", err)
+	}
+	log.Printf("💾 벤치마크 결과 저장: %s", *out)
+}
+
+// runExportDatasetCommand : 확인된 인식 이미지를 학습용 데이터셋(ImageFolder 형식)으로 내보내기
+func runExportDatasetCommand(args []string) {
+	flags := flag.NewFlagSet("export-dataset", flag.ExitOnError)
+	split := flags.String("split", "0.8,0.1,0.1", "train,val,test 비율")
+	seed := flags.Uint64("seed", 1, "split 배정 시드 (같은 시드면 같은 split)")
+	minImages := flags.Int("min-images", 1, "이미지가 이보다 적은 음료는 제외")
+	dryRun := flags.Bool("dry-run", false, "이미지를 저장하지 않고 split 결과만 출력")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		log.Fatalf("❌ 사용법: export-dataset [--split 0.8,0.1,0.1] [--seed N] [--min-images N] [--dry-run] <내보낼 폴더>")
+	}
+
+	ratios, err := services.ParseDatasetRatios(*split)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	report, err := services.ExportTrainingDataset(services.DatasetExportOptions{
+		OutDir:    flags.Arg(0),
+		Ratios:    ratios,
+		Seed:      *seed,
+		MinImages: *minImages,
+		DryRun:    *dryRun,
+	})
+	if err != nil {
+		log.Fatalf("❌ 데이터셋 내보내기 실패: %v", err)
+	}
+	printReport(report)
+	log.Printf("📦 음료 %d개, 이미지 %d장 내보내기 완료 (train %d, val %d, test %d)",
+		report.Classes, report.Total, report.Splits[services.DatasetSplitTrain], report.Splits[services.DatasetSplitVal], report.Splits[services.DatasetSplitTest])
+}
+
+// printReport : 명령 실행 결과를 JSON으로 출력
+func printReport(report interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+}
@@ -24,18 +24,93 @@ func Connect() {
 
 	log.Println("✅ MySQL 연결 성공!")
 
+	// image_hash 고유 인덱스를 만들기 전에 중복 행 정리 (인덱스가 없던 기존 DB)
+	dedupeBeverageImageHashes(database)
+
 	// 테이블 자동 생성 (Auto Migration)
 	// User, CaffeineLog 테이블이 없으면 자동으로 생성해줍니다.
 	database.AutoMigrate(
 		&models.User{},
 		&models.CaffeineLog{},
-		&models.Beverage{},         // 음료 마스터 데이터
-		&models.BeverageImage{},    // 음료 이미지 인식 데이터
-		&models.RecognitionLog{},   // 인식 시도 로그
-		&models.CaffeineFeedback{}, // 체감 피드백 (학습용)
-		&models.LearningHistory{},  // 학습 히스토리
-		&models.PersonalModel{},    // 개인별 확장 모델
+		&models.Beverage{},              // 음료 마스터 데이터
+		&models.BeverageSize{},          // 음료 사이즈별 용량/카페인
+		&models.BeverageBarcode{},       // 음료 바코드
+		&models.BeverageAlias{},         // 음료 별칭/동의어
+		&models.BeverageChangeRequest{}, // 음료 등록/수정 요청 (관리자 승인)
+		&models.BeverageRevision{},      // 음료 변경 이력
+		&models.BeverageImage{},         // 음료 이미지 인식 데이터
+		&models.RecognitionLog{},        // 인식 시도 로그
+		&models.RecognitionJob{},        // 비동기 인식 작업
+		&models.StoredImage{},           // 저장된 이미지 파일 색인
+		&models.TextEstimateCache{},     // 텍스트 카페인 추정 캐시
+		&models.CaffeineFeedback{},      // 체감 피드백 (학습용)
+		&models.LearningHistory{},       // 학습 히스토리
+		&models.PersonalModel{},         // 개인별 확장 모델
 	)
 
 	DB = database
 }
+
+// dedupeBeverageImageHashes : 같은 image_hash의 캐시 이미지를 하나만 남김
+// 신뢰도가 가장 높은 행(같으면 먼저 저장된 행)을 남기고, 인식 로그/섭취 기록은 남긴 행으로 옮기고 사용 횟수는 합침
+// 삭제된 행도 고유 인덱스에 걸리므로 함께 정리하고, 인덱스가 이미 있으면 건너뜀
+func dedupeBeverageImageHashes(database *gorm.DB) {
+	migrator := database.Migrator()
+	if !migrator.HasTable(&models.BeverageImage{}) || migrator.HasIndex(&models.BeverageImage{}, "uk_beverage_images_image_hash") {
+		return
+	}
+
+	var hashes []string
+	database.Unscoped().Model(&models.BeverageImage{}).
+		Group("image_hash").
+		Having("COUNT(*) > 1").
+		Pluck("image_hash", &hashes)
+
+	removed := 0
+	for _, hash := range hashes {
+		var rows []models.BeverageImage
+		database.Unscoped().
+			Where("image_hash = ?", hash).
+			Order("deleted_at IS NULL DESC, confidence DESC, id").
+			Find(&rows)
+		if len(rows) < 2 {
+			continue
+		}
+
+		keep := rows[0]
+		var duplicateIDs []uint
+		usage := 0
+		for _, row := range rows[1:] {
+			duplicateIDs = append(duplicateIDs, row.ID)
+			usage += row.UsageCount
+		}
+
+		err := database.Transaction(func(tx *gorm.DB) error {
+			// 인식 로그와 섭취 기록이 지울 행을 가리키지 않도록 남긴 행으로 옮김
+			// (자동 마이그레이션 전이라 컬럼이 아직 없을 수 있음)
+			for _, model := range []interface{}{&models.RecognitionLog{}, &models.CaffeineLog{}} {
+				if !tx.Migrator().HasColumn(model, "beverage_image_id") {
+					continue
+				}
+				if err := tx.Unscoped().Model(model).
+					Where("beverage_image_id IN ?", duplicateIDs).
+					Update("beverage_image_id", keep.ID).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Unscoped().Model(&keep).
+				UpdateColumn("usage_count", gorm.Expr("usage_count + ?", usage)).Error; err != nil {
+				return err
+			}
+			return tx.Unscoped().Delete(&models.BeverageImage{}, duplicateIDs).Error
+		})
+		if err != nil {
+			log.Println("⚠️ 중복 캐시 이미지 정리 실패:", hash, err)
+			continue
+		}
+		removed += len(duplicateIDs)
+	}
+	if removed > 0 {
+		log.Printf("🧹 중복 캐시 이미지 %d건 정리 (image_hash %d개)", removed, len(hashes))
+	}
+}
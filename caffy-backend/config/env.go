@@ -30,9 +30,52 @@ var (
 	UploadPath     string
 	MaxImageSizeMB int
 
+	// 이미지 저장소 설정
+	ImageStoreType string // "local" (UploadPath 폴더) 또는 "s3" (S3 호환 스토리지)
+	S3Endpoint     string // 예: https://s3.ap-northeast-2.amazonaws.com, http://localhost:9000 (MinIO)
+	S3Region       string
+	S3Bucket       string
+	S3AccessKey    string
+	S3SecretKey    string
+	S3UsePathStyle bool   // endpoint/bucket/key 형식 사용 (MinIO)
+	S3PublicURL    string // 공개 읽기 URL 접두사 (없으면 API를 통해서만 접근)
+
+	// 이미지 전처리 설정
+	ImageMaxDimension  int // 긴 변 최대 픽셀 (초과 시 축소)
+	ImageJPEGQuality   int // 재인코딩 JPEG 품질 (1~100)
+	ImageThumbnailSize int // 썸네일 긴 변 픽셀
+
+	// 이미지 보관 정책
+	ImageQuotaMBPerUser  int // 사용자별 이미지 저장 용량 (MB, 0이면 무제한)
+	ImageRetentionDays   int // 섭취 기록으로 확정되지 않은 인식 사진 보관 기간 (일)
+	ImageGCIntervalHours int // 정리 작업 주기 (시간, 0이면 자동 실행 안 함)
+
 	// JWT 설정
 	JWTSecret      string
 	JWTExpireHours int
+
+	// 예전 관리자 설정 (권한은 users.role로 판단, 관리자가 한 명도 없을 때만 이 이메일들을 admin으로 옮김)
+	LegacyAdminEmails []string
+
+	// 인식 피드백 설정
+	BeverageVerifyConfirmations int // 음료를 검증됨으로 승격하는 데 필요한 독립 확인 수 (서로 다른 사용자)
+
+	// 비동기 인식 작업 설정
+	RecognitionWorkers       int // 인식 작업을 처리하는 워커 수
+	RecognitionQueueSize     int // 대기열 크기 (가득 차면 제출 거절)
+	RecognitionJobTTLMinutes int // 작업 보관 시간 (분, 지나면 삭제)
+
+	// 인식 정책 설정
+	RecognitionEscalateBelow       float64 // 대표 음료 신뢰도가 이보다 낮으면 다음 프로바이더에도 물어봄 (0이면 재확인 안 함)
+	RecognitionCaffeineToleranceMG int     // 두 프로바이더의 카페인 값이 이만큼(또는 15%) 넘게 다르면 불일치
+
+	// LLM 프롬프트 설정
+	PromptVersion string // 사용할 프롬프트 버전 (예: "v1", 비우면 최신)
+	PromptLocale  string // 프롬프트 언어 (ko, en)
+
+	// 텍스트 카페인 추정 설정
+	TextEstimateCacheDays        int  // LLM 추정 결과 캐시 기간 (일)
+	TextEstimateProposeBeverages bool // LLM 추정 결과를 승인 대기 음료로 등록 제안
 )
 
 // LoadEnv : .env 파일에서 환경변수 로드
@@ -64,9 +107,52 @@ func LoadEnv() {
 	UploadPath = getEnv("UPLOAD_PATH", "./uploads/images")
 	MaxImageSizeMB = getEnvAsInt("MAX_IMAGE_SIZE_MB", 10)
 
+	// 이미지 저장소 설정
+	ImageStoreType = strings.ToLower(getEnv("IMAGE_STORE", "local"))
+	S3Endpoint = getEnv("S3_ENDPOINT", "")
+	S3Region = getEnv("S3_REGION", "us-east-1")
+	S3Bucket = getEnv("S3_BUCKET", "")
+	S3AccessKey = getEnv("S3_ACCESS_KEY", "")
+	S3SecretKey = getEnv("S3_SECRET_KEY", "")
+	S3UsePathStyle = getEnv("S3_USE_PATH_STYLE", "true") == "true"
+	S3PublicURL = getEnv("S3_PUBLIC_URL", "")
+
+	// 이미지 전처리 설정
+	ImageMaxDimension = getEnvAsInt("IMAGE_MAX_DIMENSION", 1600)
+	ImageJPEGQuality = getEnvAsInt("IMAGE_JPEG_QUALITY", 85)
+	ImageThumbnailSize = getEnvAsInt("IMAGE_THUMBNAIL_SIZE", 320)
+
+	// 이미지 보관 정책
+	ImageQuotaMBPerUser = getEnvAsInt("IMAGE_QUOTA_MB_PER_USER", 200)
+	ImageRetentionDays = getEnvAsInt("IMAGE_RETENTION_DAYS", 30)
+	ImageGCIntervalHours = getEnvAsInt("IMAGE_GC_INTERVAL_HOURS", 24)
+
 	// JWT 설정
 	JWTSecret = getEnv("JWT_SECRET", "your-secret-key-change-in-production")
 	JWTExpireHours = getEnvAsInt("JWT_EXPIRE_HOURS", 72)
+
+	// 예전 관리자 설정
+	LegacyAdminEmails = getEnvAsSlice("ADMIN_EMAILS", nil)
+
+	// 인식 피드백 설정
+	BeverageVerifyConfirmations = getEnvAsInt("BEVERAGE_VERIFY_CONFIRMATIONS", 3)
+
+	// 비동기 인식 작업 설정
+	RecognitionWorkers = getEnvAsInt("RECOGNITION_WORKERS", 4)
+	RecognitionQueueSize = getEnvAsInt("RECOGNITION_QUEUE_SIZE", 100)
+	RecognitionJobTTLMinutes = getEnvAsInt("RECOGNITION_JOB_TTL_MINUTES", 60)
+
+	// 인식 정책 설정
+	RecognitionEscalateBelow = getEnvAsFloat("RECOGNITION_ESCALATE_BELOW", 0.6)
+	RecognitionCaffeineToleranceMG = getEnvAsInt("RECOGNITION_CAFFEINE_TOLERANCE_MG", 20)
+
+	// LLM 프롬프트 설정
+	PromptVersion = getEnv("PROMPT_VERSION", "")
+	PromptLocale = getEnv("PROMPT_LOCALE", "ko")
+
+	// 텍스트 카페인 추정 설정
+	TextEstimateCacheDays = getEnvAsInt("TEXT_ESTIMATE_CACHE_DAYS", 30)
+	TextEstimateProposeBeverages = getEnv("TEXT_ESTIMATE_PROPOSE_BEVERAGES", "false") == "true"
 }
 
 // getEnv : 환경변수 가져오기 (기본값 지원)
@@ -87,6 +173,16 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsFloat : 환경변수를 float64로 가져오기
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 // getEnvAsSlice : 환경변수를 슬라이스로 가져오기 (쉼표로 구분)
 func getEnvAsSlice(key string, defaultValue []string) []string {
 	if value := os.Getenv(key); value != "" {
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"caffy-backend/middleware"
+	"caffy-backend/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ========================================
+// 관리자 API (사용자, 인식 로그, 음료, 유지보수 작업)
+// ========================================
+
+// adminPage : ?page=&limit= 쿼리
+func adminPage(c *gin.Context) services.AdminPage {
+	page, _ := strconv.Atoi(c.Query("page"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	return services.AdminPage{Page: page, Limit: limit}
+}
+
+// ListUsers : 사용자 목록
+// GET /api/admin/users?q=gmail&role=moderator&page=1&limit=50
+func ListUsers(c *gin.Context) {
+	users, total, err := services.ListUsers(services.UserFilter{
+		AdminPage: adminPage(c),
+		Query:     c.Query("q"),
+		Role:      c.Query("role"),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "사용자 조회 실패"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": users, "total": total})
+}
+
+// SetUserRole : 사용자 역할 변경
+// PUT /api/admin/users/:id/role {"role": "moderator"}
+func SetUserRole(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 사용자 ID입니다"})
+		return
+	}
+
+	var input struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := services.SetUserRole(uint(id), input.Role)
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ListRecognitionLogs : 인식 로그 조회
+// GET /api/admin/recognition-logs?user_id=3&source=llm&provider=gemini&prompt_version=drinks.v2.ko&feedback=incorrect
+func ListRecognitionLogs(c *gin.Context) {
+	userID, _ := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	logs, total, err := services.ListRecognitionLogs(services.RecognitionLogFilter{
+		AdminPage:     adminPage(c),
+		UserID:        uint(userID),
+		Source:        c.Query("source"),
+		PromptVersion: c.Query("prompt_version"),
+		Provider:      c.Query("provider"),
+		Feedback:      c.Query("feedback"),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "인식 로그 조회 실패"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"logs": logs, "total": total})
+}
+
+// ListAdminBeverages : 승인 대기/거절된 음료까지 포함한 음료 목록 (검토자)
+// GET /api/admin/beverages?status=pending&q=스타벅스
+func ListAdminBeverages(c *gin.Context) {
+	beverages, total, err := services.ListBeveragesForAdmin(services.AdminBeverageFilter{
+		AdminPage: adminPage(c),
+		Status:    c.Query("status"),
+		Query:     c.Query("q"),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "음료 조회 실패"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"beverages": beverages, "total": total})
+}
+
+// DeleteBeverage : 음료 삭제 (섭취 기록은 유지)
+// DELETE /api/admin/beverages/:id
+func DeleteBeverage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 음료 ID입니다"})
+		return
+	}
+
+	if err := services.DeleteBeverage(uint(id), middleware.GetUserID(c)); err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "음료가 삭제되었습니다"})
+}
+
+// ListDuplicateBeverages : 중복으로 보이는 음료 쌍 (검토자)
+// GET /api/admin/beverages/duplicates?beverage_id=12&min_score=0.8&limit=50
+func ListDuplicateBeverages(c *gin.Context) {
+	beverageID, _ := strconv.ParseUint(c.Query("beverage_id"), 10, 64)
+	minScore, _ := strconv.ParseFloat(c.Query("min_score"), 64)
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	candidates, err := services.FindDuplicateBeverages(services.DuplicateFilter{
+		BeverageID: uint(beverageID),
+		MinScore:   minScore,
+		Limit:      limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "중복 음료 조회 실패"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"candidates": candidates, "count": len(candidates)})
+}
+
+// MergeBeverage : 음료를 다른 음료로 병합 (사진, 기록, 인식 로그를 옮기고 예전 ID는 병합 대상으로 연결)
+// POST /api/admin/beverages/:id/merge {"into": 34}
+func MergeBeverage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 음료 ID입니다"})
+		return
+	}
+
+	var input struct {
+		Into uint `json:"into" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := services.MergeBeverages(uint(id), input.Into, middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ListMaintenanceJobs : 실행할 수 있는 유지보수 작업
+// GET /api/admin/jobs
+func ListMaintenanceJobs(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"jobs": services.MaintenanceJobs()})
+}
+
+// RunMaintenanceJob : 유지보수 작업 실행 (재학습, 이미지 정리, 색인 재생성 등)
+// POST /api/admin/jobs/:name?dry_run=true&user_id=3
+func RunMaintenanceJob(c *gin.Context) {
+	userID, _ := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	result, err := services.RunMaintenanceJob(c.Param("name"), services.MaintenanceJobOptions{
+		DryRun: c.Query("dry_run") == "true",
+		UserID: uint(userID),
+	})
+	if err != nil {
+		c.JSON(adminErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// adminErrorStatus : 관리자 서비스 에러 → HTTP 상태 코드
+func adminErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrBeverageNotFound),
+		errors.Is(err, services.ErrUnknownJob):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrLastAdmin):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidRole), errors.Is(err, services.ErrMergeSameBeverage),
+		errors.Is(err, services.ErrMergeTarget):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"caffy-backend/config"
 	"caffy-backend/middleware"
 	"caffy-backend/models"
+	"caffy-backend/services"
 	"net/http"
 	"strings"
 
@@ -212,3 +213,9 @@ func ChangePassword(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "비밀번호가 변경되었습니다"})
 }
+
+// GetMyStorage : 내 사진 저장 사용량 조회
+func GetMyStorage(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	c.JSON(http.StatusOK, services.GetImageStorageUsage(userID))
+}
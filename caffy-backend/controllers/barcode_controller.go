@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"caffy-backend/middleware"
+	"caffy-backend/models"
+	"caffy-backend/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ========================================
+// 바코드 API
+// ========================================
+
+// GetBeverageByBarcode : 바코드로 음료 조회
+// GET /api/beverages/barcode/:code
+// 확인 대기 중인 음료는 등록한 사용자에게만 status: "pending"으로 반환
+func GetBeverageByBarcode(c *gin.Context) {
+	code, _, err := services.NormalizeBarcode(c.Param("code"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	beverage, err := services.FindBeverageByBarcode(code)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "등록되지 않은 바코드입니다", "barcode": code})
+		return
+	}
+	if !services.CanViewBeverage(beverage, middleware.GetUserID(c), middleware.IsModerator(c)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "확인 대기 중인 바코드입니다", "barcode": code, "status": models.BeverageStatusPending})
+		return
+	}
+
+	c.JSON(http.StatusOK, beverage)
+}
+
+// ConfirmBarcodeBeverage : 모르는 바코드로 생성된 확인 대기 음료 확정
+// POST /api/beverages/barcode/:code/confirm
+// beverage_id를 주면 기존 음료에 바코드를 연결, 아니면 입력값으로 새 음료 확정
+func ConfirmBarcodeBeverage(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "인증이 필요합니다"})
+		return
+	}
+
+	code, _, err := services.NormalizeBarcode(c.Param("code"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var input services.BarcodeConfirmation
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	beverage, err := services.ConfirmPendingBeverage(code, input, userID, middleware.IsModerator(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrBarcodeNotRegistered):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrBeverageNotPending):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrPendingBeverageOwner):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if beverage.Status == models.BeverageStatusPending {
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":  "음료 등록 요청이 접수되었습니다. 관리자 승인 후 다른 사용자에게 공개됩니다",
+			"beverage": beverage,
+		})
+		return
+	}
+
+	services.InvalidateBeverageSearchIndex()
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "음료가 확정되었습니다",
+		"beverage": beverage,
+	})
+}
+
+// AddBeverageBarcode : 음료에 바코드 추가
+// POST /api/beverages/:id/barcodes
+// 검토자와 본인이 등록한 승인 대기 음료는 바로 추가하고, 그 외에는 바코드 추가 요청으로 남김
+func AddBeverageBarcode(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 음료 ID입니다"})
+		return
+	}
+
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	err = services.CheckBeverageEditable(uint(id), userID, middleware.IsModerator(c))
+	if errors.Is(err, services.ErrBeverageNotEditable) {
+		request, err := services.RequestBeverageBarcode(uint(id), input.Code, userID)
+		if err != nil {
+			c.JSON(barcodeErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":        "바코드 추가 요청이 접수되었습니다. 관리자 승인 후 반영됩니다",
+			"change_request": request,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(barcodeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	barcode, err := services.AddBarcodeToBeverage(uint(id), input.Code, userID)
+	if err != nil {
+		c.JSON(barcodeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, barcode)
+}
+
+// barcodeErrorStatus : 바코드 추가 에러 → HTTP 상태 코드
+func barcodeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrBeverageNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrBarcodeTaken), errors.Is(err, services.ErrNoBeverageChanges):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrBeverageNotEditable):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrInvalidBarcode):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"caffy-backend/middleware"
+	"caffy-backend/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ========================================
+// 음료 별칭 API (검토자)
+// ========================================
+
+// ListBeverageAliases : 별칭 목록
+// GET /api/admin/aliases?target_type=term&beverage_id=12&q=아아
+func ListBeverageAliases(c *gin.Context) {
+	beverageID, _ := strconv.ParseUint(c.Query("beverage_id"), 10, 64)
+	aliases, err := services.ListBeverageAliases(services.BeverageAliasFilter{
+		TargetType: c.Query("target_type"),
+		BeverageID: uint(beverageID),
+		Query:      c.Query("q"),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "별칭 조회 실패"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"aliases": aliases, "count": len(aliases)})
+}
+
+// CreateBeverageAlias : 별칭 등록
+// POST /api/admin/aliases
+func CreateBeverageAlias(c *gin.Context) {
+	var input services.BeverageAliasInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alias, err := services.CreateBeverageAlias(input, middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(aliasErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, alias)
+}
+
+// UpdateBeverageAlias : 별칭 수정
+// PUT /api/admin/aliases/:id
+func UpdateBeverageAlias(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 별칭 ID입니다"})
+		return
+	}
+
+	var input services.BeverageAliasInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alias, err := services.UpdateBeverageAlias(uint(id), input)
+	if err != nil {
+		c.JSON(aliasErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, alias)
+}
+
+// DeleteBeverageAlias : 별칭 삭제
+// DELETE /api/admin/aliases/:id
+func DeleteBeverageAlias(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 별칭 ID입니다"})
+		return
+	}
+
+	if err := services.DeleteBeverageAlias(uint(id)); err != nil {
+		c.JSON(aliasErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "별칭이 삭제되었습니다"})
+}
+
+// MatchBeverageText : 텍스트로 음료 매칭 결과 확인 (별칭 등록 후 점검용)
+// POST /api/admin/aliases/match
+func MatchBeverageText(c *gin.Context) {
+	var input struct {
+		Text string `json:"text" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "text가 필요합니다"})
+		return
+	}
+
+	result := services.MatchBeverageText(input.Text)
+	c.JSON(http.StatusOK, gin.H{
+		"normalized": services.NormalizeDrinkQuery(input.Text),
+		"best":       result.Best(),
+		"candidates": result.Candidates,
+		"category":   result.Category,
+	})
+}
+
+// aliasErrorStatus : 별칭 서비스 에러 → HTTP 상태 코드
+func aliasErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrAliasNotFound), errors.Is(err, services.ErrBeverageNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrAliasExists):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidAlias):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
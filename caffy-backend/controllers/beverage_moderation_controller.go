@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"caffy-backend/middleware"
+	"caffy-backend/models"
+	"caffy-backend/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ========================================
+// 음료 등록/수정 요청 API
+// ========================================
+
+// GetMyBeverageRequests : 내가 보낸 음료 등록/수정 요청
+// GET /api/me/beverage-requests?status=pending
+func GetMyBeverageRequests(c *gin.Context) {
+	requests, err := services.ListBeverageChangeRequests(services.ChangeRequestFilter{
+		Status:      c.Query("status"),
+		SubmittedBy: middleware.GetUserID(c),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "요청 조회 실패"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"requests": requests, "count": len(requests)})
+}
+
+// ListBeverageRequests : 검토할 음료 등록/수정 요청 (검토자)
+// GET /api/admin/beverage-requests?status=pending&beverage_id=12
+func ListBeverageRequests(c *gin.Context) {
+	beverageID, _ := strconv.ParseUint(c.Query("beverage_id"), 10, 64)
+	requests, err := services.ListBeverageChangeRequests(services.ChangeRequestFilter{
+		Status:     c.DefaultQuery("status", models.ChangeRequestPending),
+		BeverageID: uint(beverageID),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "요청 조회 실패"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"requests": requests, "count": len(requests)})
+}
+
+// GetBeverageRequest : 요청 하나와 변경 내역 (검토자)
+// GET /api/admin/beverage-requests/:id
+func GetBeverageRequest(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 요청 ID입니다"})
+		return
+	}
+
+	request, err := services.GetBeverageChangeRequest(uint(id))
+	if err != nil {
+		c.JSON(changeRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// ApproveBeverageRequest : 요청 승인 (검토자)
+// POST /api/admin/beverage-requests/:id/approve
+func ApproveBeverageRequest(c *gin.Context) {
+	reviewBeverageRequest(c, services.ApproveBeverageChangeRequest)
+}
+
+// RejectBeverageRequest : 요청 거절 (검토자)
+// POST /api/admin/beverage-requests/:id/reject
+func RejectBeverageRequest(c *gin.Context) {
+	reviewBeverageRequest(c, services.RejectBeverageChangeRequest)
+}
+
+// reviewBeverageRequest : 승인/거절 공통 처리 (note는 선택)
+func reviewBeverageRequest(c *gin.Context, review func(id uint, reviewerID uint, note string) (*models.BeverageChangeRequest, error)) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 요청 ID입니다"})
+		return
+	}
+
+	var input struct {
+		Note string `json:"note"`
+	}
+	c.ShouldBindJSON(&input)
+
+	request, err := review(uint(id), middleware.GetUserID(c), input.Note)
+	if err != nil {
+		c.JSON(changeRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// changeRequestErrorStatus : 음료 수정/검토 서비스 에러 → HTTP 상태 코드
+func changeRequestErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrChangeRequestNotFound), errors.Is(err, services.ErrBeverageNotFound),
+		errors.Is(err, services.ErrRevisionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrChangeRequestReviewed), errors.Is(err, services.ErrBeverageNameTaken):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrBeverageNotEditable):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrInvalidBeverageChange), errors.Is(err, services.ErrNoBeverageChanges),
+		errors.Is(err, services.ErrRevisionNotRevertable):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ========================================
+// 음료 변경 이력 API (검토자)
+// ========================================
+
+// ListBeverageRevisions : 음료 변경 이력 (최신순)
+// GET /api/admin/beverages/:id/revisions
+func ListBeverageRevisions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 음료 ID입니다"})
+		return
+	}
+
+	revisions, err := services.ListBeverageRevisions(uint(id))
+	if err != nil {
+		c.JSON(changeRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions, "count": len(revisions)})
+}
+
+// RevertBeverageRevision : 이력의 변경 전 값으로 되돌림 (recalculate_logs면 섭취 기록도 다시 계산)
+// POST /api/admin/beverages/:id/revisions/:revisionId/revert
+func RevertBeverageRevision(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 음료 ID입니다"})
+		return
+	}
+	revisionID, err := strconv.ParseUint(c.Param("revisionId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 이력 ID입니다"})
+		return
+	}
+
+	var input struct {
+		Reason          string `json:"reason"`
+		RecalculateLogs bool   `json:"recalculate_logs"`
+	}
+	c.ShouldBindJSON(&input)
+
+	beverage, err := services.RevertBeverageRevision(uint(id), uint(revisionID), services.BeverageRevisionMeta{
+		AuthorID: middleware.GetUserID(c),
+		Reason:   input.Reason,
+	})
+	if err != nil {
+		c.JSON(changeRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if !input.RecalculateLogs {
+		c.JSON(http.StatusOK, gin.H{"beverage": beverage})
+		return
+	}
+	updated, err := services.RecalculateBeverageLogs(beverage.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "섭취 기록 다시 계산 실패"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"beverage": beverage, "recalculated_logs": updated})
+}
+
+// RecalculateBeverageLogs : 현재 음료 값으로 직접 입력하지 않은 섭취 기록 다시 계산
+// POST /api/admin/beverages/:id/recalculate-logs
+func RecalculateBeverageLogs(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 음료 ID입니다"})
+		return
+	}
+
+	updated, err := services.RecalculateBeverageLogs(uint(id))
+	if err != nil {
+		c.JSON(changeRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recalculated_logs": updated})
+}
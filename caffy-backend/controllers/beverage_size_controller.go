@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"caffy-backend/middleware"
+	"caffy-backend/models"
+	"caffy-backend/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ========================================
+// 음료 사이즈 API (검토자, 또는 본인이 등록한 승인 대기 음료)
+// ========================================
+
+// beverageSizeInput : 사이즈 추가/수정 요청
+type beverageSizeInput struct {
+	Label          string  `json:"label" binding:"required"` // 사이즈 이름 (예: "Tall", "355ml 캔")
+	VolumeML       float64 `json:"volume_ml"`                // 용량 (ml)
+	CaffeineAmount float64 `json:"caffeine_amount"`          // 카페인 함량 (mg)
+	Shots          int     `json:"shots"`                    // 에스프레소 샷 수
+	IsDefault      bool    `json:"is_default"`               // 기본 사이즈로 지정
+}
+
+func (in beverageSizeInput) toModel() models.BeverageSize {
+	return models.BeverageSize{
+		Label:          in.Label,
+		VolumeML:       in.VolumeML,
+		CaffeineAmount: in.CaffeineAmount,
+		Shots:          in.Shots,
+		IsDefault:      in.IsDefault,
+	}
+}
+
+// AddBeverageSize : 음료에 사이즈 추가
+// POST /api/beverages/:id/sizes
+func AddBeverageSize(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 음료 ID입니다"})
+		return
+	}
+
+	var input beverageSizeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.CheckBeverageEditable(uint(id), middleware.GetUserID(c), middleware.IsModerator(c)); err != nil {
+		c.JSON(beverageSizeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	size, err := services.AddBeverageSize(uint(id), input.toModel(), middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(beverageSizeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, size)
+}
+
+// UpdateBeverageSize : 사이즈 수정
+// PUT /api/beverages/:id/sizes/:sizeId
+func UpdateBeverageSize(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 음료 ID입니다"})
+		return
+	}
+	sizeID, err := strconv.ParseUint(c.Param("sizeId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 사이즈 ID입니다"})
+		return
+	}
+
+	var input beverageSizeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.CheckBeverageEditable(uint(id), middleware.GetUserID(c), middleware.IsModerator(c)); err != nil {
+		c.JSON(beverageSizeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	size, err := services.UpdateBeverageSize(uint(id), uint(sizeID), input.toModel(), middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(beverageSizeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, size)
+}
+
+// DeleteBeverageSize : 사이즈 삭제 (이 사이즈로 남긴 기록은 용량만 유지)
+// DELETE /api/beverages/:id/sizes/:sizeId
+func DeleteBeverageSize(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 음료 ID입니다"})
+		return
+	}
+	sizeID, err := strconv.ParseUint(c.Param("sizeId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 사이즈 ID입니다"})
+		return
+	}
+
+	if err := services.CheckBeverageEditable(uint(id), middleware.GetUserID(c), middleware.IsModerator(c)); err != nil {
+		c.JSON(beverageSizeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if err := services.DeleteBeverageSize(uint(id), uint(sizeID)); err != nil {
+		c.JSON(beverageSizeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "사이즈가 삭제되었습니다"})
+}
+
+// beverageSizeErrorStatus : 사이즈 서비스 에러 → HTTP 상태 코드
+func beverageSizeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrBeverageNotFound), errors.Is(err, services.ErrBeverageSizeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrBeverageSizeExists):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrBeverageNotEditable):
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -28,10 +28,14 @@ func CreateUser(c *gin.Context) {
 // 2. 카페인 섭취 기록 추가
 func AddLog(c *gin.Context) {
 	var input struct {
-		DrinkName  string    `json:"drink_name"`
-		Amount     float64   `json:"amount"`
-		IntakeAt   time.Time `json:"intake_at"`
-		BeverageID *uint     `json:"beverage_id"`
+		DrinkName        string    `json:"drink_name"`
+		Amount           float64   `json:"amount"`
+		IntakeAt         time.Time `json:"intake_at"`
+		BeverageID       *uint     `json:"beverage_id"`
+		BeverageSizeID   *uint     `json:"beverage_size_id"`   // 고른 사이즈 (amount가 0이면 사이즈의 카페인량으로 기록)
+		Size             string    `json:"size"`               // 사이즈 이름 (예: "tall")
+		SizeML           float64   `json:"size_ml"`            // 용량만 아는 경우 (등록된 사이즈 사이에서 환산)
+		RecognitionLogID *uint     `json:"recognition_log_id"` // 인식 결과로 기록하는 경우 출처
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -56,6 +60,19 @@ func AddLog(c *gin.Context) {
 		BeverageID:     input.BeverageID,
 	}
 
+	// 사이즈 반영 (사이즈/용량을 주면 해당 사이즈의 카페인량 사용)
+	if err := services.ApplyBeverageSize(&log, input.BeverageSizeID, input.Size, input.SizeML); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 인식 결과에서 온 기록이면 인식 로그/사진과 연결
+	if input.RecognitionLogID != nil {
+		if !linkRecognition(c, &log, userID, *input.RecognitionLogID) {
+			return
+		}
+	}
+
 	// 시간 입력이 없으면 현재 시간으로 설정
 	if log.IntakeAt.IsZero() {
 		log.IntakeAt = time.Now()
@@ -65,6 +82,85 @@ func AddLog(c *gin.Context) {
 	c.JSON(http.StatusOK, log)
 }
 
+// 2-1. 카페인 섭취 기록 여러 건 추가 (한 사진에서 인식된 여러 음료)
+// POST /api/logs/batch
+func AddLogs(c *gin.Context) {
+	var input struct {
+		Logs []struct {
+			DrinkName        string    `json:"drink_name" binding:"required"`
+			Amount           float64   `json:"amount"`
+			IntakeAt         time.Time `json:"intake_at"`
+			BeverageID       *uint     `json:"beverage_id"`
+			BeverageSizeID   *uint     `json:"beverage_size_id"`   // 고른 사이즈
+			Size             string    `json:"size"`               // 사이즈 이름
+			SizeML           float64   `json:"size_ml"`            // 용량 (ml)
+			RecognitionLogID *uint     `json:"recognition_log_id"` // 같은 사진에서 인식된 경우 출처
+			DrinkIndex       int       `json:"drink_index"`        // 사진 속 몇 번째 음료인지
+		} `json:"logs" binding:"required,min=1,max=20,dive"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "인증이 필요합니다"})
+		return
+	}
+
+	now := time.Now()
+	logs := make([]models.CaffeineLog, 0, len(input.Logs))
+	for _, item := range input.Logs {
+		log := models.CaffeineLog{
+			UserID:         userID,
+			DrinkName:      item.DrinkName,
+			OriginalAmount: item.Amount,
+			ConsumedRatio:  1.0,
+			Amount:         item.Amount,
+			IntakeAt:       item.IntakeAt,
+			BeverageID:     item.BeverageID,
+			DrinkIndex:     item.DrinkIndex,
+		}
+		if err := services.ApplyBeverageSize(&log, item.BeverageSizeID, item.Size, item.SizeML); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "drink_name": item.DrinkName})
+			return
+		}
+		if item.RecognitionLogID != nil {
+			if !linkRecognition(c, &log, userID, *item.RecognitionLogID) {
+				return
+			}
+		}
+		if log.IntakeAt.IsZero() {
+			log.IntakeAt = now
+		}
+		logs = append(logs, log)
+	}
+
+	if err := config.DB.Create(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "기록 저장 실패"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"logs":  logs,
+		"count": len(logs),
+	})
+}
+
+// linkRecognition : 섭취 기록에 인식 로그와 캐시 이미지 연결 (실패 시 응답 후 false)
+func linkRecognition(c *gin.Context, log *models.CaffeineLog, userID uint, recognitionLogID uint) bool {
+	recognition, err := services.FindUserRecognitionLog(userID, recognitionLogID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	log.RecognitionLogID = &recognition.ID
+	log.BeverageImageID = recognition.BeverageImageID
+	return true
+}
+
 // 3. 현재 상태 조회 (ID 기반 - 레거시)
 func GetCurrentStatus(c *gin.Context) {
 	userId := c.Param("id")
@@ -199,16 +295,25 @@ func GetMyLogs(c *gin.Context) {
 		startTime = time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
 		endTime = startTime.AddDate(0, 1, 0) // 다음 달 1일
 
-		config.DB.Where("user_id = ? AND intake_at >= ? AND intake_at < ?", userID, startTime, endTime).
+		config.DB.Preload("RecognitionLog").
+			Where("user_id = ? AND intake_at >= ? AND intake_at < ?", userID, startTime, endTime).
 			Order("intake_at DESC").Find(&logs)
 	} else {
 		// 기본: 최근 30일 조회
 		const periodDays = 30
 		startTime = time.Now().Add(-time.Duration(periodDays) * 24 * time.Hour)
-		config.DB.Where("user_id = ? AND intake_at > ?", userID, startTime).
+		config.DB.Preload("RecognitionLog").
+			Where("user_id = ? AND intake_at > ?", userID, startTime).
 			Order("intake_at DESC").Find(&logs)
 	}
 
+	// 사진 인식으로 만든 기록은 사진 URL 포함
+	for i := range logs {
+		if logs[i].RecognitionLog != nil && logs[i].RecognitionLog.ImagePath != "" {
+			logs[i].ImageURL = fmt.Sprintf("/api/logs/%d/image", logs[i].ID)
+		}
+	}
+
 	// 일별 통계
 	dailyStats := make(map[string]float64)
 	for _, log := range logs {
@@ -308,6 +413,40 @@ func UpdateLog(c *gin.Context) {
 	})
 }
 
+// 7-1. 섭취 기록의 인식 사진 조회
+// GET /api/logs/:id/image (?size=thumb)
+func GetLogImage(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	logID := c.Param("id")
+
+	var log models.CaffeineLog
+	if err := config.DB.Preload("RecognitionLog").Where("id = ? AND user_id = ?", logID, userID).First(&log).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "기록을 찾을 수 없습니다"})
+		return
+	}
+	if log.RecognitionLog == nil || log.RecognitionLog.ImagePath == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "사진이 없는 기록입니다"})
+		return
+	}
+
+	// ?size=thumb 이면 썸네일 (예전에 저장되어 썸네일이 없으면 원본)
+	imageKey := log.RecognitionLog.ImagePath
+	if c.Query("size") == "thumb" {
+		if thumb, err := services.GetImageData(services.ThumbnailKey(imageKey)); err == nil {
+			c.Data(http.StatusOK, "image/jpeg", thumb)
+			return
+		}
+	}
+
+	imageData, err := services.GetImageData(imageKey)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "사진 파일을 찾을 수 없습니다"})
+		return
+	}
+
+	c.Data(http.StatusOK, http.DetectContentType(imageData), imageData)
+}
+
 // 8. 섭취 기록 삭제
 func DeleteLog(c *gin.Context) {
 	userID := middleware.GetUserID(c)
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"bytes"
+	"caffy-backend/middleware"
+	"caffy-backend/services"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ========================================
+// 음료 카탈로그 가져오기/내보내기 API (관리자)
+// ========================================
+
+// maxCatalogUploadMB : 카탈로그 파일 최대 크기
+const maxCatalogUploadMB = 20
+
+// ImportCatalog : 카탈로그 가져오기
+// POST /api/admin/catalog/import?format=csv&dry_run=true
+// 본문에 파일을 그대로 보내거나 multipart "file" 필드로 업로드
+func ImportCatalog(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCatalogUploadMB<<20)
+
+	var body io.Reader = c.Request.Body
+	format := services.CatalogFormatFromName(c.Query("format"))
+	if c.ContentType() == "multipart/form-data" {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file 필드가 필요합니다"})
+			return
+		}
+		opened, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "파일을 열 수 없습니다"})
+			return
+		}
+		defer opened.Close()
+		body = opened
+		if format == "" {
+			format = services.CatalogFormatFromName(file.Filename)
+		}
+	}
+	if format == "" {
+		format = services.CatalogFormatFromName(c.ContentType())
+	}
+	if format == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrUnknownCatalogFormat.Error()})
+		return
+	}
+
+	items, err := services.ParseCatalog(body, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := services.ImportCatalog(items, services.CatalogImportOptions{
+		DryRun: c.Query("dry_run") == "true",
+		UserID: middleware.GetUserID(c),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "카탈로그 가져오기 실패"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ExportCatalog : 카탈로그 내보내기 (가져오기와 같은 형식)
+// GET /api/admin/catalog/export?format=csv
+func ExportCatalog(c *gin.Context) {
+	format := services.CatalogFormatFromName(c.DefaultQuery("format", services.CatalogFormatJSON))
+	if format == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrUnknownCatalogFormat.Error()})
+		return
+	}
+
+	var buf bytes.Buffer
+	if _, err := services.ExportCatalog(&buf, format); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "카탈로그 내보내기 실패"})
+		return
+	}
+
+	contentType := "application/json; charset=utf-8"
+	if format == services.CatalogFormatCSV {
+		contentType = "text/csv; charset=utf-8"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="beverages-%s.%s"`, time.Now().Format("20060102"), format))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
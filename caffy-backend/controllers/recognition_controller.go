@@ -5,9 +5,12 @@ import (
 	"caffy-backend/middleware"
 	"caffy-backend/models"
 	"caffy-backend/services"
+	"errors"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -40,7 +43,7 @@ func SmartRecognizeImage(c *gin.Context) {
 	result, err := services.SmartRecognizeDrink(input.ImageBase64, userID)
 	if err != nil {
 		println("❌ 인식 실패:", err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(recognitionErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -48,6 +51,172 @@ func SmartRecognizeImage(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// SubmitRecognitionJob : 비동기 스마트 인식 작업 제출 (즉시 작업 ID 반환)
+// POST /api/recognize/jobs
+// 결과는 GET /api/recognize/jobs/:id 폴링 또는 /stream (SSE)으로 받음
+func SubmitRecognitionJob(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "인증이 필요합니다"})
+		return
+	}
+
+	var input struct {
+		ImageBase64 string `json:"image_base64" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "이미지 데이터가 필요합니다", "detail": err.Error()})
+		return
+	}
+
+	job, err := services.SubmitRecognitionJob(userID, input.ImageBase64)
+	if err != nil {
+		if errors.Is(err, services.ErrRecognitionQueueFull) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	println("📥 인식 작업 접수 - 작업 ID:", job.ID)
+	c.JSON(http.StatusAccepted, gin.H{
+		"job":        job,
+		"poll_url":   "/api/recognize/jobs/" + strconv.FormatUint(uint64(job.ID), 10),
+		"stream_url": "/api/recognize/jobs/" + strconv.FormatUint(uint64(job.ID), 10) + "/stream",
+	})
+}
+
+// GetRecognitionJob : 인식 작업 상태/결과 조회
+// GET /api/recognize/jobs/:id
+func GetRecognitionJob(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "인증이 필요합니다"})
+		return
+	}
+
+	jobID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 작업 ID입니다"})
+		return
+	}
+
+	job, err := services.GetRecognitionJob(userID, uint(jobID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// StreamRecognitionJob : 인식 작업 상태를 SSE로 전송 (완료/실패 시 종료)
+// GET /api/recognize/jobs/:id/stream
+func StreamRecognitionJob(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "인증이 필요합니다"})
+		return
+	}
+
+	jobID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 작업 ID입니다"})
+		return
+	}
+
+	// 구독을 먼저 걸어야 조회와 구독 사이의 상태 변경을 놓치지 않음
+	updates, cancel := services.WatchRecognitionJob(uint(jobID))
+	defer cancel()
+
+	job, err := services.GetRecognitionJob(userID, uint(jobID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // 프록시 버퍼링 방지
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+
+	lastStatus := ""
+	c.Stream(func(w io.Writer) bool {
+		if job.Status != lastStatus {
+			c.SSEvent(job.Status, job)
+			lastStatus = job.Status
+		}
+		if job.Finished() {
+			return false
+		}
+
+		select {
+		case <-updates:
+			latest, err := services.GetRecognitionJob(userID, uint(jobID))
+			if err != nil {
+				c.SSEvent("error", gin.H{"error": err.Error()})
+				return false
+			}
+			job = latest
+		case <-heartbeat.C:
+			c.SSEvent("ping", gin.H{"status": job.Status})
+		case <-c.Request.Context().Done():
+			return false
+		}
+		return true
+	})
+}
+
+// RecognizeAndLog : 사진 인식 후 섭취 기록까지 한 번에 생성
+// POST /api/recognize/log
+// 생성된 기록은 인식 로그/사진과 연결되어, 이후 인식 정정 시 함께 갱신됨
+func RecognizeAndLog(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "인증이 필요합니다"})
+		return
+	}
+
+	var input struct {
+		ImageBase64 string     `json:"image_base64" binding:"required"`
+		DrinkIndex  int        `json:"drink_index" binding:"min=0"`          // 사진 속 음료 중 기록할 음료 (기본: 대표 음료)
+		DrinkName   *string    `json:"drink_name"`                           // 음료 이름 직접 입력
+		Amount      *float64   `json:"amount" binding:"omitempty,min=0"`     // 카페인량 직접 입력 (mg)
+		Ratio       *float64   `json:"ratio" binding:"omitempty,gt=0,lte=1"` // 마신 비율 (0.0~1.0)
+		IntakeAt    *time.Time `json:"intake_at"`                            // 마신 시간 (기본: 지금)
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "요청 형식이 올바르지 않습니다", "detail": err.Error()})
+		return
+	}
+
+	result, log, err := services.RecognizeAndLog(userID, input.ImageBase64, services.RecognizeAndLogOptions{
+		DrinkIndex: input.DrinkIndex,
+		DrinkName:  input.DrinkName,
+		Amount:     input.Amount,
+		Ratio:      input.Ratio,
+		IntakeAt:   input.IntakeAt,
+	})
+	if err != nil {
+		if result == nil {
+			println("❌ 인식 실패:", err.Error())
+			c.JSON(recognitionErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		// 인식은 됐지만 기록할 수 없음 → 인식 결과를 함께 돌려줘 수동 입력 유도
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "recognition": result})
+		return
+	}
+
+	println("✅ 인식+기록 완료 - 음료:", log.DrinkName, "섭취량:", int(log.Amount), "소스:", result.Source)
+	c.JSON(http.StatusOK, gin.H{
+		"recognition": result,
+		"log":         log,
+	})
+}
+
 // RecognizeByText : 음료명+사이즈로 카페인 추정 (AI)
 // POST /api/recognize/text
 func RecognizeByText(c *gin.Context) {
@@ -73,7 +242,7 @@ func RecognizeByText(c *gin.Context) {
 	result, err := services.EstimateCaffeineByText(input.DrinkName, input.Size, input.SizeML, userID)
 	if err != nil {
 		println("❌ 추정 실패:", err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(recognitionErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -127,7 +296,7 @@ func RecognizeImage(c *gin.Context) {
 	// 3. 인식 수행
 	result, err := services.RecognizeBeverage(imageData, uint(userID))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(recognitionErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -141,6 +310,8 @@ func RecognizeImage(c *gin.Context) {
 		"ocr_text":        result.OCRText,
 		"detected_logos":  result.DetectedLogos,
 		"detected_labels": result.DetectedLabels,
+		"label_caffeine":  result.LabelCaffeine,
+		"label_mismatch":  result.LabelMismatched,
 	})
 }
 
@@ -150,11 +321,12 @@ func RecognizeImage(c *gin.Context) {
 
 // GetAllBeverages : 모든 음료 목록 조회
 // GET /api/beverages
+// 승인된 음료만 보이고, 로그인한 사용자에게는 본인이 등록한 승인 대기 음료도 보임
 func GetAllBeverages(c *gin.Context) {
 	var beverages []models.Beverage
 
 	// 쿼리 파라미터로 필터링
-	query := config.DB.Model(&models.Beverage{})
+	query := config.DB.Model(&models.Beverage{}).Preload("Sizes").Scopes(services.VisibleBeverages(middleware.GetUserID(c)))
 
 	if category := c.Query("category"); category != "" {
 		query = query.Where("category = ?", category)
@@ -165,6 +337,11 @@ func GetAllBeverages(c *gin.Context) {
 	if verified := c.Query("verified"); verified == "true" {
 		query = query.Where("is_verified = ?", true)
 	}
+	if size := c.Query("size"); size != "" {
+		// 사이즈 목록에 있거나, 사이즈 목록이 없는 음료는 기본 사이즈로 판단
+		query = query.Where("size = ? OR id IN (?)", size,
+			config.DB.Model(&models.BeverageSize{}).Select("beverage_id").Where("label = ?", size))
+	}
 
 	query.Order("name ASC").Find(&beverages)
 
@@ -174,87 +351,257 @@ func GetAllBeverages(c *gin.Context) {
 // GetBeverage : 특정 음료 조회
 // GET /api/beverages/:id
 func GetBeverage(c *gin.Context) {
-	id := c.Param("id")
-	var beverage models.Beverage
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 음료 ID입니다"})
+		return
+	}
 
-	if err := config.DB.Preload("Images").First(&beverage, id).Error; err != nil {
+	// 다른 음료로 병합된 ID면 병합 대상 음료를 돌려줌
+	var beverage models.Beverage
+	if err := config.DB.Preload("Images").Preload("Barcodes").Preload("Sizes").First(&beverage, services.ResolveBeverageID(uint(id))).Error; err != nil ||
+		!services.CanViewBeverage(&beverage, middleware.GetUserID(c), middleware.IsModerator(c)) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "음료를 찾을 수 없습니다"})
 		return
 	}
+	services.BeverageSizes(&beverage)
 
 	c.JSON(http.StatusOK, beverage)
 }
 
+// beverageCreateInput : 음료 등록 요청 (이미지, 상태, 병합 정보는 받지 않음)
+type beverageCreateInput struct {
+	Name           string                 `json:"name" binding:"required"`
+	Brand          string                 `json:"brand"`
+	CaffeineAmount float64                `json:"caffeine_amount"`
+	Size           string                 `json:"size"`
+	Volume         float64                `json:"volume"`
+	Category       string                 `json:"category"`
+	IsVerified     bool                   `json:"is_verified"` // 검토자만
+	Sizes          []beverageSizeInput    `json:"sizes"`
+	Barcodes       []beverageBarcodeInput `json:"barcodes"`
+}
+
+// beverageBarcodeInput : 음료 등록 시 함께 저장할 바코드
+type beverageBarcodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+func (in beverageCreateInput) toModel(isModerator bool) models.Beverage {
+	beverage := models.Beverage{
+		Name:           strings.TrimSpace(in.Name),
+		Brand:          strings.TrimSpace(in.Brand),
+		CaffeineAmount: in.CaffeineAmount,
+		Size:           in.Size,
+		Volume:         in.Volume,
+		Category:       in.Category,
+		IsVerified:     isModerator && in.IsVerified,
+	}
+	for _, size := range in.Sizes {
+		beverage.Sizes = append(beverage.Sizes, size.toModel())
+	}
+	for _, barcode := range in.Barcodes {
+		beverage.Barcodes = append(beverage.Barcodes, models.BeverageBarcode{Code: barcode.Code})
+	}
+	return beverage
+}
+
 // CreateBeverage : 새 음료 등록 (수동)
 // POST /api/beverages
+// 검토자(moderator 이상)가 아니면 승인 대기 음료로 등록되고 검토자 승인 후 다른 사용자에게 공개됨
 func CreateBeverage(c *gin.Context) {
-	var input models.Beverage
-	if err := c.ShouldBindJSON(&input); err != nil {
+	var body beverageCreateInput
+	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	input := body.toModel(middleware.IsModerator(c))
 
 	// 중복 체크
 	var existing models.Beverage
 	if err := config.DB.Where("name = ?", input.Name).First(&existing).Error; err == nil {
+		if !services.CanViewBeverage(&existing, middleware.GetUserID(c), middleware.IsModerator(c)) {
+			c.JSON(http.StatusConflict, gin.H{"error": "같은 이름의 음료가 승인 대기 중입니다"})
+			return
+		}
 		c.JSON(http.StatusConflict, gin.H{"error": "이미 존재하는 음료입니다", "beverage": existing})
 		return
 	}
 
-	config.DB.Create(&input)
-	c.JSON(http.StatusCreated, input)
+	// 바코드 정규화 (UPC-A → 13자리) 및 중복 체크
+	for i := range input.Barcodes {
+		code, format, err := services.NormalizeBarcode(input.Barcodes[i].Code)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "barcode": input.Barcodes[i].Code})
+			return
+		}
+		if _, err := services.FindBeverageByBarcode(code); err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": services.ErrBarcodeTaken.Error(), "barcode": code})
+			return
+		}
+		input.Barcodes[i] = models.BeverageBarcode{Code: code, Format: format}
+	}
+
+	// 사이즈 목록을 주면 기본 사이즈 값을 음료 필드에 채움
+	if err := services.PrepareBeverageSizes(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.CreatedByUser = middleware.GetUserID(c)
+
+	if middleware.IsModerator(c) {
+		input.Status = models.BeverageStatusActive
+		if err := config.DB.Create(&input).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "음료 저장 실패"})
+			return
+		}
+		services.InvalidateBeverageSearchIndex()
+		c.JSON(http.StatusCreated, input)
+		return
+	}
+
+	request, err := services.CreatePendingSubmission(&input, services.ChangeSourceUser)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "음료 저장 실패"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":        "음료 등록 요청이 접수되었습니다. 관리자 승인 후 다른 사용자에게 공개됩니다",
+		"beverage":       input,
+		"change_request": request,
+	})
 }
 
 // UpdateBeverage : 음료 정보 수정
 // PUT /api/beverages/:id
+// 검토자(또는 본인이 등록한 승인 대기 음료)는 바로 반영, 그 외에는 변경 내역이 담긴 수정 요청으로 접수
 func UpdateBeverage(c *gin.Context) {
-	id := c.Param("id")
-	var beverage models.Beverage
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 음료 ID입니다"})
+		return
+	}
+	userID, isModerator := middleware.GetUserID(c), middleware.IsModerator(c)
 
-	if err := config.DB.First(&beverage, id).Error; err != nil {
+	var beverage models.Beverage
+	if err := config.DB.First(&beverage, id).Error; err != nil || !services.CanViewBeverage(&beverage, userID, isModerator) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "음료를 찾을 수 없습니다"})
 		return
 	}
 
-	var input models.Beverage
+	// 바코드/사이즈는 전용 API로만 관리
+	var input services.BeverageChangeInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	config.DB.Model(&beverage).Updates(input)
-	c.JSON(http.StatusOK, beverage)
+	if services.CanEditBeverageDirectly(&beverage, userID, isModerator) {
+		updated, err := services.ApplyBeverageChange(beverage.ID, input, userID, isModerator)
+		if err != nil {
+			c.JSON(changeRequestErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		if !input.RecalculateLogs || !isModerator {
+			c.JSON(http.StatusOK, updated)
+			return
+		}
+		// 바뀐 카페인량을 직접 입력하지 않은 기존 섭취 기록에도 반영
+		recalculated, err := services.RecalculateBeverageLogs(updated.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "섭취 기록 다시 계산 실패"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"beverage": updated, "recalculated_logs": recalculated})
+		return
+	}
+
+	if beverage.Status != models.BeverageStatusActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "승인 대기 중인 음료는 등록한 사용자만 수정할 수 있습니다"})
+		return
+	}
+	request, err := services.RequestBeverageChange(beverage.ID, input, userID)
+	if err != nil {
+		c.JSON(changeRequestErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":        "음료 수정 요청이 접수되었습니다. 관리자 승인 후 반영됩니다",
+		"change_request": request,
+	})
 }
 
-// SearchBeverages : 음료 검색
-// GET /api/beverages/search?q=아메리카노
+// SearchBeverages : 음료 검색 (초성/영문 표기/오타 허용, 순위순)
+// GET /api/beverages/search?q=아메리카노&limit=20
+// 로그인한 사용자는 자주 기록한 음료가 위로 올라옴
 func SearchBeverages(c *gin.Context) {
 	query := c.Query("q")
 	if query == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "검색어가 필요합니다"})
 		return
 	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
 
-	var beverages []models.Beverage
-	config.DB.Where("name LIKE ? OR brand LIKE ?", "%"+query+"%", "%"+query+"%").
-		Order("is_verified DESC, name ASC").
-		Limit(20).
-		Find(&beverages)
+	hits := services.SearchBeverages(query, middleware.GetUserID(c), limit)
+	if len(hits) == 0 {
+		c.JSON(http.StatusOK, []models.Beverage{})
+		return
+	}
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	var found []models.Beverage
+	config.DB.Preload("Sizes").Where("id IN ?", ids).Find(&found)
+
+	// 검색 순위대로 정렬
+	byID := make(map[uint]models.Beverage, len(found))
+	for _, beverage := range found {
+		byID[beverage.ID] = beverage
+	}
+	beverages := make([]models.Beverage, 0, len(found))
+	for _, id := range ids {
+		if beverage, ok := byID[id]; ok {
+			beverages = append(beverages, beverage)
+		}
+	}
 
 	c.JSON(http.StatusOK, beverages)
 }
 
+// AutocompleteBeverages : 음료 자동완성 (키 입력마다 호출, 메모리 색인만 사용)
+// GET /api/beverages/autocomplete?q=ㅅㅂ&limit=8
+func AutocompleteBeverages(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	hits := services.AutocompleteBeverages(c.Query("q"), middleware.GetUserID(c), limit)
+
+	// 같은 입력이 반복되는 경우가 많아 잠깐 캐시 (내 기록이 반영되므로 private)
+	c.Header("Cache-Control", "private, max-age=30")
+	c.JSON(http.StatusOK, gin.H{"query": c.Query("q"), "results": hits})
+}
+
 // ========================================
 // 피드백 API (학습용)
 // ========================================
 
 // SubmitFeedback : 인식 결과에 대한 피드백
 // POST /api/feedback
+// 틀렸을 경우 캐시 이미지를 정정된 음료로 다시 연결하고, 확인이 쌓인 음료는 검증됨으로 승격
 func SubmitFeedback(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "인증이 필요합니다"})
+		return
+	}
+
 	var input struct {
 		RecognitionLogID uint  `json:"recognition_log_id" binding:"required"`
 		IsCorrect        bool  `json:"is_correct"`
 		CorrectedID      *uint `json:"corrected_id"` // 틀렸을 경우 올바른 음료 ID
+		Candidate        *int  `json:"candidate"`    // 인식 후보 중 고른 번호 (0이면 대표 결과가 맞음)
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -262,60 +609,134 @@ func SubmitFeedback(c *gin.Context) {
 		return
 	}
 
-	var log models.RecognitionLog
-	if err := config.DB.First(&log, input.RecognitionLogID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "로그를 찾을 수 없습니다"})
+	result, err := services.ApplyRecognitionFeedback(userID, input.RecognitionLogID, input.IsCorrect, input.CorrectedID, input.Candidate)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRecognitionLogNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "로그를 찾을 수 없습니다"})
+		case errors.Is(err, services.ErrBeverageNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "정정할 음료를 찾을 수 없습니다"})
+		case errors.Is(err, services.ErrInvalidCandidate):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "인식 후보를 찾을 수 없습니다"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "피드백 저장 실패"})
+		}
 		return
 	}
 
-	// 피드백 업데이트
-	log.IsCorrect = &input.IsCorrect
-	if input.CorrectedID != nil {
-		log.CorrectedID = input.CorrectedID
-	}
-
-	config.DB.Save(&log)
-
-	c.JSON(http.StatusOK, gin.H{"message": "피드백이 저장되었습니다"})
+	c.JSON(http.StatusOK, gin.H{
+		"message": "피드백이 저장되었습니다",
+		"result":  result,
+	})
 }
 
 // ========================================
 // 통계 API
 // ========================================
 
-// GetRecognitionStats : 인식 통계
-// GET /api/stats/recognition
+// GetRecognitionStats : 인식 분석 (관리자)
+// GET /api/admin/stats/recognition?from=2025-01-01&to=2025-01-31&interval=day&user_id=&source=&provider=gemini&prompt_version=
 func GetRecognitionStats(c *gin.Context) {
-	var totalLogs int64
-	var visionAPIUsed int64
-	var correctFeedbacks int64
+	filter, ok := analyticsFilter(c)
+	if !ok {
+		return
+	}
+	userID, _ := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	filter.UserID = uint(userID)
+	filter.Source = c.Query("source")
+	filter.Provider = c.Query("provider")
+	filter.PromptVersion = c.Query("prompt_version")
 
-	config.DB.Model(&models.RecognitionLog{}).Count(&totalLogs)
-	config.DB.Model(&models.RecognitionLog{}).Where("vision_api_used = ?", true).Count(&visionAPIUsed)
-	config.DB.Model(&models.RecognitionLog{}).Where("is_correct = ?", true).Count(&correctFeedbacks)
+	respondRecognitionAnalytics(c, filter)
+}
 
-	var avgProcessingTime float64
-	config.DB.Model(&models.RecognitionLog{}).Select("AVG(processing_time)").Scan(&avgProcessingTime)
+// GetMyRecognitionStats : 내 인식 분석 (정정률, 신뢰도 보정, 자주 고친 음료)
+// GET /api/me/recognition-stats?from=2025-01-01&to=2025-01-31&interval=week
+func GetMyRecognitionStats(c *gin.Context) {
+	filter, ok := analyticsFilter(c)
+	if !ok {
+		return
+	}
+	filter.UserID = middleware.GetUserID(c)
 
-	var totalBeverages int64
-	var verifiedBeverages int64
-	config.DB.Model(&models.Beverage{}).Count(&totalBeverages)
-	config.DB.Model(&models.Beverage{}).Where("is_verified = ?", true).Count(&verifiedBeverages)
+	respondRecognitionAnalytics(c, filter)
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"total_recognitions":     totalLogs,
-		"vision_api_calls":       visionAPIUsed,
-		"correct_feedbacks":      correctFeedbacks,
-		"avg_processing_time_ms": int(avgProcessingTime),
-		"total_beverages":        totalBeverages,
-		"verified_beverages":     verifiedBeverages,
-		"cache_hit_rate":         float64(totalLogs-visionAPIUsed) / float64(max(totalLogs, 1)) * 100,
-	})
+// GetMyRecognitions : 내 인식 기록 (최근순)
+// GET /api/me/recognitions?page=1&limit=20
+func GetMyRecognitions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.Query("page"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	items, total, err := services.ListRecognitionHistory(middleware.GetUserID(c), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "인식 기록 조회 실패"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recognitions": items, "total": total})
+}
+
+// analyticsFilter : ?from=&to=&interval= 쿼리 (날짜만 주면 to는 그날 끝까지)
+func analyticsFilter(c *gin.Context) (services.RecognitionAnalyticsFilter, bool) {
+	filter := services.RecognitionAnalyticsFilter{Interval: c.Query("interval")}
+	var err error
+	if filter.From, err = parseAnalyticsTime(c.Query("from"), false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from 형식이 올바르지 않습니다 (YYYY-MM-DD 또는 RFC3339)"})
+		return filter, false
+	}
+	if filter.To, err = parseAnalyticsTime(c.Query("to"), true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to 형식이 올바르지 않습니다 (YYYY-MM-DD 또는 RFC3339)"})
+		return filter, false
+	}
+	return filter, true
+}
+
+// parseAnalyticsTime : RFC3339 또는 날짜 (빈 값은 zero time)
+func parseAnalyticsTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// respondRecognitionAnalytics : 분석 결과 응답
+func respondRecognitionAnalytics(c *gin.Context, filter services.RecognitionAnalyticsFilter) {
+	analytics, err := services.GetRecognitionAnalytics(filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAnalyticsRange) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "인식 분석 실패"})
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
 }
 
-func max(a, b int64) int64 {
-	if a > b {
-		return a
+// recognitionErrorStatus : 인식 에러의 HTTP 상태 코드
+// 잘못된 업로드는 400, LLM이 형식에 맞지 않는 응답만 준 경우 502, LLM 미설정은 503
+func recognitionErrorStatus(err error) int {
+	var outputErr *services.LLMOutputError
+	switch {
+	case errors.Is(err, services.ErrUnsupportedImage),
+		errors.Is(err, services.ErrInvalidImageData),
+		errors.Is(err, services.ErrImageTooLarge):
+		return http.StatusBadRequest
+	case errors.As(err, &outputErr):
+		return http.StatusBadGateway
+	case errors.Is(err, services.ErrLLMNotConfigured):
+		return http.StatusServiceUnavailable
 	}
-	return b
+	return http.StatusInternalServerError
 }
@@ -4,8 +4,10 @@ import (
 	"caffy-backend/config"
 	"caffy-backend/controllers"
 	"caffy-backend/middleware"
+	"caffy-backend/models"
 	"caffy-backend/services"
 	"log"
+	"os"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,19 +20,35 @@ func main() {
 	config.Connect()
 
 	// 3. 이미지 저장소 초기화
-	services.InitImageStorage()
+	if err := services.InitImageStorage(); err != nil {
+		log.Fatalf("❌ 이미지 저장소 초기화 실패: %v", err)
+	}
+
+	// 기본 별칭 등록 (이미 있으면 건너뜀)
+	services.SeedBeverageAliases()
+
+	// 예전 ADMIN_EMAILS 설정이 남아 있으면 admin 역할로 옮김 (관리자가 없을 때만)
+	services.MigrateLegacyAdminEmails()
+
+	// 관리 명령 (예: go run . gc --dry-run)이면 실행 후 종료
+	if runCommand(os.Args[1:]) {
+		return
+	}
+
+	// 4. 백그라운드 작업 시작 (비동기 인식 워커, 이미지 정리)
+	services.StartRecognitionWorkers()
+	services.StartImageGC()
 
-	// 4. Gin 모드 설정
+	// 5. Gin 모드 설정
 	gin.SetMode(config.GinMode)
 
-	// 5. Gin 라우터 설정
+	// 6. Gin 라우터 설정
 	r := gin.Default()
 
 	// CORS 설정 (커스텀 미들웨어 - Credentials 지원)
 	r.Use(middleware.CORSMiddleware())
 
-	// 정적 파일 서빙 (업로드된 이미지)
-	r.Static("/uploads", config.UploadPath)
+	// 업로드된 사진은 정적 경로로 공개하지 않음 (본인 기록만 /api/logs/:id/image로 조회)
 
 	// API 라우팅 정의
 	api := r.Group("/api")
@@ -44,14 +62,20 @@ func main() {
 		protected.Use(middleware.AuthMiddleware())
 		{
 			// 사용자 정보
-			protected.GET("/me", controllers.GetMe)                    // 내 정보 조회
-			protected.PUT("/me", controllers.UpdateMe)                 // 내 정보 수정
-			protected.POST("/me/password", controllers.ChangePassword) // 비밀번호 변경
+			protected.GET("/me", controllers.GetMe)                                   // 내 정보 조회
+			protected.PUT("/me", controllers.UpdateMe)                                // 내 정보 수정
+			protected.POST("/me/password", controllers.ChangePassword)                // 비밀번호 변경
+			protected.GET("/me/storage", controllers.GetMyStorage)                    // 사진 저장 사용량
+			protected.GET("/me/beverage-requests", controllers.GetMyBeverageRequests) // 내가 보낸 음료 등록/수정 요청
+			protected.GET("/me/recognitions", controllers.GetMyRecognitions)          // 내 인식 기록
+			protected.GET("/me/recognition-stats", controllers.GetMyRecognitionStats) // 내 인식 분석 (?from=&to=&interval=)
 
 			// 카페인 관련
 			protected.POST("/logs", controllers.AddLog)                  // 마심
+			protected.POST("/logs/batch", controllers.AddLogs)           // 여러 잔 한 번에 기록 (다중 음료 인식)
 			protected.GET("/logs", controllers.GetMyLogs)                // 섭취 기록 히스토리
 			protected.PUT("/logs/:id", controllers.UpdateLog)            // 섭취 기록 수정
+			protected.GET("/logs/:id/image", controllers.GetLogImage)    // 기록의 인식 사진
 			protected.DELETE("/logs/:id", controllers.DeleteLog)         // 섭취 기록 삭제
 			protected.GET("/status", controllers.GetMyStatus)            // 내 상태 확인 (토큰 기반)
 			protected.GET("/graph", controllers.GetGraphData)            // 그래프 데이터 조회
@@ -61,10 +85,29 @@ func main() {
 			protected.POST("/recognize", controllers.RecognizeImage)            // 이미지로 음료 인식 (기존)
 			protected.POST("/recognize/smart", controllers.SmartRecognizeImage) // 스마트 인식 (DB→LLM)
 			protected.POST("/recognize/text", controllers.RecognizeByText)      // 텍스트로 카페인 추정
+			protected.POST("/recognize/log", controllers.RecognizeAndLog)       // 인식 + 섭취 기록 한 번에
+
+			// 비동기 인식 작업 (느린 네트워크에서 요청 타임아웃 방지)
+			protected.POST("/recognize/jobs", controllers.SubmitRecognitionJob)           // 작업 제출 (즉시 작업 ID 반환)
+			protected.GET("/recognize/jobs/:id", controllers.GetRecognitionJob)           // 작업 상태/결과 조회
+			protected.GET("/recognize/jobs/:id/stream", controllers.StreamRecognitionJob) // 작업 상태 스트리밍 (SSE)
 
 			// 피드백
 			protected.POST("/feedback", controllers.SubmitFeedback) // 인식 피드백
 
+			// 음료 등록/수정 (검토자가 아니면 승인 요청으로 접수)
+			protected.POST("/beverages", controllers.CreateBeverage)    // 음료 등록
+			protected.PUT("/beverages/:id", controllers.UpdateBeverage) // 음료 수정
+
+			// 바코드
+			protected.POST("/beverages/barcode/:code/confirm", controllers.ConfirmBarcodeBeverage) // 확인 대기 음료 확정
+			protected.POST("/beverages/:id/barcodes", controllers.AddBeverageBarcode)              // 음료에 바코드 추가
+
+			// 음료 사이즈
+			protected.POST("/beverages/:id/sizes", controllers.AddBeverageSize)              // 사이즈 추가
+			protected.PUT("/beverages/:id/sizes/:sizeId", controllers.UpdateBeverageSize)    // 사이즈 수정
+			protected.DELETE("/beverages/:id/sizes/:sizeId", controllers.DeleteBeverageSize) // 사이즈 삭제
+
 			// ========== 개인별 학습 API ==========
 			protected.POST("/learning/feedback", controllers.SubmitSenseFeedback)        // 체감 피드백 제출
 			protected.GET("/learning/stats", controllers.GetLearningStats)               // 학습 통계 조회
@@ -73,18 +116,69 @@ func main() {
 		}
 
 		// ========== 공개 API ==========
-		// 음료 정보 조회 (인증 불필요)
-		api.GET("/beverages", controllers.GetAllBeverages)        // 전체 음료 목록
-		api.GET("/beverages/search", controllers.SearchBeverages) // 음료 검색
-		api.GET("/beverages/:id", controllers.GetBeverage)        // 특정 음료 조회
-		api.POST("/beverages", controllers.CreateBeverage)        // 음료 등록
-		api.PUT("/beverages/:id", controllers.UpdateBeverage)     // 음료 수정
-
-		// 통계
-		api.GET("/stats/recognition", controllers.GetRecognitionStats) // 인식 통계
+		// 음료 정보 조회 (인증 불필요, 토큰이 있으면 내 승인 대기 음료와 기록을 반영)
+		public := api.Group("")
+		public.Use(middleware.OptionalAuthMiddleware())
+		{
+			public.GET("/beverages", controllers.GetAllBeverages)                    // 전체 음료 목록
+			public.GET("/beverages/barcode/:code", controllers.GetBeverageByBarcode) // 바코드로 음료 조회
+			public.GET("/beverages/search", controllers.SearchBeverages)             // 음료 검색
+			public.GET("/beverages/autocomplete", controllers.AutocompleteBeverages) // 음료 자동완성
+			public.GET("/beverages/:id", controllers.GetBeverage)                    // 특정 음료 조회
+		}
+
+		// ========== 검토자 API (moderator 이상) ==========
+		moderation := api.Group("/admin")
+		moderation.Use(middleware.AuthMiddleware(), middleware.RequireRole(models.RoleModerator))
+		{
+			// 음료 등록/수정 요청 검토
+			moderation.GET("/beverage-requests", controllers.ListBeverageRequests)                // 요청 목록 (?status=pending)
+			moderation.GET("/beverage-requests/:id", controllers.GetBeverageRequest)              // 요청과 변경 내역
+			moderation.POST("/beverage-requests/:id/approve", controllers.ApproveBeverageRequest) // 승인 (반영 후 공개)
+			moderation.POST("/beverage-requests/:id/reject", controllers.RejectBeverageRequest)   // 거절
+
+			// 음료 목록 (승인 대기/거절 포함)
+			moderation.GET("/beverages", controllers.ListAdminBeverages)
+			moderation.GET("/beverages/duplicates", controllers.ListDuplicateBeverages)                        // 중복 후보 (?beverage_id=&min_score=)
+			moderation.GET("/beverages/:id/revisions", controllers.ListBeverageRevisions)                      // 변경 이력
+			moderation.POST("/beverages/:id/revisions/:revisionId/revert", controllers.RevertBeverageRevision) // 이력 되돌리기 ({"reason", "recalculate_logs"})
+			moderation.POST("/beverages/:id/recalculate-logs", controllers.RecalculateBeverageLogs)            // 섭취 기록 다시 계산
+
+			// 음료 별칭/동의어 (OCR 매칭, 텍스트 추정, 검색에 사용)
+			moderation.GET("/aliases", controllers.ListBeverageAliases)        // 별칭 목록
+			moderation.POST("/aliases", controllers.CreateBeverageAlias)       // 별칭 등록
+			moderation.PUT("/aliases/:id", controllers.UpdateBeverageAlias)    // 별칭 수정
+			moderation.DELETE("/aliases/:id", controllers.DeleteBeverageAlias) // 별칭 삭제
+			moderation.POST("/aliases/match", controllers.MatchBeverageText)   // 텍스트 매칭 결과 확인
+		}
+
+		// ========== 관리자 API (admin) ==========
+		admin := api.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(), middleware.RequireRole(models.RoleAdmin))
+		{
+			// 사용자
+			admin.GET("/users", controllers.ListUsers)            // 사용자 목록 (?q=&role=)
+			admin.PUT("/users/:id/role", controllers.SetUserRole) // 역할 변경
+
+			// 인식 로그/통계
+			admin.GET("/recognition-logs", controllers.ListRecognitionLogs)  // 인식 로그 (?user_id=&source=&feedback=)
+			admin.GET("/stats/recognition", controllers.GetRecognitionStats) // 인식 분석 (?from=&to=&interval=&provider=)
+
+			// 음료 카탈로그 (프랜차이즈 메뉴 일괄 등록)
+			admin.POST("/catalog/import", controllers.ImportCatalog) // 카탈로그 가져오기 (?dry_run=true로 미리보기)
+			admin.GET("/catalog/export", controllers.ExportCatalog)  // 카탈로그 내보내기 (?format=csv|json)
+
+			// 음료 삭제/병합
+			admin.DELETE("/beverages/:id", controllers.DeleteBeverage)    // 음료 삭제
+			admin.POST("/beverages/:id/merge", controllers.MergeBeverage) // 다른 음료로 병합 ({"into": ID})
+
+			// 유지보수 작업 (재학습, 이미지 정리, 검색 색인, 캐시 정리)
+			admin.GET("/jobs", controllers.ListMaintenanceJobs)      // 작업 목록
+			admin.POST("/jobs/:name", controllers.RunMaintenanceJob) // 작업 실행 (?dry_run=true)
+		}
 	}
 
-	// 6. 서버 실행
+	// 7. 서버 실행
 	log.Printf("🚀 서버 시작: http://localhost:%s", config.ServerPort)
 	r.Run(":" + config.ServerPort)
 }
@@ -81,6 +81,21 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// OptionalAuthMiddleware : 토큰이 있으면 사용자 정보를 저장하고, 없거나 잘못돼도 그대로 통과
+// 공개 API에서 로그인한 사용자에게만 개인화된 결과를 줄 때 사용
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenParts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(tokenParts) == 2 && tokenParts[0] == "Bearer" {
+			if claims, err := ValidateToken(tokenParts[1]); err == nil {
+				c.Set("userID", claims.UserID)
+				c.Set("email", claims.Email)
+			}
+		}
+		c.Next()
+	}
+}
+
 // GetUserID : 컨텍스트에서 사용자 ID 가져오기
 func GetUserID(c *gin.Context) uint {
 	userID, exists := c.Get("userID")
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// roleRank : 역할 서열 (높은 역할은 낮은 역할의 권한을 모두 가짐)
+var roleRank = map[string]int{
+	models.RoleUser:      1,
+	models.RoleModerator: 2,
+	models.RoleAdmin:     3,
+}
+
+// RequireRole : 지정한 역할 이상만 통과 (AuthMiddleware 뒤에 사용)
+// 예: RequireRole(models.RoleModerator) → moderator, admin 통과
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if GetUserID(c) == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "인증 토큰이 필요합니다"})
+			c.Abort()
+			return
+		}
+		if !HasRole(c, role) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "권한이 없습니다 (" + role + " 이상 필요)"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetRole : 요청한 사용자의 역할
+// 토큰이 아니라 DB에서 읽으므로 역할을 바꾸면 다음 요청부터 바로 반영 (요청 안에서는 한 번만 조회)
+func GetRole(c *gin.Context) string {
+	if role, ok := c.Get("role"); ok {
+		return role.(string)
+	}
+
+	role := ""
+	if userID := GetUserID(c); userID != 0 {
+		config.DB.Model(&models.User{}).Select("role").Where("id = ?", userID).Scan(&role)
+	}
+	c.Set("role", role)
+	return role
+}
+
+// HasRole : 요청한 사용자가 지정한 역할 이상인지
+func HasRole(c *gin.Context, role string) bool {
+	rank, ok := roleRank[GetRole(c)]
+	return ok && rank >= roleRank[role]
+}
+
+// IsModerator : 음료 등록/수정을 검토할 수 있는 사용자인지 (moderator, admin)
+func IsModerator(c *gin.Context) bool {
+	return HasRole(c, models.RoleModerator)
+}
@@ -20,6 +20,9 @@ type User struct {
 	ExercisePerWeek int     `json:"exercise_per_week"` // 주당 운동 횟수
 	MetabolismType  int     `json:"metabolism_type"`   // 0:Normal(5h), 1:Fast(3h), 2:Slow(8h)
 
+	// 권한
+	Role string `json:"role" gorm:"type:varchar(20);default:user;index"` // "user", "moderator", "admin"
+
 	// 개인화된 학습 파라미터
 	PersonalHalfLife   float64 `json:"personal_half_life" gorm:"default:5.0"` // 학습된 개인 반감기 (시간)
 	LearningConfidence float64 `json:"learning_confidence" gorm:"default:0"`  // 학습 신뢰도 (0~1)
@@ -32,6 +35,13 @@ type User struct {
 	SenseFeedbacks []CaffeineFeedback `json:"sense_feedbacks"` // 체감 피드백
 }
 
+// 사용자 역할 (moderator: 음료 등록/수정 검토, admin: 사용자/통계/유지보수까지 전체 관리)
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 // LoginRequest : 로그인 요청
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
@@ -68,6 +78,17 @@ type CaffeineLog struct {
 	Amount         float64   `json:"amount"`                          // 실제 섭취량 (original * ratio)
 	IntakeAt       time.Time `json:"intake_at"`                       // 실제 마신 시간
 	BeverageID     *uint     `json:"beverage_id"`                     // 인식된 음료 ID (nullable)
+	BeverageSizeID *uint     `json:"beverage_size_id" gorm:"index"`   // 고른 사이즈 ID (용량으로 환산한 경우 nil)
+	VolumeML       float64   `json:"volume_ml"`                       // 마신 음료 용량 (ml, 0: 모름)
+
+	// 사진 인식으로 생성된 기록의 출처 (정정 시 함께 갱신)
+	RecognitionLogID *uint `json:"recognition_log_id" gorm:"index"`        // 이 기록을 만든 인식 로그 ID
+	BeverageImageID  *uint `json:"beverage_image_id" gorm:"index"`         // 결과를 낸 캐시 이미지 ID
+	DrinkIndex       int   `json:"drink_index" gorm:"default:0"`           // 사진 속 몇 번째 음료인지 (0: 대표 음료)
+	AmountOverridden bool  `json:"amount_overridden" gorm:"default:false"` // 사용자가 카페인량을 직접 입력함 (정정 시 덮어쓰지 않음)
+
+	RecognitionLog *RecognitionLog `json:"recognition,omitempty"`        // 히스토리 조회 시 Preload
+	ImageURL       string          `json:"image_url,omitempty" gorm:"-"` // 인식 사진 URL (응답 전용)
 }
 
 // ========================================
@@ -77,44 +98,209 @@ type CaffeineLog struct {
 // Beverage : 음료 정보 (마스터 데이터)
 type Beverage struct {
 	gorm.Model
-	Name           string          `json:"name" gorm:"type:varchar(255);uniqueIndex"` // 음료 이름 (예: 스타벅스 아메리카노)
-	Brand          string          `json:"brand" gorm:"type:varchar(100)"`            // 브랜드 (예: 스타벅스, 이디야)
-	CaffeineAmount float64         `json:"caffeine_amount"`                           // 카페인 함량 (mg)
-	Size           string          `json:"size" gorm:"type:varchar(50)"`              // 사이즈 (Tall, Grande 등)
-	Volume         float64         `json:"volume"`                                    // 용량 (ml)
-	Category       string          `json:"category" gorm:"type:varchar(50)"`          // 카테고리 (커피, 에너지드링크, 차 등)
-	IsVerified     bool            `json:"is_verified" gorm:"default:false"`          // 검증된 데이터 여부
-	Images         []BeverageImage `json:"images"`                                    // 1:N 관계
+	Name           string            `json:"name" gorm:"type:varchar(255);uniqueIndex"`           // 음료 이름 (예: 스타벅스 아메리카노)
+	Brand          string            `json:"brand" gorm:"type:varchar(100)"`                      // 브랜드 (예: 스타벅스, 이디야)
+	CaffeineAmount float64           `json:"caffeine_amount"`                                     // 카페인 함량 (mg)
+	Size           string            `json:"size" gorm:"type:varchar(50)"`                        // 사이즈 (Tall, Grande 등)
+	Volume         float64           `json:"volume"`                                              // 용량 (ml)
+	Category       string            `json:"category" gorm:"type:varchar(50)"`                    // 카테고리 (커피, 에너지드링크, 차 등)
+	IsVerified     bool              `json:"is_verified" gorm:"default:false"`                    // 검증된 데이터 여부
+	Status         string            `json:"status" gorm:"type:varchar(20);default:active;index"` // "active", "pending" (승인 대기, 등록한 사용자만 보임), "rejected"
+	CreatedByUser  uint              `json:"created_by_user"`                                     // 생성한 사용자 ID (0: 시스템)
+	MergedIntoID   *uint             `json:"merged_into_id,omitempty" gorm:"index"`               // 다른 음료로 병합됐으면 병합 대상 ID (삭제 처리, 예전 ID는 이 음료로 연결)
+	Images         []BeverageImage   `json:"images"`                                              // 1:N 관계
+	Barcodes       []BeverageBarcode `json:"barcodes,omitempty"`                                  // 1:N 관계 (용량/패키지별 바코드)
+	Sizes          []BeverageSize    `json:"sizes,omitempty"`                                     // 1:N 관계 (사이즈별 용량/카페인, Size/Volume/CaffeineAmount는 기본 사이즈 값)
+}
+
+// 음료 상태
+const (
+	BeverageStatusActive   = "active"
+	BeverageStatusPending  = "pending"
+	BeverageStatusRejected = "rejected"
+)
+
+// BeverageChangeRequest : 음료 등록/수정 요청 (관리자가 승인해야 다른 사용자에게 보임)
+type BeverageChangeRequest struct {
+	gorm.Model
+	BeverageID  uint       `json:"beverage_id" gorm:"index"`                             // 대상 음료 (등록 요청이면 승인 대기 음료)
+	Action      string     `json:"action" gorm:"type:varchar(20)"`                       // "create", "update", "barcode"
+	Status      string     `json:"status" gorm:"type:varchar(20);default:pending;index"` // "pending", "approved", "rejected"
+	Source      string     `json:"source" gorm:"type:varchar(20)"`                       // "user", "barcode", "recognition", "text_estimate"
+	Changes     string     `json:"-" gorm:"type:text"`                                   // 제안한 값 (JSON, 필드 → 값)
+	Previous    string     `json:"-" gorm:"type:text"`                                   // 요청 당시 값 (JSON, 변경 내역 표시용)
+	SubmittedBy uint       `json:"submitted_by" gorm:"index"`
+	ReviewedBy  uint       `json:"reviewed_by"`
+	ReviewedAt  *time.Time `json:"reviewed_at"`
+	ReviewNote  string     `json:"review_note" gorm:"type:varchar(500)"`
+
+	Diff     []BeverageFieldChange `json:"diff" gorm:"-"`               // 변경 내역 (응답 전용)
+	Beverage *Beverage             `json:"beverage,omitempty" gorm:"-"` // 대상 음료 (응답 전용)
+}
+
+// BeverageFieldChange : 변경 요청의 필드별 변경 내역
+type BeverageFieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// 변경 요청 종류/상태
+const (
+	ChangeActionCreate  = "create"
+	ChangeActionUpdate  = "update"
+	ChangeActionBarcode = "barcode" // 승인된 음료에 바코드 추가
+
+	ChangeRequestPending  = "pending"
+	ChangeRequestApproved = "approved"
+	ChangeRequestRejected = "rejected"
+)
+
+// BeverageRevision : 음료 변경 이력 (추가만 하고 고치거나 지우지 않음)
+type BeverageRevision struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt       time.Time `json:"created_at"`
+	BeverageID      uint      `json:"beverage_id" gorm:"index"`
+	Changes         string    `json:"-" gorm:"type:text"`              // 필드별 변경 내역 (JSON, BeverageFieldChange 목록)
+	AuthorID        uint      `json:"author_id" gorm:"index"`          // 바꾼 사용자 (0: 시스템)
+	Reason          string    `json:"reason" gorm:"type:varchar(500)"` // 변경 사유
+	Source          string    `json:"source" gorm:"type:varchar(20)"`  // "user", "review", "catalog", "size", "barcode", "feedback", "revert"
+	ChangeRequestID *uint     `json:"change_request_id,omitempty"`     // 승인한 변경 요청
+	RevertOf        *uint     `json:"revert_of,omitempty"`             // 되돌린 이력 ID
+
+	Diff []BeverageFieldChange `json:"diff" gorm:"-"` // 변경 내역 (응답 전용)
+}
+
+// BeverageSize : 음료 사이즈별 용량/카페인 (예: 아메리카노 Tall 355ml 150mg)
+type BeverageSize struct {
+	gorm.Model
+	BeverageID     uint    `json:"beverage_id" gorm:"uniqueIndex:uk_beverage_size_label"`
+	Label          string  `json:"label" gorm:"type:varchar(50);uniqueIndex:uk_beverage_size_label"` // 사이즈 이름 (Tall, Grande, 355ml 캔 등)
+	VolumeML       float64 `json:"volume_ml"`                                                        // 용량 (ml, 0: 모름)
+	CaffeineAmount float64 `json:"caffeine_amount"`                                                  // 카페인 함량 (mg)
+	Shots          int     `json:"shots"`                                                            // 에스프레소 샷 수 (0: 해당 없음)
+	IsDefault      bool    `json:"is_default" gorm:"default:false"`                                  // 사이즈를 고르지 않았을 때 쓰는 사이즈
+}
+
+// BeverageAlias : 음료 별칭/동의어 (OCR, 텍스트 추정, 검색에서 공통으로 사용)
+type BeverageAlias struct {
+	gorm.Model
+	Alias         string  `json:"alias" gorm:"type:varchar(100)"`                                    // 원문 표기 (예: "아아", "americano")
+	Normalized    string  `json:"normalized" gorm:"type:varchar(100);uniqueIndex:uk_beverage_alias"` // 비교용 표기 (소문자, 공백/문장부호 제거)
+	TargetType    string  `json:"target_type" gorm:"type:varchar(20);index"`                         // "beverage", "category", "term"
+	TargetKey     string  `json:"-" gorm:"type:varchar(150);uniqueIndex:uk_beverage_alias"`          // 중복 방지용 대상 키 (예: "beverage:12")
+	BeverageID    *uint   `json:"beverage_id" gorm:"index"`                                          // TargetType이 beverage일 때
+	Category      string  `json:"category" gorm:"type:varchar(50)"`                                  // TargetType이 category일 때
+	Term          string  `json:"term" gorm:"type:varchar(100)"`                                     // TargetType이 term일 때 바꿔 쓸 표기 (예: 스벅 → 스타벅스)
+	Language      string  `json:"language" gorm:"type:varchar(10)"`                                  // "ko", "en"
+	Weight        float64 `json:"weight" gorm:"default:1"`                                           // 매칭 가중치 (0~2, 기본 1)
+	CreatedByUser uint    `json:"created_by_user"`                                                   // 등록한 사용자 ID (0: 기본 제공)
+}
+
+// 별칭 대상 종류
+const (
+	AliasTargetBeverage = "beverage" // 특정 음료
+	AliasTargetCategory = "category" // 음료 분류
+	AliasTargetTerm     = "term"     // 검색어 치환 (줄임말, 영문 표기)
+)
+
+// BeverageBarcode : 음료 바코드 (EAN-13 / UPC-A / EAN-8)
+type BeverageBarcode struct {
+	gorm.Model
+	BeverageID uint   `json:"beverage_id" gorm:"index"`
+	Code       string `json:"code" gorm:"type:varchar(14);uniqueIndex"` // 정규화된 코드 (UPC-A는 앞에 0을 붙인 13자리)
+	Format     string `json:"format" gorm:"type:varchar(10)"`           // "ean13", "upca", "ean8"
 }
 
 // BeverageImage : 음료 이미지 인식 데이터
 type BeverageImage struct {
 	gorm.Model
-	BeverageID     *uint   `json:"beverage_id" gorm:"index"`                 // 연결된 음료 ID (nullable)
-	ImageHash      string  `json:"image_hash" gorm:"type:varchar(64);index"` // 이미지 해시 (pHash)
-	ImagePath      string  `json:"image_path" gorm:"type:varchar(500)"`      // 저장된 이미지 경로
-	DrinkName      string  `json:"drink_name" gorm:"type:varchar(255)"`      // 음료 이름 (LLM 인식 결과)
-	CaffeineAmount int     `json:"caffeine_amount"`                          // 카페인량 (mg)
-	OCRText        string  `json:"ocr_text" gorm:"type:text"`                // OCR로 추출된 텍스트
-	Labels         string  `json:"labels" gorm:"type:text"`                  // Vision API 라벨 (JSON)
-	Logos          string  `json:"logos" gorm:"type:varchar(255)"`           // 인식된 로고
-	Confidence     float64 `json:"confidence"`                               // 인식 신뢰도 (0~1)
-	Source         string  `json:"source" gorm:"type:varchar(20)"`           // "user", "llm", "admin"
-	UsageCount     int     `json:"usage_count" gorm:"default:0"`             // 사용 횟수 (인기도)
-	UploadedByUser uint    `json:"uploaded_by_user"`                         // 업로드한 사용자 ID
+	BeverageID     *uint   `json:"beverage_id" gorm:"index"`                                                     // 연결된 음료 ID (nullable)
+	ImageHash      string  `json:"image_hash" gorm:"type:varchar(64);uniqueIndex:uk_beverage_images_image_hash"` // 이미지 해시 (중복 저장 방지)
+	ImagePath      string  `json:"image_path" gorm:"type:varchar(500)"`                                          // 저장소 키 (예: 12/20250101_120000_아메리카노_ab12cd34.jpg)
+	DrinkName      string  `json:"drink_name" gorm:"type:varchar(255)"`                                          // 음료 이름 (LLM 인식 결과)
+	CaffeineAmount int     `json:"caffeine_amount"`                                                              // 카페인량 (mg)
+	OCRText        string  `json:"ocr_text" gorm:"type:text"`                                                    // OCR로 추출된 텍스트
+	Labels         string  `json:"labels" gorm:"type:text"`                                                      // Vision API 라벨 (JSON)
+	Logos          string  `json:"logos" gorm:"type:varchar(255)"`                                               // 인식된 로고
+	Detections     string  `json:"detections" gorm:"type:text"`                                                  // 사진 속 전체 음료 목록 (JSON)
+	Confidence     float64 `json:"confidence"`                                                                   // 인식 신뢰도 (0~1)
+	Source         string  `json:"source" gorm:"type:varchar(20)"`                                               // "user", "llm", "vision", "barcode", "admin"
+	UsageCount     int     `json:"usage_count" gorm:"default:0"`                                                 // 사용 횟수 (인기도)
+	UploadedByUser uint    `json:"uploaded_by_user"`                                                             // 업로드한 사용자 ID
+}
+
+// TextEstimateCache : 텍스트 카페인 추정 결과 캐시 (정규화된 음료명 + 사이즈별)
+type TextEstimateCache struct {
+	gorm.Model
+	NormalizedName string  `json:"normalized_name" gorm:"type:varchar(255);uniqueIndex:uk_text_estimate_name_size"`
+	SizeKey        string  `json:"size_key" gorm:"type:varchar(50);uniqueIndex:uk_text_estimate_name_size"` // "tall", "355ml", "" (기본)
+	DrinkName      string  `json:"drink_name" gorm:"type:varchar(255)"`
+	Brand          string  `json:"brand" gorm:"type:varchar(100)"`
+	Category       string  `json:"category" gorm:"type:varchar(50)"`
+	CaffeineAmount int     `json:"caffeine_amount"`
+	Confidence     float64 `json:"confidence"`
+	PromptVersion  string  `json:"prompt_version" gorm:"type:varchar(50)"`
+	BeverageID     *uint   `json:"beverage_id" gorm:"index"` // 제안으로 등록된 음료 (미검증)
+	HitCount       int     `json:"hit_count" gorm:"default:0"`
 }
 
+// StoredImage : 저장소에 올라간 이미지 파일 색인 (사용량 집계 및 정리용)
+type StoredImage struct {
+	gorm.Model
+	StorageKey string `json:"storage_key" gorm:"type:varchar(500);uniqueIndex"` // 저장소 키
+	UserID     uint   `json:"user_id" gorm:"index"`                             // 업로드한 사용자 (용량 제한 기준)
+	SizeBytes  int64  `json:"size_bytes"`
+	Kind       string `json:"kind" gorm:"type:varchar(20)"` // "original", "thumbnail"
+}
+
+// 저장 이미지 종류
+const (
+	StoredImageOriginal  = "original"
+	StoredImageThumbnail = "thumbnail"
+)
+
 // RecognitionLog : 인식 시도 로그 (학습 데이터용)
 type RecognitionLog struct {
 	gorm.Model
-	UserID         uint    `json:"user_id" gorm:"index"`
-	ImagePath      string  `json:"image_path" gorm:"type:varchar(500)"` // 원본 이미지 경로
-	RecognizedID   *uint   `json:"recognized_id"`                       // 인식된 음료 ID (실패시 null)
-	Confidence     float64 `json:"confidence"`                          // 인식 신뢰도
-	IsCorrect      *bool   `json:"is_correct"`                          // 사용자 피드백 (맞음/틀림)
-	CorrectedID    *uint   `json:"corrected_id"`                        // 사용자가 수정한 음료 ID
-	VisionAPIUsed  bool    `json:"vision_api_used"`                     // Vision API 사용 여부
-	ProcessingTime int     `json:"processing_time"`                     // 처리 시간 (ms)
+	UserID          uint    `json:"user_id" gorm:"index"`
+	ImagePath       string  `json:"image_path" gorm:"type:varchar(500)"`          // 원본 이미지 저장소 키
+	ImageHash       string  `json:"image_hash" gorm:"type:varchar(64);index"`     // 이미지 해시 (캐시 키)
+	BeverageImageID *uint   `json:"beverage_image_id" gorm:"index"`               // 결과를 낸 캐시 이미지 ID
+	Source          string  `json:"source" gorm:"type:varchar(20)"`               // "database", "barcode", "llm", "vision"
+	Provider        string  `json:"provider" gorm:"type:varchar(20);index"`       // 답을 낸 API ("gemini", "openai", "vision", 캐시/바코드는 "")
+	Policy          string  `json:"policy" gorm:"type:varchar(20)"`               // 인식 정책 판정 ("accepted", "consensus", "disagreement", "unconfirmed")
+	Candidates      string  `json:"-" gorm:"type:text"`                           // 캐시에 저장하지 않은 인식 후보 (JSON, 사용자가 확인하면 캐시에 저장)
+	RecognizedID    *uint   `json:"recognized_id"`                                // 인식된 음료 ID (실패시 null)
+	Confidence      float64 `json:"confidence"`                                   // 인식 신뢰도
+	IsCorrect       *bool   `json:"is_correct"`                                   // 사용자 피드백 (맞음/틀림)
+	CorrectedID     *uint   `json:"corrected_id"`                                 // 사용자가 수정한 음료 ID
+	FeedbackApplied bool    `json:"-" gorm:"default:false"`                       // 피드백이 캐시 이미지에 반영됐는지 (사용자당 한 로그만)
+	VisionAPIUsed   bool    `json:"vision_api_used"`                              // Vision API 사용 여부
+	PromptVersion   string  `json:"prompt_version" gorm:"type:varchar(50);index"` // 사용한 LLM 프롬프트 (예: "drinks.v2.ko")
+	ProcessingTime  int     `json:"processing_time"`                              // 처리 시간 (ms)
+}
+
+// 인식 작업 상태
+const (
+	JobStatusQueued     = "queued"
+	JobStatusProcessing = "processing"
+	JobStatusDone       = "done"
+	JobStatusFailed     = "failed"
+)
+
+// RecognitionJob : 비동기 이미지 인식 작업 (서버 재시작 후에도 이어서 처리)
+type RecognitionJob struct {
+	gorm.Model
+	UserID      uint       `json:"user_id" gorm:"index"`
+	Status      string     `json:"status" gorm:"type:varchar(20);index"` // "queued", "processing", "done", "failed"
+	ImageBase64 string     `json:"-" gorm:"type:longtext"`               // 처리할 이미지 (완료 후 비움)
+	Result      string     `json:"-" gorm:"type:text"`                   // 인식 결과 (SmartRecognitionResult JSON)
+	Error       string     `json:"error,omitempty" gorm:"type:varchar(500)"`
+	Attempts    int        `json:"attempts" gorm:"default:0"` // 처리 시도 횟수
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"index"` // 이 시각 이후 삭제
 }
 
 // ========================================
@@ -0,0 +1,461 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========================================
+// 관리자 서비스
+// 사용자 역할 관리, 인식 로그 조회, 음료 삭제, 유지보수 작업 실행
+// ========================================
+
+var (
+	ErrUserNotFound      = errors.New("사용자를 찾을 수 없습니다")
+	ErrInvalidRole       = errors.New("역할은 user, moderator, admin 중 하나여야 합니다")
+	ErrLastAdmin         = errors.New("마지막 관리자의 권한은 변경할 수 없습니다")
+	ErrUnknownJob        = errors.New("알 수 없는 작업입니다")
+	ErrInvalidAdminInput = errors.New("이메일과 6자 이상의 비밀번호가 필요합니다")
+)
+
+// 관리자 목록 조회 기본값
+const (
+	adminPageSize    = 50
+	adminMaxPageSize = 200
+)
+
+// AdminPage : 목록 조회 페이지 (1부터 시작)
+type AdminPage struct {
+	Page  int
+	Limit int
+}
+
+func (p AdminPage) apply(query *gorm.DB) *gorm.DB {
+	limit := clampLimit(p.Limit, adminPageSize, adminMaxPageSize)
+	page := p.Page
+	if page < 1 {
+		page = 1
+	}
+	return query.Limit(limit).Offset((page - 1) * limit)
+}
+
+// ========================================
+// 사용자
+// ========================================
+
+// UserFilter : 사용자 목록 조회 조건
+type UserFilter struct {
+	AdminPage
+	Query string // 이메일/닉네임 일부
+	Role  string
+}
+
+// ListUsers : 사용자 목록 (최근 가입순)과 전체 개수
+func ListUsers(filter UserFilter) ([]models.User, int64, error) {
+	query := config.DB.Model(&models.User{})
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("email LIKE ? OR nickname LIKE ?", like, like)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	users := []models.User{}
+	if err := filter.apply(query.Order("id DESC")).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// SetUserRole : 사용자 역할 변경 (마지막 관리자는 강등할 수 없음)
+func SetUserRole(userID uint, role string) (*models.User, error) {
+	if !isValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+
+	var user models.User
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return ErrUserNotFound
+		}
+		if user.Role == models.RoleAdmin && role != models.RoleAdmin {
+			var admins int64
+			tx.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Count(&admins)
+			if admins <= 1 {
+				return ErrLastAdmin
+			}
+		}
+		user.Role = role
+		return tx.Model(&user).Update("role", role).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	println("🔑 사용자 역할 변경:", user.Email, "→", role)
+	return &user, nil
+}
+
+// CreateAdmin : 첫 관리자 만들기 (이미 가입한 이메일이면 비밀번호는 그대로 두고 admin으로 승격)
+// 반환값 created: 새로 만든 계정인지
+func CreateAdmin(email, password, nickname string) (user *models.User, created bool, err error) {
+	email = strings.TrimSpace(email)
+	if email == "" || !strings.Contains(email, "@") {
+		return nil, false, ErrInvalidAdminInput
+	}
+
+	var existing models.User
+	if err := config.DB.Where("email = ?", email).First(&existing).Error; err == nil {
+		if err := config.DB.Model(&existing).Update("role", models.RoleAdmin).Error; err != nil {
+			return nil, false, err
+		}
+		existing.Role = models.RoleAdmin
+		return &existing, false, nil
+	}
+
+	if len(password) < 6 {
+		return nil, false, ErrInvalidAdminInput
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, false, err
+	}
+	if nickname == "" {
+		nickname = strings.Split(email, "@")[0]
+	}
+
+	// 회원가입과 같은 기본값
+	admin := models.User{
+		Email:    email,
+		Password: string(hashed),
+		Nickname: nickname,
+		Weight:   70.0,
+		Height:   170.0,
+		Role:     models.RoleAdmin,
+	}
+	if err := config.DB.Create(&admin).Error; err != nil {
+		return nil, false, err
+	}
+	return &admin, true, nil
+}
+
+// MigrateLegacyAdminEmails : 예전 ADMIN_EMAILS 설정을 역할로 옮김
+// 관리자가 한 명도 없을 때만 적용 (이후 역할 변경 API로 강등한 사용자가 재시작 때 다시 승격되지 않도록)
+func MigrateLegacyAdminEmails() {
+	if config.DB == nil || len(config.LegacyAdminEmails) == 0 {
+		return
+	}
+	println("⚠️ ADMIN_EMAILS는 더 이상 권한 판단에 쓰지 않습니다 (users.role, create-admin 명령 사용)")
+
+	var admins int64
+	config.DB.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Count(&admins)
+	if admins > 0 {
+		return
+	}
+
+	promoted := config.DB.Model(&models.User{}).
+		Where("email IN ?", config.LegacyAdminEmails).
+		Update("role", models.RoleAdmin)
+	if promoted.Error != nil {
+		println("⚠️ ADMIN_EMAILS 관리자 이전 실패:", promoted.Error.Error())
+		return
+	}
+	if promoted.RowsAffected > 0 {
+		println("👑 ADMIN_EMAILS 사용자를 admin 역할로 변경:", promoted.RowsAffected, "명")
+	}
+}
+
+func isValidRole(role string) bool {
+	switch role {
+	case models.RoleUser, models.RoleModerator, models.RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// ========================================
+// 인식 로그
+// ========================================
+
+// RecognitionLogFilter : 인식 로그 조회 조건
+type RecognitionLogFilter struct {
+	AdminPage
+	UserID        uint
+	Source        string
+	PromptVersion string
+	Provider      string // "gemini", "openai", "vision"
+	Feedback      string // "correct", "incorrect", "none" (비우면 전체)
+}
+
+// ListRecognitionLogs : 인식 로그 (최근순)와 전체 개수
+func ListRecognitionLogs(filter RecognitionLogFilter) ([]models.RecognitionLog, int64, error) {
+	query := config.DB.Model(&models.RecognitionLog{})
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Source != "" {
+		query = query.Where("source = ?", filter.Source)
+	}
+	if filter.PromptVersion != "" {
+		query = query.Where("prompt_version = ?", filter.PromptVersion)
+	}
+	if filter.Provider != "" {
+		query = query.Where("provider = ?", filter.Provider)
+	}
+	switch filter.Feedback {
+	case "correct":
+		query = query.Where("is_correct = ?", true)
+	case "incorrect":
+		query = query.Where("is_correct = ?", false)
+	case "none":
+		query = query.Where("is_correct IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	logs := []models.RecognitionLog{}
+	if err := filter.apply(query.Order("id DESC")).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// ========================================
+// 음료 관리
+// ========================================
+
+// AdminBeverageFilter : 관리용 음료 목록 조회 조건 (승인 대기/거절된 음료 포함)
+type AdminBeverageFilter struct {
+	AdminPage
+	Status string
+	Query  string // 이름/브랜드 일부
+}
+
+// ListBeveragesForAdmin : 상태와 상관없이 음료 목록 (최근순)과 전체 개수
+func ListBeveragesForAdmin(filter AdminBeverageFilter) ([]models.Beverage, int64, error) {
+	query := config.DB.Model(&models.Beverage{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("name LIKE ? OR brand LIKE ?", like, like)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	beverages := []models.Beverage{}
+	if err := filter.apply(query.Preload("Sizes").Order("id DESC")).Find(&beverages).Error; err != nil {
+		return nil, 0, err
+	}
+	return beverages, total, nil
+}
+
+// DeleteBeverage : 음료 삭제
+// 섭취 기록이 음료를 참조하므로 음료는 soft delete, 바코드는 다른 음료에 다시 쓸 수 있도록 완전히 삭제
+// 이름은 unique 인덱스에 남으므로 삭제 표시를 붙여 같은 이름으로 다시 등록할 수 있게 하고,
+// 이 음료로 답하던 캐시 이미지는 연결을 끊고 신뢰도를 낮춰 다시 인식되게 함
+// 별칭은 지우고 처리 대기 중인 등록/수정 요청은 거절로 정리
+func DeleteBeverage(beverageID uint, actorID uint) error {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var beverage models.Beverage
+		if err := tx.First(&beverage, beverageID).Error; err != nil {
+			return ErrBeverageNotFound
+		}
+		if err := tx.Unscoped().Where("beverage_id = ?", beverageID).Delete(&models.BeverageBarcode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("beverage_id = ?", beverageID).Delete(&models.BeverageAlias{}).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := tx.Model(&models.BeverageChangeRequest{}).
+			Where("beverage_id = ? AND status = ?", beverageID, models.ChangeRequestPending).
+			Updates(map[string]interface{}{
+				"status":      models.ChangeRequestRejected,
+				"reviewed_by": actorID,
+				"reviewed_at": &now,
+				"review_note": "음료가 삭제되었습니다",
+			}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.BeverageImage{}).
+			Where("beverage_id = ?", beverageID).
+			Updates(map[string]interface{}{"beverage_id": nil, "confidence": 0}).Error; err != nil {
+			return err
+		}
+
+		before := revisionFields(&beverage)
+		name := deletedBeverageName(beverage.Name, beverage.ID)
+		if err := tx.Model(&beverage).Update("name", name).Error; err != nil {
+			return err
+		}
+		meta := BeverageRevisionMeta{AuthorID: actorID, Source: RevisionSourceDelete, Reason: "음료 삭제"}
+		if err := recordBeverageRevision(tx, beverage.ID, before, map[string]interface{}{"name": name}, meta); err != nil {
+			return err
+		}
+		return tx.Delete(&beverage).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	InvalidateBeverageAliasCache()
+	println("🗑️ 음료 삭제:", beverageID)
+	return nil
+}
+
+// deletedBeverageName : 삭제한 음료의 이름 (원래 이름은 새 음료가 쓸 수 있도록 비움, 255자 이내)
+func deletedBeverageName(name string, id uint) string {
+	suffix := fmt.Sprintf(" (삭제됨 #%d)", id)
+	return truncateRunes(name, 255-len([]rune(suffix))) + suffix
+}
+
+// ========================================
+// 유지보수 작업
+// ========================================
+
+// MaintenanceJobOptions : 작업 실행 옵션
+type MaintenanceJobOptions struct {
+	DryRun bool
+	UserID uint // learning: 지정하면 이 사용자만 학습
+}
+
+// MaintenanceJobResult : 작업 실행 결과
+type MaintenanceJobResult struct {
+	Job        string      `json:"job"`
+	DryRun     bool        `json:"dry_run"`
+	StartedAt  time.Time   `json:"started_at"`
+	DurationMs int64       `json:"duration_ms"`
+	Result     interface{} `json:"result"`
+}
+
+// maintenanceJob : 관리자가 실행할 수 있는 작업
+type maintenanceJob struct {
+	description string
+	run         func(opts MaintenanceJobOptions) (interface{}, error)
+}
+
+// maintenanceJobs : 작업 이름 → 실행 함수
+var maintenanceJobs = map[string]maintenanceJob{
+	"learning": {
+		description: "개인별 카페인 대사 배치 학습 (user_id를 주면 한 사용자만)",
+		run:         runLearningJob,
+	},
+	"image-gc": {
+		description: "보관 기간이 지난 사진과 고아 파일 정리",
+		run: func(opts MaintenanceJobOptions) (interface{}, error) {
+			return RunImageGC(opts.DryRun)
+		},
+	},
+	"search-index": {
+		description: "별칭/검색 색인 다시 만들기",
+		run: func(opts MaintenanceJobOptions) (interface{}, error) {
+			if !opts.DryRun {
+				InvalidateBeverageAliasCache()
+			}
+			return map[string]interface{}{"invalidated": !opts.DryRun}, nil
+		},
+	},
+	"text-estimate-cache": {
+		description: "기간이 지난 텍스트 추정 캐시 삭제 (TEXT_ESTIMATE_CACHE_DAYS)",
+		run:         runTextEstimateCacheJob,
+	},
+}
+
+// MaintenanceJobs : 실행할 수 있는 작업 목록 (이름 → 설명)
+func MaintenanceJobs() map[string]string {
+	jobs := make(map[string]string, len(maintenanceJobs))
+	for name, job := range maintenanceJobs {
+		jobs[name] = job.description
+	}
+	return jobs
+}
+
+// RunMaintenanceJob : 작업 1회 실행
+func RunMaintenanceJob(name string, opts MaintenanceJobOptions) (*MaintenanceJobResult, error) {
+	job, ok := maintenanceJobs[name]
+	if !ok {
+		return nil, ErrUnknownJob
+	}
+
+	started := time.Now()
+	result, err := job.run(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	println("🛠️ 유지보수 작업 실행:", name)
+	return &MaintenanceJobResult{
+		Job:        name,
+		DryRun:     opts.DryRun,
+		StartedAt:  started,
+		DurationMs: time.Since(started).Milliseconds(),
+		Result:     result,
+	}, nil
+}
+
+// runLearningJob : 배치 학습 (학습할 피드백이 쌓인 사용자만)
+func runLearningJob(opts MaintenanceJobOptions) (interface{}, error) {
+	var userIDs []uint
+	query := config.DB.Model(&models.CaffeineFeedback{}).
+		Where("is_used_for_learning = ?", false).
+		Distinct("user_id")
+	if opts.UserID != 0 {
+		query = query.Where("user_id = ?", opts.UserID)
+	}
+	if err := query.Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{"users": len(userIDs)}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	ls := NewLearningService()
+	failed := 0
+	for _, userID := range userIDs {
+		if err := ls.BatchLearn(userID); err != nil {
+			failed++
+		}
+	}
+	result["failed"] = failed
+	return result, nil
+}
+
+// runTextEstimateCacheJob : 기간이 지나 더 이상 쓰이지 않는 텍스트 추정 캐시 삭제
+func runTextEstimateCacheJob(opts MaintenanceJobOptions) (interface{}, error) {
+	query := config.DB.Unscoped().
+		Where("updated_at <= ?", time.Now().AddDate(0, 0, -config.TextEstimateCacheDays))
+	if opts.DryRun {
+		var expired int64
+		if err := query.Model(&models.TextEstimateCache{}).Count(&expired).Error; err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"expired": expired}, nil
+	}
+
+	result := query.Delete(&models.TextEstimateCache{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return map[string]interface{}{"deleted": result.RowsAffected}, nil
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeletedBeverageName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		id   uint
+		want string
+	}{
+		{name: "짧은 이름", in: "아메리카노", id: 12, want: "아메리카노 (삭제됨 #12)"},
+		{name: "긴 이름은 잘라서 255자", in: strings.Repeat("가", 255), id: 7, want: strings.Repeat("가", 255-len([]rune(" (삭제됨 #7)"))) + " (삭제됨 #7)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := deletedBeverageName(tt.in, tt.id)
+			if got != tt.want {
+				t.Errorf("deletedBeverageName = %q, want %q", got, tt.want)
+			}
+			if n := len([]rune(got)); n > 255 {
+				t.Errorf("이름 길이 = %d, want <= 255", n)
+			}
+		})
+	}
+}
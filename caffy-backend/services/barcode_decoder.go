@@ -0,0 +1,395 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"  // GIF 디코더 등록
+	_ "image/jpeg" // JPEG 디코더 등록
+	_ "image/png"  // PNG 디코더 등록
+	"math"
+)
+
+// ========================================
+// 바코드 디코더 (EAN-13 / UPC-A / EAN-8)
+// ========================================
+
+var ErrBarcodeNotFound = errors.New("이미지에서 바코드를 찾을 수 없습니다")
+
+// 바코드 형식
+const (
+	BarcodeEAN13 = "ean13"
+	BarcodeUPCA  = "upca"
+	BarcodeEAN8  = "ean8"
+)
+
+// EAN 숫자 패턴 (L 코드 기준 4개 모듈 폭: 공백-바-공백-바)
+// G 코드는 L 코드를 뒤집은 것, R 코드는 L 코드와 폭이 같고 바부터 시작
+var eanDigitWidths = [10][4]float64{
+	{3, 2, 1, 1}, // 0
+	{2, 2, 2, 1}, // 1
+	{2, 1, 2, 2}, // 2
+	{1, 4, 1, 1}, // 3
+	{1, 1, 3, 2}, // 4
+	{1, 2, 3, 1}, // 5
+	{1, 1, 1, 4}, // 6
+	{1, 3, 1, 2}, // 7
+	{1, 2, 1, 3}, // 8
+	{3, 1, 1, 2}, // 9
+}
+
+// eanFirstDigitParity : EAN-13 첫 자리 → 왼쪽 6자리의 L/G 패턴 (G=1)
+var eanFirstDigitParity = map[int]int{
+	0b000000: 0, 0b001011: 1, 0b001101: 2, 0b001110: 3, 0b010011: 4,
+	0b011001: 5, 0b011100: 6, 0b010101: 7, 0b010110: 8, 0b011010: 9,
+}
+
+// 최대 허용 패턴 오차 (모듈 단위 합)
+const barcodeMaxDigitError = 1.6
+
+// DecodeBarcode : 이미지에서 EAN-13/UPC-A/EAN-8 바코드 읽기
+// 가로/세로 여러 줄을 스캔하고 정방향/역방향(뒤집힌 사진)을 모두 시도
+func DecodeBarcode(imageData []byte) (code string, format string, err error) {
+	img, _, err := image.Decode(bytes.NewReader(imageData))
+	if err != nil {
+		return "", "", ErrBarcodeNotFound
+	}
+	return decodeBarcodeImage(img)
+}
+
+// decodeBarcodeImage : 디코딩된 이미지에서 바코드 읽기
+// 여러 스캔라인에서 같은 값이 나오면 채택 (체크섬을 통과한 값만 집계)
+func decodeBarcodeImage(img image.Image) (string, string, error) {
+	if img == nil { // 디코딩하지 않고 넘긴 형식 (WebP/HEIC)
+		return "", "", ErrBarcodeNotFound
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 30 || height < 10 {
+		return "", "", ErrBarcodeNotFound
+	}
+
+	votes := make(map[string]int)
+	formats := make(map[string]string)
+	record := func(line []float64) {
+		for _, candidate := range [][]float64{line, reversed(line)} {
+			if code, format, ok := decodeScanline(candidate); ok {
+				votes[code]++
+				formats[code] = format
+			}
+		}
+	}
+
+	const scanlines = 24
+	for i := 1; i <= scanlines; i++ {
+		y := bounds.Min.Y + height*i/(scanlines+1)
+		row := make([]float64, width)
+		for x := 0; x < width; x++ {
+			row[x] = luminance(img, bounds.Min.X+x, y)
+		}
+		record(row)
+	}
+	for i := 1; i <= scanlines; i++ {
+		x := bounds.Min.X + width*i/(scanlines+1)
+		col := make([]float64, height)
+		for y := 0; y < height; y++ {
+			col[y] = luminance(img, x, bounds.Min.Y+y)
+		}
+		record(col)
+	}
+
+	best, bestVotes := "", 0
+	for code, count := range votes {
+		if count > bestVotes || (count == bestVotes && code < best) {
+			best, bestVotes = code, count
+		}
+	}
+	if best == "" {
+		return "", "", ErrBarcodeNotFound
+	}
+	return best, formats[best], nil
+}
+
+// luminance : 픽셀 밝기 (0~255)
+func luminance(img image.Image, x, y int) float64 {
+	r, g, b, _ := img.At(x, y).RGBA()
+	return (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 257
+}
+
+func reversed(line []float64) []float64 {
+	out := make([]float64, len(line))
+	for i, v := range line {
+		out[len(line)-1-i] = v
+	}
+	return out
+}
+
+// decodeScanline : 한 줄의 밝기 값에서 바코드 읽기 (전역/지역 두 가지 이진화 시도)
+func decodeScanline(line []float64) (string, string, bool) {
+	for _, bits := range [][]bool{binarizeGlobal(line), binarizeLocal(line)} {
+		if bits == nil {
+			continue
+		}
+		runs := runLengths(bits)
+		if code, format, ok := decodeRuns(runs); ok {
+			return code, format, true
+		}
+	}
+	return "", "", false
+}
+
+// binarizeGlobal : 최소/최대 밝기의 중간값 기준 이진화 (true = 바/어두움)
+func binarizeGlobal(line []float64) []bool {
+	lo, hi := math.MaxFloat64, -math.MaxFloat64
+	for _, v := range line {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	if hi-lo < 40 {
+		return nil // 대비가 너무 낮음
+	}
+	threshold := (lo + hi) / 2
+	bits := make([]bool, len(line))
+	for i, v := range line {
+		bits[i] = v < threshold
+	}
+	return bits
+}
+
+// binarizeLocal : 주변 평균 기준 이진화 (조명이 고르지 않은 사진용)
+func binarizeLocal(line []float64) []bool {
+	n := len(line)
+	window := n / 12
+	if window < 8 {
+		return nil
+	}
+
+	prefix := make([]float64, n+1)
+	for i, v := range line {
+		prefix[i+1] = prefix[i] + v
+	}
+
+	bits := make([]bool, n)
+	for i := range line {
+		lo := i - window
+		if lo < 0 {
+			lo = 0
+		}
+		hi := i + window
+		if hi > n {
+			hi = n
+		}
+		mean := (prefix[hi] - prefix[lo]) / float64(hi-lo)
+		bits[i] = line[i] < mean-2
+	}
+	return bits
+}
+
+// barcodeRun : 같은 색이 이어진 구간
+type barcodeRun struct {
+	dark  bool
+	width float64
+}
+
+// runLengths : 이진화된 줄을 구간 길이 목록으로 변환
+func runLengths(bits []bool) []barcodeRun {
+	var runs []barcodeRun
+	for i, bit := range bits {
+		if i == 0 || bit != bits[i-1] {
+			runs = append(runs, barcodeRun{dark: bit})
+		}
+		runs[len(runs)-1].width++
+	}
+	return runs
+}
+
+// decodeRuns : 구간 목록에서 시작 가드를 찾아 EAN-13, EAN-8 순으로 시도
+func decodeRuns(runs []barcodeRun) (string, string, bool) {
+	for start := 1; start+3 <= len(runs); start++ {
+		if !runs[start].dark || !isGuard(runs[start:start+3]) {
+			continue
+		}
+		module := (runs[start].width + runs[start+1].width + runs[start+2].width) / 3
+		// 시작 가드 앞은 충분한 여백(quiet zone)이어야 함
+		if runs[start-1].width < module*3 {
+			continue
+		}
+
+		if code, ok := decodeEAN13(runs, start); ok {
+			if code[0] == '0' {
+				return code, BarcodeUPCA, true
+			}
+			return code, BarcodeEAN13, true
+		}
+		if code, ok := decodeEAN8(runs, start); ok {
+			return code, BarcodeEAN8, true
+		}
+	}
+	return "", "", false
+}
+
+// isGuard : 폭이 비슷한 구간 3개(바-공백-바)인지
+func isGuard(runs []barcodeRun) bool {
+	module := (runs[0].width + runs[1].width + runs[2].width) / 3
+	for _, r := range runs {
+		if math.Abs(r.width-module) > module*0.7 {
+			return false
+		}
+	}
+	return true
+}
+
+// isCenterGuard : 폭이 비슷한 구간 5개(공백-바-공백-바-공백)인지
+func isCenterGuard(runs []barcodeRun, module float64) bool {
+	for _, r := range runs {
+		if math.Abs(r.width-module) > module*0.8 {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeDigit : 구간 4개를 숫자로 해석 (g=true면 G 코드 패턴과 비교)
+func decodeDigit(runs []barcodeRun, g bool) (int, float64) {
+	total := 0.0
+	for _, r := range runs {
+		total += r.width
+	}
+
+	bestDigit, bestErr := -1, math.MaxFloat64
+	for digit, widths := range eanDigitWidths {
+		errSum := 0.0
+		for i := 0; i < 4; i++ {
+			expected := widths[i]
+			if g {
+				expected = widths[3-i]
+			}
+			errSum += math.Abs(runs[i].width*7/total - expected)
+		}
+		if errSum < bestErr {
+			bestDigit, bestErr = digit, errSum
+		}
+	}
+	return bestDigit, bestErr
+}
+
+// decodeEAN13 : 시작 가드 위치부터 EAN-13 (59구간) 해석
+func decodeEAN13(runs []barcodeRun, start int) (string, bool) {
+	if start+59 > len(runs) {
+		return "", false
+	}
+	module := (runs[start].width + runs[start+1].width + runs[start+2].width) / 3
+
+	digits := make([]byte, 13)
+	parity := 0
+	pos := start + 3
+
+	// 왼쪽 6자리 (L 또는 G 코드)
+	for i := 0; i < 6; i++ {
+		group := runs[pos : pos+4]
+		lDigit, lErr := decodeDigit(group, false)
+		gDigit, gErr := decodeDigit(group, true)
+		parity <<= 1
+		if gErr < lErr {
+			if gErr > barcodeMaxDigitError {
+				return "", false
+			}
+			digits[i+1] = byte('0' + gDigit)
+			parity |= 1
+		} else {
+			if lErr > barcodeMaxDigitError {
+				return "", false
+			}
+			digits[i+1] = byte('0' + lDigit)
+		}
+		pos += 4
+	}
+
+	first, ok := eanFirstDigitParity[parity]
+	if !ok {
+		return "", false
+	}
+	digits[0] = byte('0' + first)
+
+	if !isCenterGuard(runs[pos:pos+5], module) {
+		return "", false
+	}
+	pos += 5
+
+	// 오른쪽 6자리 (R 코드)
+	for i := 0; i < 6; i++ {
+		digit, errSum := decodeDigit(runs[pos:pos+4], false)
+		if errSum > barcodeMaxDigitError {
+			return "", false
+		}
+		digits[i+7] = byte('0' + digit)
+		pos += 4
+	}
+
+	if !isGuard(runs[pos : pos+3]) {
+		return "", false
+	}
+
+	code := string(digits)
+	if !validGTINChecksum(code) {
+		return "", false
+	}
+	return code, true
+}
+
+// decodeEAN8 : 시작 가드 위치부터 EAN-8 (43구간) 해석
+func decodeEAN8(runs []barcodeRun, start int) (string, bool) {
+	if start+43 > len(runs) {
+		return "", false
+	}
+	module := (runs[start].width + runs[start+1].width + runs[start+2].width) / 3
+
+	digits := make([]byte, 8)
+	pos := start + 3
+	for i := 0; i < 8; i++ {
+		if i == 4 {
+			if !isCenterGuard(runs[pos:pos+5], module) {
+				return "", false
+			}
+			pos += 5
+		}
+		digit, errSum := decodeDigit(runs[pos:pos+4], false)
+		if errSum > barcodeMaxDigitError {
+			return "", false
+		}
+		digits[i] = byte('0' + digit)
+		pos += 4
+	}
+
+	if !isGuard(runs[pos : pos+3]) {
+		return "", false
+	}
+
+	code := string(digits)
+	if !validGTINChecksum(code) {
+		return "", false
+	}
+	return code, true
+}
+
+// validGTINChecksum : GTIN(EAN/UPC) 체크 digit 검증
+func validGTINChecksum(code string) bool {
+	if len(code) < 8 {
+		return false
+	}
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		d := int(code[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		// 체크 digit 바로 왼쪽부터 3, 1, 3, 1... 가중치
+		if (len(code)-2-i)%2 == 0 {
+			sum += d * 3
+		} else {
+			sum += d
+		}
+	}
+	check := (10 - sum%10) % 10
+	return int(code[len(code)-1]-'0') == check
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========================================
+// 바코드 기반 음료 조회 서비스
+// ========================================
+
+var (
+	ErrInvalidBarcode       = errors.New("올바른 바코드가 아닙니다 (EAN-13, UPC-A, EAN-8)")
+	ErrBarcodeTaken         = errors.New("이미 다른 음료에 등록된 바코드입니다")
+	ErrBeverageNotPending   = errors.New("확인 대기 중인 음료가 아닙니다")
+	ErrBarcodeNotRegistered = errors.New("등록되지 않은 바코드입니다")
+	ErrPendingBeverageOwner = errors.New("다른 사용자가 등록한 확인 대기 음료입니다")
+)
+
+// BarcodeRecognitionConfidence : 바코드로 찾은 음료의 인식 신뢰도
+const BarcodeRecognitionConfidence = 0.98
+
+// NormalizeBarcode : 바코드 문자열 정규화 및 검증
+// 숫자 이외의 문자(공백, 하이픈)를 제거하고 UPC-A(12자리)는 EAN-13으로 맞춤
+func NormalizeBarcode(raw string) (code string, format string, err error) {
+	var digits strings.Builder
+	for _, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ' || r == '-':
+		default:
+			return "", "", ErrInvalidBarcode
+		}
+	}
+
+	code = digits.String()
+	switch len(code) {
+	case 8:
+		format = BarcodeEAN8
+	case 12:
+		code = "0" + code
+		format = BarcodeUPCA
+	case 13:
+		format = BarcodeEAN13
+		if code[0] == '0' {
+			format = BarcodeUPCA
+		}
+	default:
+		return "", "", ErrInvalidBarcode
+	}
+
+	if !validGTINChecksum(code) {
+		return "", "", ErrInvalidBarcode
+	}
+	return code, format, nil
+}
+
+// FindBeverageByBarcode : 바코드로 음료 조회 (확인 대기 중인 음료 포함)
+func FindBeverageByBarcode(code string) (*models.Beverage, error) {
+	var barcode models.BeverageBarcode
+	if err := config.DB.Where("code = ?", code).First(&barcode).Error; err != nil {
+		return nil, ErrBarcodeNotRegistered
+	}
+
+	var beverage models.Beverage
+	if err := config.DB.Preload("Barcodes").First(&beverage, barcode.BeverageID).Error; err != nil {
+		return nil, ErrBarcodeNotRegistered
+	}
+	return &beverage, nil
+}
+
+// CreatePendingBeverage : 모르는 바코드로 확인 대기 음료 생성
+// LLM 추정값이 있으면 초기값으로 채우고, 사용자가 확인하면 active로 전환됨
+// 같은 바코드가 동시에 들어오면 먼저 생성된 음료를 반환
+func CreatePendingBeverage(code string, format string, guess *DetectedDrink, userID uint) (*models.Beverage, error) {
+	beverage := models.Beverage{
+		Name:          pendingBeverageName(code),
+		Status:        models.BeverageStatusPending,
+		CreatedByUser: userID,
+	}
+	if guess != nil {
+		beverage.Brand = guess.Brand
+		beverage.CaffeineAmount = float64(guess.CaffeineAmount)
+		beverage.Category = guess.Category
+		if guess.DrinkName != "" {
+			beverage.Name = guess.DrinkName
+		}
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// 음료 이름이 이미 있으면 바코드가 들어간 임시 이름 사용
+		created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&beverage)
+		if created.Error != nil {
+			return created.Error
+		}
+		if created.RowsAffected == 0 {
+			beverage.ID = 0
+			beverage.Name = pendingBeverageName(code)
+			retried := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&beverage)
+			if retried.Error != nil {
+				return retried.Error
+			}
+			if retried.RowsAffected == 0 {
+				return ErrBarcodeTaken
+			}
+		}
+
+		barcode := models.BeverageBarcode{BeverageID: beverage.ID, Code: code, Format: format}
+		linked := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&barcode)
+		if linked.Error != nil {
+			return linked.Error
+		}
+		if linked.RowsAffected == 0 {
+			return ErrBarcodeTaken
+		}
+		return nil
+	})
+	if errors.Is(err, ErrBarcodeTaken) {
+		// 다른 요청이 같은 바코드를 먼저 등록함
+		return FindBeverageByBarcode(code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	beverage.Barcodes = []models.BeverageBarcode{{BeverageID: beverage.ID, Code: code, Format: format}}
+	return &beverage, nil
+}
+
+// pendingBeverageName : 이름을 모르는 바코드 음료의 임시 이름
+func pendingBeverageName(code string) string {
+	return fmt.Sprintf("미확인 상품 %s", code)
+}
+
+// BarcodeConfirmation : 확인 대기 음료 확정 요청
+// BeverageID를 주면 바코드를 기존 음료로 옮기고 임시 음료는 삭제,
+// 아니면 입력한 값으로 임시 음료를 채움 (검토자는 바로 active, 그 외에는 등록 요청을 남기고 승인 대기)
+type BarcodeConfirmation struct {
+	BeverageID     *uint   `json:"beverage_id"`
+	Name           string  `json:"name"`
+	Brand          string  `json:"brand"`
+	CaffeineAmount float64 `json:"caffeine_amount"`
+	Size           string  `json:"size"`
+	Volume         float64 `json:"volume"`
+	Category       string  `json:"category"`
+}
+
+// ConfirmPendingBeverage : 바코드에 연결된 확인 대기 음료를 확정 (음료를 등록한 사용자나 검토자만)
+func ConfirmPendingBeverage(code string, input BarcodeConfirmation, userID uint, isModerator bool) (*models.Beverage, error) {
+	var confirmedID uint
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var barcode models.BeverageBarcode
+		if err := tx.Where("code = ?", code).First(&barcode).Error; err != nil {
+			return ErrBarcodeNotRegistered
+		}
+
+		var pending models.Beverage
+		if err := tx.First(&pending, barcode.BeverageID).Error; err != nil {
+			return ErrBarcodeNotRegistered
+		}
+		if pending.Status != models.BeverageStatusPending {
+			return ErrBeverageNotPending
+		}
+		if !isModerator && pending.CreatedByUser != userID {
+			return ErrPendingBeverageOwner
+		}
+
+		// 1. 기존 음료에 연결
+		if input.BeverageID != nil {
+			var target models.Beverage
+			if err := tx.Where("id = ? AND status = ?", *input.BeverageID, models.BeverageStatusActive).First(&target).Error; err != nil {
+				return ErrBeverageNotFound
+			}
+			if err := tx.Model(&models.BeverageBarcode{}).Where("beverage_id = ?", pending.ID).Update("beverage_id", target.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.BeverageImage{}).Where("beverage_id = ?", pending.ID).Update("beverage_id", target.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.RecognitionLog{}).Where("recognized_id = ?", pending.ID).Update("recognized_id", target.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.CaffeineLog{}).Where("beverage_id = ?", pending.ID).Update("beverage_id", target.ID).Error; err != nil {
+				return err
+			}
+			// 임시 음료는 이름 unique 제약을 계속 차지하지 않도록 완전히 삭제
+			if err := tx.Unscoped().Delete(&pending).Error; err != nil {
+				return err
+			}
+			confirmedID = target.ID
+			return nil
+		}
+
+		// 2. 입력값으로 임시 음료 확정
+		name := strings.TrimSpace(input.Name)
+		if name == "" {
+			return fmt.Errorf("음료 이름이 필요합니다")
+		}
+		var existing models.Beverage
+		if err := tx.Where("name = ? AND id <> ?", name, pending.ID).First(&existing).Error; err == nil {
+			return fmt.Errorf("이미 존재하는 음료 이름입니다. beverage_id로 기존 음료(%d)에 연결하세요", existing.ID)
+		}
+
+		updates := map[string]interface{}{
+			"name":            name,
+			"brand":           input.Brand,
+			"caffeine_amount": input.CaffeineAmount,
+			"size":            input.Size,
+			"volume":          input.Volume,
+			"category":        input.Category,
+		}
+		if isModerator {
+			updates["status"] = models.BeverageStatusActive
+		}
+		before := revisionFields(&pending)
+		if err := tx.Model(&pending).Updates(updates).Error; err != nil {
+			return err
+		}
+		meta := BeverageRevisionMeta{AuthorID: userID, Source: ChangeSourceBarcode, Reason: "바코드 " + code + " 확인"}
+		if err := recordBeverageRevision(tx, pending.ID, before, updates, meta); err != nil {
+			return err
+		}
+		confirmedID = pending.ID
+		if !isModerator {
+			if err := tx.First(&pending, pending.ID).Error; err != nil {
+				return err
+			}
+			_, err := SubmitNewBeverage(tx, &pending, ChangeSourceBarcode)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var beverage models.Beverage
+	if err := config.DB.Preload("Barcodes").First(&beverage, confirmedID).Error; err != nil {
+		return nil, err
+	}
+	return &beverage, nil
+}
+
+// AddBarcodeToBeverage : 음료에 바코드 바로 추가 (검토자, 본인이 등록한 승인 대기 음료, 한 음료에 여러 바코드 가능)
+func AddBarcodeToBeverage(beverageID uint, raw string, userID uint) (*models.BeverageBarcode, error) {
+	var barcode *models.BeverageBarcode
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var beverage models.Beverage
+		if err := tx.First(&beverage, beverageID).Error; err != nil {
+			return ErrBeverageNotFound
+		}
+		var err error
+		barcode, err = attachBarcode(tx, &beverage, raw, BeverageRevisionMeta{AuthorID: userID, Source: ChangeSourceBarcode})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return barcode, nil
+}
+
+// RequestBeverageBarcode : 승인된 음료에 바코드 추가 요청 (같은 사용자의 같은 바코드 요청은 하나만)
+// 바코드로 찾은 음료는 모든 사용자의 인식 결과가 되므로 검토자가 승인해야 반영
+func RequestBeverageBarcode(beverageID uint, raw string, userID uint) (*models.BeverageChangeRequest, error) {
+	code, _, err := NormalizeBarcode(raw)
+	if err != nil {
+		return nil, err
+	}
+	var beverage models.Beverage
+	if err := config.DB.First(&beverage, beverageID).Error; err != nil {
+		return nil, ErrBeverageNotFound
+	}
+	if beverage.Status != models.BeverageStatusActive {
+		return nil, ErrBeverageNotEditable
+	}
+	var existing models.BeverageBarcode
+	if err := config.DB.Where("code = ?", code).First(&existing).Error; err == nil {
+		if existing.BeverageID == beverage.ID {
+			return nil, ErrNoBeverageChanges
+		}
+		return nil, ErrBarcodeTaken
+	}
+
+	changes, _ := json.Marshal(map[string]interface{}{"barcode": code})
+	request := models.BeverageChangeRequest{
+		BeverageID:  beverage.ID,
+		Action:      models.ChangeActionBarcode,
+		Status:      models.ChangeRequestPending,
+		Source:      ChangeSourceUser,
+		Changes:     string(changes),
+		Previous:    "{}",
+		SubmittedBy: userID,
+	}
+	var pending models.BeverageChangeRequest
+	if err := config.DB.Where("beverage_id = ? AND action = ? AND status = ? AND submitted_by = ? AND changes = ?",
+		beverage.ID, models.ChangeActionBarcode, models.ChangeRequestPending, userID, request.Changes).First(&pending).Error; err == nil {
+		fillChangeRequest(&pending, &beverage)
+		return &pending, nil
+	}
+	if err := config.DB.Create(&request).Error; err != nil {
+		return nil, err
+	}
+
+	fillChangeRequest(&request, &beverage)
+	println("📝 바코드 추가 요청:", beverage.Name, code)
+	return &request, nil
+}
+
+// attachBarcode : 음료에 바코드 저장하고 변경 이력 남김 (이미 이 음료의 바코드면 그대로 반환)
+func attachBarcode(tx *gorm.DB, beverage *models.Beverage, raw string, meta BeverageRevisionMeta) (*models.BeverageBarcode, error) {
+	code, format, err := NormalizeBarcode(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	barcode := models.BeverageBarcode{BeverageID: beverage.ID, Code: code, Format: format}
+	created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&barcode)
+	if created.Error != nil {
+		return nil, created.Error
+	}
+	if created.RowsAffected == 0 {
+		var existing models.BeverageBarcode
+		if err := tx.Where("code = ?", code).First(&existing).Error; err == nil && existing.BeverageID == beverage.ID {
+			return &existing, nil
+		}
+		return nil, ErrBarcodeTaken
+	}
+
+	before := map[string]interface{}{}
+	after := map[string]interface{}{"barcode": code}
+	if err := recordBeverageRevision(tx, beverage.ID, before, after, meta); err != nil {
+		return nil, err
+	}
+	return &barcode, nil
+}
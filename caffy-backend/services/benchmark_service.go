@@ -0,0 +1,491 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ========================================
+// 오프라인 인식 벤치마크
+// 라벨이 달린 이미지 세트(caffy-ai 데이터셋 형식: images/ + labels.json)를
+// 설정된 인식 체인으로 돌려 정확도, 카페인 오차, 프로바이더별 지연/비용을 측정
+// 인식 결과는 DB에 저장하지 않음 (캐시/바코드 조회도 건너뛰고 항상 프로바이더 호출)
+// ========================================
+
+// benchmarkConfusionLimit : 리포트에 남기는 혼동 쌍 수
+const benchmarkConfusionLimit = 20
+
+// BenchmarkLabel : labels.json 항목 (caffy-ai DatasetService.save_image와 같은 형식)
+type BenchmarkLabel struct {
+	ID             string   `json:"id"`
+	ImagePath      string   `json:"image_path"`
+	DrinkName      *string  `json:"drink_name"`
+	Brand          *string  `json:"brand"`
+	CaffeineAmount *float64 `json:"caffeine_amount"`
+	Verified       bool     `json:"verified"`
+}
+
+// BenchmarkItem : 평가할 이미지 하나 (라벨 + 실제 파일 경로)
+type BenchmarkItem struct {
+	BenchmarkLabel
+	File string
+}
+
+// BenchmarkDataset : 불러온 데이터셋
+type BenchmarkDataset struct {
+	Dir     string
+	Items   []BenchmarkItem
+	Skipped map[string]string // 건너뛴 항목 ID → 이유
+}
+
+// BenchmarkOptions : 벤치마크 실행 옵션
+type BenchmarkOptions struct {
+	Limit int // 평가할 최대 이미지 수 (0이면 전부)
+}
+
+// BenchmarkCase : 이미지 하나의 평가 결과
+type BenchmarkCase struct {
+	ID                string            `json:"id"`
+	ExpectedName      string            `json:"expected_name"`
+	ExpectedBrand     string            `json:"expected_brand,omitempty"`
+	ExpectedCaffeine  *float64          `json:"expected_caffeine,omitempty"`
+	PredictedName     string            `json:"predicted_name"`
+	PredictedBrand    string            `json:"predicted_brand,omitempty"`
+	PredictedCaffeine int               `json:"predicted_caffeine"`
+	Confidence        float64           `json:"confidence"`
+	PromptVersion     string            `json:"prompt_version,omitempty"`
+	Provider          string            `json:"provider,omitempty"` // 답을 낸 프로바이더
+	Policy            string            `json:"policy,omitempty"`   // 인식 정책 판정 (합의/불일치 등)
+	NameMatch         bool              `json:"name_match"`         // 정규화한 이름이 정답과 같음
+	AliasMatch        bool              `json:"alias_match"`        // 이름이 같거나 별칭으로 같은 음료를 가리킴
+	CaffeineError     *float64          `json:"caffeine_error,omitempty"`
+	LatencyMS         int64             `json:"latency_ms"`
+	CostUSD           float64           `json:"cost_usd"`
+	Attempts          []ProviderAttempt `json:"attempts"`
+	Error             string            `json:"error,omitempty"`
+}
+
+// BenchmarkStats : 값 분포 요약
+type BenchmarkStats struct {
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	P90    float64 `json:"p90"`
+	P95    float64 `json:"p95"`
+	Max    float64 `json:"max"`
+}
+
+// BenchmarkProviderStats : 프로바이더별 호출 결과
+type BenchmarkProviderStats struct {
+	Provider     string         `json:"provider"`
+	Calls        int            `json:"calls"`    // 실제로 호출한 횟수 (키 없음 제외)
+	Answered     int            `json:"answered"` // 최종 답을 낸 횟수
+	Failed       int            `json:"failed"`
+	Skipped      int            `json:"skipped"` // API 키가 없어 건너뜀
+	LatencyMS    BenchmarkStats `json:"latency_ms"`
+	InputTokens  int            `json:"input_tokens"`
+	OutputTokens int            `json:"output_tokens"`
+	CostUSD      float64        `json:"cost_usd"`
+}
+
+// BenchmarkConfusion : 자주 틀린 정답 → 예측 쌍
+type BenchmarkConfusion struct {
+	Expected  string `json:"expected"`
+	Predicted string `json:"predicted"`
+	Count     int    `json:"count"`
+}
+
+// BenchmarkLabelStats : 정답 음료별 정확도
+type BenchmarkLabelStats struct {
+	Label    string  `json:"label"`
+	Total    int     `json:"total"`
+	Correct  int     `json:"correct"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+// BenchmarkReport : 벤치마크 결과 (두 실행을 비교할 수 있도록 케이스는 ID순)
+type BenchmarkReport struct {
+	StartedAt      time.Time                `json:"started_at"`
+	FinishedAt     time.Time                `json:"finished_at"`
+	Dataset        string                   `json:"dataset"`
+	PromptVersion  string                   `json:"prompt_version"` // 설정값 (비우면 최신)
+	PromptLocale   string                   `json:"prompt_locale"`
+	PromptVersions []string                 `json:"prompt_versions"` // 실제로 사용된 프롬프트
+	Providers      []string                 `json:"providers"`
+	Total          int                      `json:"total"`    // 평가한 이미지 수
+	Skipped        map[string]string        `json:"skipped"`  // 평가하지 않은 항목 ID → 이유
+	Failed         int                      `json:"failed"`   // 모든 프로바이더가 실패한 이미지 수
+	Policies       map[string]int           `json:"policies"` // 인식 정책 판정별 이미지 수
+	NameAccuracy   float64                  `json:"name_accuracy"`
+	AliasAccuracy  float64                  `json:"alias_accuracy"`
+	CaffeineError  BenchmarkStats           `json:"caffeine_error"` // 절대 오차 (mg)
+	LatencyMS      BenchmarkStats           `json:"latency_ms"`     // 이미지당 체인 전체 지연
+	CostUSD        float64                  `json:"cost_usd"`
+	ProviderStats  []BenchmarkProviderStats `json:"provider_stats"`
+	Confusions     []BenchmarkConfusion     `json:"confusions"`
+	Labels         []BenchmarkLabelStats    `json:"labels"`
+	Cases          []BenchmarkCase          `json:"cases"`
+
+	Comparison *BenchmarkComparison `json:"comparison,omitempty"` // --baseline으로 준 이전 결과와 비교
+}
+
+// BenchmarkComparison : 이전 실행 대비 변화 (같은 ID의 케이스끼리 비교)
+type BenchmarkComparison struct {
+	Baseline           string   `json:"baseline"`
+	Common             int      `json:"common"` // 양쪽에 모두 있는 케이스 수
+	NameAccuracyDelta  float64  `json:"name_accuracy_delta"`
+	AliasAccuracyDelta float64  `json:"alias_accuracy_delta"`
+	CaffeineMAEDelta   float64  `json:"caffeine_mae_delta"`
+	LatencyMeanDelta   float64  `json:"latency_mean_delta"`
+	CostUSDDelta       float64  `json:"cost_usd_delta"`
+	Fixed              []string `json:"fixed"`     // 틀렸다가 맞힌 케이스
+	Regressed          []string `json:"regressed"` // 맞혔다가 틀린 케이스
+}
+
+// LoadBenchmarkDataset : 데이터셋 폴더의 labels.json과 이미지 목록 불러오기
+// 이미지는 images/ 아래 image_path의 파일 이름으로 찾고, 없으면 image_path 그대로 사용
+// 음료 이름이 없거나 이미지가 없는 항목은 Skipped에 이유와 함께 남김
+func LoadBenchmarkDataset(dir string, verifiedOnly bool) (*BenchmarkDataset, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "labels.json"))
+	if err != nil {
+		return nil, fmt.Errorf("labels.json을 읽을 수 없습니다: %w", err)
+	}
+	labels := map[string]BenchmarkLabel{}
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return nil, fmt.Errorf("labels.json 형식 오류: %w", err)
+	}
+
+	dataset := &BenchmarkDataset{Dir: dir, Skipped: map[string]string{}}
+	for id, label := range labels {
+		if label.ID == "" {
+			label.ID = id
+		}
+		switch {
+		case label.DrinkName == nil || strings.TrimSpace(*label.DrinkName) == "":
+			dataset.Skipped[label.ID] = "음료 이름 없음"
+			continue
+		case verifiedOnly && !label.Verified:
+			dataset.Skipped[label.ID] = "검증되지 않은 라벨"
+			continue
+		}
+
+		file := benchmarkImageFile(dir, label)
+		if file == "" {
+			dataset.Skipped[label.ID] = "이미지 파일 없음"
+			continue
+		}
+		dataset.Items = append(dataset.Items, BenchmarkItem{BenchmarkLabel: label, File: file})
+	}
+
+	sort.Slice(dataset.Items, func(i, j int) bool { return dataset.Items[i].ID < dataset.Items[j].ID })
+	return dataset, nil
+}
+
+// benchmarkImageFile : 라벨의 이미지 파일 경로 (없으면 "")
+// caffy-ai는 실행 위치 기준 경로를 저장하므로 데이터셋 폴더의 images/를 먼저 찾음
+func benchmarkImageFile(dir string, label BenchmarkLabel) string {
+	var candidates []string
+	if label.ImagePath != "" {
+		candidates = append(candidates, filepath.Join(dir, "images", filepath.Base(label.ImagePath)), label.ImagePath)
+	} else {
+		matches, _ := filepath.Glob(filepath.Join(dir, "images", label.ID+".*"))
+		candidates = append(candidates, matches...)
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// RunBenchmark : 데이터셋의 이미지를 차례로 인식 체인에 넣고 결과 집계
+func RunBenchmark(dataset *BenchmarkDataset, opts BenchmarkOptions) *BenchmarkReport {
+	report := &BenchmarkReport{
+		StartedAt:     time.Now(),
+		Dataset:       dataset.Dir,
+		PromptVersion: config.PromptVersion,
+		PromptLocale:  config.PromptLocale,
+		Skipped:       dataset.Skipped,
+		Cases:         []BenchmarkCase{},
+	}
+	for _, provider := range recognitionProviders {
+		report.Providers = append(report.Providers, provider.Name)
+	}
+
+	items := dataset.Items
+	if opts.Limit > 0 && len(items) > opts.Limit {
+		items = items[:opts.Limit]
+	}
+	for i, item := range items {
+		benchmarkCase := runBenchmarkCase(item)
+		status := "✅"
+		if benchmarkCase.Error != "" {
+			status = "❌"
+		} else if !benchmarkCase.AliasMatch {
+			status = "⚠️"
+		}
+		println(status, "벤치마크", i+1, "/", len(items), item.ID, benchmarkCase.ExpectedName, "→", benchmarkCase.PredictedName)
+		report.Cases = append(report.Cases, benchmarkCase)
+	}
+
+	summarizeBenchmark(report)
+	report.FinishedAt = time.Now()
+	return report
+}
+
+// runBenchmarkCase : 이미지 하나를 전처리 후 인식 체인으로 평가
+func runBenchmarkCase(item BenchmarkItem) BenchmarkCase {
+	result := BenchmarkCase{
+		ID:               item.ID,
+		ExpectedName:     strings.TrimSpace(*item.DrinkName),
+		ExpectedCaffeine: item.CaffeineAmount,
+		Attempts:         []ProviderAttempt{},
+	}
+	if item.Brand != nil {
+		result.ExpectedBrand = *item.Brand
+	}
+
+	raw, err := os.ReadFile(item.File)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	processed, err := PreprocessImage(raw)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	started := time.Now()
+	recognized, attempts, err := RecognizeWithPolicy(processed.Base64(), processed.MIME)
+	result.LatencyMS = time.Since(started).Milliseconds()
+	result.Attempts = attempts
+	for _, attempt := range attempts {
+		result.CostUSD += attempt.CostUSD
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Provider = recognized.Provider
+	result.Policy = recognized.Policy
+	result.PredictedName = recognized.DrinkName
+	result.PredictedBrand = recognized.Brand
+	result.PredictedCaffeine = recognized.CaffeineAmount
+	result.Confidence = recognized.Confidence
+	result.PromptVersion = recognized.PromptVersion
+
+	result.NameMatch = aliasKey(result.PredictedName) == aliasKey(result.ExpectedName)
+	result.AliasMatch = result.NameMatch
+	if !result.AliasMatch {
+		expectedID, expectedOK := aliasBeverageID(result.ExpectedName)
+		predictedID, predictedOK := aliasBeverageID(result.PredictedName)
+		result.AliasMatch = expectedOK && predictedOK && expectedID == predictedID
+	}
+	if item.CaffeineAmount != nil {
+		diff := math.Abs(float64(recognized.CaffeineAmount) - *item.CaffeineAmount)
+		result.CaffeineError = &diff
+	}
+	return result
+}
+
+// summarizeBenchmark : 케이스 결과로 정확도/오차/프로바이더별 통계 계산
+func summarizeBenchmark(report *BenchmarkReport) {
+	report.Total = len(report.Cases)
+	report.Policies = map[string]int{}
+	report.PromptVersions = []string{}
+	report.ProviderStats = []BenchmarkProviderStats{}
+	report.Confusions = []BenchmarkConfusion{}
+	report.Labels = []BenchmarkLabelStats{}
+
+	var nameCorrect, aliasCorrect int
+	var caffeineErrors, latencies []float64
+	promptVersions := map[string]bool{}
+	providers := map[string]*BenchmarkProviderStats{}
+	providerLatencies := map[string][]float64{}
+	confusions := map[[2]string]int{}
+	labels := map[string]*BenchmarkLabelStats{}
+
+	for _, c := range report.Cases {
+		label := labels[c.ExpectedName]
+		if label == nil {
+			label = &BenchmarkLabelStats{Label: c.ExpectedName}
+			labels[c.ExpectedName] = label
+		}
+		label.Total++
+
+		for _, attempt := range c.Attempts {
+			stats := providers[attempt.Provider]
+			if stats == nil {
+				stats = &BenchmarkProviderStats{Provider: attempt.Provider}
+				providers[attempt.Provider] = stats
+			}
+			if attempt.Skipped {
+				stats.Skipped++
+				continue
+			}
+			stats.Calls++
+			if attempt.Error != "" {
+				stats.Failed++
+			} else {
+				stats.Answered++
+			}
+			stats.InputTokens += attempt.Usage.InputTokens
+			stats.OutputTokens += attempt.Usage.OutputTokens
+			stats.CostUSD += attempt.CostUSD
+			providerLatencies[attempt.Provider] = append(providerLatencies[attempt.Provider], float64(attempt.LatencyMS))
+		}
+		report.CostUSD += c.CostUSD
+
+		if c.Error != "" {
+			report.Failed++
+			confusions[[2]string{c.ExpectedName, "(실패)"}]++
+			continue
+		}
+		latencies = append(latencies, float64(c.LatencyMS))
+		report.Policies[c.Policy]++
+		if c.PromptVersion != "" {
+			promptVersions[c.PromptVersion] = true
+		}
+		if c.NameMatch {
+			nameCorrect++
+		}
+		if c.AliasMatch {
+			aliasCorrect++
+			label.Correct++
+		} else {
+			confusions[[2]string{c.ExpectedName, c.PredictedName}]++
+		}
+		if c.CaffeineError != nil {
+			caffeineErrors = append(caffeineErrors, *c.CaffeineError)
+		}
+	}
+
+	if report.Total > 0 {
+		report.NameAccuracy = float64(nameCorrect) / float64(report.Total)
+		report.AliasAccuracy = float64(aliasCorrect) / float64(report.Total)
+	}
+	report.CaffeineError = benchmarkStats(caffeineErrors)
+	report.LatencyMS = benchmarkStats(latencies)
+
+	for version := range promptVersions {
+		report.PromptVersions = append(report.PromptVersions, version)
+	}
+	sort.Strings(report.PromptVersions)
+
+	for _, name := range report.Providers {
+		if stats := providers[name]; stats != nil {
+			stats.LatencyMS = benchmarkStats(providerLatencies[name])
+			report.ProviderStats = append(report.ProviderStats, *stats)
+		}
+	}
+
+	for pair, count := range confusions {
+		report.Confusions = append(report.Confusions, BenchmarkConfusion{Expected: pair[0], Predicted: pair[1], Count: count})
+	}
+	sort.Slice(report.Confusions, func(i, j int) bool {
+		a, b := report.Confusions[i], report.Confusions[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Expected != b.Expected {
+			return a.Expected < b.Expected
+		}
+		return a.Predicted < b.Predicted
+	})
+	if len(report.Confusions) > benchmarkConfusionLimit {
+		report.Confusions = report.Confusions[:benchmarkConfusionLimit]
+	}
+
+	for _, label := range labels {
+		label.Accuracy = float64(label.Correct) / float64(label.Total)
+		report.Labels = append(report.Labels, *label)
+	}
+	sort.Slice(report.Labels, func(i, j int) bool {
+		a, b := report.Labels[i], report.Labels[j]
+		if a.Accuracy != b.Accuracy {
+			return a.Accuracy < b.Accuracy
+		}
+		return a.Label < b.Label
+	})
+}
+
+// benchmarkStats : 값 목록의 평균/중앙값/분위수 (nearest-rank)
+func benchmarkStats(values []float64) BenchmarkStats {
+	stats := BenchmarkStats{Count: len(values)}
+	if len(values) == 0 {
+		return stats
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	rank := func(p float64) float64 {
+		i := int(math.Ceil(p*float64(len(sorted)))) - 1
+		return sorted[max(i, 0)]
+	}
+	stats.Mean = sum / float64(len(sorted))
+	stats.Median = rank(0.5)
+	stats.P90 = rank(0.9)
+	stats.P95 = rank(0.95)
+	stats.Max = sorted[len(sorted)-1]
+	return stats
+}
+
+// LoadBenchmarkReport : 저장해 둔 벤치마크 결과 파일 읽기
+func LoadBenchmarkReport(path string) (*BenchmarkReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report BenchmarkReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("벤치마크 결과 형식 오류: %w", err)
+	}
+	return &report, nil
+}
+
+// CompareBenchmarkReports : 이전 결과 대비 지표 변화와 결과가 바뀐 케이스
+func CompareBenchmarkReports(baseline *BenchmarkReport, baselineName string, current *BenchmarkReport) *BenchmarkComparison {
+	comparison := &BenchmarkComparison{
+		Baseline:           baselineName,
+		NameAccuracyDelta:  current.NameAccuracy - baseline.NameAccuracy,
+		AliasAccuracyDelta: current.AliasAccuracy - baseline.AliasAccuracy,
+		CaffeineMAEDelta:   current.CaffeineError.Mean - baseline.CaffeineError.Mean,
+		LatencyMeanDelta:   current.LatencyMS.Mean - baseline.LatencyMS.Mean,
+		CostUSDDelta:       current.CostUSD - baseline.CostUSD,
+		Fixed:              []string{},
+		Regressed:          []string{},
+	}
+
+	before := make(map[string]BenchmarkCase, len(baseline.Cases))
+	for _, c := range baseline.Cases {
+		before[c.ID] = c
+	}
+	for _, c := range current.Cases {
+		old, ok := before[c.ID]
+		if !ok {
+			continue
+		}
+		comparison.Common++
+		switch {
+		case c.AliasMatch && !old.AliasMatch:
+			comparison.Fixed = append(comparison.Fixed, c.ID)
+		case !c.AliasMatch && old.AliasMatch:
+			comparison.Regressed = append(comparison.Regressed, c.ID)
+		}
+	}
+	return comparison
+}
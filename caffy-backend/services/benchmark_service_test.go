@@ -0,0 +1,171 @@
+package services
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestBenchmarkStats(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   BenchmarkStats
+	}{
+		{name: "값 없음", values: nil, want: BenchmarkStats{}},
+		{name: "값 하나", values: []float64{5}, want: BenchmarkStats{Count: 1, Mean: 5, Median: 5, P90: 5, P95: 5, Max: 5}},
+		{name: "정렬되지 않은 입력", values: []float64{3, 1, 2}, want: BenchmarkStats{Count: 3, Mean: 2, Median: 2, P90: 3, P95: 3, Max: 3}},
+		{
+			name:   "1~10 (nearest-rank)",
+			values: []float64{10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+			want:   BenchmarkStats{Count: 10, Mean: 5.5, Median: 5, P90: 9, P95: 10, Max: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := benchmarkStats(tt.values); got != tt.want {
+				t.Errorf("benchmarkStats(%v) = %+v, want %+v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+// benchmarkFixtureCases : 맞힘, 별칭으로 맞힘, 틀림, 실패, 카페인 정답 없음
+func benchmarkFixtureCases() []BenchmarkCase {
+	errorOf := func(v float64) *float64 { return &v }
+	return []BenchmarkCase{
+		{
+			ID: "1", ExpectedName: "아메리카노", PredictedName: "아메리카노", NameMatch: true, AliasMatch: true,
+			CaffeineError: errorOf(10), LatencyMS: 100, CostUSD: 0.01, Policy: PolicyAccepted, PromptVersion: "v2",
+			Attempts: []ProviderAttempt{{Provider: "gemini", LatencyMS: 100, Usage: LLMUsage{InputTokens: 100, OutputTokens: 20}, CostUSD: 0.01}},
+		},
+		{
+			ID: "2", ExpectedName: "아메리카노", PredictedName: "아이스 아메리카노", AliasMatch: true,
+			CaffeineError: errorOf(0), LatencyMS: 200, CostUSD: 0.02, Policy: PolicyAccepted,
+			Attempts: []ProviderAttempt{
+				{Provider: "gemini", LatencyMS: 50, Error: "timeout"},
+				{Provider: "openai", LatencyMS: 150, Usage: LLMUsage{InputTokens: 200, OutputTokens: 30}, CostUSD: 0.02},
+			},
+		},
+		{
+			ID: "3", ExpectedName: "라떼", PredictedName: "카푸치노",
+			CaffeineError: errorOf(40), LatencyMS: 300, CostUSD: 0.01, Policy: PolicyDisagreement, PromptVersion: "v1",
+			Attempts: []ProviderAttempt{{Provider: "gemini", LatencyMS: 300, Usage: LLMUsage{InputTokens: 100, OutputTokens: 20}, CostUSD: 0.01}},
+		},
+		{
+			ID: "4", ExpectedName: "라떼", Error: "모든 프로바이더 실패", LatencyMS: 500,
+			Attempts: []ProviderAttempt{
+				{Provider: "gemini", LatencyMS: 400, Error: "HTTP 500"},
+				{Provider: "openai", Skipped: true},
+			},
+		},
+		{
+			ID: "5", ExpectedName: "콜드브루", PredictedName: "콜드브루", NameMatch: true, AliasMatch: true,
+			LatencyMS: 400, CostUSD: 0.01, Policy: PolicyConsensus, PromptVersion: "v2",
+			Attempts: []ProviderAttempt{
+				{Provider: "gemini", LatencyMS: 200, Usage: LLMUsage{InputTokens: 100, OutputTokens: 20}, CostUSD: 0.005},
+				{Provider: "openai", LatencyMS: 200, Usage: LLMUsage{InputTokens: 200, OutputTokens: 30}, CostUSD: 0.005},
+			},
+		},
+	}
+}
+
+func TestSummarizeBenchmark(t *testing.T) {
+	report := &BenchmarkReport{Providers: []string{"gemini", "openai"}, Cases: benchmarkFixtureCases()}
+	summarizeBenchmark(report)
+
+	approx := func(name string, got, want float64) {
+		t.Helper()
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+
+	if report.Total != 5 || report.Failed != 1 {
+		t.Errorf("Total/Failed = %d/%d, want 5/1", report.Total, report.Failed)
+	}
+	approx("NameAccuracy", report.NameAccuracy, 0.4)
+	approx("AliasAccuracy", report.AliasAccuracy, 0.6)
+	approx("CostUSD", report.CostUSD, 0.05)
+
+	// 카페인 오차는 정답이 있는 성공 케이스만 (10, 0, 40)
+	approx("CaffeineError.Mean (MAE)", report.CaffeineError.Mean, 50.0/3)
+	if got := report.CaffeineError; got.Count != 3 || got.Median != 10 || got.P90 != 40 || got.Max != 40 {
+		t.Errorf("CaffeineError = %+v", got)
+	}
+	// 지연은 실패 케이스 제외 (100, 200, 300, 400)
+	if got, want := report.LatencyMS, (BenchmarkStats{Count: 4, Mean: 250, Median: 200, P90: 400, P95: 400, Max: 400}); got != want {
+		t.Errorf("LatencyMS = %+v, want %+v", got, want)
+	}
+
+	wantPolicies := map[string]int{PolicyAccepted: 2, PolicyDisagreement: 1, PolicyConsensus: 1}
+	if !reflect.DeepEqual(report.Policies, wantPolicies) {
+		t.Errorf("Policies = %v, want %v", report.Policies, wantPolicies)
+	}
+	if want := []string{"v1", "v2"}; !reflect.DeepEqual(report.PromptVersions, want) {
+		t.Errorf("PromptVersions = %v, want %v", report.PromptVersions, want)
+	}
+
+	if len(report.ProviderStats) != 2 {
+		t.Fatalf("ProviderStats = %+v", report.ProviderStats)
+	}
+	gemini, openai := report.ProviderStats[0], report.ProviderStats[1]
+	if gemini.Provider != "gemini" || gemini.Calls != 5 || gemini.Answered != 3 || gemini.Failed != 2 || gemini.Skipped != 0 ||
+		gemini.InputTokens != 300 || gemini.OutputTokens != 60 {
+		t.Errorf("gemini = %+v", gemini)
+	}
+	approx("gemini.CostUSD", gemini.CostUSD, 0.025)
+	approx("gemini.LatencyMS.Mean", gemini.LatencyMS.Mean, 210)
+	if openai.Provider != "openai" || openai.Calls != 2 || openai.Answered != 2 || openai.Failed != 0 || openai.Skipped != 1 {
+		t.Errorf("openai = %+v", openai)
+	}
+	approx("openai.LatencyMS.Mean", openai.LatencyMS.Mean, 175)
+
+	wantConfusions := []BenchmarkConfusion{
+		{Expected: "라떼", Predicted: "(실패)", Count: 1},
+		{Expected: "라떼", Predicted: "카푸치노", Count: 1},
+	}
+	if !reflect.DeepEqual(report.Confusions, wantConfusions) {
+		t.Errorf("Confusions = %+v, want %+v", report.Confusions, wantConfusions)
+	}
+
+	wantLabels := []BenchmarkLabelStats{
+		{Label: "라떼", Total: 2, Correct: 0, Accuracy: 0},
+		{Label: "아메리카노", Total: 2, Correct: 2, Accuracy: 1},
+		{Label: "콜드브루", Total: 1, Correct: 1, Accuracy: 1},
+	}
+	if !reflect.DeepEqual(report.Labels, wantLabels) {
+		t.Errorf("Labels = %+v, want %+v", report.Labels, wantLabels)
+	}
+}
+
+func TestSummarizeBenchmarkEmpty(t *testing.T) {
+	report := &BenchmarkReport{}
+	summarizeBenchmark(report)
+	if report.Total != 0 || report.NameAccuracy != 0 || report.CaffeineError.Count != 0 || report.Labels == nil {
+		t.Errorf("빈 결과 요약 = %+v", report)
+	}
+}
+
+func TestCompareBenchmarkReports(t *testing.T) {
+	baseline := &BenchmarkReport{Cases: benchmarkFixtureCases()}
+	summarizeBenchmark(baseline)
+
+	current := &BenchmarkReport{Cases: benchmarkFixtureCases()}
+	current.Cases[1].AliasMatch = false // 2번 회귀
+	current.Cases[2].AliasMatch = true  // 3번 개선
+	current.Cases = append(current.Cases, BenchmarkCase{ID: "6", ExpectedName: "모카", AliasMatch: true})
+	summarizeBenchmark(current)
+
+	comparison := CompareBenchmarkReports(baseline, "base.json", current)
+	if comparison.Common != 5 {
+		t.Errorf("Common = %d, want 5", comparison.Common)
+	}
+	if !reflect.DeepEqual(comparison.Fixed, []string{"3"}) || !reflect.DeepEqual(comparison.Regressed, []string{"2"}) {
+		t.Errorf("Fixed/Regressed = %v/%v", comparison.Fixed, comparison.Regressed)
+	}
+	if math.Abs(comparison.AliasAccuracyDelta-(4.0/6-0.6)) > 1e-9 {
+		t.Errorf("AliasAccuracyDelta = %v", comparison.AliasAccuracyDelta)
+	}
+}
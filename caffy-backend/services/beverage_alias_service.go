@@ -0,0 +1,415 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========================================
+// 음료 별칭/동의어 서비스
+// 별칭 → 음료(beverage), 음료 분류(category), 검색어 치환(term)
+// OCR 매칭, 텍스트 추정, 검색이 모두 이 테이블을 사용 (코드 수정 없이 API로 추가)
+// ========================================
+
+var (
+	ErrAliasNotFound = errors.New("별칭을 찾을 수 없습니다")
+	ErrAliasExists   = errors.New("이미 등록된 별칭입니다")
+	ErrInvalidAlias  = errors.New("별칭 입력값이 올바르지 않습니다")
+)
+
+// aliasCacheTTL : 별칭 목록 캐시 시간 (API로 수정하면 바로 무효화)
+const aliasCacheTTL = 5 * time.Minute
+
+// 별칭 가중치 범위
+const (
+	defaultAliasWeight = 1.0
+	maxAliasWeight     = 2.0
+)
+
+// builtinTermAliases : 기본 제공 검색어 치환 (처음 실행 시 별칭 테이블에 등록, 이후에는 API로 관리)
+var builtinTermAliases = map[string]string{
+	"스벅":         "스타벅스",
+	"starbucks":  "스타벅스",
+	"투썸":         "투썸플레이스",
+	"twosome":    "투썸플레이스",
+	"이디야커피":      "이디야",
+	"ediya":      "이디야",
+	"메가":         "메가커피",
+	"빽다방커피":      "빽다방",
+	"아아":         "아이스 아메리카노",
+	"뜨아":         "아메리카노",
+	"americano":  "아메리카노",
+	"latte":      "라떼",
+	"라테":         "라떼",
+	"cappuccino": "카푸치노",
+	"espresso":   "에스프레소",
+	"coldbrew":   "콜드브루",
+	"mocha":      "모카",
+	"macchiato":  "마끼아또",
+	"마키아토":       "마끼아또",
+	"redbull":    "레드불",
+	"hot6":       "핫식스",
+	"monster":    "몬스터",
+}
+
+// BeverageAliasInput : 별칭 등록/수정 요청
+type BeverageAliasInput struct {
+	Alias      string   `json:"alias" binding:"required"`
+	TargetType string   `json:"target_type" binding:"required"` // "beverage", "category", "term"
+	BeverageID *uint    `json:"beverage_id"`
+	Category   string   `json:"category"`
+	Term       string   `json:"term"`
+	Language   string   `json:"language"` // 비우면 자동 판별 ("ko", "en")
+	Weight     *float64 `json:"weight"`   // 0 초과 2 이하 (기본 1)
+}
+
+// BeverageAliasFilter : 별칭 목록 조회 조건
+type BeverageAliasFilter struct {
+	TargetType string
+	BeverageID uint
+	Query      string
+}
+
+// aliasTarget : 캐시된 별칭 (key는 검색어 치환까지 적용한 비교용 표기)
+type aliasTarget struct {
+	alias      string
+	key        string
+	beverageID uint
+	category   string
+	weight     float64
+}
+
+// aliasIndex : 별칭 캐시
+type aliasIndex struct {
+	terms      map[string]string      // 검색어 치환
+	beverages  []aliasTarget          // 음료 별칭
+	categories []aliasTarget          // 분류 별칭
+	byBeverage map[uint][]aliasTarget // 음료별 별칭 (검색 색인용)
+}
+
+var aliasCache struct {
+	sync.Mutex
+	index     *aliasIndex
+	expiresAt time.Time
+}
+
+// aliasKey : 별칭 비교용 표기 (소문자, 공백/문장부호 제거)
+func aliasKey(alias string) string {
+	return compactQuery(strings.Join(strings.Fields(stripPunctuation(alias)), " "))
+}
+
+// aliasLanguage : 한글이 있으면 "ko", 아니면 "en"
+func aliasLanguage(alias string) string {
+	for _, r := range alias {
+		if unicode.Is(unicode.Hangul, r) {
+			return "ko"
+		}
+	}
+	return "en"
+}
+
+// aliasSnapshot : 현재 별칭 캐시 (만료됐으면 다시 읽음, DB가 없으면 기본 제공 치환만)
+func aliasSnapshot() *aliasIndex {
+	aliasCache.Lock()
+	defer aliasCache.Unlock()
+	if aliasCache.index != nil && time.Now().Before(aliasCache.expiresAt) {
+		return aliasCache.index
+	}
+	if config.DB == nil {
+		return builtinAliasIndex()
+	}
+
+	var aliases []models.BeverageAlias
+	if err := config.DB.Find(&aliases).Error; err != nil {
+		println("⚠️ 별칭 목록 조회 실패:", err.Error())
+		if aliasCache.index != nil {
+			return aliasCache.index
+		}
+		return builtinAliasIndex()
+	}
+
+	aliasCache.index = buildAliasIndex(aliases)
+	aliasCache.expiresAt = time.Now().Add(aliasCacheTTL)
+	return aliasCache.index
+}
+
+// builtinAliasIndex : 기본 제공 치환만 있는 캐시 (DB 연결 전/테스트용)
+func builtinAliasIndex() *aliasIndex {
+	terms := make(map[string]string, len(builtinTermAliases))
+	for alias, term := range builtinTermAliases {
+		terms[aliasKey(alias)] = term
+	}
+	return &aliasIndex{terms: terms, byBeverage: map[uint][]aliasTarget{}}
+}
+
+// buildAliasIndex : 별칭 행으로 캐시 구성 (치환을 먼저 모은 뒤 음료/분류 별칭에 적용)
+func buildAliasIndex(aliases []models.BeverageAlias) *aliasIndex {
+	index := &aliasIndex{terms: map[string]string{}, byBeverage: map[uint][]aliasTarget{}}
+	for _, alias := range aliases {
+		if alias.TargetType == models.AliasTargetTerm {
+			index.terms[alias.Normalized] = strings.ToLower(strings.TrimSpace(alias.Term))
+		}
+	}
+
+	for _, alias := range aliases {
+		target := aliasTarget{
+			alias:    alias.Alias,
+			key:      compactQuery(normalizeWithTerms(alias.Alias, index.terms)),
+			category: alias.Category,
+			weight:   alias.Weight,
+		}
+		if target.key == "" {
+			continue
+		}
+		switch alias.TargetType {
+		case models.AliasTargetBeverage:
+			if alias.BeverageID == nil {
+				continue
+			}
+			target.beverageID = *alias.BeverageID
+			index.beverages = append(index.beverages, target)
+			index.byBeverage[target.beverageID] = append(index.byBeverage[target.beverageID], target)
+		case models.AliasTargetCategory:
+			index.categories = append(index.categories, target)
+		}
+	}
+	return index
+}
+
+// InvalidateBeverageAliasCache : 별칭이 바뀌면 별칭 캐시와 검색 색인을 다시 만듦
+func InvalidateBeverageAliasCache() {
+	aliasCache.Lock()
+	aliasCache.expiresAt = time.Time{}
+	aliasCache.Unlock()
+	InvalidateBeverageSearchIndex()
+}
+
+// SeedBeverageAliases : 기본 제공 치환을 별칭 테이블에 등록 (이미 있거나 삭제한 별칭은 다시 만들지 않음)
+func SeedBeverageAliases() {
+	if config.DB == nil {
+		return
+	}
+
+	seeds := make([]models.BeverageAlias, 0, len(builtinTermAliases))
+	for alias, term := range builtinTermAliases {
+		seeds = append(seeds, models.BeverageAlias{
+			Alias:      alias,
+			Normalized: aliasKey(alias),
+			TargetType: models.AliasTargetTerm,
+			TargetKey:  aliasTargetKey(models.AliasTargetTerm, nil, ""),
+			Term:       term,
+			Language:   aliasLanguage(alias),
+			Weight:     defaultAliasWeight,
+		})
+	}
+	created := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&seeds)
+	if created.Error != nil {
+		println("⚠️ 기본 별칭 등록 실패:", created.Error.Error())
+		return
+	}
+	if created.RowsAffected > 0 {
+		println("🏷️ 기본 별칭 등록:", created.RowsAffected, "개")
+		InvalidateBeverageAliasCache()
+	}
+}
+
+// aliasTargetKey : 같은 별칭이 같은 대상을 두 번 가리키지 않도록 하는 키
+func aliasTargetKey(targetType string, beverageID *uint, category string) string {
+	switch targetType {
+	case models.AliasTargetBeverage:
+		return fmt.Sprintf("beverage:%d", *beverageID)
+	case models.AliasTargetCategory:
+		return "category:" + category
+	default:
+		// 치환은 별칭 하나에 대상 하나
+		return "term"
+	}
+}
+
+// ListBeverageAliases : 별칭 목록
+func ListBeverageAliases(filter BeverageAliasFilter) ([]models.BeverageAlias, error) {
+	query := config.DB.Model(&models.BeverageAlias{})
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.BeverageID != 0 {
+		query = query.Where("beverage_id = ?", filter.BeverageID)
+	}
+	if filter.Query != "" {
+		query = query.Where("normalized LIKE ?", "%"+aliasKey(filter.Query)+"%")
+	}
+
+	aliases := []models.BeverageAlias{}
+	err := query.Order("target_type ASC, normalized ASC").Find(&aliases).Error
+	return aliases, err
+}
+
+// CreateBeverageAlias : 별칭 등록
+func CreateBeverageAlias(input BeverageAliasInput, userID uint) (*models.BeverageAlias, error) {
+	alias := models.BeverageAlias{CreatedByUser: userID}
+	if err := applyAliasInput(&alias, input); err != nil {
+		return nil, err
+	}
+
+	created := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&alias)
+	if created.Error != nil {
+		return nil, created.Error
+	}
+	if created.RowsAffected == 0 {
+		// 삭제했던 별칭을 다시 등록하면 되살림
+		var deleted models.BeverageAlias
+		if err := config.DB.Unscoped().
+			Where("normalized = ? AND target_key = ? AND deleted_at IS NOT NULL", alias.Normalized, alias.TargetKey).
+			First(&deleted).Error; err != nil {
+			return nil, ErrAliasExists
+		}
+		alias.ID, alias.CreatedAt = deleted.ID, deleted.CreatedAt
+		if err := config.DB.Unscoped().Save(&alias).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	InvalidateBeverageAliasCache()
+	return &alias, nil
+}
+
+// UpdateBeverageAlias : 별칭 수정
+func UpdateBeverageAlias(id uint, input BeverageAliasInput) (*models.BeverageAlias, error) {
+	var alias models.BeverageAlias
+	if err := config.DB.First(&alias, id).Error; err != nil {
+		return nil, ErrAliasNotFound
+	}
+	if err := applyAliasInput(&alias, input); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	config.DB.Model(&models.BeverageAlias{}).
+		Where("normalized = ? AND target_key = ? AND id <> ?", alias.Normalized, alias.TargetKey, alias.ID).
+		Count(&count)
+	if count > 0 {
+		return nil, ErrAliasExists
+	}
+
+	if err := config.DB.Save(&alias).Error; err != nil {
+		return nil, err
+	}
+	InvalidateBeverageAliasCache()
+	return &alias, nil
+}
+
+// DeleteBeverageAlias : 별칭 삭제 (기본 제공 별칭도 삭제하면 다시 등록되지 않음)
+func DeleteBeverageAlias(id uint) error {
+	result := config.DB.Delete(&models.BeverageAlias{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAliasNotFound
+	}
+	InvalidateBeverageAliasCache()
+	return nil
+}
+
+// ensureBeverageAlias : 음료 별칭이 없으면 등록 (지워진 같은 별칭은 되살림), 새로 생겼으면 true
+func ensureBeverageAlias(tx *gorm.DB, beverageID uint, raw string, userID uint) (bool, error) {
+	normalized := aliasKey(raw)
+	targetKey := aliasTargetKey(models.AliasTargetBeverage, &beverageID, "")
+
+	var alias models.BeverageAlias
+	err := tx.Unscoped().Where("normalized = ? AND target_key = ?", normalized, targetKey).First(&alias).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		alias = models.BeverageAlias{
+			Alias:         raw,
+			Normalized:    normalized,
+			TargetType:    models.AliasTargetBeverage,
+			TargetKey:     targetKey,
+			BeverageID:    &beverageID,
+			Language:      aliasLanguage(raw),
+			Weight:        defaultAliasWeight,
+			CreatedByUser: userID,
+		}
+		return true, tx.Create(&alias).Error
+	case err != nil:
+		return false, err
+	case alias.DeletedAt.Valid:
+		return true, tx.Unscoped().Model(&alias).Update("deleted_at", nil).Error
+	}
+	return false, nil
+}
+
+// applyAliasInput : 입력값 검증 후 별칭에 반영
+func applyAliasInput(alias *models.BeverageAlias, input BeverageAliasInput) error {
+	normalized := aliasKey(input.Alias)
+	if normalized == "" {
+		return fmt.Errorf("%w: 별칭이 비어 있습니다", ErrInvalidAlias)
+	}
+	if len([]rune(normalized)) > 100 {
+		return fmt.Errorf("%w: 별칭은 100자 이하여야 합니다", ErrInvalidAlias)
+	}
+
+	weight := defaultAliasWeight
+	if input.Weight != nil {
+		weight = *input.Weight
+	}
+	if weight <= 0 || weight > maxAliasWeight {
+		return fmt.Errorf("%w: weight는 0 초과 %g 이하여야 합니다", ErrInvalidAlias, maxAliasWeight)
+	}
+
+	language := input.Language
+	if language == "" {
+		language = aliasLanguage(input.Alias)
+	}
+
+	alias.BeverageID, alias.Category, alias.Term = nil, "", ""
+	switch input.TargetType {
+	case models.AliasTargetBeverage:
+		if input.BeverageID == nil {
+			return fmt.Errorf("%w: beverage 별칭은 beverage_id가 필요합니다", ErrInvalidAlias)
+		}
+		var beverage models.Beverage
+		if err := config.DB.First(&beverage, *input.BeverageID).Error; err != nil {
+			return ErrBeverageNotFound
+		}
+		alias.BeverageID = &beverage.ID
+	case models.AliasTargetCategory:
+		if !isDrinkCategory(input.Category) {
+			return fmt.Errorf("%w: category는 %s 중 하나여야 합니다", ErrInvalidAlias, strings.Join(drinkCategories, ", "))
+		}
+		alias.Category = input.Category
+	case models.AliasTargetTerm:
+		if strings.TrimSpace(input.Term) == "" {
+			return fmt.Errorf("%w: term 별칭은 바꿔 쓸 표기(term)가 필요합니다", ErrInvalidAlias)
+		}
+		alias.Term = strings.TrimSpace(input.Term)
+	default:
+		return fmt.Errorf("%w: target_type은 beverage, category, term 중 하나여야 합니다", ErrInvalidAlias)
+	}
+
+	alias.Alias = strings.TrimSpace(input.Alias)
+	alias.Normalized = normalized
+	alias.TargetType = input.TargetType
+	alias.TargetKey = aliasTargetKey(input.TargetType, alias.BeverageID, alias.Category)
+	alias.Language = language
+	alias.Weight = weight
+	return nil
+}
+
+func isDrinkCategory(category string) bool {
+	for _, c := range drinkCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
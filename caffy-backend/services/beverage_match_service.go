@@ -0,0 +1,195 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ========================================
+// 음료 매칭 엔진 (OCR 텍스트/로고 → 음료 후보와 점수)
+// 음료 이름, 음료 별칭, 브랜드, 분류 별칭을 모두 보고 가장 그럴듯한 음료를 고름
+// ========================================
+
+// minBeverageMatchScore : 이 점수 이상이어야 음료로 인정
+const minBeverageMatchScore = 0.5
+
+// beverageMatchLimit : 매칭 결과로 돌려줄 최대 후보 수
+const beverageMatchLimit = 5
+
+// logoBrandScore : 이름/별칭은 없고 로고만 브랜드와 맞을 때의 점수 (같은 브랜드 중 인기 음료가 앞)
+const logoBrandScore = minBeverageMatchScore
+
+// BeverageMatch : 매칭된 음료 후보
+type BeverageMatch struct {
+	BeverageID uint     `json:"beverage_id"`
+	Name       string   `json:"name"`
+	Brand      string   `json:"brand"`
+	Category   string   `json:"category"`
+	Score      float64  `json:"score"`      // 0~1
+	MatchedBy  []string `json:"matched_by"` // 예: ["name", "brand"], ["alias:아아"]
+}
+
+// BeverageMatchResult : 매칭 결과 (음료 후보가 없어도 분류는 알 수 있음)
+type BeverageMatchResult struct {
+	Candidates []BeverageMatch `json:"candidates"` // 점수순
+	Category   string          `json:"category"`   // 분류 별칭으로 알아낸 분류
+}
+
+// Best : 기준 점수를 넘는 가장 좋은 후보 (없으면 nil)
+func (r *BeverageMatchResult) Best() *BeverageMatch {
+	if len(r.Candidates) == 0 || r.Candidates[0].Score < minBeverageMatchScore {
+		return nil
+	}
+	return &r.Candidates[0]
+}
+
+// MatchBeverageText : 텍스트(OCR 전문, 상품명 등)에서 음료 후보 찾기
+func MatchBeverageText(texts ...string) *BeverageMatchResult {
+	return MatchBeverageVision(strings.Join(texts, " "), nil)
+}
+
+// MatchBeverageVision : OCR 전문과 로고로 음료 후보 찾기
+// 로고는 텍스트처럼 이름/별칭 매칭에도 쓰고, 이름을 못 찾으면 로고 브랜드의 음료를 후보로 둠
+func MatchBeverageVision(fullText string, logos []string) *BeverageMatchResult {
+	aliases := aliasSnapshot()
+	text := compactQuery(normalizeWithTerms(strings.Join(append([]string{fullText}, logos...), " "), aliases.terms))
+	if text == "" {
+		return &BeverageMatchResult{Candidates: []BeverageMatch{}}
+	}
+
+	logoKeys := make([]string, 0, len(logos))
+	for _, logo := range logos {
+		logoKeys = append(logoKeys, compactQuery(normalizeWithTerms(logo, aliases.terms)))
+	}
+	return matchBeverageText(text, logoKeys, beverageSearchEntries(), aliases.categories)
+}
+
+// matchBeverageText : 정규화된 텍스트와 로고를 색인된 음료와 비교
+func matchBeverageText(text string, logos []string, entries []searchEntry, categoryAliases []aliasTarget) *BeverageMatchResult {
+	result := &BeverageMatchResult{Candidates: []BeverageMatch{}}
+
+	// 분류 별칭 (가장 긴 별칭 우선)
+	categories := make(map[string]bool)
+	longest := 0
+	for _, alias := range categoryAliases {
+		if strings.Contains(text, alias.key) {
+			categories[alias.category] = true
+			if n := len([]rune(alias.key)); n > longest {
+				longest, result.Category = n, alias.category
+			}
+		}
+	}
+
+	popularity := make(map[uint]int, len(entries))
+	for i := range entries {
+		e := &entries[i]
+		score, matchedBy := 0.0, []string{}
+
+		// 음료 이름이 텍스트에 있으면 이름이 길수록 확실
+		if n := len([]rune(e.nameCompact)); n >= 2 && strings.Contains(text, e.nameCompact) {
+			score = 0.55 + 0.25*math.Min(1, float64(n)/10)
+			matchedBy = append(matchedBy, "name")
+		}
+
+		// 음료 별칭 (가중치와 길이 반영)
+		for _, alias := range e.aliases {
+			n := len([]rune(alias.key))
+			if n < 2 || !strings.Contains(text, alias.key) {
+				continue
+			}
+			aliasScore := math.Min(0.95, 0.5*alias.weight+0.2*math.Min(1, float64(n)/8)+0.1)
+			if aliasScore > score {
+				score = aliasScore
+			}
+			matchedBy = append(matchedBy, "alias:"+alias.alias)
+		}
+		logoOnly := false
+		if score == 0 && logoMatchesBrand(logos, e.brandCompact) {
+			score, logoOnly = logoBrandScore, true
+			matchedBy = append(matchedBy, "logo")
+		}
+		if score == 0 {
+			continue
+		}
+
+		if !logoOnly && e.brandCompact != "" && strings.Contains(text, e.brandCompact) {
+			score += 0.15
+			matchedBy = append(matchedBy, "brand")
+		}
+		if categories[e.hit.Category] {
+			score += 0.05
+			matchedBy = append(matchedBy, "category")
+		}
+
+		popularity[e.hit.ID] = e.popularity
+		result.Candidates = append(result.Candidates, BeverageMatch{
+			BeverageID: e.hit.ID,
+			Name:       e.hit.Name,
+			Brand:      e.hit.Brand,
+			Category:   e.hit.Category,
+			Score:      math.Round(math.Min(1, score)*100) / 100,
+			MatchedBy:  matchedBy,
+		})
+	}
+
+	sort.SliceStable(result.Candidates, func(i, j int) bool {
+		a, b := result.Candidates[i], result.Candidates[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return popularity[a.BeverageID] > popularity[b.BeverageID]
+	})
+	if len(result.Candidates) > beverageMatchLimit {
+		result.Candidates = result.Candidates[:beverageMatchLimit]
+	}
+	return result
+}
+
+// logoMatchesBrand : 로고가 음료 브랜드에 포함되는지 ("스타벅스" 로고 → "스타벅스 리저브" 브랜드도 포함)
+func logoMatchesBrand(logos []string, brand string) bool {
+	if brand == "" {
+		return false
+	}
+	for _, logo := range logos {
+		if len([]rune(logo)) >= 2 && strings.Contains(brand, logo) {
+			return true
+		}
+	}
+	return false
+}
+
+// aliasBeverageID : 검색어 전체가 음료 별칭과 같으면 그 음료 (가중치가 가장 큰 별칭)
+// 별칭 키와 같은 방식으로 정규화해서 비교 (대소문자, 공백, "latte" → "라떼" 같은 치환)
+func aliasBeverageID(query string) (uint, bool) {
+	aliases := aliasSnapshot()
+	key := compactQuery(normalizeWithTerms(query, aliases.terms))
+	var best *aliasTarget
+	for i := range aliases.beverages {
+		alias := &aliases.beverages[i]
+		if alias.key == key && (best == nil || alias.weight > best.weight) {
+			best = alias
+		}
+	}
+	if best == nil {
+		return 0, false
+	}
+	return best.beverageID, true
+}
+
+// findBeverageByAlias : 별칭이 가리키는 활성 음료 (사이즈 포함)
+func findBeverageByAlias(query string) *models.Beverage {
+	id, ok := aliasBeverageID(query)
+	if !ok {
+		return nil
+	}
+	var beverage models.Beverage
+	if err := config.DB.Preload("Sizes").
+		Where("id = ? AND status = ?", id, models.BeverageStatusActive).
+		First(&beverage).Error; err != nil {
+		return nil
+	}
+	return &beverage
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"caffy-backend/models"
+	"testing"
+)
+
+// 매칭 테스트용 음료 목록 (예전 knownPatterns에 있던 음료 위주)
+var matchFixtureBeverages = []struct {
+	id         uint
+	name       string
+	brand      string
+	category   string
+	popularity int
+}{
+	{1, "아메리카노", "스타벅스", "커피", 50},
+	{2, "라떼", "이디야", "커피", 20},
+	{3, "카푸치노", "투썸플레이스", "커피", 5},
+	{4, "에스프레소", "", "커피", 5},
+	{5, "콜드브루", "스타벅스", "커피", 10},
+	{6, "모카", "", "커피", 5},
+	{7, "마끼아또", "", "커피", 5},
+	{8, "레드불", "레드불", "에너지드링크", 30},
+	{9, "몬스터", "몬스터", "에너지드링크", 30},
+	{10, "핫식스", "롯데칠성", "에너지드링크", 30},
+	{11, "아이스티 샷 추가", "메가커피", "차", 5},
+}
+
+// newMatchFixture : DB 없이 기본 치환 + 테스트 별칭으로 색인 구성
+func newMatchFixture() ([]searchEntry, *aliasIndex) {
+	var rows []models.BeverageAlias
+	for alias, term := range builtinTermAliases {
+		rows = append(rows, models.BeverageAlias{Alias: alias, Normalized: aliasKey(alias), TargetType: models.AliasTargetTerm, Term: term, Weight: 1})
+	}
+	shotID := uint(11)
+	rows = append(rows,
+		models.BeverageAlias{Alias: "아샷추", Normalized: aliasKey("아샷추"), TargetType: models.AliasTargetBeverage, BeverageID: &shotID, Weight: 1},
+		models.BeverageAlias{Alias: "energy drink", Normalized: aliasKey("energy drink"), TargetType: models.AliasTargetCategory, Category: "에너지드링크", Weight: 1},
+	)
+	aliases := buildAliasIndex(rows)
+
+	entries := make([]searchEntry, 0, len(matchFixtureBeverages))
+	for _, b := range matchFixtureBeverages {
+		beverage := models.Beverage{Name: b.name, Brand: b.brand, Category: b.category, Status: models.BeverageStatusActive}
+		beverage.ID = b.id
+		entries = append(entries, newSearchEntry(beverage, b.popularity, aliases))
+	}
+	return entries, aliases
+}
+
+// matchFixture : MatchBeverageVision과 같은 방식으로 정규화해서 매칭
+func matchFixture(fullText string, logos ...string) *BeverageMatchResult {
+	entries, aliases := newMatchFixture()
+	text := fullText
+	for _, logo := range logos {
+		text += " " + logo
+	}
+	logoKeys := make([]string, 0, len(logos))
+	for _, logo := range logos {
+		logoKeys = append(logoKeys, compactQuery(normalizeWithTerms(logo, aliases.terms)))
+	}
+	return matchBeverageText(compactQuery(normalizeWithTerms(text, aliases.terms)), logoKeys, entries, aliases.categories)
+}
+
+func TestMatchBeverageTextKnownPatterns(t *testing.T) {
+	// 예전 knownPatterns의 한글/영문 표기가 모두 같은 음료로 매칭되어야 함
+	tests := []struct {
+		ocr    string
+		wantID uint
+	}{
+		{"스타벅스 아메리카노 Tall", 1},
+		{"STARBUCKS AMERICANO", 1},
+		{"EDIYA LATTE", 2},
+		{"이디야 라테", 2},
+		{"twosome cappuccino", 3},
+		{"카푸치노", 3},
+		{"Double ESPRESSO", 4},
+		{"에스프레소", 4},
+		{"Starbucks Cold Brew", 5},
+		{"콜드브루", 5},
+		{"Caffe Mocha", 6},
+		{"Caramel Macchiato", 7},
+		{"캬라멜 마키아토", 7},
+		{"Red Bull Energy Drink 250ml", 8},
+		{"레드불", 8},
+		{"MONSTER ENERGY", 9},
+		{"몬스터", 9},
+		{"HOT6 250ml", 10},
+		{"핫식스", 10},
+		{"아샷추 주세요", 11},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ocr, func(t *testing.T) {
+			best := matchFixture(tt.ocr).Best()
+			if best == nil {
+				t.Fatalf("매칭 없음, want %d", tt.wantID)
+			}
+			if best.BeverageID != tt.wantID {
+				t.Errorf("BeverageID = %d (%s, %v), want %d", best.BeverageID, best.Name, best.MatchedBy, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestMatchBeverageTextScoring(t *testing.T) {
+	// 이름 + 브랜드가 같이 있으면 브랜드 가산점
+	result := matchFixture("스타벅스 아메리카노")
+	if best := result.Best(); best == nil || best.BeverageID != 1 || !containsString(best.MatchedBy, "brand") {
+		t.Fatalf("이름+브랜드 매칭 실패: %+v", result.Candidates)
+	}
+
+	// 분류 별칭으로 분류를 알아냄
+	result = matchFixture("Red Bull Energy Drink")
+	if result.Category != "에너지드링크" {
+		t.Errorf("Category = %q, want 에너지드링크", result.Category)
+	}
+	if best := result.Best(); best == nil || !containsString(best.MatchedBy, "category") {
+		t.Errorf("분류 가산점 없음: %+v", result.Candidates)
+	}
+
+	// 음료 별칭
+	if best := matchFixture("아샷추").Best(); best == nil || !containsString(best.MatchedBy, "alias:아샷추") {
+		t.Errorf("별칭 매칭 실패: %+v", best)
+	}
+
+	// 아무것도 없으면 후보 없음
+	if result := matchFixture("영양정보 나트륨 10mg"); len(result.Candidates) != 0 || result.Best() != nil {
+		t.Errorf("관련 없는 텍스트가 매칭됨: %+v", result.Candidates)
+	}
+}
+
+func TestMatchBeverageTextLogoOnly(t *testing.T) {
+	tests := []struct {
+		name   string
+		ocr    string
+		logos  []string
+		wantID uint // 0이면 매칭 없음
+		wantBy string
+	}{
+		{name: "로고만 있으면 그 브랜드의 인기 음료", ocr: "GRANDE 473ml", logos: []string{"Starbucks"}, wantID: 1, wantBy: "logo"},
+		{name: "로고 줄임말도 치환", ocr: "", logos: []string{"스벅"}, wantID: 1, wantBy: "logo"},
+		{name: "이름이 있으면 이름 우선", ocr: "콜드브루", logos: []string{"STARBUCKS"}, wantID: 5, wantBy: "name"},
+		{name: "로고 브랜드 다른 음료보다 이름 매칭 우선", ocr: "핫식스", logos: []string{"Starbucks"}, wantID: 10, wantBy: "name"},
+		{name: "모르는 로고", ocr: "", logos: []string{"Nike"}},
+		{name: "한 글자 로고는 무시", ocr: "", logos: []string{"스"}},
+		{name: "로고가 아닌 텍스트의 브랜드만으로는 매칭하지 않음", ocr: "스타벅스"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			best := matchFixture(tt.ocr, tt.logos...).Best()
+			if tt.wantID == 0 {
+				if best != nil {
+					t.Fatalf("매칭되면 안 됨: %+v", best)
+				}
+				return
+			}
+			if best == nil {
+				t.Fatalf("매칭 없음, want %d", tt.wantID)
+			}
+			if best.BeverageID != tt.wantID || !containsString(best.MatchedBy, tt.wantBy) {
+				t.Errorf("best = %d %v, want %d (%s)", best.BeverageID, best.MatchedBy, tt.wantID, tt.wantBy)
+			}
+		})
+	}
+}
+
+func TestRecognizedBeverageMatch(t *testing.T) {
+	entries, aliases := newMatchFixture()
+	match := func(brand, name string) *BeverageMatch {
+		text := compactQuery(normalizeWithTerms(brand+" "+name, aliases.terms))
+		return recognizedBeverageMatch(matchBeverageText(text, nil, entries, aliases.categories), brand, aliases.terms)
+	}
+
+	tests := []struct {
+		name   string
+		brand  string
+		drink  string
+		wantID uint // 0이면 새 음료로 등록
+	}{
+		{name: "같은 음료", brand: "스타벅스", drink: "아메리카노", wantID: 1},
+		{name: "영문 표기", brand: "Starbucks", drink: "Cold Brew", wantID: 5},
+		{name: "별칭", brand: "메가커피", drink: "아샷추", wantID: 11},
+		{name: "같은 브랜드의 새 음료", brand: "스타벅스", drink: "자몽 허니 블랙티", wantID: 0},
+		{name: "다른 브랜드의 같은 이름", brand: "이디야", drink: "아메리카노", wantID: 0},
+		{name: "브랜드 없이 인식", brand: "", drink: "레드불", wantID: 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := match(tt.brand, tt.drink)
+			switch {
+			case tt.wantID == 0 && got != nil:
+				t.Errorf("%s %s → %s(%d), want 새 음료", tt.brand, tt.drink, got.Name, got.BeverageID)
+			case tt.wantID != 0 && (got == nil || got.BeverageID != tt.wantID):
+				t.Errorf("%s %s → %+v, want %d", tt.brand, tt.drink, got, tt.wantID)
+			}
+		})
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
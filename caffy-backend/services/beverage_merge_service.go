@@ -0,0 +1,563 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========================================
+// 중복 음료 찾기/병합
+// LLM 인식은 이름이 조금만 달라도 새 음료를 만들기 때문에
+// "스타벅스 아메리카노", "Starbucks Americano", "아메리카노(스타벅스)" 같은 중복이 생김
+// 병합하면 사진/기록/인식 로그를 한 음료로 모으고, 예전 ID와 이름은 병합 대상으로 연결됨
+// ========================================
+
+var (
+	ErrMergeSameBeverage = errors.New("같은 음료끼리는 병합할 수 없습니다")
+	ErrMergeTarget       = errors.New("병합 대상은 승인된 음료여야 합니다")
+)
+
+// 중복 판단 기준
+const (
+	defaultDuplicateScore = 0.75 // 이 점수 이상이면 중복 후보
+	minDuplicateNameSim   = 0.75 // 이름 유사도가 이보다 낮으면 비교하지 않음
+	duplicateCaffeineTol  = 0.1  // 카페인 차이가 이 비율 이내면 "close_caffeine"
+	duplicateCaffeineMax  = 0.3  // 카페인 차이가 이 비율 이상이면 카페인 점수 0
+	defaultDuplicateLimit = 50
+	maxDuplicateLimit     = 500
+)
+
+// DuplicateFilter : 중복 후보 조회 조건
+type DuplicateFilter struct {
+	BeverageID uint    // 지정하면 이 음료가 포함된 후보만
+	MinScore   float64 // 0이면 기본값
+	Limit      int
+}
+
+// DuplicateBeverage : 중복 후보 음료 요약
+type DuplicateBeverage struct {
+	ID             uint    `json:"id"`
+	Name           string  `json:"name"`
+	Brand          string  `json:"brand"`
+	CaffeineAmount float64 `json:"caffeine_amount"`
+	Status         string  `json:"status"`
+	IsVerified     bool    `json:"is_verified"`
+	Logs           int64   `json:"logs"` // 이 음료로 남긴 섭취 기록 수
+}
+
+// DuplicateCandidate : 중복으로 보이는 음료 한 쌍 (Merge를 Keep으로 합치기를 추천)
+type DuplicateCandidate struct {
+	Keep    DuplicateBeverage `json:"keep"`
+	Merge   DuplicateBeverage `json:"merge"`
+	Score   float64           `json:"score"`   // 0~1
+	Reasons []string          `json:"reasons"` // "same_name", "similar_name", "same_brand", "close_caffeine"
+}
+
+// BeverageMergeReport : 병합 결과 (옮긴 행 수)
+type BeverageMergeReport struct {
+	SourceID        uint  `json:"source_id"`
+	TargetID        uint  `json:"target_id"`
+	Images          int64 `json:"images"`
+	Logs            int64 `json:"logs"`
+	RecognitionLogs int64 `json:"recognition_logs"`
+	TextEstimates   int64 `json:"text_estimates"`
+	Sizes           int64 `json:"sizes"`
+	Barcodes        int64 `json:"barcodes"`
+	Aliases         int64 `json:"aliases"`
+	Requests        int64 `json:"requests"` // 거절 처리한 대기 중 요청
+}
+
+// duplicateEntry : 비교용으로 정규화한 음료
+type duplicateEntry struct {
+	beverage models.Beverage
+	brand    string // 정규화된 브랜드 (비어 있으면 이름에서 찾음)
+	core     string // 브랜드를 뺀 이름
+	coreRune []rune
+}
+
+// ========================================
+// 중복 후보 찾기
+// ========================================
+
+// FindDuplicateBeverages : 중복으로 보이는 음료 쌍 (점수순)
+// 이름(브랜드를 뺀 이름, 영문 표기는 별칭으로 치환), 브랜드, 카페인 함량을 비교
+func FindDuplicateBeverages(filter DuplicateFilter) ([]DuplicateCandidate, error) {
+	minScore := filter.MinScore
+	if minScore <= 0 {
+		minScore = defaultDuplicateScore
+	}
+
+	var beverages []models.Beverage
+	if err := config.DB.
+		Where("status IN ?", []string{models.BeverageStatusActive, models.BeverageStatusPending}).
+		Find(&beverages).Error; err != nil {
+		return nil, err
+	}
+	entries := newDuplicateEntries(beverages, aliasSnapshot().terms)
+
+	type pair struct {
+		a, b    *duplicateEntry
+		score   float64
+		reasons []string
+	}
+	var pairs []pair
+	compare := func(a, b *duplicateEntry) {
+		if filter.BeverageID != 0 && a.beverage.ID != filter.BeverageID && b.beverage.ID != filter.BeverageID {
+			return
+		}
+		if score, reasons := scoreDuplicatePair(a, b); score >= minScore {
+			pairs = append(pairs, pair{a, b, score, reasons})
+		}
+	}
+
+	// 같은 브랜드끼리만 비교 (브랜드를 모르는 음료는 이름이 같은 음료와도 비교)
+	byBrand := make(map[string][]*duplicateEntry)
+	byCore := make(map[string][]*duplicateEntry)
+	for i := range entries {
+		e := &entries[i]
+		byBrand[e.brand] = append(byBrand[e.brand], e)
+		byCore[e.core] = append(byCore[e.core], e)
+	}
+	for _, group := range byBrand {
+		for i := range group {
+			for j := i + 1; j < len(group); j++ {
+				compare(group[i], group[j])
+			}
+		}
+	}
+	for _, e := range byBrand[""] {
+		for _, other := range byCore[e.core] {
+			if other.brand != "" {
+				compare(e, other)
+			}
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		if pairs[i].score != pairs[j].score {
+			return pairs[i].score > pairs[j].score
+		}
+		return pairs[i].a.beverage.ID < pairs[j].a.beverage.ID
+	})
+	if limit := clampLimit(filter.Limit, defaultDuplicateLimit, maxDuplicateLimit); len(pairs) > limit {
+		pairs = pairs[:limit]
+	}
+
+	ids := make([]uint, 0, len(pairs)*2)
+	for _, p := range pairs {
+		ids = append(ids, p.a.beverage.ID, p.b.beverage.ID)
+	}
+	logCounts := beverageLogCounts(ids)
+
+	candidates := make([]DuplicateCandidate, 0, len(pairs))
+	for _, p := range pairs {
+		a, b := duplicateSummary(p.a.beverage, logCounts), duplicateSummary(p.b.beverage, logCounts)
+		if preferKeep(b, a) {
+			a, b = b, a
+		}
+		candidates = append(candidates, DuplicateCandidate{
+			Keep:    a,
+			Merge:   b,
+			Score:   math.Round(p.score*100) / 100,
+			Reasons: p.reasons,
+		})
+	}
+	return candidates, nil
+}
+
+// newDuplicateEntries : 음료 이름/브랜드 정규화 (브랜드가 비어 있으면 이름에 들어 있는 알려진 브랜드 사용)
+func newDuplicateEntries(beverages []models.Beverage, terms map[string]string) []duplicateEntry {
+	entries := make([]duplicateEntry, len(beverages))
+	knownBrands := make(map[string]bool)
+	for i, beverage := range beverages {
+		entries[i] = duplicateEntry{
+			beverage: beverage,
+			brand:    compactQuery(normalizeWithTerms(beverage.Brand, terms)),
+			core:     compactQuery(normalizeWithTerms(beverage.Name, terms)),
+		}
+		if entries[i].brand != "" {
+			knownBrands[entries[i].brand] = true
+		}
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		if e.brand == "" {
+			for brand := range knownBrands {
+				if strings.Contains(e.core, brand) && len(brand) > len(e.brand) {
+					e.brand = brand
+				}
+			}
+		}
+		if e.brand != "" {
+			if core := strings.ReplaceAll(e.core, e.brand, ""); core != "" {
+				e.core = core
+			}
+		}
+		e.coreRune = []rune(e.core)
+	}
+	return entries
+}
+
+// scoreDuplicatePair : 두 음료가 같은 음료일 가능성 (0~1)
+// 이름 60%, 브랜드 25%, 카페인 15%
+func scoreDuplicatePair(a, b *duplicateEntry) (float64, []string) {
+	var reasons []string
+
+	nameSim := 1.0
+	if a.core == b.core {
+		reasons = append(reasons, "same_name")
+	} else {
+		longest := max(len(a.coreRune), len(b.coreRune))
+		limit := int(float64(longest) * (1 - minDuplicateNameSim))
+		dist := levenshtein(a.coreRune, b.coreRune, limit)
+		if dist > limit {
+			return 0, nil
+		}
+		nameSim = 1 - float64(dist)/float64(longest)
+		reasons = append(reasons, "similar_name")
+	}
+
+	brandScore := 0.0
+	if a.brand != "" && a.brand == b.brand {
+		brandScore = 1
+		reasons = append(reasons, "same_brand")
+	} else if a.brand == "" && b.brand == "" {
+		brandScore = 0.5
+	}
+
+	caffeineScore := 0.5 // 한쪽이라도 모르면 중간값
+	if x, y := a.beverage.CaffeineAmount, b.beverage.CaffeineAmount; x > 0 && y > 0 {
+		diff := math.Abs(x-y) / math.Max(x, y)
+		caffeineScore = math.Max(0, 1-diff/duplicateCaffeineMax)
+		if diff <= duplicateCaffeineTol {
+			reasons = append(reasons, "close_caffeine")
+		}
+	}
+
+	return 0.6*nameSim + 0.25*brandScore + 0.15*caffeineScore, reasons
+}
+
+// preferKeep : a를 b보다 남길 음료로 추천하는지 (승인됨 > 검증됨 > 기록 많음 > 먼저 등록)
+func preferKeep(a, b DuplicateBeverage) bool {
+	if (a.Status == models.BeverageStatusActive) != (b.Status == models.BeverageStatusActive) {
+		return a.Status == models.BeverageStatusActive
+	}
+	if a.IsVerified != b.IsVerified {
+		return a.IsVerified
+	}
+	if a.Logs != b.Logs {
+		return a.Logs > b.Logs
+	}
+	return a.ID < b.ID
+}
+
+func duplicateSummary(beverage models.Beverage, logCounts map[uint]int64) DuplicateBeverage {
+	return DuplicateBeverage{
+		ID:             beverage.ID,
+		Name:           beverage.Name,
+		Brand:          beverage.Brand,
+		CaffeineAmount: beverage.CaffeineAmount,
+		Status:         beverage.Status,
+		IsVerified:     beverage.IsVerified,
+		Logs:           logCounts[beverage.ID],
+	}
+}
+
+// beverageLogCounts : 음료별 섭취 기록 수
+func beverageLogCounts(ids []uint) map[uint]int64 {
+	counts := make(map[uint]int64, len(ids))
+	if len(ids) == 0 {
+		return counts
+	}
+	var rows []struct {
+		BeverageID uint
+		Total      int64
+	}
+	config.DB.Model(&models.CaffeineLog{}).
+		Select("beverage_id, COUNT(*) AS total").
+		Where("beverage_id IN ?", ids).
+		Group("beverage_id").
+		Scan(&rows)
+	for _, row := range rows {
+		counts[row.BeverageID] = row.Total
+	}
+	return counts
+}
+
+// ========================================
+// 병합
+// ========================================
+
+// MergeBeverages : source 음료를 target 음료로 병합 (한 트랜잭션)
+// 사진, 섭취 기록, 인식 로그, 텍스트 추정 캐시, 사이즈, 바코드, 별칭을 target으로 옮기고
+// source 이름은 target의 별칭으로 남긴 뒤 source는 merged_into_id를 남기고 삭제 처리
+func MergeBeverages(sourceID, targetID, actorID uint) (*BeverageMergeReport, error) {
+	if sourceID == targetID {
+		return nil, ErrMergeSameBeverage
+	}
+
+	report := &BeverageMergeReport{SourceID: sourceID, TargetID: targetID}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var source, target models.Beverage
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&source, sourceID).Error; err != nil {
+			return ErrBeverageNotFound
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&target, targetID).Error; err != nil {
+			return ErrBeverageNotFound
+		}
+		if target.Status != models.BeverageStatusActive {
+			return ErrMergeTarget
+		}
+
+		// 참조 옮기기
+		moves := []struct {
+			model  interface{}
+			column string
+			count  *int64
+		}{
+			{&models.BeverageImage{}, "beverage_id", &report.Images},
+			{&models.CaffeineLog{}, "beverage_id", &report.Logs},
+			{&models.RecognitionLog{}, "recognized_id", &report.RecognitionLogs},
+			{&models.RecognitionLog{}, "corrected_id", &report.RecognitionLogs},
+			{&models.TextEstimateCache{}, "beverage_id", &report.TextEstimates},
+			{&models.BeverageBarcode{}, "beverage_id", &report.Barcodes},
+		}
+		for _, move := range moves {
+			result := tx.Unscoped().Model(move.model).Where(move.column+" = ?", sourceID).Update(move.column, targetID)
+			if result.Error != nil {
+				return result.Error
+			}
+			*move.count += result.RowsAffected
+		}
+
+		// 캐시 이미지에 저장된 사진 속 음료 목록의 음료 ID도 옮김
+		var images []models.BeverageImage
+		if err := tx.Unscoped().Select("id", "detections").
+			Where("detections LIKE ?", fmt.Sprintf(`%%"beverage_id":%d%%`, sourceID)).
+			Find(&images).Error; err != nil {
+			return err
+		}
+		for _, image := range images {
+			detections, changed := remapDetectedDrinks(image.Detections, sourceID, targetID)
+			if !changed {
+				continue
+			}
+			if err := tx.Unscoped().Model(&models.BeverageImage{}).Where("id = ?", image.ID).
+				Update("detections", detections).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := mergeBeverageSizes(tx, &source, &target, report); err != nil {
+			return err
+		}
+		if err := mergeBeverageAliases(tx, &source, &target, actorID, report); err != nil {
+			return err
+		}
+
+		// 처리 대기 중인 요청은 더 이상 반영할 음료가 없으므로 거절로 정리
+		now := time.Now()
+		result := tx.Model(&models.BeverageChangeRequest{}).
+			Where("beverage_id = ? AND status = ?", sourceID, models.ChangeRequestPending).
+			Updates(map[string]interface{}{
+				"status":      models.ChangeRequestRejected,
+				"reviewed_by": actorID,
+				"reviewed_at": &now,
+				"review_note": fmt.Sprintf("음료 #%d로 병합되었습니다", targetID),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		report.Requests = result.RowsAffected
+
+		// 예전에 source로 병합된 음료도 target으로 바로 연결 (한 번만 따라가면 되도록)
+		if err := tx.Unscoped().Model(&models.Beverage{}).
+			Where("merged_into_id = ?", sourceID).
+			Update("merged_into_id", targetID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&source).Update("merged_into_id", targetID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&source).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	InvalidateBeverageAliasCache()
+	println("🔗 음료 병합:", sourceID, "→", targetID, "기록", report.Logs, "건")
+	return report, nil
+}
+
+// mergeBeverageSizes : source 사이즈를 target으로 옮김
+// 같은 이름의 사이즈가 target에 있으면 기록을 target 사이즈로 연결하고 source 사이즈는 삭제
+func mergeBeverageSizes(tx *gorm.DB, source, target *models.Beverage, report *BeverageMergeReport) error {
+	var sourceSizes, targetSizes []models.BeverageSize
+	if err := tx.Where("beverage_id = ?", source.ID).Find(&sourceSizes).Error; err != nil {
+		return err
+	}
+	if len(sourceSizes) == 0 {
+		return nil
+	}
+	if err := tx.Where("beverage_id = ?", target.ID).Find(&targetSizes).Error; err != nil {
+		return err
+	}
+
+	// 사이즈가 없던 target은 음료 필드 값을 기본 사이즈로 먼저 저장 (옮겨 온 사이즈에 묻히지 않도록)
+	if len(targetSizes) == 0 && target.CaffeineAmount > 0 {
+		def := defaultSizeFromBeverage(target)
+		if err := tx.Create(&def).Error; err != nil {
+			return err
+		}
+		targetSizes = append(targetSizes, def)
+	}
+	hasDefault := false
+	for _, size := range targetSizes {
+		hasDefault = hasDefault || size.IsDefault
+	}
+
+	for _, size := range sourceSizes {
+		if existing := findSizeByLabel(targetSizes, size.Label); existing != nil {
+			if err := tx.Model(&models.CaffeineLog{}).
+				Where("beverage_size_id = ?", size.ID).
+				Update("beverage_size_id", existing.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Delete(&size).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		makeDefault := size.IsDefault && !hasDefault
+		if err := tx.Model(&size).Updates(map[string]interface{}{
+			"beverage_id": target.ID,
+			"is_default":  makeDefault,
+		}).Error; err != nil {
+			return err
+		}
+		if makeDefault {
+			if err := setDefaultSize(tx, target, size); err != nil {
+				return err
+			}
+			hasDefault = true
+		}
+		targetSizes = append(targetSizes, size)
+		report.Sizes++
+	}
+	return nil
+}
+
+// mergeBeverageAliases : source 별칭을 target으로 옮기고 source 이름을 target 별칭으로 추가
+func mergeBeverageAliases(tx *gorm.DB, source, target *models.Beverage, actorID uint, report *BeverageMergeReport) error {
+	var aliases []models.BeverageAlias
+	if err := tx.Where("beverage_id = ?", source.ID).Find(&aliases).Error; err != nil {
+		return err
+	}
+	targetKey := aliasTargetKey(models.AliasTargetBeverage, &target.ID, "")
+	for _, alias := range aliases {
+		// target에 같은 별칭이 있으면 source 별칭만 지우고, 없으면 가중치 그대로 옮김
+		var existing int64
+		tx.Model(&models.BeverageAlias{}).Where("normalized = ? AND target_key = ?", alias.Normalized, targetKey).Count(&existing)
+		if existing > 0 {
+			if err := tx.Unscoped().Delete(&alias).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err := tx.Unscoped().Where("normalized = ? AND target_key = ?", alias.Normalized, targetKey).
+			Delete(&models.BeverageAlias{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&alias).Updates(map[string]interface{}{
+			"beverage_id": target.ID,
+			"target_key":  targetKey,
+		}).Error; err != nil {
+			return err
+		}
+		report.Aliases++
+	}
+
+	// 예전 이름으로 인식/검색돼도 target으로 연결
+	if key := aliasKey(source.Name); key != aliasKey(target.Name) && len([]rune(key)) <= 100 {
+		added, err := ensureBeverageAlias(tx, target.ID, source.Name, actorID)
+		if err != nil {
+			return err
+		}
+		if added {
+			report.Aliases++
+		}
+	}
+	return nil
+}
+
+// ========================================
+// 병합된 음료 연결
+// ========================================
+
+// ResolveBeverageID : 병합된 음료 ID면 병합 대상 ID, 아니면 그대로
+func ResolveBeverageID(id uint) uint {
+	var merged models.Beverage
+	if err := config.DB.Unscoped().
+		Where("id = ? AND merged_into_id IS NOT NULL", id).
+		First(&merged).Error; err != nil {
+		return id
+	}
+	return *merged.MergedIntoID
+}
+
+// remapDetectedDrinks : 저장된 음료 목록(JSON)에서 source 음료 ID를 target으로 바꿈 (바뀐 게 없으면 false)
+func remapDetectedDrinks(data string, sourceID, targetID uint) (string, bool) {
+	var drinks []DetectedDrink
+	if data == "" || json.Unmarshal([]byte(data), &drinks) != nil {
+		return data, false
+	}
+	changed := false
+	for i := range drinks {
+		if id := drinks[i].BeverageID; id != nil && *id == sourceID {
+			drinks[i].BeverageID = &targetID
+			changed = true
+		}
+	}
+	if !changed {
+		return data, false
+	}
+	remapped, _ := json.Marshal(drinks)
+	return string(remapped), true
+}
+
+// resolveBeverageRef : nil이 아닌 음료 ID를 병합 대상으로 바꿈
+func resolveBeverageRef(id *uint) *uint {
+	if id == nil {
+		return nil
+	}
+	resolved := ResolveBeverageID(*id)
+	return &resolved
+}
+
+// mergedBeverageByName : 병합으로 사라진 음료 이름이면 병합 대상 음료 (사용자에게 보이는 경우만)
+func mergedBeverageByName(name string, userID uint) *models.Beverage {
+	var merged models.Beverage
+	if err := config.DB.Unscoped().
+		Where("name = ? AND merged_into_id IS NOT NULL", name).
+		First(&merged).Error; err != nil {
+		return nil
+	}
+	var target models.Beverage
+	if err := config.DB.Scopes(VisibleBeverages(userID)).First(&target, *merged.MergedIntoID).Error; err != nil {
+		return nil
+	}
+	return &target
+}
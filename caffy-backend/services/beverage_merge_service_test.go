@@ -0,0 +1,172 @@
+package services
+
+import (
+	"caffy-backend/models"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestScoreDuplicatePair(t *testing.T) {
+	type drink struct {
+		name     string
+		brand    string
+		caffeine float64
+	}
+	tests := []struct {
+		name        string
+		a, b        drink
+		wantScore   float64
+		wantReasons []string
+	}{
+		{
+			name:        "영문 표기와 한글 표기",
+			a:           drink{"스타벅스 아메리카노", "스타벅스", 150},
+			b:           drink{"Starbucks Americano", "Starbucks", 150},
+			wantScore:   1,
+			wantReasons: []string{"same_name", "same_brand", "close_caffeine"},
+		},
+		{
+			name:        "브랜드가 이름에만 있음",
+			a:           drink{"아메리카노(스타벅스)", "", 150},
+			b:           drink{"아메리카노", "스타벅스", 150},
+			wantScore:   1,
+			wantReasons: []string{"same_name", "same_brand", "close_caffeine"},
+		},
+		{
+			name:        "한 글자 오타, 둘 다 브랜드 없음",
+			a:           drink{"카페라떼", "", 75},
+			b:           drink{"카페라때", "", 75},
+			wantScore:   0.6*0.75 + 0.25*0.5 + 0.15,
+			wantReasons: []string{"similar_name", "close_caffeine"},
+		},
+		{
+			name:        "브랜드가 다르면 이름이 같아도 브랜드 점수 없음",
+			a:           drink{"아메리카노", "이디야", 150},
+			b:           drink{"아메리카노", "스타벅스", 150},
+			wantScore:   0.6 + 0.15,
+			wantReasons: []string{"same_name", "close_caffeine"},
+		},
+		{
+			name:        "한쪽 카페인을 모르면 카페인 점수는 중간값",
+			a:           drink{"아메리카노", "스타벅스", 0},
+			b:           drink{"아메리카노", "스타벅스", 150},
+			wantScore:   0.6 + 0.25 + 0.15*0.5,
+			wantReasons: []string{"same_name", "same_brand"},
+		},
+		{
+			name:        "카페인 차이가 30% 이상이면 카페인 점수 0",
+			a:           drink{"아메리카노", "스타벅스", 100},
+			b:           drink{"아메리카노", "스타벅스", 200},
+			wantScore:   0.6 + 0.25,
+			wantReasons: []string{"same_name", "same_brand"},
+		},
+		{
+			name:        "카페인 10% 이내",
+			a:           drink{"아메리카노", "스타벅스", 100},
+			b:           drink{"아메리카노", "스타벅스", 110},
+			wantScore:   0.6 + 0.25 + 0.15*(1-(10.0/110)/duplicateCaffeineMax),
+			wantReasons: []string{"same_name", "same_brand", "close_caffeine"},
+		},
+		{
+			name: "이름이 많이 다르면 비교하지 않음",
+			a:    drink{"아메리카노", "스타벅스", 150},
+			b:    drink{"카페라떼", "스타벅스", 150},
+		},
+	}
+
+	terms := builtinAliasIndex().terms
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := newDuplicateEntries([]models.Beverage{
+				{Name: tt.a.name, Brand: tt.a.brand, CaffeineAmount: tt.a.caffeine},
+				{Name: tt.b.name, Brand: tt.b.brand, CaffeineAmount: tt.b.caffeine},
+			}, terms)
+
+			score, reasons := scoreDuplicatePair(&entries[0], &entries[1])
+			if math.Abs(score-tt.wantScore) > 1e-9 {
+				t.Errorf("score = %v, want %v", score, tt.wantScore)
+			}
+			if !reflect.DeepEqual(reasons, tt.wantReasons) {
+				t.Errorf("reasons = %v, want %v", reasons, tt.wantReasons)
+			}
+
+			// 순서를 바꿔도 같은 점수
+			if reversed, _ := scoreDuplicatePair(&entries[1], &entries[0]); math.Abs(reversed-score) > 1e-9 {
+				t.Errorf("순서에 따라 점수가 다름: %v, %v", score, reversed)
+			}
+		})
+	}
+}
+
+func TestPreferKeep(t *testing.T) {
+	active := DuplicateBeverage{ID: 2, Status: models.BeverageStatusActive}
+	pending := DuplicateBeverage{ID: 1, Status: models.BeverageStatusPending, IsVerified: true, Logs: 10}
+	if !preferKeep(active, pending) || preferKeep(pending, active) {
+		t.Error("승인된 음료를 남겨야 함")
+	}
+
+	verified := DuplicateBeverage{ID: 3, Status: models.BeverageStatusActive, IsVerified: true}
+	if !preferKeep(verified, active) {
+		t.Error("검증된 음료를 남겨야 함")
+	}
+
+	used := DuplicateBeverage{ID: 4, Status: models.BeverageStatusActive, Logs: 5}
+	if !preferKeep(used, active) {
+		t.Error("기록이 많은 음료를 남겨야 함")
+	}
+
+	older := DuplicateBeverage{ID: 1, Status: models.BeverageStatusActive}
+	if !preferKeep(older, active) {
+		t.Error("나머지가 같으면 먼저 등록된 음료를 남겨야 함")
+	}
+}
+
+func TestRemapDetectedDrinks(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantChanged bool
+		wantIDs     []uint // 0이면 음료 ID 없음
+	}{
+		{
+			name:        "병합한 음료만 바꿈",
+			data:        `[{"drink_name":"아메리카노","beverage_id":5},{"drink_name":"레드불","beverage_id":50},{"drink_name":"물"}]`,
+			wantChanged: true,
+			wantIDs:     []uint{9, 50, 0},
+		},
+		{name: "해당 음료 없음", data: `[{"drink_name":"레드불","beverage_id":50}]`, wantIDs: []uint{50}},
+		{name: "빈 값", data: ""},
+		{name: "깨진 JSON", data: `[{"beverage_id":5`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := remapDetectedDrinks(tt.data, 5, 9)
+			if changed != tt.wantChanged {
+				t.Fatalf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if !changed {
+				if got != tt.data {
+					t.Errorf("바뀌지 않았는데 값이 다름: %s", got)
+				}
+				return
+			}
+			var drinks []DetectedDrink
+			if err := json.Unmarshal([]byte(got), &drinks); err != nil {
+				t.Fatalf("결과 JSON 오류: %v", err)
+			}
+			var ids []uint
+			for _, drink := range drinks {
+				id := uint(0)
+				if drink.BeverageID != nil {
+					id = *drink.BeverageID
+				}
+				ids = append(ids, id)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("음료 ID = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
@@ -0,0 +1,519 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========================================
+// 음료 등록/수정 검토 서비스
+// 모든 사용자의 섭취 기록이 음료의 카페인 값을 쓰므로,
+// 검토자(moderator 이상)가 아닌 사용자의 등록/수정은 변경 요청으로 남기고 검토자가 승인한 값만 다른 사용자에게 보임
+// ========================================
+
+var (
+	ErrChangeRequestNotFound = errors.New("음료 변경 요청을 찾을 수 없습니다")
+	ErrChangeRequestReviewed = errors.New("이미 처리된 변경 요청입니다")
+	ErrNoBeverageChanges     = errors.New("바뀐 내용이 없습니다")
+	ErrBeverageNameTaken     = errors.New("이미 존재하는 음료 이름입니다")
+	ErrBeverageNotEditable   = errors.New("승인된 음료는 관리자만 바로 수정할 수 있습니다. 음료 수정 요청을 이용하세요")
+	ErrInvalidBeverageChange = errors.New("음료 입력값이 올바르지 않습니다")
+)
+
+// 변경 요청 출처
+const (
+	ChangeSourceUser         = "user"
+	ChangeSourceBarcode      = "barcode"
+	ChangeSourceRecognition  = "recognition"
+	ChangeSourceTextEstimate = "text_estimate"
+)
+
+// BeverageChangeInput : 음료 수정 입력 (보낸 필드만 반영)
+type BeverageChangeInput struct {
+	Name           *string  `json:"name,omitempty"`
+	Brand          *string  `json:"brand,omitempty"`
+	CaffeineAmount *float64 `json:"caffeine_amount,omitempty"`
+	Size           *string  `json:"size,omitempty"`
+	Volume         *float64 `json:"volume,omitempty"`
+	Category       *string  `json:"category,omitempty"`
+	IsVerified     *bool    `json:"is_verified,omitempty"` // 검토자만
+
+	Reason          string `json:"reason,omitempty"`           // 수정 사유 (변경 이력에 남음)
+	RecalculateLogs bool   `json:"recalculate_logs,omitempty"` // 직접 입력하지 않은 섭취 기록도 새 값으로 다시 계산 (검토자만)
+}
+
+// ChangeRequestFilter : 변경 요청 목록 조회 조건
+type ChangeRequestFilter struct {
+	Status      string
+	SubmittedBy uint
+	BeverageID  uint
+}
+
+// VisibleBeverages : 다른 사용자에게는 승인된 음료만, 본인에게는 본인이 등록한 승인 대기 음료도 보이도록 하는 조건
+func VisibleBeverages(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if userID == 0 {
+			return db.Where("beverages.status = ?", models.BeverageStatusActive)
+		}
+		return db.Where("beverages.status = ? OR (beverages.status = ? AND beverages.created_by_user = ?)",
+			models.BeverageStatusActive, models.BeverageStatusPending, userID)
+	}
+}
+
+// CanViewBeverage : 음료를 볼 수 있는지 (승인된 음료, 본인이 등록한 음료, 검토자)
+func CanViewBeverage(beverage *models.Beverage, userID uint, isModerator bool) bool {
+	return isModerator || beverage.Status == models.BeverageStatusActive ||
+		(userID != 0 && beverage.CreatedByUser == userID)
+}
+
+// CanEditBeverageDirectly : 요청 없이 바로 수정할 수 있는지 (검토자, 또는 본인이 등록한 승인 대기 음료)
+func CanEditBeverageDirectly(beverage *models.Beverage, userID uint, isModerator bool) bool {
+	return isModerator || (userID != 0 && beverage.Status == models.BeverageStatusPending && beverage.CreatedByUser == userID)
+}
+
+// CheckBeverageEditable : 요청 없이 바로 고칠 수 없는 음료면 에러 (사이즈처럼 검토 요청이 없는 변경에 사용)
+func CheckBeverageEditable(beverageID uint, userID uint, isModerator bool) error {
+	var beverage models.Beverage
+	if err := config.DB.First(&beverage, beverageID).Error; err != nil || !CanViewBeverage(&beverage, userID, isModerator) {
+		return ErrBeverageNotFound
+	}
+	if !CanEditBeverageDirectly(&beverage, userID, isModerator) {
+		return ErrBeverageNotEditable
+	}
+	return nil
+}
+
+// SubmitNewBeverage : 검토자가 아닌 사용자가 만든 음료를 승인 대기로 두고 등록 요청을 남김
+// beverage는 Status가 pending으로 이미 저장된 상태여야 함
+func SubmitNewBeverage(db *gorm.DB, beverage *models.Beverage, source string) (*models.BeverageChangeRequest, error) {
+	changes, _ := json.Marshal(beverageFields(beverage))
+	request := models.BeverageChangeRequest{
+		BeverageID:  beverage.ID,
+		Action:      models.ChangeActionCreate,
+		Status:      models.ChangeRequestPending,
+		Source:      source,
+		Changes:     string(changes),
+		SubmittedBy: beverage.CreatedByUser,
+	}
+
+	// 같은 음료의 등록 요청이 이미 있으면 값만 갱신 (바코드 음료를 다시 확정하는 경우 등)
+	var existing models.BeverageChangeRequest
+	if err := db.Where("beverage_id = ? AND action = ? AND status = ?", beverage.ID, models.ChangeActionCreate, models.ChangeRequestPending).
+		First(&existing).Error; err == nil {
+		existing.Changes, existing.Source = request.Changes, source
+		return &existing, db.Save(&existing).Error
+	}
+	if err := db.Create(&request).Error; err != nil {
+		return nil, err
+	}
+	println("📝 음료 등록 요청:", beverage.Name, "(", source, ")")
+	return &request, nil
+}
+
+// CreatePendingSubmission : 승인 대기 음료 저장과 등록 요청을 한 번에
+func CreatePendingSubmission(beverage *models.Beverage, source string) (*models.BeverageChangeRequest, error) {
+	beverage.Status = models.BeverageStatusPending
+	beverage.IsVerified = false
+	beverage.MergedIntoID = nil
+	beverage.Images = nil // 캐시 이미지는 인식/피드백으로만 만듦
+
+	var request *models.BeverageChangeRequest
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(beverage).Error; err != nil {
+			return err
+		}
+		var err error
+		request, err = SubmitNewBeverage(tx, beverage, source)
+		return err
+	})
+	return request, err
+}
+
+// submitRecognizedBeverage : 인식 과정에서 만든 음료를 승인 대기로 남김 (요청 저장 실패는 인식을 막지 않음)
+func submitRecognizedBeverage(beverage *models.Beverage, source string) {
+	if beverage.Status != models.BeverageStatusPending {
+		return
+	}
+	if _, err := SubmitNewBeverage(config.DB, beverage, source); err != nil {
+		println("⚠️ 음료 등록 요청 저장 실패:", err.Error())
+	}
+}
+
+// RequestBeverageChange : 승인된 음료의 수정 요청 (같은 사용자의 대기 중인 요청은 새 값으로 교체)
+func RequestBeverageChange(beverageID uint, input BeverageChangeInput, userID uint) (*models.BeverageChangeRequest, error) {
+	var beverage models.Beverage
+	if err := config.DB.First(&beverage, beverageID).Error; err != nil {
+		return nil, ErrBeverageNotFound
+	}
+	input.IsVerified = nil
+	if err := validateBeverageChange(&beverage, input); err != nil {
+		return nil, err
+	}
+
+	changes := changedBeverageFields(&beverage, input)
+	if len(changes) == 0 {
+		return nil, ErrNoBeverageChanges
+	}
+	changesJSON, _ := json.Marshal(changes)
+	previous := make(map[string]interface{}, len(changes))
+	current := beverageFields(&beverage)
+	for field := range changes {
+		previous[field] = current[field]
+	}
+	previousJSON, _ := json.Marshal(previous)
+
+	request := models.BeverageChangeRequest{
+		BeverageID:  beverage.ID,
+		Action:      models.ChangeActionUpdate,
+		Status:      models.ChangeRequestPending,
+		Source:      ChangeSourceUser,
+		Changes:     string(changesJSON),
+		Previous:    string(previousJSON),
+		SubmittedBy: userID,
+	}
+	var existing models.BeverageChangeRequest
+	if err := config.DB.Where("beverage_id = ? AND action = ? AND status = ? AND submitted_by = ?",
+		beverage.ID, models.ChangeActionUpdate, models.ChangeRequestPending, userID).First(&existing).Error; err == nil {
+		request.ID, request.CreatedAt = existing.ID, existing.CreatedAt
+	}
+	if err := config.DB.Save(&request).Error; err != nil {
+		return nil, err
+	}
+
+	fillChangeRequest(&request, &beverage)
+	println("📝 음료 수정 요청:", beverage.Name, len(changes), "개 필드")
+	return &request, nil
+}
+
+// ApplyBeverageChange : 음료 수정 바로 반영 (검토자, 본인이 등록한 승인 대기 음료)
+func ApplyBeverageChange(beverageID uint, input BeverageChangeInput, userID uint, isModerator bool) (*models.Beverage, error) {
+	var beverage models.Beverage
+	if err := config.DB.First(&beverage, beverageID).Error; err != nil {
+		return nil, ErrBeverageNotFound
+	}
+	if !isModerator {
+		input.IsVerified = nil
+	}
+	if err := validateBeverageChange(&beverage, input); err != nil {
+		return nil, err
+	}
+
+	changes := changedBeverageFields(&beverage, input)
+	if len(changes) == 0 {
+		return &beverage, nil
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		meta := BeverageRevisionMeta{AuthorID: userID, Source: ChangeSourceUser, Reason: input.Reason}
+		if err := applyBeverageFields(tx, &beverage, changes, meta); err != nil {
+			return err
+		}
+		// 등록 요청을 검토 중이면 요청에 남긴 값도 맞춤
+		if beverage.Status == models.BeverageStatusPending {
+			fields, _ := json.Marshal(beverageFields(&beverage))
+			return tx.Model(&models.BeverageChangeRequest{}).
+				Where("beverage_id = ? AND action = ? AND status = ?", beverage.ID, models.ChangeActionCreate, models.ChangeRequestPending).
+				Update("changes", string(fields)).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	InvalidateBeverageSearchIndex()
+	return &beverage, nil
+}
+
+// ListBeverageChangeRequests : 변경 요청 목록 (최신순, 변경 내역 포함)
+func ListBeverageChangeRequests(filter ChangeRequestFilter) ([]models.BeverageChangeRequest, error) {
+	query := config.DB.Model(&models.BeverageChangeRequest{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.SubmittedBy != 0 {
+		query = query.Where("submitted_by = ?", filter.SubmittedBy)
+	}
+	if filter.BeverageID != 0 {
+		query = query.Where("beverage_id = ?", filter.BeverageID)
+	}
+
+	requests := []models.BeverageChangeRequest{}
+	if err := query.Order("created_at DESC").Limit(200).Find(&requests).Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(requests))
+	for _, request := range requests {
+		ids = append(ids, request.BeverageID)
+	}
+	var beverages []models.Beverage
+	config.DB.Unscoped().Where("id IN ?", ids).Find(&beverages)
+	byID := make(map[uint]*models.Beverage, len(beverages))
+	for i := range beverages {
+		byID[beverages[i].ID] = &beverages[i]
+	}
+	for i := range requests {
+		fillChangeRequest(&requests[i], byID[requests[i].BeverageID])
+	}
+	return requests, nil
+}
+
+// GetBeverageChangeRequest : 변경 요청 하나 (변경 내역 포함)
+func GetBeverageChangeRequest(id uint) (*models.BeverageChangeRequest, error) {
+	var request models.BeverageChangeRequest
+	if err := config.DB.First(&request, id).Error; err != nil {
+		return nil, ErrChangeRequestNotFound
+	}
+	var beverage models.Beverage
+	if err := config.DB.Unscoped().First(&beverage, request.BeverageID).Error; err != nil {
+		fillChangeRequest(&request, nil)
+	} else {
+		fillChangeRequest(&request, &beverage)
+	}
+	return &request, nil
+}
+
+// ApproveBeverageChangeRequest : 변경 요청 승인
+// 등록 요청은 음료를 공개하고 검증됨으로 표시, 수정 요청은 제안한 값을, 바코드 요청은 바코드를 반영
+func ApproveBeverageChangeRequest(id uint, reviewerID uint, note string) (*models.BeverageChangeRequest, error) {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		request, beverage, err := pendingChangeRequest(tx, id)
+		if err != nil {
+			return err
+		}
+
+		// 이력에는 요청한 사용자를 작성자로, 검토 메모를 사유로 남김
+		meta := BeverageRevisionMeta{
+			AuthorID:        request.SubmittedBy,
+			Source:          RevisionSourceReview,
+			Reason:          note,
+			ChangeRequestID: &request.ID,
+		}
+		switch request.Action {
+		case models.ChangeActionCreate:
+			changes := map[string]interface{}{
+				"status":      models.BeverageStatusActive,
+				"is_verified": true,
+			}
+			if err := applyBeverageFields(tx, beverage, changes, meta); err != nil {
+				return err
+			}
+		case models.ChangeActionBarcode:
+			var changes struct {
+				Barcode string `json:"barcode"`
+			}
+			if err := json.Unmarshal([]byte(request.Changes), &changes); err != nil {
+				return fmt.Errorf("%w: 요청 내용을 읽을 수 없습니다", ErrInvalidBeverageChange)
+			}
+			if _, err := attachBarcode(tx, beverage, changes.Barcode, meta); err != nil {
+				return err
+			}
+		default:
+			var changes map[string]interface{}
+			if err := json.Unmarshal([]byte(request.Changes), &changes); err != nil {
+				return fmt.Errorf("%w: 요청 내용을 읽을 수 없습니다", ErrInvalidBeverageChange)
+			}
+			if name, ok := changes["name"].(string); ok && name != beverage.Name {
+				if err := checkBeverageNameFree(tx, name, beverage.ID); err != nil {
+					return err
+				}
+			}
+			if err := applyBeverageFields(tx, beverage, changes, meta); err != nil {
+				return err
+			}
+		}
+		return markChangeRequestReviewed(tx, request, models.ChangeRequestApproved, reviewerID, note)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	InvalidateBeverageSearchIndex()
+	return GetBeverageChangeRequest(id)
+}
+
+// RejectBeverageChangeRequest : 변경 요청 거절 (등록 요청이면 음료를 rejected로 두어 등록한 사용자에게만 남김)
+func RejectBeverageChangeRequest(id uint, reviewerID uint, note string) (*models.BeverageChangeRequest, error) {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		request, beverage, err := pendingChangeRequest(tx, id)
+		if err != nil {
+			return err
+		}
+		if request.Action == models.ChangeActionCreate && beverage.Status == models.BeverageStatusPending {
+			meta := BeverageRevisionMeta{AuthorID: reviewerID, Source: RevisionSourceReview, Reason: note, ChangeRequestID: &request.ID}
+			if err := applyBeverageFields(tx, beverage, map[string]interface{}{"status": models.BeverageStatusRejected}, meta); err != nil {
+				return err
+			}
+		}
+		return markChangeRequestReviewed(tx, request, models.ChangeRequestRejected, reviewerID, note)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	InvalidateBeverageSearchIndex()
+	return GetBeverageChangeRequest(id)
+}
+
+// pendingChangeRequest : 처리 대기 중인 요청과 대상 음료 (행 잠금)
+func pendingChangeRequest(tx *gorm.DB, id uint) (*models.BeverageChangeRequest, *models.Beverage, error) {
+	var request models.BeverageChangeRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, id).Error; err != nil {
+		return nil, nil, ErrChangeRequestNotFound
+	}
+	if request.Status != models.ChangeRequestPending {
+		return nil, nil, ErrChangeRequestReviewed
+	}
+	var beverage models.Beverage
+	if err := tx.First(&beverage, request.BeverageID).Error; err != nil {
+		return nil, nil, ErrBeverageNotFound
+	}
+	return &request, &beverage, nil
+}
+
+func markChangeRequestReviewed(tx *gorm.DB, request *models.BeverageChangeRequest, status string, reviewerID uint, note string) error {
+	now := time.Now()
+	return tx.Model(request).Updates(map[string]interface{}{
+		"status":      status,
+		"reviewed_by": reviewerID,
+		"reviewed_at": &now,
+		"review_note": strings.TrimSpace(note),
+	}).Error
+}
+
+// ========================================
+// 필드 비교/반영
+// ========================================
+
+// beverageFields : 검토 대상 필드 값
+func beverageFields(beverage *models.Beverage) map[string]interface{} {
+	return map[string]interface{}{
+		"name":            beverage.Name,
+		"brand":           beverage.Brand,
+		"caffeine_amount": beverage.CaffeineAmount,
+		"size":            beverage.Size,
+		"volume":          beverage.Volume,
+		"category":        beverage.Category,
+	}
+}
+
+// beverageFieldOrder : 변경 내역 표시 순서
+var beverageFieldOrder = []string{"name", "brand", "category", "caffeine_amount", "size", "volume", "is_verified", "status", "barcode"}
+
+// changedBeverageFields : 입력값 중 현재 값과 다른 필드
+func changedBeverageFields(beverage *models.Beverage, input BeverageChangeInput) map[string]interface{} {
+	changes := make(map[string]interface{})
+	if input.Name != nil && strings.TrimSpace(*input.Name) != beverage.Name {
+		changes["name"] = strings.TrimSpace(*input.Name)
+	}
+	if input.Brand != nil && strings.TrimSpace(*input.Brand) != beverage.Brand {
+		changes["brand"] = strings.TrimSpace(*input.Brand)
+	}
+	if input.CaffeineAmount != nil && *input.CaffeineAmount != beverage.CaffeineAmount {
+		changes["caffeine_amount"] = *input.CaffeineAmount
+	}
+	if input.Size != nil && strings.TrimSpace(*input.Size) != beverage.Size {
+		changes["size"] = strings.TrimSpace(*input.Size)
+	}
+	if input.Volume != nil && *input.Volume != beverage.Volume {
+		changes["volume"] = *input.Volume
+	}
+	if input.Category != nil && *input.Category != beverage.Category {
+		changes["category"] = *input.Category
+	}
+	if input.IsVerified != nil && *input.IsVerified != beverage.IsVerified {
+		changes["is_verified"] = *input.IsVerified
+	}
+	return changes
+}
+
+// validateBeverageChange : 입력값 범위와 이름 중복 확인
+func validateBeverageChange(beverage *models.Beverage, input BeverageChangeInput) error {
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" || len([]rune(name)) > 255 {
+			return fmt.Errorf("%w: 음료 이름은 1~255자여야 합니다", ErrInvalidBeverageChange)
+		}
+		if name != beverage.Name {
+			if err := checkBeverageNameFree(config.DB, name, beverage.ID); err != nil {
+				return err
+			}
+		}
+	}
+	if input.CaffeineAmount != nil && (*input.CaffeineAmount < 0 || *input.CaffeineAmount > maxCaffeineAmountMG) {
+		return fmt.Errorf("%w: 카페인 함량은 0~%dmg 사이여야 합니다", ErrInvalidBeverageChange, maxCaffeineAmountMG)
+	}
+	if input.Volume != nil && *input.Volume < 0 {
+		return fmt.Errorf("%w: 용량은 0 이상이어야 합니다", ErrInvalidBeverageChange)
+	}
+	if input.Category != nil && *input.Category != "" && !isDrinkCategory(*input.Category) {
+		return fmt.Errorf("%w: category는 %s 중 하나여야 합니다", ErrInvalidBeverageChange, strings.Join(drinkCategories, ", "))
+	}
+	return nil
+}
+
+func checkBeverageNameFree(db *gorm.DB, name string, beverageID uint) error {
+	var count int64
+	db.Model(&models.Beverage{}).Unscoped().Where("name = ? AND id <> ?", name, beverageID).Count(&count)
+	if count > 0 {
+		return ErrBeverageNameTaken
+	}
+	return nil
+}
+
+// applyBeverageFields : 필드 반영 후 변경 이력을 남기고 기본 사이즈를 맞춤
+// 트랜잭션 안에서 부르므로 검색 색인은 호출한 쪽에서 커밋한 뒤에 무효화 (커밋 전 값이 색인에 남지 않도록)
+func applyBeverageFields(db *gorm.DB, beverage *models.Beverage, changes map[string]interface{}, meta BeverageRevisionMeta) error {
+	before := revisionFields(beverage)
+	if err := db.Model(beverage).Updates(changes).Error; err != nil {
+		return err
+	}
+	if err := recordBeverageRevision(db, beverage.ID, before, changes, meta); err != nil {
+		return err
+	}
+	if err := db.First(beverage, beverage.ID).Error; err != nil {
+		return err
+	}
+
+	_, size := changes["size"]
+	_, volume := changes["volume"]
+	_, caffeine := changes["caffeine_amount"]
+	if size || volume || caffeine {
+		syncDefaultBeverageSize(db, beverage)
+	}
+	return nil
+}
+
+// fillChangeRequest : 응답용 변경 내역 채우기
+// 대기 중인 요청은 현재 값과 비교하고, 처리된 요청은 요청 당시 값과 비교
+func fillChangeRequest(request *models.BeverageChangeRequest, beverage *models.Beverage) {
+	request.Beverage = beverage
+
+	var changes, previous map[string]interface{}
+	json.Unmarshal([]byte(request.Changes), &changes)
+	json.Unmarshal([]byte(request.Previous), &previous)
+	if request.Status == models.ChangeRequestPending && beverage != nil && request.Action == models.ChangeActionUpdate {
+		previous = beverageFields(beverage)
+		previous["is_verified"] = beverage.IsVerified
+	}
+	if request.Status == models.ChangeRequestPending && beverage != nil && request.Action == models.ChangeActionCreate {
+		changes = beverageFields(beverage) // 등록한 사용자가 승인 전에 고친 값
+	}
+
+	request.Diff = []models.BeverageFieldChange{}
+	for _, field := range beverageFieldOrder {
+		after, ok := changes[field]
+		if !ok {
+			continue
+		}
+		request.Diff = append(request.Diff, models.BeverageFieldChange{Field: field, Before: previous[field], After: after})
+	}
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+// ========================================
+// 음료 변경 이력
+// 음료 필드가 바뀔 때마다 이전 값/새 값, 바꾼 사람, 사유, 출처를 남기고
+// 이력 단위로 되돌리거나 직접 입력하지 않은 섭취 기록의 카페인량을 다시 계산
+// ========================================
+
+var (
+	ErrRevisionNotFound      = errors.New("변경 이력을 찾을 수 없습니다")
+	ErrRevisionNotRevertable = errors.New("되돌릴 수 있는 값이 없는 이력입니다 (새로 등록된 음료 등)")
+)
+
+// 이력 출처 (ChangeSource* 외에 추가)
+const (
+	RevisionSourceReview   = "review"   // 변경 요청 승인/거절
+	RevisionSourceCatalog  = "catalog"  // 카탈로그 가져오기
+	RevisionSourceSize     = "size"     // 기본 사이즈 변경
+	RevisionSourceFeedback = "feedback" // 인식 피드백으로 검증됨 승격
+	RevisionSourceRevert   = "revert"   // 이력 되돌리기
+	RevisionSourceDelete   = "delete"   // 음료 삭제 (이름을 비워 다시 등록할 수 있게 함)
+)
+
+// revertableFields : 되돌릴 수 있는 필드 (상태는 승인/거절 흐름으로만 바꿈)
+var revertableFields = map[string]bool{
+	"name": true, "brand": true, "category": true, "caffeine_amount": true,
+	"size": true, "volume": true, "is_verified": true,
+}
+
+// BeverageRevisionMeta : 이력에 남길 작성자/사유/출처
+type BeverageRevisionMeta struct {
+	AuthorID        uint
+	Source          string
+	Reason          string
+	ChangeRequestID *uint
+	RevertOf        *uint
+}
+
+// revisionFields : 이력으로 비교하는 필드 값 (검토 대상 필드 + 검증/상태)
+func revisionFields(beverage *models.Beverage) map[string]interface{} {
+	fields := beverageFields(beverage)
+	fields["is_verified"] = beverage.IsVerified
+	fields["status"] = beverage.Status
+	return fields
+}
+
+// recordBeverageRevision : 바뀐 필드가 있으면 이력 추가 (before가 nil이면 새로 등록된 음료)
+func recordBeverageRevision(db *gorm.DB, beverageID uint, before, after map[string]interface{}, meta BeverageRevisionMeta) error {
+	diff := []models.BeverageFieldChange{}
+	for _, field := range beverageFieldOrder {
+		value, ok := after[field]
+		if !ok {
+			continue
+		}
+		var previous interface{}
+		if before != nil {
+			previous = before[field]
+			if fmt.Sprint(previous) == fmt.Sprint(value) {
+				continue
+			}
+		}
+		diff = append(diff, models.BeverageFieldChange{Field: field, Before: previous, After: value})
+	}
+	if len(diff) == 0 {
+		return nil
+	}
+
+	changes, _ := json.Marshal(diff)
+	return db.Create(&models.BeverageRevision{
+		BeverageID:      beverageID,
+		Changes:         string(changes),
+		AuthorID:        meta.AuthorID,
+		Reason:          truncateRunes(meta.Reason, 500),
+		Source:          meta.Source,
+		ChangeRequestID: meta.ChangeRequestID,
+		RevertOf:        meta.RevertOf,
+	}).Error
+}
+
+// ListBeverageRevisions : 음료 변경 이력 (최신순)
+func ListBeverageRevisions(beverageID uint) ([]models.BeverageRevision, error) {
+	var count int64
+	config.DB.Unscoped().Model(&models.Beverage{}).Where("id = ?", beverageID).Count(&count)
+	if count == 0 {
+		return nil, ErrBeverageNotFound
+	}
+
+	revisions := []models.BeverageRevision{}
+	if err := config.DB.Where("beverage_id = ?", beverageID).Order("id DESC").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	for i := range revisions {
+		json.Unmarshal([]byte(revisions[i].Changes), &revisions[i].Diff)
+	}
+	return revisions, nil
+}
+
+// RevertBeverageRevision : 이력의 변경 전 값으로 되돌림 (되돌린 것도 새 이력으로 남음)
+func RevertBeverageRevision(beverageID, revisionID uint, meta BeverageRevisionMeta) (*models.Beverage, error) {
+	var revision models.BeverageRevision
+	if err := config.DB.Where("id = ? AND beverage_id = ?", revisionID, beverageID).First(&revision).Error; err != nil {
+		return nil, ErrRevisionNotFound
+	}
+	var diff []models.BeverageFieldChange
+	json.Unmarshal([]byte(revision.Changes), &diff)
+
+	changes := make(map[string]interface{})
+	for _, change := range diff {
+		if revertableFields[change.Field] && change.Before != nil {
+			changes[change.Field] = change.Before
+		}
+	}
+	if len(changes) == 0 {
+		return nil, ErrRevisionNotRevertable
+	}
+
+	var beverage models.Beverage
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&beverage, beverageID).Error; err != nil {
+			return ErrBeverageNotFound
+		}
+		current := revisionFields(&beverage)
+		for field, value := range changes {
+			if fmt.Sprint(current[field]) == fmt.Sprint(value) {
+				delete(changes, field)
+			}
+		}
+		if len(changes) == 0 {
+			return ErrNoBeverageChanges
+		}
+		if name, ok := changes["name"].(string); ok {
+			if err := checkBeverageNameFree(tx, name, beverage.ID); err != nil {
+				return err
+			}
+		}
+
+		meta.Source = RevisionSourceRevert
+		meta.RevertOf = &revision.ID
+		if meta.Reason == "" {
+			meta.Reason = fmt.Sprintf("이력 #%d 되돌리기", revision.ID)
+		}
+		return applyBeverageFields(tx, &beverage, changes, meta)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	InvalidateBeverageSearchIndex()
+	println("⏪ 음료 이력 되돌리기:", beverage.Name, "이력", revision.ID)
+	return &beverage, nil
+}
+
+// RecalculateBeverageLogs : 음료의 현재 사이즈/카페인 값으로 섭취 기록 다시 계산
+// 사이즈나 용량으로 기록했거나 예전 카탈로그 값 그대로인 기록만 바꾸고,
+// 카페인량을 직접 입력한 기록(amount_overridden, 카탈로그 값과 다른 예전 기록)은 건드리지 않음, 바뀐 기록 수를 반환
+func RecalculateBeverageLogs(beverageID uint) (int64, error) {
+	var beverage models.Beverage
+	if err := config.DB.Preload("Sizes").First(&beverage, beverageID).Error; err != nil {
+		return 0, ErrBeverageNotFound
+	}
+
+	var revisions []models.BeverageRevision
+	if err := config.DB.Where("beverage_id = ?", beverage.ID).Find(&revisions).Error; err != nil {
+		return 0, err
+	}
+	catalogValues := catalogCaffeineValues(revisions, beverage.CaffeineAmount)
+
+	var updated int64
+	var logs []models.CaffeineLog
+	err := config.DB.
+		Where("beverage_id = ? AND amount_overridden = ?", beverage.ID, false).
+		FindInBatches(&logs, 200, func(_ *gorm.DB, batch int) error {
+			for i := range logs {
+				log := &logs[i]
+				if !recalculableLog(log, catalogValues) {
+					continue
+				}
+				choice, err := ResolveBeverageSize(&beverage, log.BeverageSizeID, "", log.VolumeML)
+				if err != nil {
+					continue // 지워진 사이즈, 보간할 수 없는 용량은 그대로 둠
+				}
+				original := math.Round(choice.CaffeineAmount)
+				if original == log.OriginalAmount {
+					continue
+				}
+				if err := config.DB.Model(log).Updates(map[string]interface{}{
+					"original_amount": original,
+					"amount":          original * log.ConsumedRatio,
+				}).Error; err != nil {
+					return err
+				}
+				updated++
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return updated, err
+	}
+
+	println("🧮 섭취 기록 다시 계산:", beverage.Name, updated, "건")
+	return updated, nil
+}
+
+// catalogCaffeineValues : 음료의 기본 카페인량이 가졌던 값 (현재 값과 이력의 변경 전/후 값)
+func catalogCaffeineValues(revisions []models.BeverageRevision, current float64) []float64 {
+	values := []float64{current}
+	for _, revision := range revisions {
+		var diff []models.BeverageFieldChange
+		if err := json.Unmarshal([]byte(revision.Changes), &diff); err != nil {
+			continue
+		}
+		for _, change := range diff {
+			if change.Field != "caffeine_amount" {
+				continue
+			}
+			for _, value := range []interface{}{change.Before, change.After} {
+				if amount, ok := value.(float64); ok {
+					values = append(values, amount)
+				}
+			}
+		}
+	}
+	return values
+}
+
+// recalculableLog : 음료 값이 바뀌면 따라 바뀌어야 하는 기록인지
+// 사이즈/용량으로 기록했으면 그 사이즈 값을 따르고, 사이즈 없는 예전 기록은 카탈로그 값 그대로일 때만 (1mg 이내)
+func recalculableLog(log *models.CaffeineLog, catalogValues []float64) bool {
+	if log.AmountOverridden {
+		return false
+	}
+	if log.BeverageSizeID != nil || log.VolumeML > 0 {
+		return true
+	}
+	for _, value := range catalogValues {
+		if math.Abs(log.OriginalAmount-value) < 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"caffy-backend/models"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// revisionWith : 필드 변경 내역으로 이력 만들기
+func revisionWith(changes ...models.BeverageFieldChange) models.BeverageRevision {
+	data, _ := json.Marshal(changes)
+	return models.BeverageRevision{Changes: string(data)}
+}
+
+func TestCatalogCaffeineValues(t *testing.T) {
+	revisions := []models.BeverageRevision{
+		// 새로 등록 (변경 전 값 없음)
+		revisionWith(
+			models.BeverageFieldChange{Field: "name", After: "아메리카노"},
+			models.BeverageFieldChange{Field: "caffeine_amount", After: 150.0},
+		),
+		// 카페인 외 필드만 바뀐 이력
+		revisionWith(models.BeverageFieldChange{Field: "brand", Before: "", After: "스타벅스"}),
+		// 카페인 수정
+		revisionWith(models.BeverageFieldChange{Field: "caffeine_amount", Before: 150.0, After: 180.0}),
+		{Changes: "깨진 JSON"},
+	}
+
+	got := catalogCaffeineValues(revisions, 180)
+	want := []float64{180, 150, 150, 180}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("catalogCaffeineValues = %v, want %v", got, want)
+	}
+}
+
+func TestRecalculableLog(t *testing.T) {
+	sizeID := uint(3)
+	// 카페인을 150 → 180으로 고친 음료
+	catalogValues := []float64{180, 150}
+
+	tests := []struct {
+		name string
+		log  models.CaffeineLog
+		want bool
+	}{
+		{
+			name: "사이즈로 기록",
+			log:  models.CaffeineLog{BeverageSizeID: &sizeID, OriginalAmount: 150},
+			want: true,
+		},
+		{
+			name: "용량으로 기록",
+			log:  models.CaffeineLog{VolumeML: 473, OriginalAmount: 190},
+			want: true,
+		},
+		{
+			name: "사이즈 없는 예전 기록이 수정 전 카탈로그 값",
+			log:  models.CaffeineLog{OriginalAmount: 150},
+			want: true,
+		},
+		{
+			name: "반올림 차이는 카탈로그 값으로 봄",
+			log:  models.CaffeineLog{OriginalAmount: 150.4},
+			want: true,
+		},
+		{
+			name: "사이즈 없이 직접 입력한 예전 기록은 카페인 수정 후에도 유지",
+			log:  models.CaffeineLog{OriginalAmount: 200},
+			want: false,
+		},
+		{
+			name: "직접 입력 표시된 기록",
+			log:  models.CaffeineLog{AmountOverridden: true, BeverageSizeID: &sizeID, OriginalAmount: 150},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recalculableLog(&tt.log, catalogValues); got != tt.want {
+				t.Errorf("recalculableLog = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecalculateKeepsManualAmountAfterCaffeineEdit(t *testing.T) {
+	// 카탈로그 150mg일 때 남긴 기록 두 건: 카탈로그 값 그대로, 직접 입력한 200mg
+	revisions := []models.BeverageRevision{
+		revisionWith(models.BeverageFieldChange{Field: "caffeine_amount", Before: 150.0, After: 180.0}),
+	}
+	values := catalogCaffeineValues(revisions, 180)
+
+	catalogLog := models.CaffeineLog{OriginalAmount: 150, ConsumedRatio: 1}
+	manualLog := models.CaffeineLog{OriginalAmount: 200, ConsumedRatio: 1}
+	if !recalculableLog(&catalogLog, values) {
+		t.Error("카탈로그 값으로 남긴 기록은 새 값으로 다시 계산해야 함")
+	}
+	if recalculableLog(&manualLog, values) {
+		t.Error("직접 입력한 200mg 기록이 카페인 수정으로 바뀌면 안 됨")
+	}
+}
@@ -0,0 +1,590 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ========================================
+// 음료 검색 서비스
+// 초성 검색(ㅅㅂㅇㅁㄹㅋㄴ), 영문/한글 표기 차이(americano ↔ 아메리카노), 오타 허용,
+// 검증 여부/인기도/내 기록 순위를 반영. 매 키 입력마다 부르는 자동완성은 메모리 색인만 사용
+// ========================================
+
+// 검색 결과 개수
+const (
+	defaultSearchLimit       = 20
+	maxSearchLimit           = 50
+	defaultAutocompleteLimit = 8
+	maxAutocompleteLimit     = 20
+)
+
+// beverageSearchIndexTTL : 검색 색인을 다시 만드는 주기 (음료 등록/수정 시에는 바로 무효화)
+const beverageSearchIndexTTL = 5 * time.Minute
+
+// 내 기록 반영
+const (
+	userHistoryDays     = 90          // 최근 며칠 기록을 볼지
+	userHistoryTTL      = time.Minute // 사용자별 기록 캐시 시간 (자동완성 키 입력마다 조회하지 않도록)
+	userHistoryMaxUsers = 1000        // 캐시에 보관할 최대 사용자 수
+	userHistoryMaxBoost = 120         // 내 기록 가산점 상한
+	userHistoryPerLog   = 30          // 기록 1건당 가산점
+	popularityMaxBoost  = 60          // 인기도 가산점 상한
+	verifiedBoost       = 40          // 검증된 음료 가산점
+	searchHistoryWindow = 24 * time.Hour * userHistoryDays
+)
+
+// 매칭 종류 (자동완성 응답에 포함해 강조 표시에 사용)
+const (
+	SearchMatchExact    = "exact"
+	SearchMatchPrefix   = "prefix"
+	SearchMatchContains = "contains"
+	SearchMatchChosung  = "chosung"
+	SearchMatchRoman    = "transliteration"
+	SearchMatchFuzzy    = "fuzzy"
+	SearchMatchAlias    = "alias"
+	SearchMatchCategory = "category"
+)
+
+// hangulChosung : 한글 초성 (유니코드 순서)
+var hangulChosung = []rune("ㄱㄲㄴㄷㄸㄹㅁㅂㅃㅅㅆㅇㅈㅉㅊㅋㅌㅍㅎ")
+
+// 한글 로마자 표기 (국어의 로마자 표기법 기준, 외래어 비교용이라 단순화)
+var (
+	romanInitials = []string{"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s", "ss", "", "j", "jj", "ch", "k", "t", "p", "h"}
+	romanMedials  = []string{"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa", "wae", "oe", "yo", "u", "wo", "we", "wi", "yu", "eu", "ui", "i"}
+	romanFinals   = []string{"", "k", "k", "k", "n", "n", "n", "t", "l", "k", "m", "l", "l", "l", "p", "l", "m", "p", "p", "t", "t", "ng", "t", "t", "k", "t", "p", "t"}
+)
+
+// phoneticReplacer : 영문 표기와 한글 로마자 표기의 차이를 줄이는 치환 (americano, 아메리카노 → amelikano)
+var phoneticReplacer = strings.NewReplacer(
+	"ph", "p", "th", "t", "ck", "k", "c", "k", "q", "k", "x", "ks", "z", "j", "v", "b", "f", "p",
+	"r", "l", "eo", "o", "eu", "", "ae", "e", "oo", "u",
+)
+
+// BeverageSearchHit : 검색 결과 한 건
+type BeverageSearchHit struct {
+	ID             uint    `json:"id"`
+	Name           string  `json:"name"`
+	Brand          string  `json:"brand"`
+	CaffeineAmount float64 `json:"caffeine_amount"`
+	Size           string  `json:"size"`
+	Category       string  `json:"category"`
+	IsVerified     bool    `json:"is_verified"`
+	Match          string  `json:"match"` // 매칭 종류
+	Score          int     `json:"score"`
+}
+
+// searchEntry : 색인된 음료 (검색에 쓰는 표기를 미리 계산)
+type searchEntry struct {
+	hit          BeverageSearchHit
+	compact      string        // 정규화된 "브랜드 이름" (공백 제거)
+	nameCompact  string        // 정규화된 이름 (공백 제거)
+	brandCompact string        // 정규화된 브랜드 (공백 제거)
+	aliases      []aliasTarget // 이 음료를 가리키는 별칭
+	chosungs     []string      // 초성 (브랜드 줄임말 포함 변형)
+	phonetic     string        // 로마자 발음 키 (브랜드+이름)
+	namePhonetic string        // 로마자 발음 키 (이름)
+	jamo         []rune        // 자모 분해한 이름 (오타 거리 계산용)
+	fullJamo     []rune        // 자모 분해한 브랜드+이름
+	popularity   int           // 이미지 인식에 쓰인 횟수 합계
+}
+
+// searchQuery : 미리 계산한 검색어 표기
+type searchQuery struct {
+	compact    string
+	categories map[string]float64 // 검색어가 분류 별칭이면 분류 → 가중치
+	tokens     []string
+	chosung    string // 검색어에 초성이 있을 때만
+	phonetic   string
+	jamo       []rune
+}
+
+var searchIndex struct {
+	sync.Mutex
+	entries    []searchEntry
+	expiresAt  time.Time
+	generation int // 무효화될 때마다 증가 (만드는 도중 무효화된 색인은 저장하지 않음)
+}
+
+var userHistoryCache struct {
+	sync.Mutex
+	counts map[uint]userHistory
+}
+
+type userHistory struct {
+	counts    map[uint]int
+	expiresAt time.Time
+}
+
+// InvalidateBeverageSearchIndex : 음료가 추가/수정되면 다음 검색 때 색인을 다시 만듦
+func InvalidateBeverageSearchIndex() {
+	searchIndex.Lock()
+	searchIndex.expiresAt = time.Time{}
+	searchIndex.generation++
+	searchIndex.Unlock()
+}
+
+// SearchBeverages : 음료 검색 (순위순, 로그인한 사용자는 자주 마신 음료가 위로)
+func SearchBeverages(query string, userID uint, limit int) []BeverageSearchHit {
+	return searchBeverages(query, userID, clampLimit(limit, defaultSearchLimit, maxSearchLimit))
+}
+
+// AutocompleteBeverages : 자동완성 (메모리 색인만 사용, 키 입력마다 호출해도 DB를 거의 조회하지 않음)
+func AutocompleteBeverages(prefix string, userID uint, limit int) []BeverageSearchHit {
+	return searchBeverages(prefix, userID, clampLimit(limit, defaultAutocompleteLimit, maxAutocompleteLimit))
+}
+
+func clampLimit(limit, def, maxLimit int) int {
+	if limit <= 0 {
+		return def
+	}
+	return min(limit, maxLimit)
+}
+
+func searchBeverages(raw string, userID uint, limit int) []BeverageSearchHit {
+	q := newSearchQuery(raw)
+	if q.compact == "" && q.chosung == "" {
+		return []BeverageSearchHit{}
+	}
+
+	entries := beverageSearchEntries()
+	history := userBeverageHistory(userID)
+
+	hits := []BeverageSearchHit{}
+	for i := range entries {
+		score, match := scoreSearchEntry(&entries[i], q)
+		if score == 0 {
+			continue
+		}
+		hit := entries[i].hit
+		hit.Match = match
+		hit.Score = score + rankingBoost(&entries[i], history[hit.ID])
+		hits = append(hits, hit)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Name < hits[j].Name
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// newSearchQuery : 검색어 정규화 (별칭 치환, 초성/로마자/자모 표기 계산)
+func newSearchQuery(raw string) searchQuery {
+	normalized := NormalizeDrinkQuery(raw)
+	q := searchQuery{
+		compact:  compactQuery(normalized),
+		tokens:   strings.Fields(normalized),
+		phonetic: phoneticKey(raw),
+		jamo:     decomposeHangul(compactQuery(normalized)),
+	}
+	if containsJamo(raw) {
+		q.chosung = hangulChosungOf(compactQuery(strings.ToLower(raw)))
+	}
+	for _, alias := range aliasSnapshot().categories {
+		if alias.key == q.compact {
+			if q.categories == nil {
+				q.categories = make(map[string]float64)
+			}
+			q.categories[alias.category] = math.Max(q.categories[alias.category], alias.weight)
+		}
+	}
+	return q
+}
+
+// scoreSearchEntry : 검색어와 음료의 일치 점수 (0: 불일치)
+func scoreSearchEntry(e *searchEntry, q searchQuery) (int, string) {
+	best, match := 0, ""
+	consider := func(score int, kind string) {
+		if score > best {
+			best, match = score, kind
+		}
+	}
+
+	if q.compact != "" {
+		switch {
+		case q.compact == e.nameCompact || q.compact == e.compact:
+			consider(1000, SearchMatchExact)
+		case strings.HasPrefix(e.nameCompact, q.compact) || strings.HasPrefix(e.compact, q.compact):
+			consider(800, SearchMatchPrefix)
+		case containsAllTokens(e.compact, q.tokens):
+			consider(600, SearchMatchContains)
+		}
+
+		// 음료 별칭 (가중치 반영)
+		for _, alias := range e.aliases {
+			switch {
+			case alias.key == q.compact:
+				consider(int(900*math.Min(1, alias.weight))+50, SearchMatchAlias)
+			case strings.HasPrefix(alias.key, q.compact):
+				consider(int(700*math.Min(1, alias.weight)), SearchMatchAlias)
+			}
+		}
+	}
+
+	// 분류 별칭 (예: "에너지음료" → 에너지드링크 분류 전체)
+	if weight, ok := q.categories[e.hit.Category]; ok {
+		consider(int(400*math.Min(1, weight)), SearchMatchCategory)
+	}
+
+	if q.chosung != "" {
+		for _, chosung := range e.chosungs {
+			if strings.HasPrefix(chosung, q.chosung) {
+				consider(700, SearchMatchChosung)
+			} else if strings.Contains(chosung, q.chosung) {
+				consider(500, SearchMatchChosung)
+			}
+		}
+	}
+
+	if len(q.phonetic) >= 3 {
+		if strings.HasPrefix(e.namePhonetic, q.phonetic) || strings.HasPrefix(e.phonetic, q.phonetic) {
+			consider(500, SearchMatchRoman)
+		} else if strings.Contains(e.phonetic, q.phonetic) {
+			consider(450, SearchMatchRoman)
+		}
+		if d, ok := fuzzyDistance([]rune(q.phonetic), []rune(e.namePhonetic), []rune(e.phonetic)); ok {
+			consider(300-60*d, SearchMatchRoman)
+		}
+	}
+
+	if d, ok := fuzzyDistance(q.jamo, e.jamo, e.fullJamo); ok {
+		consider(350-60*d, SearchMatchFuzzy)
+	}
+
+	return best, match
+}
+
+// rankingBoost : 검증 여부, 인기도, 내 기록 가산점
+func rankingBoost(e *searchEntry, myLogs int) int {
+	boost := 0
+	if e.hit.IsVerified {
+		boost += verifiedBoost
+	}
+	if e.popularity > 0 {
+		boost += min(popularityMaxBoost, int(15*math.Log2(float64(1+e.popularity))))
+	}
+	if myLogs > 0 {
+		boost += min(userHistoryMaxBoost, userHistoryPerLog*myLogs)
+	}
+	return boost
+}
+
+// fuzzyDistance : 검색어와 대상(전체 또는 같은 길이의 앞부분) 사이의 편집 거리가 허용 범위인지
+func fuzzyDistance(query []rune, targets ...[]rune) (int, bool) {
+	tolerance := typoTolerance(len(query))
+	if tolerance == 0 {
+		return 0, false
+	}
+
+	best := tolerance + 1
+	for _, target := range targets {
+		if len(target) == 0 {
+			continue
+		}
+		best = min(best, levenshtein(query, target, tolerance))
+		if len(target) > len(query) {
+			best = min(best, levenshtein(query, target[:len(query)], tolerance))
+		}
+	}
+	return best, best <= tolerance
+}
+
+// typoTolerance : 검색어 길이(자모/글자 수)에 따른 허용 오타 수
+func typoTolerance(length int) int {
+	switch {
+	case length < 4:
+		return 0
+	case length < 8:
+		return 1
+	case length < 14:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// levenshtein : 편집 거리 (limit를 넘으면 limit+1)
+func levenshtein(a, b []rune, limit int) int {
+	if diff := len(a) - len(b); diff > limit || -diff > limit {
+		return limit + 1
+	}
+
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(min(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev, curr = curr, prev
+	}
+	return min(prev[len(b)], limit+1)
+}
+
+func containsAllTokens(s string, tokens []string) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	for _, token := range tokens {
+		if !strings.Contains(s, token) {
+			return false
+		}
+	}
+	return true
+}
+
+// ========================================
+// 한글 처리
+// ========================================
+
+const (
+	hangulBase  = 0xAC00
+	hangulLast  = 0xD7A3
+	jamoPerInit = 21 * 28
+)
+
+func isHangulSyllable(r rune) bool {
+	return r >= hangulBase && r <= hangulLast
+}
+
+// containsJamo : 완성되지 않은 자음(ㄱ~ㅎ)이 있는지
+func containsJamo(s string) bool {
+	for _, r := range s {
+		if r >= 'ㄱ' && r <= 'ㅎ' {
+			return true
+		}
+	}
+	return false
+}
+
+// hangulChosungOf : 초성만 추출 (한글이 아닌 글자는 그대로, 공백은 제거)
+func hangulChosungOf(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case isHangulSyllable(r):
+			b.WriteRune(hangulChosung[(r-hangulBase)/jamoPerInit])
+		case unicode.IsSpace(r):
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// decomposeHangul : 음절을 초성/중성/종성 자모로 분해 (오타 거리를 자모 단위로 계산)
+func decomposeHangul(s string) []rune {
+	var jamo []rune
+	for _, r := range s {
+		if !isHangulSyllable(r) {
+			jamo = append(jamo, r)
+			continue
+		}
+		offset := r - hangulBase
+		jamo = append(jamo, 0x1100+offset/jamoPerInit, 0x1161+(offset%jamoPerInit)/28)
+		if final := offset % 28; final > 0 {
+			jamo = append(jamo, 0x11A7+final)
+		}
+	}
+	return jamo
+}
+
+// romanizeHangul : 한글을 로마자로 (한글이 아닌 글자는 그대로)
+func romanizeHangul(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if !isHangulSyllable(r) {
+			b.WriteRune(r)
+			continue
+		}
+		offset := r - hangulBase
+		b.WriteString(romanInitials[offset/jamoPerInit])
+		b.WriteString(romanMedials[(offset%jamoPerInit)/28])
+		b.WriteString(romanFinals[offset%28])
+	}
+	return b.String()
+}
+
+// phoneticKey : 표기와 상관없이 비슷하게 읽히면 같아지는 키 (americano, 아메리카노 → amelikano)
+func phoneticKey(s string) string {
+	var letters strings.Builder
+	for _, r := range strings.ToLower(romanizeHangul(s)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			letters.WriteRune(r)
+		}
+	}
+
+	replaced := phoneticReplacer.Replace(letters.String())
+	var b strings.Builder
+	var last rune
+	for _, r := range replaced {
+		if r != last {
+			b.WriteRune(r)
+		}
+		last = r
+	}
+	return b.String()
+}
+
+// ========================================
+// 색인 / 사용자 기록
+// ========================================
+
+// beverageSearchEntries : 검색 색인 (만료됐으면 다시 만듦)
+// DB 조회와 색인 계산은 잠금 밖에서 하고, 완성된 색인만 잠금 안에서 교체
+func beverageSearchEntries() []searchEntry {
+	searchIndex.Lock()
+	if time.Now().Before(searchIndex.expiresAt) {
+		entries := searchIndex.entries
+		searchIndex.Unlock()
+		return entries
+	}
+	generation := searchIndex.generation
+	searchIndex.Unlock()
+
+	if config.DB == nil {
+		return nil
+	}
+	entries := loadBeverageSearchEntries()
+
+	searchIndex.Lock()
+	defer searchIndex.Unlock()
+	if searchIndex.generation != generation {
+		// 만드는 동안 음료가 바뀜: 이번 결과는 쓰되 저장하지 않아 다음 검색에서 다시 만듦
+		return entries
+	}
+	searchIndex.entries = entries
+	searchIndex.expiresAt = time.Now().Add(beverageSearchIndexTTL)
+	println("🔎 음료 검색 색인 갱신:", len(entries), "개")
+	return entries
+}
+
+// loadBeverageSearchEntries : 활성 음료, 인기도, 별칭을 읽어 색인 생성
+func loadBeverageSearchEntries() []searchEntry {
+	var beverages []models.Beverage
+	config.DB.Where("status = ?", models.BeverageStatusActive).Find(&beverages)
+
+	var usage []struct {
+		BeverageID uint
+		Total      int
+	}
+	config.DB.Model(&models.BeverageImage{}).
+		Select("beverage_id, SUM(usage_count) AS total").
+		Where("beverage_id IS NOT NULL").
+		Group("beverage_id").
+		Scan(&usage)
+	popularity := make(map[uint]int, len(usage))
+	for _, u := range usage {
+		popularity[u.BeverageID] = u.Total
+	}
+
+	aliases := aliasSnapshot()
+	entries := make([]searchEntry, 0, len(beverages))
+	for _, beverage := range beverages {
+		entries = append(entries, newSearchEntry(beverage, popularity[beverage.ID], aliases))
+	}
+	return entries
+}
+
+// newSearchEntry : 음료 한 건의 검색 표기 계산
+func newSearchEntry(beverage models.Beverage, popularity int, aliases *aliasIndex) searchEntry {
+	name := normalizeWithTerms(beverage.Name, aliases.terms)
+	full := normalizeWithTerms(beverage.Brand+" "+beverage.Name, aliases.terms)
+	brand := normalizeWithTerms(beverage.Brand, aliases.terms)
+
+	// 초성은 브랜드 줄임말로도 찾을 수 있게 (ㅅㅂ → 스벅 → 스타벅스)
+	chosungs := []string{hangulChosungOf(name), hangulChosungOf(full)}
+	for alias, term := range aliases.terms {
+		if brand != "" && term == brand {
+			chosungs = append(chosungs, hangulChosungOf(alias+name))
+		}
+	}
+	// 음료 별칭도 초성으로 찾을 수 있게
+	for _, alias := range aliases.byBeverage[beverage.ID] {
+		chosungs = append(chosungs, hangulChosungOf(alias.key))
+	}
+
+	return searchEntry{
+		hit: BeverageSearchHit{
+			ID:             beverage.ID,
+			Name:           beverage.Name,
+			Brand:          beverage.Brand,
+			CaffeineAmount: beverage.CaffeineAmount,
+			Size:           beverage.Size,
+			Category:       beverage.Category,
+			IsVerified:     beverage.IsVerified,
+		},
+		compact:      compactQuery(full),
+		nameCompact:  compactQuery(name),
+		brandCompact: compactQuery(brand),
+		aliases:      aliases.byBeverage[beverage.ID],
+		chosungs:     chosungs,
+		phonetic:     phoneticKey(beverage.Brand + beverage.Name),
+		namePhonetic: phoneticKey(beverage.Name),
+		jamo:         decomposeHangul(compactQuery(name)),
+		fullJamo:     decomposeHangul(compactQuery(full)),
+		popularity:   popularity,
+	}
+}
+
+// userBeverageHistory : 사용자가 최근 기록한 음료별 횟수 (짧게 캐시)
+func userBeverageHistory(userID uint) map[uint]int {
+	if userID == 0 || config.DB == nil {
+		return nil
+	}
+
+	userHistoryCache.Lock()
+	now := time.Now()
+	if cached, ok := userHistoryCache.counts[userID]; ok && now.Before(cached.expiresAt) {
+		userHistoryCache.Unlock()
+		return cached.counts
+	}
+	userHistoryCache.Unlock()
+
+	var rows []struct {
+		BeverageID uint
+		Total      int
+	}
+	config.DB.Model(&models.CaffeineLog{}).
+		Select("beverage_id, COUNT(*) AS total").
+		Where("user_id = ? AND beverage_id IS NOT NULL AND intake_at > ?", userID, now.Add(-searchHistoryWindow)).
+		Group("beverage_id").
+		Scan(&rows)
+	counts := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		counts[row.BeverageID] = row.Total
+	}
+
+	userHistoryCache.Lock()
+	defer userHistoryCache.Unlock()
+	if userHistoryCache.counts == nil || len(userHistoryCache.counts) >= userHistoryMaxUsers {
+		userHistoryCache.counts = make(map[uint]userHistory)
+	}
+	userHistoryCache.counts[userID] = userHistory{counts: counts, expiresAt: now.Add(userHistoryTTL)}
+	return counts
+}
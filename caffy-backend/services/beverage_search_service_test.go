@@ -0,0 +1,157 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  string
+		limit int
+		want  int
+	}{
+		{name: "같은 문자열", a: "latte", b: "latte", limit: 3, want: 0},
+		{name: "빈 문자열", a: "", b: "abc", limit: 5, want: 3},
+		{name: "치환/삽입", a: "kitten", b: "sitting", limit: 5, want: 3},
+		{name: "limit 초과는 limit+1", a: "kitten", b: "sitting", limit: 2, want: 3},
+		{name: "길이 차이만으로 limit 초과", a: "a", b: "abcd", limit: 1, want: 2},
+		{name: "한글 글자 단위", a: "아메리카노", b: "아메리까노", limit: 2, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := levenshtein([]rune(tt.a), []rune(tt.b), tt.limit); got != tt.want {
+				t.Errorf("levenshtein(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevenshteinOnJamo(t *testing.T) {
+	// 자모 단위로 비교하면 받침 하나 차이는 거리 1
+	a := decomposeHangul("카페라떼")
+	b := decomposeHangul("카페랏떼")
+	if got := levenshtein(a, b, 3); got != 1 {
+		t.Errorf("자모 거리 = %d, want 1", got)
+	}
+}
+
+func TestTypoTolerance(t *testing.T) {
+	tests := []struct {
+		length int
+		want   int
+	}{
+		{0, 0}, {3, 0}, {4, 1}, {7, 1}, {8, 2}, {13, 2}, {14, 3}, {40, 3},
+	}
+
+	for _, tt := range tests {
+		if got := typoTolerance(tt.length); got != tt.want {
+			t.Errorf("typoTolerance(%d) = %d, want %d", tt.length, got, tt.want)
+		}
+	}
+}
+
+func TestHangulChosungOf(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "공백 제거", in: "스타벅스 아메리카노", want: "ㅅㅌㅂㅅㅇㅁㄹㅋㄴ"},
+		{name: "받침은 무시", in: "각", want: "ㄱ"},
+		{name: "쌍자음", in: "까페 라떼", want: "ㄲㅍㄹㄸ"},
+		{name: "한글이 아닌 글자는 그대로", in: "CU 커피 2+1", want: "CUㅋㅍ2+1"},
+		{name: "이미 자음", in: "ㅅㅂ", want: "ㅅㅂ"},
+		{name: "빈 문자열", in: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hangulChosungOf(tt.in); got != tt.want {
+				t.Errorf("hangulChosungOf(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecomposeHangul(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []rune
+	}{
+		{name: "받침 없음", in: "가", want: []rune{0x1100, 0x1161}},
+		{name: "받침 있음", in: "각", want: []rune{0x1100, 0x1161, 0x11A8}},
+		{name: "겹받침", in: "닭", want: []rune{0x1103, 0x1161, 0x11B0}},
+		{name: "쌍자음 초성", in: "까", want: []rune{0x1101, 0x1161}},
+		{name: "한글이 아닌 글자는 그대로", in: "a가1", want: []rune{'a', 0x1100, 0x1161, '1'}},
+		{name: "빈 문자열", in: "", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decomposeHangul(tt.in)
+			if string(got) != string(tt.want) {
+				t.Errorf("decomposeHangul(%q) = %U, want %U", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRomanizeHangul(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "아메리카노", want: "amerikano"},
+		{in: "각", want: "gak"},
+		{in: "닭", want: "dak"},
+		{in: "까페", want: "kkape"},
+		{in: "라떼", want: "ratte"},
+		{in: "Cold Brew", want: "Cold Brew"},
+		{in: "콜드brew", want: "koldeubrew"},
+	}
+
+	for _, tt := range tests {
+		if got := romanizeHangul(tt.in); got != tt.want {
+			t.Errorf("romanizeHangul(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPhoneticKey(t *testing.T) {
+	// 한글 표기와 영문 표기가 같은 키가 되어야 함
+	same := []struct {
+		a, b string
+	}{
+		{"아메리카노", "Americano"},
+		{"라떼", "Latte"},
+		{"카페 라떼", "cafe latte"},
+		{"에스프레소", "espresso"},
+	}
+	for _, tt := range same {
+		if ka, kb := phoneticKey(tt.a), phoneticKey(tt.b); ka != kb {
+			t.Errorf("phoneticKey(%q) = %q, phoneticKey(%q) = %q, 같아야 함", tt.a, ka, tt.b, kb)
+		}
+	}
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "아메리카노", want: "amelikano"},
+		{in: "Americano!", want: "amelikano"},
+		{in: "Red Bull 250", want: "ledbul250"},
+		{in: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := phoneticKey(tt.in); got != tt.want {
+			t.Errorf("phoneticKey(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	if phoneticKey("아메리카노") == phoneticKey("카페라떼") {
+		t.Error("다른 음료는 다른 키여야 함")
+	}
+}
@@ -0,0 +1,463 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========================================
+// 음료 사이즈 서비스 (사이즈별 용량/카페인, 용량 입력 시 보간)
+// ========================================
+
+var (
+	ErrBeverageSizeNotFound = errors.New("음료에서 해당 사이즈를 찾을 수 없습니다")
+	ErrBeverageSizeExists   = errors.New("이미 등록된 사이즈입니다")
+	ErrSizeVolumeUnknown    = errors.New("사이즈별 용량 정보가 없어 카페인량을 계산할 수 없습니다")
+	ErrBeverageRequired     = errors.New("사이즈를 고르려면 beverage_id가 필요합니다")
+)
+
+// sameVolumeToleranceML : 이 차이 이내면 같은 용량으로 봄
+const sameVolumeToleranceML = 1.0
+
+// defaultSizeLabel : 사이즈 정보가 없는 음료의 기본 사이즈 이름
+const defaultSizeLabel = "기본"
+
+// SizeChoice : 섭취 기록/추정에 사용할 사이즈
+type SizeChoice struct {
+	SizeID         *uint   `json:"beverage_size_id,omitempty"` // 등록된 사이즈 (용량으로 환산한 경우 nil)
+	Label          string  `json:"label"`
+	VolumeML       float64 `json:"volume_ml"`
+	CaffeineAmount float64 `json:"caffeine_amount"`
+	Interpolated   bool    `json:"interpolated"` // 등록된 사이즈 사이/바깥 용량이라 환산한 값
+}
+
+// BeverageSizes : 음료의 사이즈 목록 (용량 순)
+// 사이즈가 하나도 없으면 음료의 Size/Volume/CaffeineAmount로 기본 사이즈를 만들어 돌려줌 (저장하지 않아 ID 0)
+// 저장은 사이즈를 실제로 추가할 때 (persistDefaultSize)
+// 확인 대기 중인 음료는 값이 확정되지 않았으므로 만들지 않음
+func BeverageSizes(beverage *models.Beverage) []models.BeverageSize {
+	sizes := beverage.Sizes
+	if len(sizes) == 0 && beverage.ID != 0 {
+		config.DB.Where("beverage_id = ?", beverage.ID).Find(&sizes)
+	}
+	if len(sizes) == 0 && beverage.ID != 0 && beverage.Status == models.BeverageStatusActive && beverage.CaffeineAmount > 0 {
+		sizes = []models.BeverageSize{defaultSizeFromBeverage(beverage)}
+	}
+
+	sortSizesByVolume(sizes)
+	beverage.Sizes = sizes
+	return sizes
+}
+
+// defaultSizeFromBeverage : 음료의 단일 사이즈 필드로 기본 사이즈 생성
+func defaultSizeFromBeverage(beverage *models.Beverage) models.BeverageSize {
+	label := beverage.Size
+	if label == "" && beverage.Volume > 0 {
+		label = fmt.Sprintf("%gml", beverage.Volume)
+	}
+	if label == "" {
+		label = defaultSizeLabel
+	}
+	return models.BeverageSize{
+		BeverageID:     beverage.ID,
+		Label:          label,
+		VolumeML:       beverage.Volume,
+		CaffeineAmount: beverage.CaffeineAmount,
+		IsDefault:      true,
+	}
+}
+
+// sortSizesByVolume : 용량 순 정렬 (용량을 모르는 사이즈는 뒤로)
+func sortSizesByVolume(sizes []models.BeverageSize) {
+	sort.SliceStable(sizes, func(i, j int) bool {
+		vi, vj := sizes[i].VolumeML, sizes[j].VolumeML
+		if (vi > 0) != (vj > 0) {
+			return vi > 0
+		}
+		return vi < vj
+	})
+}
+
+// ResolveBeverageSize : 사이즈 ID, 사이즈 이름, 용량 순으로 사이즈 결정
+// 아무것도 주지 않으면 기본 사이즈, 용량만 주면 등록된 사이즈 사이에서 보간
+func ResolveBeverageSize(beverage *models.Beverage, sizeID *uint, label string, volumeML float64) (*SizeChoice, error) {
+	sizes := BeverageSizes(beverage)
+	if len(sizes) == 0 {
+		return nil, ErrBeverageSizeNotFound
+	}
+
+	switch {
+	case sizeID != nil:
+		for _, size := range sizes {
+			if size.ID == *sizeID {
+				return choiceFromSize(size), nil
+			}
+		}
+		return nil, ErrBeverageSizeNotFound
+
+	case strings.TrimSpace(label) != "":
+		if size := findSizeByLabel(sizes, label); size != nil {
+			return choiceFromSize(*size), nil
+		}
+		return nil, ErrBeverageSizeNotFound
+
+	case volumeML > 0:
+		return sizeForVolume(sizes, volumeML)
+	}
+
+	return choiceFromSize(defaultSize(beverage, sizes)), nil
+}
+
+// findSizeByLabel : 사이즈 이름으로 찾기 ("tall", "톨", "Tall" 모두 같은 사이즈)
+func findSizeByLabel(sizes []models.BeverageSize, label string) *models.BeverageSize {
+	key := normalizeSizeKey(label, 0)
+	for i := range sizes {
+		if normalizeSizeKey(sizes[i].Label, 0) == key {
+			return &sizes[i]
+		}
+	}
+	return nil
+}
+
+// defaultSize : IsDefault 사이즈, 없으면 음료의 Size와 같은 사이즈, 그래도 없으면 첫 사이즈
+func defaultSize(beverage *models.Beverage, sizes []models.BeverageSize) models.BeverageSize {
+	for _, size := range sizes {
+		if size.IsDefault {
+			return size
+		}
+	}
+	if size := findSizeByLabel(sizes, beverage.Size); beverage.Size != "" && size != nil {
+		return *size
+	}
+	return sizes[0]
+}
+
+// choiceFromSize : 사이즈 선택 (저장되지 않은 기본 사이즈면 SizeID nil)
+func choiceFromSize(size models.BeverageSize) *SizeChoice {
+	var sizeID *uint
+	if size.ID != 0 {
+		id := size.ID
+		sizeID = &id
+	}
+	return &SizeChoice{
+		SizeID:         sizeID,
+		Label:          size.Label,
+		VolumeML:       size.VolumeML,
+		CaffeineAmount: size.CaffeineAmount,
+	}
+}
+
+// sizeForVolume : 용량이 같은 사이즈가 있으면 그 사이즈, 없으면 보간한 값
+func sizeForVolume(sizes []models.BeverageSize, volumeML float64) (*SizeChoice, error) {
+	for _, size := range sizes {
+		if size.VolumeML > 0 && math.Abs(size.VolumeML-volumeML) <= sameVolumeToleranceML {
+			return choiceFromSize(size), nil
+		}
+	}
+
+	caffeine, err := InterpolateCaffeine(sizes, volumeML)
+	if err != nil {
+		return nil, err
+	}
+	return &SizeChoice{
+		Label:          fmt.Sprintf("%gml", volumeML),
+		VolumeML:       volumeML,
+		CaffeineAmount: caffeine,
+		Interpolated:   true,
+	}, nil
+}
+
+// InterpolateCaffeine : 등록된 사이즈들로 임의 용량의 카페인량 계산
+// 두 사이즈 사이면 선형 보간, 범위 밖이면 가장 가까운 사이즈의 ml당 카페인으로 비례 환산
+func InterpolateCaffeine(sizes []models.BeverageSize, volumeML float64) (float64, error) {
+	var known []models.BeverageSize
+	for _, size := range sizes {
+		if size.VolumeML > 0 {
+			known = append(known, size)
+		}
+	}
+	if len(known) == 0 || volumeML <= 0 {
+		return 0, ErrSizeVolumeUnknown
+	}
+	sortSizesByVolume(known)
+
+	first, last := known[0], known[len(known)-1]
+	switch {
+	case volumeML <= first.VolumeML:
+		return first.CaffeineAmount * volumeML / first.VolumeML, nil
+	case volumeML >= last.VolumeML:
+		return last.CaffeineAmount * volumeML / last.VolumeML, nil
+	}
+
+	for i := 1; i < len(known); i++ {
+		lo, hi := known[i-1], known[i]
+		if volumeML <= hi.VolumeML {
+			t := (volumeML - lo.VolumeML) / (hi.VolumeML - lo.VolumeML)
+			return lo.CaffeineAmount + t*(hi.CaffeineAmount-lo.CaffeineAmount), nil
+		}
+	}
+	return last.CaffeineAmount * volumeML / last.VolumeML, nil
+}
+
+// ApplyBeverageSize : 섭취 기록에 사이즈 반영
+// amount가 0이면 사이즈의 카페인량으로 채우고, 직접 입력한 양이 사이즈 값과 다르면 직접 입력으로 표시
+// 사이즈 입력 없이 양을 직접 입력했으면 그대로 두고, 기본 사이즈 값과 다르면 직접 입력으로 표시
+// (직접 입력으로 표시된 기록은 음료 카페인이 바뀌어도 다시 계산하지 않음)
+func ApplyBeverageSize(log *models.CaffeineLog, sizeID *uint, label string, volumeML float64) error {
+	log.BeverageID = resolveBeverageRef(log.BeverageID) // 병합된 음료면 병합 대상으로 기록
+	hasSizeInput := sizeID != nil || strings.TrimSpace(label) != "" || volumeML > 0
+	if log.BeverageID == nil {
+		if sizeID != nil || strings.TrimSpace(label) != "" {
+			return ErrBeverageRequired
+		}
+		log.VolumeML = volumeML
+		return nil
+	}
+	if !hasSizeInput && log.OriginalAmount > 0 {
+		markManualAmount(log)
+		return nil
+	}
+
+	var beverage models.Beverage
+	if err := config.DB.First(&beverage, *log.BeverageID).Error; err != nil {
+		return ErrBeverageNotFound
+	}
+	choice, err := ResolveBeverageSize(&beverage, sizeID, label, volumeML)
+	if err != nil {
+		if !hasSizeInput {
+			return nil // 사이즈 정보가 없는 음료는 기존처럼 입력한 양으로 기록
+		}
+		return err
+	}
+
+	log.BeverageSizeID = choice.SizeID
+	log.VolumeML = choice.VolumeML
+	if log.OriginalAmount == 0 {
+		log.OriginalAmount = math.Round(choice.CaffeineAmount)
+	} else if math.Abs(log.OriginalAmount-choice.CaffeineAmount) >= 1 {
+		log.AmountOverridden = true
+	}
+	log.Amount = log.OriginalAmount * log.ConsumedRatio
+	return nil
+}
+
+// markManualAmount : 사이즈 없이 직접 입력한 양이 기본 사이즈 값과 같으면 기본 사이즈로 기록, 다르면 직접 입력으로 표시
+// 음료나 사이즈 정보를 찾을 수 없으면 입력한 양을 지키도록 직접 입력으로 표시
+func markManualAmount(log *models.CaffeineLog) {
+	log.AmountOverridden = true
+
+	var beverage models.Beverage
+	if err := config.DB.First(&beverage, *log.BeverageID).Error; err != nil {
+		return
+	}
+	choice, err := ResolveBeverageSize(&beverage, nil, "", 0)
+	if err != nil || math.Abs(log.OriginalAmount-choice.CaffeineAmount) >= 1 {
+		return
+	}
+	log.AmountOverridden = false
+	log.BeverageSizeID = choice.SizeID
+	log.VolumeML = choice.VolumeML
+}
+
+// persistDefaultSize : 저장되지 않은 기본 사이즈(BeverageSizes가 만든 ID 0 사이즈)를 저장
+func persistDefaultSize(tx *gorm.DB, sizes []models.BeverageSize) error {
+	for i := range sizes {
+		if sizes[i].ID != 0 {
+			continue
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&sizes[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddBeverageSize : 음료에 사이즈 추가 (기본 사이즈로 지정하면 음료의 Size/Volume/CaffeineAmount도 갱신)
+func AddBeverageSize(beverageID uint, size models.BeverageSize, userID uint) (*models.BeverageSize, error) {
+	var beverage models.Beverage
+	if err := config.DB.First(&beverage, beverageID).Error; err != nil {
+		return nil, ErrBeverageNotFound
+	}
+	if err := validateBeverageSize(size); err != nil {
+		return nil, err
+	}
+	sizes := BeverageSizes(&beverage)
+	if findSizeByLabel(sizes, size.Label) != nil {
+		return nil, ErrBeverageSizeExists
+	}
+
+	size.ID = 0
+	size.BeverageID = beverage.ID
+	if len(sizes) == 0 {
+		size.IsDefault = true
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// 음료 필드로 만든 기본 사이즈는 새 사이즈와 함께 저장 (새 사이즈가 기본 사이즈를 가리지 않도록)
+		if err := persistDefaultSize(tx, sizes); err != nil {
+			return err
+		}
+		if err := tx.Create(&size).Error; err != nil {
+			return err
+		}
+		if size.IsDefault {
+			return setDefaultSizeWithRevision(tx, &beverage, size, userID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &size, nil
+}
+
+// UpdateBeverageSize : 사이즈 수정
+func UpdateBeverageSize(beverageID uint, sizeID uint, input models.BeverageSize, userID uint) (*models.BeverageSize, error) {
+	var beverage models.Beverage
+	if err := config.DB.First(&beverage, beverageID).Error; err != nil {
+		return nil, ErrBeverageNotFound
+	}
+	var size models.BeverageSize
+	if err := config.DB.Where("id = ? AND beverage_id = ?", sizeID, beverageID).First(&size).Error; err != nil {
+		return nil, ErrBeverageSizeNotFound
+	}
+	if err := validateBeverageSize(input); err != nil {
+		return nil, err
+	}
+	if other := findSizeByLabel(BeverageSizes(&beverage), input.Label); other != nil && other.ID != size.ID {
+		return nil, ErrBeverageSizeExists
+	}
+
+	size.Label = input.Label
+	size.VolumeML = input.VolumeML
+	size.CaffeineAmount = input.CaffeineAmount
+	size.Shots = input.Shots
+	size.IsDefault = size.IsDefault || input.IsDefault
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&size).Error; err != nil {
+			return err
+		}
+		if size.IsDefault {
+			return setDefaultSizeWithRevision(tx, &beverage, size, userID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &size, nil
+}
+
+// DeleteBeverageSize : 사이즈 삭제 (기본 사이즈는 다른 사이즈를 기본으로 지정한 뒤에 삭제 가능)
+func DeleteBeverageSize(beverageID uint, sizeID uint) error {
+	var size models.BeverageSize
+	if err := config.DB.Where("id = ? AND beverage_id = ?", sizeID, beverageID).First(&size).Error; err != nil {
+		return ErrBeverageSizeNotFound
+	}
+	if size.IsDefault {
+		return fmt.Errorf("기본 사이즈는 삭제할 수 없습니다. 다른 사이즈를 기본으로 지정하세요")
+	}
+	// 기록이 참조하던 사이즈 ID는 지우고 용량은 남김
+	config.DB.Model(&models.CaffeineLog{}).Where("beverage_size_id = ?", size.ID).Update("beverage_size_id", nil)
+	return config.DB.Unscoped().Delete(&size).Error
+}
+
+// syncDefaultBeverageSize : 음료의 Size/Volume/CaffeineAmount가 바뀌면 기본 사이즈에도 반영
+func syncDefaultBeverageSize(db *gorm.DB, beverage *models.Beverage) {
+	db.Model(&models.BeverageSize{}).
+		Where("beverage_id = ? AND is_default = ?", beverage.ID, true).
+		Updates(map[string]interface{}{
+			"label":           defaultSizeFromBeverage(beverage).Label,
+			"volume_ml":       beverage.Volume,
+			"caffeine_amount": beverage.CaffeineAmount,
+		})
+}
+
+// PrepareBeverageSizes : 새 음료 등록 시 사이즈 목록 검증, 기본 사이즈 값을 음료 필드에 채움
+func PrepareBeverageSizes(beverage *models.Beverage) error {
+	if len(beverage.Sizes) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	defaultIndex := -1
+	for i := range beverage.Sizes {
+		size := &beverage.Sizes[i]
+		if err := validateBeverageSize(*size); err != nil {
+			return err
+		}
+		key := normalizeSizeKey(size.Label, 0)
+		if seen[key] {
+			return fmt.Errorf("%w: %s", ErrBeverageSizeExists, size.Label)
+		}
+		seen[key] = true
+		if size.IsDefault {
+			if defaultIndex >= 0 {
+				size.IsDefault = false
+			} else {
+				defaultIndex = i
+			}
+		}
+	}
+	if defaultIndex < 0 {
+		defaultIndex = 0
+		beverage.Sizes[0].IsDefault = true
+	}
+
+	def := beverage.Sizes[defaultIndex]
+	beverage.Size = def.Label
+	beverage.Volume = def.VolumeML
+	beverage.CaffeineAmount = def.CaffeineAmount
+	return nil
+}
+
+// setDefaultSize : 기본 사이즈 변경 (다른 사이즈의 기본 표시 해제, 음료 필드 갱신)
+func setDefaultSize(tx *gorm.DB, beverage *models.Beverage, size models.BeverageSize) error {
+	if err := tx.Model(&models.BeverageSize{}).
+		Where("beverage_id = ? AND id <> ?", beverage.ID, size.ID).
+		Update("is_default", false).Error; err != nil {
+		return err
+	}
+	return tx.Model(beverage).Updates(defaultSizeFields(size)).Error
+}
+
+// setDefaultSizeWithRevision : 기본 사이즈 변경 후 음료 값이 바뀌었으면 변경 이력 남김
+func setDefaultSizeWithRevision(tx *gorm.DB, beverage *models.Beverage, size models.BeverageSize, userID uint) error {
+	before := revisionFields(beverage)
+	if err := setDefaultSize(tx, beverage, size); err != nil {
+		return err
+	}
+	meta := BeverageRevisionMeta{AuthorID: userID, Source: RevisionSourceSize, Reason: "기본 사이즈: " + size.Label}
+	return recordBeverageRevision(tx, beverage.ID, before, defaultSizeFields(size), meta)
+}
+
+// defaultSizeFields : 기본 사이즈가 채우는 음료 필드
+func defaultSizeFields(size models.BeverageSize) map[string]interface{} {
+	return map[string]interface{}{
+		"size":            size.Label,
+		"volume":          size.VolumeML,
+		"caffeine_amount": size.CaffeineAmount,
+	}
+}
+
+// validateBeverageSize : 사이즈 입력값 검증
+func validateBeverageSize(size models.BeverageSize) error {
+	switch {
+	case strings.TrimSpace(size.Label) == "":
+		return fmt.Errorf("사이즈 이름(label)이 필요합니다")
+	case size.VolumeML < 0:
+		return fmt.Errorf("용량은 0 이상이어야 합니다")
+	case size.CaffeineAmount < 0 || size.CaffeineAmount > maxCaffeineAmountMG:
+		return fmt.Errorf("카페인 함량은 0~%dmg 사이여야 합니다", maxCaffeineAmountMG)
+	case size.Shots < 0:
+		return fmt.Errorf("샷 수는 0 이상이어야 합니다")
+	}
+	return nil
+}
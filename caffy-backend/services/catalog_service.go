@@ -0,0 +1,739 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ========================================
+// 음료 카탈로그 가져오기/내보내기 (CSV, JSON)
+// 프랜차이즈 메뉴(스타벅스, 이디야, 메가커피 등)를 한 번에 등록
+// 음료 이름 기준 upsert: 없으면 생성, 있으면 다른 값만 갱신 (같은 파일을 다시 넣어도 변화 없음)
+// ========================================
+
+var (
+	ErrUnknownCatalogFormat = errors.New("지원하지 않는 카탈로그 형식입니다 (csv, json)")
+	ErrInvalidCatalog       = errors.New("카탈로그 파일을 읽을 수 없습니다")
+)
+
+// 카탈로그 형식
+const (
+	CatalogFormatCSV  = "csv"
+	CatalogFormatJSON = "json"
+)
+
+// 행별 처리 결과
+const (
+	CatalogRowCreated   = "created"
+	CatalogRowUpdated   = "updated"
+	CatalogRowUnchanged = "unchanged"
+	CatalogRowError     = "error"
+)
+
+// catalogListSeparator : CSV 한 칸에 여러 값을 넣을 때 구분자 (별칭, 바코드)
+const catalogListSeparator = "|"
+
+// catalogCSVHeader : CSV 열 (사이즈 하나당 한 줄, 같은 이름의 줄은 한 음료로 합침)
+var catalogCSVHeader = []string{"name", "brand", "category", "verified", "size", "volume_ml", "caffeine_mg", "shots", "is_default", "aliases", "barcodes"}
+
+// errCatalogDryRun : 미리보기 실행 후 트랜잭션을 되돌리기 위한 내부 에러
+var errCatalogDryRun = errors.New("dry run")
+
+// CatalogSize : 카탈로그 음료 사이즈
+type CatalogSize struct {
+	Label          string  `json:"label"`
+	VolumeML       float64 `json:"volume_ml"`
+	CaffeineAmount float64 `json:"caffeine_amount"`
+	Shots          int     `json:"shots,omitempty"`
+	IsDefault      bool    `json:"is_default,omitempty"`
+}
+
+// CatalogItem : 카탈로그 음료 한 개 (사이즈, 별칭, 바코드 포함)
+type CatalogItem struct {
+	Name       string        `json:"name"`
+	Brand      string        `json:"brand"`
+	Category   string        `json:"category"`
+	IsVerified *bool         `json:"is_verified,omitempty"` // 비우면 검증됨 (공식 메뉴 기준)
+	Sizes      []CatalogSize `json:"sizes"`
+	Aliases    []string      `json:"aliases,omitempty"`
+	Barcodes   []string      `json:"barcodes,omitempty"`
+
+	row      int      // 파일에서의 위치 (CSV는 첫 줄 번호, JSON은 순번)
+	problems []string // 파일을 읽으면서 발견한 문제
+}
+
+// CatalogImportOptions : 가져오기 옵션
+type CatalogImportOptions struct {
+	DryRun bool // 검증과 변경 내용만 보고하고 저장하지 않음
+	UserID uint // 별칭 등록자로 기록 (0: 시스템)
+}
+
+// CatalogRowResult : 음료별 처리 결과
+type CatalogRowResult struct {
+	Row        int      `json:"row"`
+	Name       string   `json:"name"`
+	Status     string   `json:"status"` // "created", "updated", "unchanged", "error"
+	BeverageID uint     `json:"beverage_id,omitempty"`
+	Changes    []string `json:"changes,omitempty"` // 예: ["brand", "size:Tall", "alias:아아", "barcode:8801234567893"]
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// CatalogImportReport : 가져오기 결과
+type CatalogImportReport struct {
+	DryRun    bool               `json:"dry_run"`
+	Total     int                `json:"total"`
+	Created   int                `json:"created"`
+	Updated   int                `json:"updated"`
+	Unchanged int                `json:"unchanged"`
+	Failed    int                `json:"failed"`
+	Rows      []CatalogRowResult `json:"rows"`
+}
+
+// CatalogFormatFromName : 파일 이름(확장자)이나 형식 이름으로 카탈로그 형식 판별
+func CatalogFormatFromName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch {
+	case name == CatalogFormatCSV || strings.HasSuffix(name, ".csv") || strings.Contains(name, "text/csv"):
+		return CatalogFormatCSV
+	case name == CatalogFormatJSON || strings.HasSuffix(name, ".json") || strings.Contains(name, "application/json"):
+		return CatalogFormatJSON
+	}
+	return ""
+}
+
+// ParseCatalog : 형식에 맞게 카탈로그 파일 읽기
+func ParseCatalog(r io.Reader, format string) ([]CatalogItem, error) {
+	switch format {
+	case CatalogFormatCSV:
+		return ParseCatalogCSV(r)
+	case CatalogFormatJSON:
+		return ParseCatalogJSON(r)
+	}
+	return nil, ErrUnknownCatalogFormat
+}
+
+// ParseCatalogJSON : JSON 카탈로그 읽기 (음료 배열 또는 {"beverages": [...]})
+func ParseCatalogJSON(r io.Reader) ([]CatalogItem, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCatalog, err)
+	}
+
+	var items []CatalogItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		var wrapped struct {
+			Beverages []CatalogItem `json:"beverages"`
+		}
+		if err2 := json.Unmarshal(data, &wrapped); err2 != nil || wrapped.Beverages == nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCatalog, err)
+		}
+		items = wrapped.Beverages
+	}
+
+	for i := range items {
+		items[i].row = i + 1
+	}
+	return items, nil
+}
+
+// ParseCatalogCSV : CSV 카탈로그 읽기
+// 사이즈 하나당 한 줄이고 같은 이름의 줄은 한 음료로 합침 (별칭/바코드는 "|"로 구분)
+func ParseCatalogCSV(r io.Reader) ([]CatalogItem, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: 헤더 줄이 없습니다", ErrInvalidCatalog)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) // 엑셀 BOM
+		if !isCatalogColumn(name) {
+			return nil, fmt.Errorf("%w: 알 수 없는 열 %q (사용 가능: %s)", ErrInvalidCatalog, name, strings.Join(catalogCSVHeader, ", "))
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("%w: name 열이 필요합니다", ErrInvalidCatalog)
+	}
+
+	var items []CatalogItem
+	byName := make(map[string]int)
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("%w: %d번째 줄: %v", ErrInvalidCatalog, line, err)
+		}
+		cell := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		name := cell("name")
+		if name == "" && strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue // 빈 줄
+		}
+
+		index, ok := byName[name]
+		if !ok || name == "" {
+			index = len(items)
+			byName[name] = index
+			items = append(items, CatalogItem{Name: name, row: line})
+		}
+		item := &items[index]
+		item.mergeCSVRow(line, cell)
+	}
+	return items, nil
+}
+
+// mergeCSVRow : CSV 한 줄을 음료에 합침 (숫자 오류 등은 행 문제로 기록)
+func (item *CatalogItem) mergeCSVRow(line int, cell func(string) string) {
+	problem := func(format string, args ...interface{}) {
+		item.problems = append(item.problems, fmt.Sprintf("%d번째 줄: ", line)+fmt.Sprintf(format, args...))
+	}
+	mergeText := func(field *string, column, value string) {
+		switch {
+		case value == "":
+		case *field == "":
+			*field = value
+		case *field != value:
+			problem("%s 값이 같은 음료의 다른 줄과 다릅니다 (%q, %q)", column, *field, value)
+		}
+	}
+	number := func(column string) float64 {
+		value := cell(column)
+		if value == "" {
+			return 0
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			problem("%s는 숫자여야 합니다 (%q)", column, value)
+		}
+		return f
+	}
+	flag := func(column string) (bool, bool) {
+		value := strings.ToLower(cell(column))
+		switch value {
+		case "":
+			return false, false
+		case "true", "1", "y", "yes", "o":
+			return true, true
+		case "false", "0", "n", "no", "x":
+			return false, true
+		}
+		problem("%s는 true 또는 false여야 합니다 (%q)", column, value)
+		return false, false
+	}
+
+	mergeText(&item.Brand, "brand", cell("brand"))
+	mergeText(&item.Category, "category", cell("category"))
+	if verified, ok := flag("verified"); ok {
+		if item.IsVerified != nil && *item.IsVerified != verified {
+			problem("verified 값이 같은 음료의 다른 줄과 다릅니다")
+		}
+		item.IsVerified = &verified
+	}
+
+	size := CatalogSize{
+		Label:          cell("size"),
+		VolumeML:       number("volume_ml"),
+		CaffeineAmount: number("caffeine_mg"),
+		Shots:          int(number("shots")),
+	}
+	size.IsDefault, _ = flag("is_default")
+	if size.Label != "" || cell("caffeine_mg") != "" {
+		if size.Label == "" {
+			size.Label = defaultSizeLabel
+		}
+		item.Sizes = append(item.Sizes, size)
+	}
+
+	item.Aliases = append(item.Aliases, splitCatalogList(cell("aliases"))...)
+	item.Barcodes = append(item.Barcodes, splitCatalogList(cell("barcodes"))...)
+}
+
+func isCatalogColumn(name string) bool {
+	for _, column := range catalogCSVHeader {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+func splitCatalogList(value string) []string {
+	var list []string
+	for _, v := range strings.Split(value, catalogListSeparator) {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// ImportCatalog : 카탈로그 가져오기
+// 음료마다 별도 트랜잭션이라 한 음료가 실패해도 나머지는 저장됨
+// DryRun이면 전체를 하나의 트랜잭션에서 실제로 실행한 뒤 되돌려서, 저장할 때와 같은 결과를 보고
+func ImportCatalog(items []CatalogItem, opts CatalogImportOptions) (*CatalogImportReport, error) {
+	report := &CatalogImportReport{DryRun: opts.DryRun, Total: len(items), Rows: make([]CatalogRowResult, 0, len(items))}
+
+	run := func(db *gorm.DB) {
+		seen := make(map[string]int)
+		for i := range items {
+			item := &items[i]
+			row := CatalogRowResult{Row: item.row, Name: item.Name}
+			if item.row == 0 {
+				row.Row = i + 1
+			}
+
+			row.Errors = validateCatalogItem(item)
+			if first, ok := seen[strings.TrimSpace(item.Name)]; ok && item.Name != "" {
+				row.Errors = append(row.Errors, fmt.Sprintf("파일 안에서 음료 이름이 중복됩니다 (%d번째와 같음)", first))
+			}
+			seen[strings.TrimSpace(item.Name)] = row.Row
+
+			if len(row.Errors) == 0 {
+				err := db.Transaction(func(tx *gorm.DB) error {
+					return importCatalogItem(tx, item, opts, &row)
+				})
+				if err != nil {
+					row.Errors = append(row.Errors, err.Error())
+				}
+			}
+
+			if len(row.Errors) > 0 {
+				row.Status, row.Changes = CatalogRowError, nil
+			}
+			switch row.Status {
+			case CatalogRowCreated:
+				report.Created++
+			case CatalogRowUpdated:
+				report.Updated++
+			case CatalogRowUnchanged:
+				report.Unchanged++
+			default:
+				report.Failed++
+			}
+			report.Rows = append(report.Rows, row)
+		}
+	}
+
+	if opts.DryRun {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			run(tx)
+			return errCatalogDryRun
+		})
+		if err != nil && !errors.Is(err, errCatalogDryRun) {
+			return nil, err
+		}
+		return report, nil
+	}
+
+	run(config.DB)
+	if report.Created+report.Updated > 0 {
+		InvalidateBeverageAliasCache()
+		println("📦 카탈로그 가져오기:", report.Created, "개 생성,", report.Updated, "개 갱신,", report.Failed, "개 실패")
+	}
+	return report, nil
+}
+
+// validateCatalogItem : DB를 보기 전에 확인할 수 있는 문제 (형식, 범위, 중복)
+func validateCatalogItem(item *CatalogItem) []string {
+	problems := append([]string{}, item.problems...)
+
+	item.Name = strings.TrimSpace(item.Name)
+	item.Brand = strings.TrimSpace(item.Brand)
+	item.Category = strings.TrimSpace(item.Category)
+	switch {
+	case item.Name == "":
+		problems = append(problems, "음료 이름(name)이 필요합니다")
+	case len([]rune(item.Name)) > 255:
+		problems = append(problems, "음료 이름은 255자 이하여야 합니다")
+	}
+	if len([]rune(item.Brand)) > 100 {
+		problems = append(problems, "브랜드는 100자 이하여야 합니다")
+	}
+	// 비워두면 새 음료는 "기타", 기존 음료는 저장된 분류를 그대로 둠
+	if item.Category != "" && !isDrinkCategory(item.Category) {
+		problems = append(problems, fmt.Sprintf("category는 %s 중 하나여야 합니다 (%q)", strings.Join(drinkCategories, ", "), item.Category))
+	}
+
+	labels := make(map[string]bool)
+	defaults := 0
+	for _, size := range item.Sizes {
+		size.Label = strings.TrimSpace(size.Label)
+		if err := validateBeverageSize(models.BeverageSize{Label: size.Label, VolumeML: size.VolumeML, CaffeineAmount: size.CaffeineAmount, Shots: size.Shots}); err != nil {
+			problems = append(problems, fmt.Sprintf("사이즈 %q: %v", size.Label, err))
+			continue
+		}
+		key := normalizeSizeKey(size.Label, 0)
+		if labels[key] {
+			problems = append(problems, fmt.Sprintf("사이즈 %q가 중복됩니다", size.Label))
+		}
+		labels[key] = true
+		if size.IsDefault {
+			defaults++
+		}
+	}
+	if defaults > 1 {
+		problems = append(problems, "기본 사이즈(is_default)는 하나만 지정할 수 있습니다")
+	}
+
+	for i, raw := range item.Barcodes {
+		code, _, err := NormalizeBarcode(raw)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("바코드 %q: %v", raw, err))
+			continue
+		}
+		item.Barcodes[i] = code
+	}
+	for _, alias := range item.Aliases {
+		if n := len([]rune(aliasKey(alias))); n == 0 || n > 100 {
+			problems = append(problems, fmt.Sprintf("별칭 %q는 1~100자여야 합니다", alias))
+		}
+	}
+	return problems
+}
+
+// importCatalogItem : 음료 한 개 upsert (음료 → 사이즈 → 별칭 → 바코드)
+// 파일에 없는 사이즈/별칭/바코드는 지우지 않음
+func importCatalogItem(tx *gorm.DB, item *CatalogItem, opts CatalogImportOptions, row *CatalogRowResult) error {
+	verified := item.IsVerified == nil || *item.IsVerified
+
+	var beverage models.Beverage
+	var before map[string]interface{} // 변경 이력용 (새 음료면 nil)
+	err := tx.Unscoped().Where("name = ?", item.Name).First(&beverage).Error
+	if err == nil {
+		before = revisionFields(&beverage)
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if len(item.Sizes) == 0 {
+			return fmt.Errorf("새 음료는 사이즈가 최소 하나 필요합니다 (size, volume_ml, caffeine_mg)")
+		}
+		category := item.Category
+		if category == "" {
+			category = "기타"
+		}
+		beverage = models.Beverage{
+			Name:       item.Name,
+			Brand:      item.Brand,
+			Category:   category,
+			IsVerified: verified,
+			Status:     models.BeverageStatusActive,
+		}
+		if err := tx.Create(&beverage).Error; err != nil {
+			return err
+		}
+		row.Status = CatalogRowCreated
+	case err != nil:
+		return err
+	case beverage.MergedIntoID != nil:
+		return fmt.Errorf("음료 #%d로 병합된 이름입니다. 병합된 음료 이름으로 가져오세요", *beverage.MergedIntoID)
+	default:
+		updates := map[string]interface{}{}
+		if beverage.DeletedAt.Valid {
+			updates["deleted_at"] = nil
+			row.Changes = append(row.Changes, "restored")
+		}
+		if beverage.Status != models.BeverageStatusActive {
+			updates["status"] = models.BeverageStatusActive
+			row.Changes = append(row.Changes, "status")
+		}
+		if item.Brand != "" && beverage.Brand != item.Brand {
+			updates["brand"] = item.Brand
+			row.Changes = append(row.Changes, "brand")
+		}
+		if item.Category != "" && beverage.Category != item.Category {
+			updates["category"] = item.Category
+			row.Changes = append(row.Changes, "category")
+		}
+		if beverage.IsVerified != verified {
+			updates["is_verified"] = verified
+			row.Changes = append(row.Changes, "is_verified")
+		}
+		if len(updates) > 0 {
+			if err := tx.Unscoped().Model(&beverage).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+	}
+	row.BeverageID = beverage.ID
+
+	if err := importCatalogSizes(tx, &beverage, item.Sizes, row); err != nil {
+		return err
+	}
+	if err := importCatalogAliases(tx, &beverage, item.Aliases, opts.UserID, row); err != nil {
+		return err
+	}
+	if err := importCatalogBarcodes(tx, &beverage, item.Barcodes, row); err != nil {
+		return err
+	}
+
+	// 음료 값(기본 사이즈 포함)이 바뀌었으면 변경 이력 남김
+	if err := tx.First(&beverage, beverage.ID).Error; err != nil {
+		return err
+	}
+	meta := BeverageRevisionMeta{AuthorID: opts.UserID, Source: RevisionSourceCatalog, Reason: "카탈로그 가져오기"}
+	if err := recordBeverageRevision(tx, beverage.ID, before, revisionFields(&beverage), meta); err != nil {
+		return err
+	}
+
+	if row.Status == "" {
+		row.Status = CatalogRowUnchanged
+		if len(row.Changes) > 0 {
+			row.Status = CatalogRowUpdated
+		}
+	}
+	return nil
+}
+
+// importCatalogSizes : 사이즈를 이름 기준으로 upsert하고 기본 사이즈를 맞춤
+func importCatalogSizes(tx *gorm.DB, beverage *models.Beverage, sizes []CatalogSize, row *CatalogRowResult) error {
+	var existing []models.BeverageSize
+	if err := tx.Where("beverage_id = ?", beverage.ID).Find(&existing).Error; err != nil {
+		return err
+	}
+	// 아래에서 새 사이즈를 덧붙여도 포인터가 유효하도록 미리 용량 확보
+	existing = append(make([]models.BeverageSize, 0, len(existing)+len(sizes)), existing...)
+
+	var def *models.BeverageSize
+	for i := range existing {
+		if existing[i].IsDefault {
+			def = &existing[i]
+		}
+	}
+
+	for i, input := range sizes {
+		label := strings.TrimSpace(input.Label)
+		size := findSizeByLabel(existing, label)
+		switch {
+		case size == nil:
+			existing = append(existing, models.BeverageSize{
+				BeverageID:     beverage.ID,
+				Label:          label,
+				VolumeML:       input.VolumeML,
+				CaffeineAmount: input.CaffeineAmount,
+				Shots:          input.Shots,
+			})
+			size = &existing[len(existing)-1]
+			if err := tx.Create(size).Error; err != nil {
+				return err
+			}
+			row.Changes = append(row.Changes, "size:"+label)
+		case size.Label != label || size.VolumeML != input.VolumeML || size.CaffeineAmount != input.CaffeineAmount || size.Shots != input.Shots:
+			size.Label = label
+			size.VolumeML = input.VolumeML
+			size.CaffeineAmount = input.CaffeineAmount
+			size.Shots = input.Shots
+			if err := tx.Save(size).Error; err != nil {
+				return err
+			}
+			row.Changes = append(row.Changes, "size:"+label)
+		}
+
+		// 파일에서 지정한 기본 사이즈, 지정이 없고 기본 사이즈도 없으면 첫 사이즈
+		if input.IsDefault || (def == nil && i == 0 && !anyDefaultCatalogSize(sizes)) {
+			def = size
+		}
+	}
+
+	if def == nil {
+		return nil
+	}
+	if !def.IsDefault || beverage.Size != def.Label || beverage.Volume != def.VolumeML || beverage.CaffeineAmount != def.CaffeineAmount {
+		def.IsDefault = true
+		if err := tx.Model(def).Update("is_default", true).Error; err != nil {
+			return err
+		}
+		if err := setDefaultSize(tx, beverage, *def); err != nil {
+			return err
+		}
+		if row.Status != CatalogRowCreated {
+			row.Changes = append(row.Changes, "default_size:"+def.Label)
+		}
+	}
+	return nil
+}
+
+func anyDefaultCatalogSize(sizes []CatalogSize) bool {
+	for _, size := range sizes {
+		if size.IsDefault {
+			return true
+		}
+	}
+	return false
+}
+
+// importCatalogAliases : 음료 별칭 추가 (삭제했던 별칭도 카탈로그에 있으면 되살림)
+func importCatalogAliases(tx *gorm.DB, beverage *models.Beverage, aliases []string, userID uint, row *CatalogRowResult) error {
+	for _, raw := range aliases {
+		raw = strings.TrimSpace(raw)
+		added, err := ensureBeverageAlias(tx, beverage.ID, raw, userID)
+		if err != nil {
+			return err
+		}
+		if added {
+			row.Changes = append(row.Changes, "alias:"+raw)
+		}
+	}
+	return nil
+}
+
+// importCatalogBarcodes : 바코드 추가 (다른 음료에 등록된 바코드면 실패)
+func importCatalogBarcodes(tx *gorm.DB, beverage *models.Beverage, codes []string, row *CatalogRowResult) error {
+	for _, raw := range codes {
+		code, format, err := NormalizeBarcode(raw)
+		if err != nil {
+			return fmt.Errorf("바코드 %q: %w", raw, err)
+		}
+
+		var barcode models.BeverageBarcode
+		err = tx.Unscoped().Where("code = ?", code).First(&barcode).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			barcode = models.BeverageBarcode{BeverageID: beverage.ID, Code: code, Format: format}
+			if err := tx.Create(&barcode).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case barcode.DeletedAt.Valid:
+			if err := tx.Unscoped().Model(&barcode).Updates(map[string]interface{}{"deleted_at": nil, "beverage_id": beverage.ID}).Error; err != nil {
+				return err
+			}
+		case barcode.BeverageID != beverage.ID:
+			return fmt.Errorf("바코드 %s: %w (음료 ID %d)", code, ErrBarcodeTaken, barcode.BeverageID)
+		default:
+			continue
+		}
+		row.Changes = append(row.Changes, "barcode:"+code)
+	}
+	return nil
+}
+
+// ExportCatalog : 활성 음료 카탈로그를 파일로 내보내기 (가져오기와 같은 형식)
+func ExportCatalog(w io.Writer, format string) (int, error) {
+	if format != CatalogFormatCSV && format != CatalogFormatJSON {
+		return 0, ErrUnknownCatalogFormat
+	}
+
+	var beverages []models.Beverage
+	if err := config.DB.Preload("Sizes").Preload("Barcodes").
+		Where("status = ?", models.BeverageStatusActive).
+		Order("brand ASC, name ASC").
+		Find(&beverages).Error; err != nil {
+		return 0, err
+	}
+
+	var aliases []models.BeverageAlias
+	if err := config.DB.Where("target_type = ?", models.AliasTargetBeverage).
+		Order("normalized ASC").
+		Find(&aliases).Error; err != nil {
+		return 0, err
+	}
+	aliasesByBeverage := make(map[uint][]string)
+	for _, alias := range aliases {
+		if alias.BeverageID != nil {
+			aliasesByBeverage[*alias.BeverageID] = append(aliasesByBeverage[*alias.BeverageID], alias.Alias)
+		}
+	}
+
+	items := make([]CatalogItem, 0, len(beverages))
+	for i := range beverages {
+		items = append(items, catalogItemFromBeverage(&beverages[i], aliasesByBeverage[beverages[i].ID]))
+	}
+
+	if format == CatalogFormatJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return len(items), encoder.Encode(items)
+	}
+	return len(items), writeCatalogCSV(w, items)
+}
+
+// catalogItemFromBeverage : 음료를 카탈로그 형식으로 변환
+func catalogItemFromBeverage(beverage *models.Beverage, aliases []string) CatalogItem {
+	verified := beverage.IsVerified
+	item := CatalogItem{
+		Name:       beverage.Name,
+		Brand:      beverage.Brand,
+		Category:   beverage.Category,
+		IsVerified: &verified,
+		Aliases:    aliases,
+	}
+
+	sizes := beverage.Sizes
+	if len(sizes) == 0 && (beverage.CaffeineAmount > 0 || beverage.Volume > 0) {
+		sizes = []models.BeverageSize{defaultSizeFromBeverage(beverage)}
+	}
+	sortSizesByVolume(sizes)
+	for _, size := range sizes {
+		item.Sizes = append(item.Sizes, CatalogSize{
+			Label:          size.Label,
+			VolumeML:       size.VolumeML,
+			CaffeineAmount: size.CaffeineAmount,
+			Shots:          size.Shots,
+			IsDefault:      size.IsDefault,
+		})
+	}
+	for _, barcode := range beverage.Barcodes {
+		item.Barcodes = append(item.Barcodes, barcode.Code)
+	}
+	return item
+}
+
+// writeCatalogCSV : 사이즈 하나당 한 줄 (별칭/바코드는 음료의 첫 줄에만)
+func writeCatalogCSV(w io.Writer, items []CatalogItem) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(catalogCSVHeader); err != nil {
+		return err
+	}
+
+	formatNumber := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	for _, item := range items {
+		sizes := item.Sizes
+		if len(sizes) == 0 {
+			sizes = []CatalogSize{{}}
+		}
+		for i, size := range sizes {
+			record := []string{
+				item.Name, item.Brand, item.Category, strconv.FormatBool(item.IsVerified == nil || *item.IsVerified),
+				size.Label, formatNumber(size.VolumeML), formatNumber(size.CaffeineAmount), strconv.Itoa(size.Shots), strconv.FormatBool(size.IsDefault),
+				"", "",
+			}
+			if size.Label == "" {
+				record[5], record[6], record[7], record[8] = "", "", "", ""
+			}
+			if i == 0 {
+				record[9] = strings.Join(item.Aliases, catalogListSeparator)
+				record[10] = strings.Join(item.Barcodes, catalogListSeparator)
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
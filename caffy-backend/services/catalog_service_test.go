@@ -0,0 +1,84 @@
+package services
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestCatalogCSVRoundTrip(t *testing.T) {
+	verified, unverified := true, false
+	items := []CatalogItem{
+		{
+			Name: "아메리카노", Brand: "스타벅스", Category: "커피", IsVerified: &verified,
+			Sizes: []CatalogSize{
+				{Label: "Tall", VolumeML: 355, CaffeineAmount: 150, Shots: 2, IsDefault: true},
+				{Label: "Grande", VolumeML: 473, CaffeineAmount: 225, Shots: 3},
+			},
+			Aliases:  []string{"스벅 아메리카노", "starbucks americano"},
+			Barcodes: []string{"8801234567893"},
+		},
+		{
+			// 분류가 비어 있어도 그대로 비어 있어야 함 ("기타"로 바뀌면 안 됨)
+			Name: "콜드브루, 디카페인", Brand: "", Category: "", IsVerified: &unverified,
+			Sizes: []CatalogSize{{Label: "기본", VolumeML: 500, CaffeineAmount: 12.5}},
+		},
+		{
+			// 사이즈 없이 별칭만 있는 음료
+			Name: "레드불", Brand: "레드불", Category: "에너지드링크", IsVerified: &verified,
+			Aliases: []string{"red bull"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeCatalogCSV(&buf, items); err != nil {
+		t.Fatalf("writeCatalogCSV: %v", err)
+	}
+	parsed, err := ParseCatalogCSV(&buf)
+	if err != nil {
+		t.Fatalf("ParseCatalogCSV: %v", err)
+	}
+	if len(parsed) != len(items) {
+		t.Fatalf("음료 수 = %d, want %d", len(parsed), len(items))
+	}
+
+	for i := range parsed {
+		if len(parsed[i].problems) > 0 {
+			t.Errorf("%s: 읽기 문제 %v", parsed[i].Name, parsed[i].problems)
+		}
+		if problems := validateCatalogItem(&parsed[i]); len(problems) > 0 {
+			t.Errorf("%s: 검증 문제 %v", parsed[i].Name, problems)
+		}
+		parsed[i].row, parsed[i].problems = 0, nil
+		if !reflect.DeepEqual(parsed[i], items[i]) {
+			t.Errorf("왕복 후 달라짐\n got  %+v\n want %+v", parsed[i], items[i])
+		}
+	}
+}
+
+func TestValidateCatalogItemCategory(t *testing.T) {
+	tests := []struct {
+		name         string
+		category     string
+		wantCategory string
+		wantProblem  bool
+	}{
+		{name: "비어 있으면 그대로 (기존 분류 유지)", category: "", wantCategory: ""},
+		{name: "공백은 비어 있는 것으로", category: "  ", wantCategory: ""},
+		{name: "알려진 분류", category: " 커피 ", wantCategory: "커피"},
+		{name: "모르는 분류", category: "스무디", wantCategory: "스무디", wantProblem: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := CatalogItem{Name: "테스트 음료", Category: tt.category}
+			problems := validateCatalogItem(&item)
+			if item.Category != tt.wantCategory {
+				t.Errorf("Category = %q, want %q", item.Category, tt.wantCategory)
+			}
+			if (len(problems) > 0) != tt.wantProblem {
+				t.Errorf("problems = %v, wantProblem %v", problems, tt.wantProblem)
+			}
+		})
+	}
+}
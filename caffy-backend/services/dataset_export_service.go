@@ -0,0 +1,583 @@
+package services
+
+import (
+	"bytes"
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"math/bits"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ========================================
+// 학습용 데이터셋 내보내기
+// 확인된 라벨(사용자 확인/정정, 관리자 등록, "맞음" 피드백)이 달린 인식 이미지를
+// ImageFolder 형식(<split>/<음료>/<이미지>)과 labels.json으로 저장
+// 지각 해시(dHash)가 거의 같은 이미지는 항상 같은 split에 넣어 학습/평가 데이터가 섞이지 않게 함
+// ========================================
+
+// 데이터셋 split
+const (
+	DatasetSplitTrain = "train"
+	DatasetSplitVal   = "val"
+	DatasetSplitTest  = "test"
+)
+
+// datasetHashRadius : 지각 해시가 이 비트 수 이하로 다르면 같은 사진(크기 조정, 재압축 사본)으로 봄
+const datasetHashRadius = 5
+
+// datasetStagingDir : split을 정하기 전에 이미지를 내려받아 두는 폴더 (내보낸 폴더 안, 끝나면 삭제)
+const datasetStagingDir = ".staging"
+
+// datasetSplits : split 순서 (DatasetExportOptions.Ratios와 같은 순서)
+var datasetSplits = []string{DatasetSplitTrain, DatasetSplitVal, DatasetSplitTest}
+
+// 라벨 출처
+const (
+	DatasetLabelUser       = "user"       // 사용자가 확인하거나 정정한 캐시 이미지
+	DatasetLabelAdmin      = "admin"      // 관리자가 등록한 캐시 이미지
+	DatasetLabelFeedback   = "feedback"   // 인식 결과에 "맞음" 피드백을 받음
+	DatasetLabelCorrection = "correction" // 캐시에 없는 인식 로그의 사용자 정정
+)
+
+var (
+	ErrDatasetOutputNotEmpty = errors.New("내보낼 폴더가 비어 있지 않습니다")
+	ErrInvalidDatasetSplit   = errors.New("split 비율 형식 오류 (예: 0.8,0.1,0.1)")
+)
+
+// DatasetExportOptions : 데이터셋 내보내기 옵션
+type DatasetExportOptions struct {
+	OutDir    string
+	Ratios    [3]float64 // train, val, test 비율 (합이 1이 아니면 정규화)
+	Seed      uint64     // 같은 시드와 같은 데이터면 같은 split
+	MinImages int        // 이미지가 이보다 적은 음료는 제외
+	DryRun    bool       // 이미지를 저장하지 않고 split 결과만 계산
+}
+
+// DatasetClass : 데이터셋의 음료 (ImageFolder 클래스)
+type DatasetClass struct {
+	Dir            string         `json:"dir"` // 클래스 폴더 이름
+	BeverageID     uint           `json:"beverage_id"`
+	Name           string         `json:"name"`
+	Brand          string         `json:"brand"`
+	CaffeineAmount float64        `json:"caffeine_amount"`
+	Category       string         `json:"category"`
+	IsVerified     bool           `json:"is_verified"`
+	Images         map[string]int `json:"images"` // split별 이미지 수
+}
+
+// DatasetImage : 데이터셋 이미지 하나의 라벨
+type DatasetImage struct {
+	File           string  `json:"file"` // 데이터셋 폴더 기준 경로
+	Split          string  `json:"split"`
+	BeverageID     uint    `json:"beverage_id"`
+	DrinkName      string  `json:"drink_name"`
+	Brand          string  `json:"brand"`
+	CaffeineAmount float64 `json:"caffeine_amount"`
+	Category       string  `json:"category"`
+	LabelSource    string  `json:"label_source"` // 라벨 출처 (user, admin, feedback, correction)
+	ImageHash      string  `json:"image_hash"`
+	PerceptualHash string  `json:"perceptual_hash"` // dHash (16진수 64비트)
+
+	key    string // 저장소 키
+	phash  uint64
+	ext    string // 이미지 확장자
+	staged string // 내려받아 둔 임시 파일 (split이 정해지면 옮김)
+}
+
+// DatasetManifest : labels.json 내용
+type DatasetManifest struct {
+	CreatedAt time.Time          `json:"created_at"`
+	Seed      uint64             `json:"seed"`
+	Ratios    map[string]float64 `json:"ratios"`
+	Classes   []DatasetClass     `json:"classes"`
+	Images    []DatasetImage     `json:"images"`
+}
+
+// DatasetExportReport : 내보내기 결과
+type DatasetExportReport struct {
+	OutDir       string         `json:"out_dir"`
+	DryRun       bool           `json:"dry_run"`
+	Total        int            `json:"total"` // 내보낸 이미지 수
+	Classes      int            `json:"classes"`
+	Splits       map[string]int `json:"splits"`        // split별 이미지 수
+	Sources      map[string]int `json:"sources"`       // 라벨 출처별 이미지 수
+	SharedHashes int            `json:"shared_hashes"` // 지각 해시가 거의 같은 이미지가 2장 이상인 묶음 수 (같은 split으로 묶음)
+	Skipped      map[string]int `json:"skipped"`       // 제외 이유별 이미지 수
+}
+
+// ParseDatasetRatios : "0.8,0.1,0.1" 형식의 split 비율
+func ParseDatasetRatios(value string) ([3]float64, error) {
+	var ratios [3]float64
+	parts := strings.Split(value, ",")
+	if len(parts) != len(ratios) {
+		return ratios, ErrInvalidDatasetSplit
+	}
+	total := 0.0
+	for i, part := range parts {
+		ratio, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || ratio < 0 {
+			return ratios, ErrInvalidDatasetSplit
+		}
+		ratios[i] = ratio
+		total += ratio
+	}
+	if total <= 0 {
+		return ratios, ErrInvalidDatasetSplit
+	}
+	for i := range ratios {
+		ratios[i] /= total
+	}
+	return ratios, nil
+}
+
+// ExportTrainingDataset : 확인된 라벨의 인식 이미지를 ImageFolder 형식으로 내보내기
+func ExportTrainingDataset(opts DatasetExportOptions) (*DatasetExportReport, error) {
+	if opts.Ratios == ([3]float64{}) {
+		opts.Ratios = [3]float64{0.8, 0.1, 0.1}
+	}
+	if !opts.DryRun {
+		if entries, err := os.ReadDir(opts.OutDir); err == nil && len(entries) > 0 {
+			return nil, fmt.Errorf("%w: %s", ErrDatasetOutputNotEmpty, opts.OutDir)
+		}
+		if err := os.MkdirAll(filepath.Join(opts.OutDir, datasetStagingDir), 0755); err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(filepath.Join(opts.OutDir, datasetStagingDir))
+	}
+
+	report := &DatasetExportReport{
+		OutDir:  opts.OutDir,
+		DryRun:  opts.DryRun,
+		Splits:  map[string]int{},
+		Sources: map[string]int{},
+		Skipped: map[string]int{},
+	}
+
+	images, err := confirmedDatasetImages(report)
+	if err != nil {
+		return nil, err
+	}
+	classes, err := datasetClasses(images, report)
+	if err != nil {
+		return nil, err
+	}
+
+	// 이미지를 읽어 지각 해시 계산 (읽을 수 없는 이미지는 제외)
+	// 모든 이미지를 메모리에 두지 않도록 임시 폴더에 내려받아 둠
+	loaded := images[:0]
+	for _, item := range images {
+		if classes[item.BeverageID] == nil {
+			continue
+		}
+		data, err := GetImageData(item.key)
+		if err != nil {
+			report.Skipped["이미지 없음"]++
+			continue
+		}
+		decoded, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			report.Skipped["이미지 디코딩 실패"]++
+			continue
+		}
+		item.phash = dHash(decoded)
+		item.PerceptualHash = fmt.Sprintf("%016x", item.phash)
+		item.ext = datasetImageExt(data)
+		if !opts.DryRun {
+			item.staged = filepath.Join(opts.OutDir, datasetStagingDir, item.ImageHash+item.ext)
+			if err := os.WriteFile(item.staged, data, 0644); err != nil {
+				return nil, err
+			}
+		}
+		loaded = append(loaded, item)
+	}
+	images = loaded
+
+	// 이미지가 적은 음료 제외
+	counts := map[uint]int{}
+	for _, item := range images {
+		counts[item.BeverageID]++
+	}
+	kept := images[:0]
+	for _, item := range images {
+		if counts[item.BeverageID] < opts.MinImages {
+			report.Skipped["음료별 이미지 수 부족"]++
+			continue
+		}
+		kept = append(kept, item)
+	}
+	images = kept
+
+	report.SharedHashes = assignDatasetSplits(images, opts.Ratios, opts.Seed)
+
+	manifest := DatasetManifest{
+		CreatedAt: time.Now(),
+		Seed:      opts.Seed,
+		Ratios:    map[string]float64{},
+	}
+	for i, split := range datasetSplits {
+		manifest.Ratios[split] = opts.Ratios[i]
+	}
+
+	for i := range images {
+		item := &images[i]
+		class := classes[item.BeverageID]
+		class.Images[item.Split]++
+		item.File = filepath.ToSlash(filepath.Join(item.Split, class.Dir, item.ImageHash[:min(16, len(item.ImageHash))]+item.ext))
+		report.Splits[item.Split]++
+		report.Sources[item.LabelSource]++
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].File < images[j].File })
+
+	for _, class := range classes {
+		if len(class.Images) > 0 {
+			manifest.Classes = append(manifest.Classes, *class)
+		}
+	}
+	sort.Slice(manifest.Classes, func(i, j int) bool { return manifest.Classes[i].Dir < manifest.Classes[j].Dir })
+	manifest.Images = images
+	report.Total = len(images)
+	report.Classes = len(manifest.Classes)
+
+	if opts.DryRun {
+		return report, nil
+	}
+	if err := writeDataset(opts.OutDir, &manifest); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// confirmedDatasetImages : 확인된 라벨이 있는 이미지 목록 (이미지 해시당 하나)
+// 캐시 이미지는 마지막 피드백이 정정 없는 "틀림"이면 제외하고,
+// 캐시에 없는 이미지는 인식 로그의 사용자 확인/정정을 라벨로 사용
+func confirmedDatasetImages(report *DatasetExportReport) ([]DatasetImage, error) {
+	var cached []models.BeverageImage
+	if err := config.DB.
+		Where("beverage_id IS NOT NULL AND image_path <> '' AND confidence >= ?", MinCacheConfidence).
+		Order("id").
+		Find(&cached).Error; err != nil {
+		return nil, err
+	}
+
+	// 캐시 이미지별 마지막 피드백
+	var feedbackLogs []models.RecognitionLog
+	if err := config.DB.
+		Where("beverage_image_id IS NOT NULL AND is_correct IS NOT NULL").
+		Order("id").
+		Find(&feedbackLogs).Error; err != nil {
+		return nil, err
+	}
+	lastFeedback := map[uint]models.RecognitionLog{}
+	for _, log := range feedbackLogs {
+		lastFeedback[*log.BeverageImageID] = log
+	}
+
+	var images []DatasetImage
+	seen := map[string]bool{}
+	for _, cachedImage := range cached {
+		source := ""
+		feedback, hasFeedback := lastFeedback[cachedImage.ID]
+		switch {
+		case hasFeedback && !*feedback.IsCorrect && feedback.CorrectedID == nil:
+			report.Skipped["틀렸다는 피드백"]++
+			continue
+		case cachedImage.Source == "user":
+			source = DatasetLabelUser
+		case cachedImage.Source == "admin":
+			source = DatasetLabelAdmin
+		case hasFeedback && *feedback.IsCorrect:
+			source = DatasetLabelFeedback
+		default:
+			report.Skipped["확인되지 않은 라벨"]++
+			continue
+		}
+		seen[cachedImage.ImageHash] = true
+		images = append(images, DatasetImage{
+			BeverageID:  *cachedImage.BeverageID,
+			LabelSource: source,
+			ImageHash:   cachedImage.ImageHash,
+			key:         cachedImage.ImagePath,
+		})
+	}
+
+	// 캐시에 없는 이미지의 사용자 확인/정정 (같은 이미지는 마지막 피드백 사용)
+	var logs []models.RecognitionLog
+	if err := config.DB.
+		Where("beverage_image_id IS NULL AND image_path <> '' AND image_hash <> ''").
+		Where("corrected_id IS NOT NULL OR (is_correct = ? AND recognized_id IS NOT NULL)", true).
+		Order("id DESC").
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	for _, log := range logs {
+		if seen[log.ImageHash] {
+			continue
+		}
+		seen[log.ImageHash] = true
+		item := DatasetImage{ImageHash: log.ImageHash, key: log.ImagePath}
+		if log.CorrectedID != nil {
+			item.BeverageID = *log.CorrectedID
+			item.LabelSource = DatasetLabelCorrection
+		} else {
+			item.BeverageID = *log.RecognizedID
+			item.LabelSource = DatasetLabelFeedback
+		}
+		images = append(images, item)
+	}
+	return images, nil
+}
+
+// datasetClasses : 이미지 라벨을 병합 대상 음료로 바꾸고 음료 정보를 채움
+// 승인되지 않았거나 삭제된 음료의 이미지는 제외
+func datasetClasses(images []DatasetImage, report *DatasetExportReport) (map[uint]*DatasetClass, error) {
+	var merged []models.Beverage
+	if err := config.DB.Unscoped().Where("merged_into_id IS NOT NULL").Find(&merged).Error; err != nil {
+		return nil, err
+	}
+	mergedInto := map[uint]uint{}
+	for _, beverage := range merged {
+		mergedInto[beverage.ID] = *beverage.MergedIntoID
+	}
+
+	ids := map[uint]bool{}
+	for i := range images {
+		// 병합이 여러 번 이어졌을 수 있으므로 끝까지 따라감 (순환 방지로 횟수 제한)
+		for hops := 0; hops < 10; hops++ {
+			target, ok := mergedInto[images[i].BeverageID]
+			if !ok {
+				break
+			}
+			images[i].BeverageID = target
+		}
+		ids[images[i].BeverageID] = true
+	}
+
+	var beverageIDs []uint
+	for id := range ids {
+		beverageIDs = append(beverageIDs, id)
+	}
+	var beverages []models.Beverage
+	if len(beverageIDs) > 0 {
+		if err := config.DB.Where("id IN ? AND status = ?", beverageIDs, models.BeverageStatusActive).Find(&beverages).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	classes := map[uint]*DatasetClass{}
+	for _, beverage := range beverages {
+		classes[beverage.ID] = &DatasetClass{
+			Dir:            datasetClassDir(beverage),
+			BeverageID:     beverage.ID,
+			Name:           beverage.Name,
+			Brand:          beverage.Brand,
+			CaffeineAmount: beverage.CaffeineAmount,
+			Category:       beverage.Category,
+			IsVerified:     beverage.IsVerified,
+			Images:         map[string]int{},
+		}
+	}
+	for i := range images {
+		class := classes[images[i].BeverageID]
+		if class == nil {
+			report.Skipped["승인되지 않았거나 삭제된 음료"]++
+			continue
+		}
+		images[i].DrinkName = class.Name
+		images[i].Brand = class.Brand
+		images[i].CaffeineAmount = class.CaffeineAmount
+		images[i].Category = class.Category
+	}
+	return classes, nil
+}
+
+// datasetClassDir : 클래스 폴더 이름 (음료 ID + 파일 이름에 쓸 수 있는 음료 이름)
+func datasetClassDir(beverage models.Beverage) string {
+	name := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '/', '\\', ':', '*', '?', '"', '<', '>', '|', '.':
+			return '_'
+		}
+		return r
+	}, beverage.Name)
+	return fmt.Sprintf("%d_%s", beverage.ID, truncateRunes(name, 60))
+}
+
+// datasetImageExt : 이미지 내용에 맞는 확장자 (저장된 이미지는 대부분 JPEG)
+func datasetImageExt(data []byte) string {
+	switch http.DetectContentType(data) {
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	}
+	return ".jpg"
+}
+
+// assignDatasetSplits : 음료별 비율을 맞춰 split 배정 (지각 해시가 거의 같은 이미지는 한 묶음)
+// 묶음은 가장 많은 음료를 기준으로, 음료마다 목표 수보다 가장 부족한 split에 차례로 배정
+// 이미지가 2장 이상인 묶음 수를 반환
+func assignDatasetSplits(images []DatasetImage, ratios [3]float64, seed uint64) int {
+	groups := datasetHashGroups(images)
+
+	classGroups := map[uint][]int{}
+	classTotals := map[uint]int{}
+	shared := 0
+	for g, members := range groups {
+		if len(members) > 1 {
+			shared++
+		}
+		votes := map[uint]int{}
+		var class uint
+		for _, i := range members {
+			id := images[i].BeverageID
+			votes[id]++
+			if votes[id] > votes[class] || (votes[id] == votes[class] && id < class) {
+				class = id
+			}
+		}
+		classGroups[class] = append(classGroups[class], g)
+		classTotals[class] += len(members)
+	}
+
+	// 같은 시드면 같은 결과가 나오도록 음료 순서를 고정
+	classes := make([]uint, 0, len(classGroups))
+	for class := range classGroups {
+		classes = append(classes, class)
+	}
+	sort.Slice(classes, func(i, j int) bool { return classes[i] < classes[j] })
+
+	rng := rand.New(rand.NewPCG(seed, seed))
+	for _, class := range classes {
+		order := classGroups[class]
+		rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+
+		var assigned [3]int
+		for _, g := range order {
+			best, bestDeficit := -1, 0.0
+			for s, ratio := range ratios {
+				if ratio == 0 {
+					continue
+				}
+				deficit := ratio*float64(classTotals[class]) - float64(assigned[s])
+				if best < 0 || deficit > bestDeficit {
+					best, bestDeficit = s, deficit
+				}
+			}
+			assigned[best] += len(groups[g])
+			for _, i := range groups[g] {
+				images[i].Split = datasetSplits[best]
+			}
+		}
+	}
+	return shared
+}
+
+// datasetHashGroups : 지각 해시가 datasetHashRadius 이내인 이미지를 union-find로 묶음
+// A~B, B~C처럼 이어진 이미지도 한 묶음, 묶음은 가장 앞 이미지 순서로 정렬
+func datasetHashGroups(images []DatasetImage) [][]int {
+	parent := make([]int, len(images))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	for i := range images {
+		for j := i + 1; j < len(images); j++ {
+			if bits.OnesCount64(images[i].phash^images[j].phash) > datasetHashRadius {
+				continue
+			}
+			if a, b := find(i), find(j); a != b {
+				parent[max(a, b)] = min(a, b)
+			}
+		}
+	}
+
+	index := map[int]int{}
+	var groups [][]int
+	for i := range images {
+		root := find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
+
+// writeDataset : 내려받아 둔 이미지를 split/클래스 폴더로 옮기고 labels.json 저장
+func writeDataset(outDir string, manifest *DatasetManifest) error {
+	for _, item := range manifest.Images {
+		path := filepath.Join(outDir, filepath.FromSlash(item.File))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(item.staged, path); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outDir, "labels.json"), data, 0644)
+}
+
+// dHash : 차이 해시 (9x8로 줄인 흑백 이미지에서 가로로 이웃한 칸의 밝기 비교, 64비트)
+// 크기 조정이나 재압축에도 거의 바뀌지 않아 같은 사진의 사본을 찾는 데 사용
+func dHash(img image.Image) uint64 {
+	const w, h = 9, 8
+	bounds := img.Bounds()
+	dx, dy := bounds.Dx(), bounds.Dy()
+	if dx == 0 || dy == 0 {
+		return 0
+	}
+
+	var sums [h][w]float64
+	var counts [h][w]int
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		cy := (y - bounds.Min.Y) * h / dy
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			cx := (x - bounds.Min.X) * w / dx
+			sums[cy][cx] += luminance(img, x, y)
+			counts[cy][cx]++
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w-1; x++ {
+			hash <<= 1
+			left, right := sums[y][x], sums[y][x+1]
+			if counts[y][x] > 0 {
+				left /= float64(counts[y][x])
+			}
+			if counts[y][x+1] > 0 {
+				right /= float64(counts[y][x+1])
+			}
+			if left < right {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"math/bits"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func TestParseDatasetRatios(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  [3]float64
+	}{
+		{name: "합이 1", value: "0.8,0.1,0.1", want: [3]float64{0.8, 0.1, 0.1}},
+		{name: "정규화", value: "8, 1, 1", want: [3]float64{0.8, 0.1, 0.1}},
+		{name: "test 없음", value: "3,1,0", want: [3]float64{0.75, 0.25, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDatasetRatios(tt.value)
+			if err != nil {
+				t.Fatalf("ParseDatasetRatios(%q) error = %v", tt.value, err)
+			}
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Errorf("ParseDatasetRatios(%q) = %v, want %v", tt.value, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+
+	for _, value := range []string{"", "0.8,0.2", "0.8,0.1,0.1,0", "0.8,-0.1,0.3", "0.8,a,0.1", "0,0,0"} {
+		if _, err := ParseDatasetRatios(value); !errors.Is(err, ErrInvalidDatasetSplit) {
+			t.Errorf("ParseDatasetRatios(%q) error = %v, want ErrInvalidDatasetSplit", value, err)
+		}
+	}
+}
+
+// patternImage : 가로세로 물결무늬 흑백 이미지 (크기가 달라도 같은 무늬)
+func patternImage(w, h int, invert bool) image.Image {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fx, fy := float64(x)/float64(w), float64(y)/float64(h)
+			v := 128 + 100*math.Sin(fx*11)*math.Cos(fy*7)
+			if invert {
+				v = 255 - v
+			}
+			img.SetGray(x, y, color.Gray{Y: uint8(v)})
+		}
+	}
+	return img
+}
+
+func TestDHash(t *testing.T) {
+	original := dHash(patternImage(360, 320, false))
+	if original == 0 {
+		t.Fatal("dHash = 0, 무늬가 해시에 반영되지 않음")
+	}
+
+	// 크기를 바꾼 사본은 같은 사진으로 묶이는 거리 안
+	for _, size := range [][2]int{{180, 160}, {90, 80}, {720, 640}} {
+		resized := dHash(patternImage(size[0], size[1], false))
+		if d := bits.OnesCount64(original ^ resized); d > datasetHashRadius {
+			t.Errorf("%dx%d 사본 거리 = %d, want <= %d", size[0], size[1], d, datasetHashRadius)
+		}
+	}
+
+	// 밝기를 뒤집은 이미지는 다른 사진
+	if d := bits.OnesCount64(original ^ dHash(patternImage(360, 320, true))); d <= datasetHashRadius {
+		t.Errorf("반전 이미지 거리 = %d, want > %d", d, datasetHashRadius)
+	}
+
+	if got := dHash(image.NewGray(image.Rect(0, 0, 0, 0))); got != 0 {
+		t.Errorf("빈 이미지 dHash = %x, want 0", got)
+	}
+}
+
+// datasetImagesFor : 음료별 이미지 수만큼 서로 먼 지각 해시를 가진 이미지 목록
+func datasetImagesFor(counts map[uint]int) []DatasetImage {
+	rng := rand.New(rand.NewPCG(1, 2))
+	var images []DatasetImage
+	for id := uint(1); id <= uint(len(counts)); id++ {
+		for i := 0; i < counts[id]; i++ {
+			images = append(images, DatasetImage{BeverageID: id, phash: rng.Uint64()})
+		}
+	}
+	return images
+}
+
+func TestAssignDatasetSplitsStratified(t *testing.T) {
+	images := datasetImagesFor(map[uint]int{1: 100, 2: 50, 3: 20})
+	if shared := assignDatasetSplits(images, [3]float64{0.8, 0.1, 0.1}, 42); shared != 0 {
+		t.Fatalf("shared = %d, want 0", shared)
+	}
+
+	got := map[uint]map[string]int{}
+	for _, item := range images {
+		if got[item.BeverageID] == nil {
+			got[item.BeverageID] = map[string]int{}
+		}
+		got[item.BeverageID][item.Split]++
+	}
+	want := map[uint]map[string]int{
+		1: {DatasetSplitTrain: 80, DatasetSplitVal: 10, DatasetSplitTest: 10},
+		2: {DatasetSplitTrain: 40, DatasetSplitVal: 5, DatasetSplitTest: 5},
+		3: {DatasetSplitTrain: 16, DatasetSplitVal: 2, DatasetSplitTest: 2},
+	}
+	for id, splits := range want {
+		for split, count := range splits {
+			if diff := got[id][split] - count; diff < -1 || diff > 1 {
+				t.Errorf("음료 %d %s = %d, want %d±1", id, split, got[id][split], count)
+			}
+		}
+	}
+}
+
+func TestAssignDatasetSplitsZeroRatio(t *testing.T) {
+	images := datasetImagesFor(map[uint]int{1: 30, 2: 7})
+	assignDatasetSplits(images, [3]float64{0.75, 0.25, 0}, 7)
+	for _, item := range images {
+		if item.Split == DatasetSplitTest || item.Split == "" {
+			t.Fatalf("음료 %d split = %q, test 비율이 0이면 train/val만", item.BeverageID, item.Split)
+		}
+	}
+}
+
+func TestAssignDatasetSplitsNearDuplicates(t *testing.T) {
+	images := datasetImagesFor(map[uint]int{1: 40, 2: 40})
+	// 0~2번: 이웃끼리 5비트씩 다른 사본 (0번과 2번은 10비트 차이지만 1번을 통해 한 묶음)
+	// 3번: 다른 음료로 잘못 라벨된 0번의 사본
+	base := images[0].phash
+	images[1].phash = base ^ 0x1F
+	images[2].phash = base ^ 0x1F ^ (0x1F << 20)
+	images[3].phash = base ^ (1 << 40)
+	images[3].BeverageID = 2
+
+	for seed := uint64(0); seed < 20; seed++ {
+		if shared := assignDatasetSplits(images, [3]float64{0.6, 0.2, 0.2}, seed); shared != 1 {
+			t.Fatalf("seed %d: shared = %d, want 1", seed, shared)
+		}
+		for _, i := range []int{1, 2, 3} {
+			if images[i].Split != images[0].Split {
+				t.Errorf("seed %d: 이미지 %d split = %s, 이미지 0 = %s", seed, i, images[i].Split, images[0].Split)
+			}
+		}
+	}
+
+	// 어떤 묶음도 두 split에 걸치지 않음
+	groups := datasetHashGroups(images)
+	for _, members := range groups {
+		for _, i := range members[1:] {
+			if images[i].Split != images[members[0]].Split {
+				t.Errorf("묶음 %v가 여러 split에 걸침", members)
+				break
+			}
+		}
+	}
+}
+
+func TestAssignDatasetSplitsDeterministic(t *testing.T) {
+	first := datasetImagesFor(map[uint]int{1: 30, 2: 30, 3: 30})
+	second := datasetImagesFor(map[uint]int{1: 30, 2: 30, 3: 30})
+	assignDatasetSplits(first, [3]float64{0.8, 0.1, 0.1}, 99)
+	assignDatasetSplits(second, [3]float64{0.8, 0.1, 0.1}, 99)
+	for i := range first {
+		if first[i].Split != second[i].Split {
+			t.Fatalf("같은 시드인데 이미지 %d split이 다름: %s, %s", i, first[i].Split, second[i].Split)
+		}
+	}
+}
+
+func TestDatasetHashGroups(t *testing.T) {
+	images := []DatasetImage{
+		{phash: 0},
+		{phash: math.MaxUint64},
+		{phash: 0b111},                 // 0번과 3비트
+		{phash: math.MaxUint64 ^ 0b11}, // 1번과 2비트
+		{phash: 0xFF00},                // 누구와도 8비트 이상
+	}
+	got := datasetHashGroups(images)
+	want := [][]int{{0, 2}, {1, 3}, {4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("datasetHashGroups = %v, want %v", got, want)
+	}
+}
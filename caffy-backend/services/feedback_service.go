@@ -0,0 +1,392 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========================================
+// 인식 피드백 반영 서비스
+// ========================================
+
+const (
+	MinCacheConfidence      = 0.2 // 이 신뢰도 미만인 캐시 이미지는 재인식
+	ContradictionPenalty    = 0.5 // "틀림" 피드백 시 신뢰도 감소 비율
+	ConfirmationBonus       = 0.1 // "맞음" 피드백 시 신뢰도 증가량
+	UserCorrectedConfidence = 0.6 // 사용자가 정정한 이미지의 신뢰도
+)
+
+var (
+	ErrRecognitionLogNotFound = errors.New("인식 로그를 찾을 수 없습니다")
+	ErrBeverageNotFound       = errors.New("음료를 찾을 수 없습니다")
+	ErrInvalidCandidate       = errors.New("인식 후보를 찾을 수 없습니다")
+)
+
+// FeedbackResult : 피드백 반영 결과
+type FeedbackResult struct {
+	ImageID          *uint   `json:"image_id,omitempty"`    // 반영된 캐시 이미지 ID
+	ImageConfidence  float64 `json:"image_confidence"`      // 반영 후 이미지 신뢰도
+	BeverageID       *uint   `json:"beverage_id,omitempty"` // 확인/정정된 음료 ID
+	Confirmations    int64   `json:"confirmations"`         // 음료의 독립 확인 수
+	BeveragePromoted bool    `json:"beverage_promoted"`     // 이번 피드백으로 검증됨으로 승격되었는지
+	UpdatedLogs      int64   `json:"updated_logs"`          // 정정이 반영된 섭취 기록 수
+}
+
+// ApplyRecognitionFeedback : 인식 결과 피드백을 로그, 캐시 이미지, 음료 카탈로그에 반영
+// candidate는 인식 후보 중 사용자가 고른 번호 (0이면 대표 결과 확인, 그 외는 해당 후보로 정정)
+// 캐시에 저장하지 않은 인식 결과는 사용자가 확인하거나 정정하면 그때 캐시에 저장
+func ApplyRecognitionFeedback(userID uint, logID uint, isCorrect bool, correctedID *uint, candidate *int) (*FeedbackResult, error) {
+	correctedID = resolveBeverageRef(correctedID) // 병합된 음료로 정정하면 병합 대상으로
+	result := &FeedbackResult{}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var log models.RecognitionLog
+		if err := tx.Where("id = ? AND user_id = ?", logID, userID).First(&log).Error; err != nil {
+			return ErrRecognitionLogNotFound
+		}
+
+		var picked *RecognitionCandidate
+		if candidate != nil {
+			var err error
+			if picked, err = pickRecognitionCandidate(recognitionCandidates(&log), *candidate); err != nil {
+				return err
+			}
+			isCorrect = *candidate == 0
+			if !isCorrect {
+				correctedID = picked.BeverageID
+			}
+		}
+
+		// 정정 대상은 승인된 음료 또는 본인의 승인 대기 음료만
+		// 승인 대기 음료로 정정하면 본인 기록에만 반영하고 공유 캐시에는 "틀림"으로만 반영
+		var corrected, sharedCorrection *models.Beverage
+		if !isCorrect && correctedID != nil {
+			var beverage models.Beverage
+			if err := tx.First(&beverage, *correctedID).Error; err != nil {
+				return ErrBeverageNotFound
+			}
+			shared, err := checkCorrectionTarget(&beverage, userID)
+			if err != nil {
+				return err
+			}
+			corrected = &beverage
+			if shared {
+				sharedCorrection = corrected
+			}
+		}
+
+		// 1. 로그에 피드백 기록 (이전 피드백은 캐시 이미지에서 되돌리는 데 사용)
+		previous := log
+		log.IsCorrect = &isCorrect
+		log.CorrectedID = nil
+		if corrected != nil {
+			log.CorrectedID = &corrected.ID
+		}
+		if err := tx.Save(&log).Error; err != nil {
+			return err
+		}
+
+		// 2. 결과를 낸 캐시 이미지 갱신 (아직 캐시에 없으면 확인/정정된 결과로 저장)
+		if log.BeverageImageID == nil && log.Candidates != "" && corrected == sharedCorrection {
+			image, err := cacheConfirmedRecognition(tx, &log, &previous, isCorrect, sharedCorrection, picked)
+			if err != nil {
+				return err
+			}
+			if image != nil {
+				result.ImageID = &image.ID
+				result.ImageConfidence = image.Confidence
+			}
+		} else if log.BeverageImageID != nil {
+			var image models.BeverageImage
+			if err := tx.First(&image, *log.BeverageImageID).Error; err == nil {
+				if err := updateImageFeedback(tx, &log, &previous, &image, isCorrect, sharedCorrection); err != nil {
+					return err
+				}
+				result.ImageID = &image.ID
+				result.ImageConfidence = image.Confidence
+			}
+		}
+
+		// 3. 이 인식으로 만든 섭취 기록도 정정된 음료로 갱신
+		if corrected != nil {
+			updated, err := applyCorrectionToIntakeLogs(tx, log.ID, corrected)
+			if err != nil {
+				return err
+			}
+			result.UpdatedLogs = updated
+		}
+
+		// 4. 확인된 음료의 검증 승격 여부 확인
+		confirmedID := log.RecognizedID
+		if !isCorrect {
+			confirmedID = log.CorrectedID
+		}
+		if confirmedID == nil {
+			return nil
+		}
+
+		result.BeverageID = confirmedID
+		promoted, confirmations, err := promoteBeverageIfConfirmed(tx, *confirmedID)
+		if err != nil {
+			return err
+		}
+		result.BeveragePromoted = promoted
+		result.Confirmations = confirmations
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// checkCorrectionTarget : 인식 결과를 정정할 수 있는 음료인지, 공유 캐시에 반영해도 되는지
+// 승인된 음료는 모두에게 반영하고, 본인이 등록한 승인 대기 음료는 본인 기록에만 반영
+// 다른 사용자의 승인 대기 음료나 거절된 음료는 찾을 수 없는 음료로 취급
+func checkCorrectionTarget(beverage *models.Beverage, userID uint) (bool, error) {
+	switch {
+	case beverage.Status == models.BeverageStatusActive:
+		return true, nil
+	case beverage.Status == models.BeverageStatusPending && userID != 0 && beverage.CreatedByUser == userID:
+		return false, nil
+	}
+	return false, ErrBeverageNotFound
+}
+
+// recognitionCandidates : 로그에 저장된 인식 후보
+// 인식 후에 음료가 병합됐을 수 있으므로 후보와 사진 속 음료의 음료 ID는 병합 대상으로 바꿈
+func recognitionCandidates(log *models.RecognitionLog) []RecognitionCandidate {
+	if log.Candidates == "" {
+		return nil
+	}
+	var candidates []RecognitionCandidate
+	json.Unmarshal([]byte(log.Candidates), &candidates)
+	for i := range candidates {
+		candidate := &candidates[i]
+		candidate.BeverageID = resolveBeverageRef(candidate.BeverageID)
+		for j := range candidate.Drinks {
+			candidate.Drinks[j].BeverageID = resolveBeverageRef(candidate.Drinks[j].BeverageID)
+		}
+	}
+	return candidates
+}
+
+// cacheConfirmedRecognition : 캐시에 저장하지 않은 인식 결과를 사용자 피드백으로 확정해 캐시에 저장
+// 대표 결과를 확인하면 그 값을, 정정하거나 다른 후보를 고르면 그 값을 저장 ("틀림"만 표시하면 저장하지 않음)
+// 같은 이미지가 이미 캐시에 있으면 그 행에 피드백을 반영
+func cacheConfirmedRecognition(tx *gorm.DB, log, previous *models.RecognitionLog, isCorrect bool, corrected *models.Beverage, picked *RecognitionCandidate) (*models.BeverageImage, error) {
+	if log.ImageHash == "" {
+		return nil, nil
+	}
+	image := confirmedRecognitionImage(log, recognitionCandidates(log), isCorrect, corrected, picked)
+	if image == nil {
+		return nil, nil
+	}
+
+	created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(image)
+	if created.Error != nil {
+		return nil, created.Error
+	}
+	if created.RowsAffected == 0 {
+		// 다른 인식이 먼저 캐시에 저장함 → 기존 행에 피드백 반영
+		if err := tx.Where("image_hash = ?", log.ImageHash).First(image).Error; err != nil {
+			return nil, err
+		}
+		log.BeverageImageID = &image.ID
+		if err := tx.Model(log).Update("beverage_image_id", image.ID).Error; err != nil {
+			return nil, err
+		}
+		if err := updateImageFeedback(tx, log, previous, image, isCorrect, corrected); err != nil {
+			return nil, err
+		}
+		return image, nil
+	}
+
+	// 새로 저장한 행은 이 피드백으로 만든 값이므로 반영된 것으로 기록
+	log.BeverageImageID = &image.ID
+	log.FeedbackApplied = true
+	if err := tx.Model(log).Updates(map[string]interface{}{"beverage_image_id": image.ID, "feedback_applied": true}).Error; err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+// pickRecognitionCandidate : 인식 후보 중 사용자가 고른 번호의 후보
+func pickRecognitionCandidate(candidates []RecognitionCandidate, index int) (*RecognitionCandidate, error) {
+	if index < 0 || index >= len(candidates) {
+		return nil, ErrInvalidCandidate
+	}
+	picked := candidates[index]
+	return &picked, nil
+}
+
+// confirmedRecognitionImage : 피드백으로 확정된 인식 결과를 캐시 이미지로 (저장할 값이 없으면 nil)
+// 음료 DB와 연결되지 않은 후보를 골라도 후보의 이름/카페인 값으로 저장
+func confirmedRecognitionImage(log *models.RecognitionLog, candidates []RecognitionCandidate, isCorrect bool, corrected *models.Beverage, picked *RecognitionCandidate) *models.BeverageImage {
+	source := picked
+	if source == nil && len(candidates) > 0 {
+		source = &candidates[0]
+	}
+	if source == nil || (!isCorrect && corrected == nil && picked == nil) {
+		return nil
+	}
+
+	detectionsJSON, _ := json.Marshal(source.Drinks)
+	image := &models.BeverageImage{
+		BeverageID:     source.BeverageID,
+		ImageHash:      log.ImageHash,
+		ImagePath:      log.ImagePath,
+		DrinkName:      source.DrinkName,
+		CaffeineAmount: source.CaffeineAmount,
+		Detections:     string(detectionsJSON),
+		Confidence:     UserCorrectedConfidence,
+		Source:         "user",
+		UsageCount:     1,
+		UploadedByUser: log.UserID,
+	}
+	switch {
+	case corrected != nil:
+		image.BeverageID = &corrected.ID
+		image.DrinkName = corrected.Name
+		image.CaffeineAmount = int(math.Round(corrected.CaffeineAmount))
+	case isCorrect:
+		image.Confidence = math.Max(UserCorrectedConfidence, math.Min(1.0, source.Confidence+ConfirmationBonus))
+	}
+	return image
+}
+
+// updateImageFeedback : 사용자의 피드백을 캐시 이미지에 반영
+// 캐시 이미지의 신뢰도는 모든 사용자가 함께 쓰므로 한 사용자가 같은 이미지의 여러 로그에
+// 피드백을 반복해 신뢰도를 끌어올리거나 내리지 못하도록 사용자당 한 로그의 피드백만 반영하고,
+// 그 로그의 피드백을 바꾸면 이전 피드백의 효과를 되돌린 뒤 새 피드백을 반영
+func updateImageFeedback(tx *gorm.DB, log, previous *models.RecognitionLog, image *models.BeverageImage, isCorrect bool, corrected *models.Beverage) error {
+	if previous.FeedbackApplied {
+		if sameFeedback(previous, isCorrect, corrected) {
+			return nil
+		}
+		name, caffeine := recognizedImageValues(tx, previous)
+		undoFeedbackOnImage(image, previous, name, caffeine)
+	} else {
+		var count int64
+		if err := tx.Model(&models.RecognitionLog{}).
+			Where("user_id = ? AND beverage_image_id = ? AND id <> ? AND feedback_applied = ?", log.UserID, image.ID, log.ID, true).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+	}
+
+	applyFeedbackToImage(image, isCorrect, corrected)
+	if err := tx.Save(image).Error; err != nil {
+		return err
+	}
+	log.FeedbackApplied = true
+	return tx.Model(log).Update("feedback_applied", true).Error
+}
+
+// sameFeedback : 이전 피드백과 같은 피드백인지 (다시 반영하지 않음)
+func sameFeedback(previous *models.RecognitionLog, isCorrect bool, corrected *models.Beverage) bool {
+	if previous.IsCorrect == nil || *previous.IsCorrect != isCorrect {
+		return false
+	}
+	if corrected == nil || previous.CorrectedID == nil {
+		return corrected == nil && previous.CorrectedID == nil
+	}
+	return *previous.CorrectedID == corrected.ID
+}
+
+// recognizedImageValues : 로그가 처음 인식한 음료의 이름과 카페인량 (정정을 되돌릴 때 사용)
+func recognizedImageValues(tx *gorm.DB, log *models.RecognitionLog) (string, int) {
+	if candidates := recognitionCandidates(log); len(candidates) > 0 {
+		return candidates[0].DrinkName, candidates[0].CaffeineAmount
+	}
+	if log.RecognizedID != nil {
+		var beverage models.Beverage
+		if err := tx.Unscoped().First(&beverage, *log.RecognizedID).Error; err == nil {
+			return beverage.Name, int(math.Round(beverage.CaffeineAmount))
+		}
+	}
+	return "", 0
+}
+
+// undoFeedbackOnImage : 이전에 반영한 피드백의 효과를 캐시 이미지에서 되돌림
+// 정정으로 다시 연결했던 이미지는 처음 인식한 음료와 신뢰도로 되돌림
+func undoFeedbackOnImage(image *models.BeverageImage, previous *models.RecognitionLog, recognizedName string, recognizedCaffeine int) {
+	switch {
+	case previous.IsCorrect == nil:
+		return
+	case *previous.IsCorrect:
+		image.Confidence = math.Max(0, image.Confidence-ConfirmationBonus)
+	case previous.CorrectedID != nil && image.BeverageID != nil && *image.BeverageID == *previous.CorrectedID:
+		image.BeverageID = previous.RecognizedID
+		image.DrinkName = recognizedName
+		image.CaffeineAmount = recognizedCaffeine
+		image.Confidence = previous.Confidence
+	default:
+		// 정정 없는 "틀림" (또는 공유 캐시에 반영하지 않은 정정)
+		image.Confidence = math.Min(1.0, image.Confidence/ContradictionPenalty)
+	}
+}
+
+// applyFeedbackToImage : 피드백에 따라 캐시 이미지의 음료 연결과 신뢰도 조정
+func applyFeedbackToImage(image *models.BeverageImage, isCorrect bool, corrected *models.Beverage) {
+	if isCorrect {
+		image.Confidence = math.Min(1.0, image.Confidence+ConfirmationBonus)
+		return
+	}
+
+	if corrected == nil {
+		// 정정 없이 "틀림"만 표시 → 신뢰도를 낮춰 일정 수준 아래면 재인식되도록 함
+		image.Confidence *= ContradictionPenalty
+		return
+	}
+
+	// 올바른 음료로 다시 연결 (이후 캐시 히트 시 정정된 값 반환)
+	image.BeverageID = &corrected.ID
+	image.DrinkName = corrected.Name
+	image.CaffeineAmount = int(math.Round(corrected.CaffeineAmount))
+	image.Source = "user"
+	image.Confidence = UserCorrectedConfidence
+}
+
+// promoteBeverageIfConfirmed : 서로 다른 사용자의 확인이 충분히 쌓이면 음료를 검증됨으로 승격
+func promoteBeverageIfConfirmed(tx *gorm.DB, beverageID uint) (bool, int64, error) {
+	var confirmations int64
+	err := tx.Model(&models.RecognitionLog{}).
+		Where("(is_correct = ? AND recognized_id = ?) OR corrected_id = ?", true, beverageID, beverageID).
+		Distinct("user_id").
+		Count(&confirmations).Error
+	if err != nil {
+		return false, 0, err
+	}
+
+	if confirmations < int64(config.BeverageVerifyConfirmations) {
+		return false, confirmations, nil
+	}
+
+	updated := tx.Model(&models.Beverage{}).
+		Where("id = ? AND is_verified = ?", beverageID, false).
+		Update("is_verified", true)
+	if updated.Error != nil {
+		return false, confirmations, updated.Error
+	}
+	if updated.RowsAffected == 0 {
+		return false, confirmations, nil
+	}
+
+	meta := BeverageRevisionMeta{Source: RevisionSourceFeedback, Reason: fmt.Sprintf("사용자 %d명이 인식 결과를 확인", confirmations)}
+	if err := recordBeverageRevision(tx, beverageID, map[string]interface{}{"is_verified": false}, map[string]interface{}{"is_verified": true}, meta); err != nil {
+		return false, confirmations, err
+	}
+	return true, confirmations, nil
+}
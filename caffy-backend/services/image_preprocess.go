@@ -0,0 +1,324 @@
+package services
+
+import (
+	"bytes"
+	"caffy-backend/config"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+	"strings"
+)
+
+// ========================================
+// 이미지 전처리 (인식/저장 전)
+// 실제 형식 확인 → 디코딩 → EXIF 회전 적용 → 축소 → 메타데이터 제거(재인코딩) → 썸네일
+// ========================================
+
+var (
+	ErrUnsupportedImage = errors.New("지원하지 않는 이미지 형식입니다 (JPEG, PNG, GIF, WebP, HEIC만 가능)")
+	ErrInvalidImageData = errors.New("이미지 데이터 형식 오류")
+	ErrImageTooLarge    = errors.New("이미지 해상도가 너무 큽니다")
+)
+
+// maxImagePixels : 디코딩을 허용하는 최대 픽셀 수 (압축 폭탄 방지)
+const maxImagePixels = 50_000_000
+
+// thumbnailJPEGQuality : 썸네일 JPEG 품질
+const thumbnailJPEGQuality = 75
+
+// supportedImageTypes : 표준 라이브러리로 디코딩 가능한 형식
+var supportedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// PreprocessedImage : 전처리된 이미지
+type PreprocessedImage struct {
+	Data          []byte // 재인코딩된 JPEG (메타데이터 없음)
+	MIME          string // 항상 "image/jpeg"
+	Width         int
+	Height        int
+	Thumbnail     []byte // 썸네일 JPEG
+	OriginalMIME  string // 업로드된 실제 형식
+	OriginalBytes int    // 업로드된 크기
+
+	decoded image.Image // 회전/축소가 적용된 이미지 (바코드 인식 등에 재사용)
+}
+
+// Base64 : 전처리된 이미지의 Base64 (LLM 전송용)
+func (p *PreprocessedImage) Base64() string {
+	return base64.StdEncoding.EncodeToString(p.Data)
+}
+
+// DecodeImageBase64 : Base64 이미지 디코딩 ("data:image/...;base64," 접두사 허용)
+func DecodeImageBase64(imageBase64 string) ([]byte, error) {
+	if strings.HasPrefix(imageBase64, "data:") {
+		if comma := strings.Index(imageBase64, ","); comma >= 0 {
+			imageBase64 = imageBase64[comma+1:]
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(imageBase64))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImageData, err)
+	}
+	return data, nil
+}
+
+// PreprocessImage : 업로드된 이미지를 인식/저장용으로 정리
+// 표준 라이브러리로 디코딩할 수 없는 WebP/HEIC는 손대지 않고 그대로 사용 (회전/축소/썸네일 없음)
+func PreprocessImage(raw []byte) (*PreprocessedImage, error) {
+	mimeType := http.DetectContentType(raw)
+	if !supportedImageTypes[mimeType] {
+		passthrough := passthroughImageType(raw, mimeType)
+		if passthrough == "" {
+			return nil, ErrUnsupportedImage
+		}
+		return &PreprocessedImage{
+			Data:          raw,
+			MIME:          passthrough,
+			OriginalMIME:  passthrough,
+			OriginalBytes: len(raw),
+		}, nil
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, fmt.Errorf("%w (%dx%d)", ErrImageTooLarge, cfg.Width, cfg.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	// 축소하면서 투명 배경은 흰색으로 합성 (JPEG는 알파 채널이 없음)
+	// 원본 크기의 사본을 만들지 않고, 축소를 먼저 해서 회전 비용도 줄임 (최대 변 기준이라 순서와 무관)
+	resized := downscale(src, config.ImageMaxDimension)
+
+	orientation := 1
+	if mimeType == "image/jpeg" {
+		orientation = jpegOrientation(raw)
+	}
+	oriented := applyOrientation(resized, orientation)
+
+	// 재인코딩으로 EXIF(GPS 포함) 등 메타데이터 제거
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, oriented, &jpeg.Options{Quality: config.ImageJPEGQuality}); err != nil {
+		return nil, fmt.Errorf("이미지 인코딩 실패: %v", err)
+	}
+
+	var thumb bytes.Buffer
+	if err := jpeg.Encode(&thumb, downscale(oriented, config.ImageThumbnailSize), &jpeg.Options{Quality: thumbnailJPEGQuality}); err != nil {
+		return nil, fmt.Errorf("썸네일 인코딩 실패: %v", err)
+	}
+
+	return &PreprocessedImage{
+		Data:          buf.Bytes(),
+		MIME:          "image/jpeg",
+		Width:         oriented.Bounds().Dx(),
+		Height:        oriented.Bounds().Dy(),
+		Thumbnail:     thumb.Bytes(),
+		OriginalMIME:  mimeType,
+		OriginalBytes: len(raw),
+		decoded:       oriented,
+	}, nil
+}
+
+// downscale : 긴 변이 maxDim을 넘으면 비율을 유지해 축소 (영역 평균), 투명한 부분은 흰색으로 합성
+// 결과 한 줄에 해당하는 원본 띠만 RGBA로 옮겨 평균하므로 원본 크기의 버퍼를 만들지 않음
+func downscale(src image.Image, maxDim int) *image.RGBA {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dw, dh := w, h
+	if maxDim > 0 && (w > maxDim || h > maxDim) {
+		scale := float64(maxDim) / float64(w)
+		if h > w {
+			scale = float64(maxDim) / float64(h)
+		}
+		dw = max(1, int(float64(w)*scale+0.5))
+		dh = max(1, int(float64(h)*scale+0.5))
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	if w == 0 || h == 0 {
+		return dst
+	}
+	band := image.NewRGBA(image.Rect(0, 0, w, (h+dh-1)/dh+1))
+	for y := 0; y < dh; y++ {
+		sy0 := y * h / dh
+		sy1 := (y + 1) * h / dh
+		if sy1 <= sy0 {
+			sy1 = sy0 + 1
+		}
+		rows := image.Rect(0, 0, w, sy1-sy0)
+		draw.Draw(band, rows, &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+		draw.Draw(band, rows, src, image.Pt(bounds.Min.X, bounds.Min.Y+sy0), draw.Over)
+
+		for x := 0; x < dw; x++ {
+			sx0 := x * w / dw
+			sx1 := (x + 1) * w / dw
+			if sx1 <= sx0 {
+				sx1 = sx0 + 1
+			}
+
+			var r, g, b, n uint32
+			for by := 0; by < sy1-sy0; by++ {
+				row := band.Pix[by*band.Stride:]
+				for sx := sx0; sx < sx1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint32(p[0])
+					g += uint32(p[1])
+					b += uint32(p[2])
+					n++
+				}
+			}
+
+			d := dst.Pix[y*dst.Stride+x*4:]
+			d[0] = uint8(r / n)
+			d[1] = uint8(g / n)
+			d[2] = uint8(b / n)
+			d[3] = 0xFF
+		}
+	}
+	return dst
+}
+
+// passthroughImageType : 디코딩 없이 그대로 넘길 수 있는 이미지 형식 (아니면 "")
+// WebP는 net/http가 판별하고, HEIC/HEIF는 ISO BMFF ftyp 상자의 브랜드로 판별
+func passthroughImageType(raw []byte, detected string) string {
+	if detected == "image/webp" {
+		return detected
+	}
+	if len(raw) >= 12 && string(raw[4:8]) == "ftyp" {
+		switch string(raw[8:12]) {
+		case "heic", "heix", "hevc", "hevx", "heim", "heis":
+			return "image/heic"
+		case "mif1", "msf1":
+			return "image/heif"
+		}
+	}
+	return ""
+}
+
+// applyOrientation : EXIF Orientation(1~8)에 맞게 회전/반전
+func applyOrientation(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // 좌우 반전
+				sx, sy = w-1-x, y
+			case 3: // 180도 회전
+				sx, sy = w-1-x, h-1-y
+			case 4: // 상하 반전
+				sx, sy = x, h-1-y
+			case 5: // 대각선 반전 (transpose)
+				sx, sy = y, x
+			case 6: // 시계 방향 90도 회전
+				sx, sy = y, h-1-x
+			case 7: // 반대 대각선 반전 (transverse)
+				sx, sy = w-1-y, h-1-x
+			case 8: // 반시계 방향 90도 회전
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[y*dst.Stride+x*4:y*dst.Stride+x*4+4], src.Pix[sy*src.Stride+sx*4:sy*src.Stride+sx*4+4])
+		}
+	}
+	return dst
+}
+
+// jpegOrientation : JPEG의 EXIF Orientation 값 (없으면 1)
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return 1
+		}
+		marker := data[pos+1]
+		if marker == 0xFF { // 채움 바이트
+			pos++
+			continue
+		}
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) { // 길이 없는 마커
+			pos += 2
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 { // 이미지 데이터 시작 → EXIF 없음
+			return 1
+		}
+
+		segmentLength := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if segmentLength < 2 || pos+2+segmentLength > len(data) {
+			return 1
+		}
+		segment := data[pos+4 : pos+2+segmentLength]
+		if marker == 0xE1 && len(segment) >= 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+		pos += 2 + segmentLength
+	}
+	return 1
+}
+
+// tiffOrientation : EXIF(TIFF) 첫 번째 IFD에서 Orientation(0x0112) 태그 읽기
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			value := int(order.Uint16(tiff[entry+8:]))
+			if value >= 1 && value <= 8 {
+				return value
+			}
+			return 1
+		}
+	}
+	return 1
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"bytes"
+	"caffy-backend/config"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// 표준 라이브러리로 디코딩할 수 없는 WebP/HEIC는 그대로 통과, 이미지가 아닌 데이터는 거부
+func TestPreprocessImagePassthrough(t *testing.T) {
+	webp := append([]byte("RIFF\x24\x00\x00\x00WEBPVP8 "), make([]byte, 32)...)
+	heic := append([]byte("\x00\x00\x00\x18ftypheic"), make([]byte, 32)...)
+
+	tests := []struct {
+		name     string
+		raw      []byte
+		wantMIME string
+		wantErr  error
+	}{
+		{"WebP", webp, "image/webp", nil},
+		{"HEIC", heic, "image/heic", nil},
+		{"텍스트", []byte("hello, not an image"), "", ErrUnsupportedImage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processed, err := PreprocessImage(tt.raw)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if processed.MIME != tt.wantMIME || !bytes.Equal(processed.Data, tt.raw) {
+				t.Fatalf("통과 결과 = %s (%d bytes), want %s 원본 그대로", processed.MIME, len(processed.Data), tt.wantMIME)
+			}
+		})
+	}
+}
+
+// 투명 PNG는 흰 배경으로 합성되고 긴 변 기준으로 축소되어야 함
+func TestPreprocessImageFlattensAndDownscales(t *testing.T) {
+	original := config.ImageMaxDimension
+	config.ImageMaxDimension = 100
+	defer func() { config.ImageMaxDimension = original }()
+
+	src := image.NewNRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 200; x < 400; x++ {
+			src.Set(x, y, color.NRGBA{R: 0, G: 0, B: 0, A: 255}) // 오른쪽 절반만 검정, 왼쪽은 투명
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	processed, err := PreprocessImage(buf.Bytes())
+	if err != nil {
+		t.Fatalf("전처리 실패: %v", err)
+	}
+	if processed.Width != 100 || processed.Height != 50 {
+		t.Fatalf("크기 = %dx%d, want 100x50", processed.Width, processed.Height)
+	}
+
+	decoded, err := jpeg.Decode(bytes.NewReader(processed.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	left := luminance(decoded, 10, 25)
+	right := luminance(decoded, 90, 25)
+	if left < 240 || right > 15 {
+		t.Fatalf("투명 영역 밝기 = %.0f (흰색이어야 함), 불투명 영역 = %.0f (검정이어야 함)", left, right)
+	}
+}
@@ -0,0 +1,552 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========================================
+// 이미지 보관 정책 (사용자별 용량 제한, 미확정 사진 보관 기간, 고아 파일 정리)
+//
+// 보관 기준
+//   - BeverageImage(인식 캐시/학습 데이터)가 참조하는 사진 → 보관
+//   - 섭취 기록으로 이어진 인식 로그의 사진 → 보관
+//   - 인식 로그만 참조하는 사진 (미확정) → IMAGE_RETENTION_DAYS 이후 삭제
+//   - 탈퇴한 사용자의 사진 → 삭제
+//   - 어떤 행도 참조하지 않는 파일 → 유예 시간 이후 삭제
+//   - 반대로 파일이 없어진 행은 경로를 비움
+// ========================================
+
+var ErrImageQuotaExceeded = errors.New("이미지 저장 용량을 초과했습니다")
+
+// orphanImageGracePeriod : 참조 없는 파일을 지우기 전 유예 시간
+// 파일 저장 직후 로그 행이 만들어지기 전에 정리되지 않도록 함
+const orphanImageGracePeriod = 6 * time.Hour
+
+// gcReportKeyLimit : 리포트에 나열할 키 최대 개수 (종류별)
+const gcReportKeyLimit = 50
+
+// imageGCBatchSize : 한 번에 갱신/삭제할 행 수
+const imageGCBatchSize = 500
+
+// imageGCMu : 정리 작업 중복 실행 방지
+var imageGCMu sync.Mutex
+
+// ImageStorageUsage : 사용자 이미지 저장 사용량
+type ImageStorageUsage struct {
+	UsedBytes  int64 `json:"used_bytes"`
+	QuotaBytes int64 `json:"quota_bytes"` // 0이면 무제한
+	ImageCount int64 `json:"image_count"`
+}
+
+// GetImageStorageUsage : 사용자의 이미지 저장 사용량 조회
+func GetImageStorageUsage(userID uint) ImageStorageUsage {
+	var usage ImageStorageUsage
+	config.DB.Model(&models.StoredImage{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(SUM(size_bytes), 0)").
+		Scan(&usage.UsedBytes)
+	config.DB.Model(&models.StoredImage{}).
+		Where("user_id = ? AND kind = ?", userID, models.StoredImageOriginal).
+		Count(&usage.ImageCount)
+	usage.QuotaBytes = imageQuotaBytes()
+	return usage
+}
+
+// imageQuotaBytes : 사용자별 저장 용량 (바이트, 0이면 무제한)
+func imageQuotaBytes() int64 {
+	if config.ImageQuotaMBPerUser <= 0 {
+		return 0
+	}
+	return int64(config.ImageQuotaMBPerUser) * 1024 * 1024
+}
+
+// recordStoredImage : 저장한 파일을 색인에 기록 (같은 키면 무시)
+func recordStoredImage(key string, userID uint, size int64, kind string) {
+	config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.StoredImage{
+		StorageKey: key,
+		UserID:     userID,
+		SizeBytes:  size,
+		Kind:       kind,
+	})
+}
+
+// ensureImageQuota : incoming 바이트를 저장할 공간 확보
+// 모자라면 오래된 미확정 사진부터 지우고, 그래도 모자라면 ErrImageQuotaExceeded
+func ensureImageQuota(userID uint, incoming int64) error {
+	quota := imageQuotaBytes()
+	if quota == 0 {
+		return nil
+	}
+	if incoming > quota {
+		return ErrImageQuotaExceeded
+	}
+
+	used := GetImageStorageUsage(userID).UsedBytes
+	if used+incoming <= quota {
+		return nil
+	}
+
+	var candidates []models.StoredImage
+	config.DB.Where("user_id = ? AND kind = ?", userID, models.StoredImageOriginal).
+		Where("storage_key NOT IN (?)", beverageImageKeys()).
+		Where("storage_key NOT IN (?)", confirmedImageKeys()).
+		Order("id ASC").
+		Find(&candidates)
+
+	for _, candidate := range candidates {
+		if used+incoming <= quota {
+			break
+		}
+		freed, err := evictImage(candidate.StorageKey)
+		if err != nil {
+			println("⚠️ 이미지 정리 실패:", candidate.StorageKey, err.Error())
+			continue
+		}
+		used -= freed
+	}
+
+	if used+incoming > quota {
+		return ErrImageQuotaExceeded
+	}
+	return nil
+}
+
+// evictImage : 사진과 썸네일을 지우고 참조 행의 경로를 비움 (확보한 바이트 반환)
+func evictImage(key string) (int64, error) {
+	keys := []string{key, ThumbnailKey(key)}
+	for _, k := range keys {
+		if err := imageStore.Delete(k); err != nil {
+			return 0, err
+		}
+	}
+
+	var freed int64
+	config.DB.Model(&models.StoredImage{}).
+		Where("storage_key IN ?", keys).
+		Select("COALESCE(SUM(size_bytes), 0)").
+		Scan(&freed)
+	config.DB.Unscoped().Where("storage_key IN ?", keys).Delete(&models.StoredImage{})
+	config.DB.Model(&models.RecognitionLog{}).Where("image_path = ?", key).Update("image_path", "")
+	return freed, nil
+}
+
+// beverageImageKeys : 인식 캐시가 참조하는 저장소 키 (서브쿼리)
+func beverageImageKeys() *gorm.DB {
+	return config.DB.Model(&models.BeverageImage{}).Select("image_path").Where("image_path <> ?", "")
+}
+
+// confirmedImageKeys : 삭제되지 않은 섭취 기록으로 이어진 인식 로그의 저장소 키 (서브쿼리)
+func confirmedImageKeys() *gorm.DB {
+	linked := config.DB.Model(&models.CaffeineLog{}).
+		Select("recognition_log_id").
+		Where("recognition_log_id IS NOT NULL")
+	return config.DB.Model(&models.RecognitionLog{}).
+		Select("image_path").
+		Where("image_path <> ? AND id IN (?)", "", linked)
+}
+
+// ========================================
+// 정리 작업 (GC)
+// ========================================
+
+// ImageGCBucket : 삭제 사유별 집계
+type ImageGCBucket struct {
+	Count int      `json:"count"`
+	Bytes int64    `json:"bytes"`
+	Keys  []string `json:"keys,omitempty"` // 최대 gcReportKeyLimit개
+}
+
+// ImageGCReport : 정리 작업 결과 (dry-run이면 실제로 지우지 않은 예정 목록)
+type ImageGCReport struct {
+	DryRun         bool          `json:"dry_run"`
+	StartedAt      time.Time     `json:"started_at"`
+	DurationMs     int64         `json:"duration_ms"`
+	ScannedObjects int           `json:"scanned_objects"`
+	ScannedBytes   int64         `json:"scanned_bytes"`
+	Expired        ImageGCBucket `json:"expired"`       // 보관 기간이 지난 미확정 사진
+	DeletedUsers   ImageGCBucket `json:"deleted_users"` // 탈퇴한 사용자의 사진
+	Orphaned       ImageGCBucket `json:"orphaned"`      // 어떤 행도 참조하지 않는 파일
+	MissingFiles   int           `json:"missing_files"` // 파일이 없어 경로를 비운 행
+	StaleIndexRows int           `json:"stale_index_rows"`
+	Backfilled     int           `json:"backfilled"` // 색인에 없던 파일 (예전 업로드)
+	FreedBytes     int64         `json:"freed_bytes"`
+	Errors         []string      `json:"errors,omitempty"`
+}
+
+// 삭제 사유
+const (
+	gcReasonExpired     = "expired"
+	gcReasonDeletedUser = "deleted_user"
+	gcReasonOrphaned    = "orphaned"
+)
+
+// imageRef : 저장소 키를 참조하는 행
+type imageRef struct {
+	Table     string // "beverage_images", "recognition_logs"
+	ID        uint
+	Key       string
+	UserID    uint
+	CreatedAt time.Time
+	Confirmed bool // 섭취 기록으로 이어진 인식 로그
+}
+
+// imageGCInput : 정리 계획에 필요한 현재 상태
+type imageGCInput struct {
+	Objects     []ImageObject
+	Refs        []imageRef
+	Indexed     map[string]time.Time // 색인 키 → 기록 시각
+	ActiveUsers map[uint]bool
+	ListedAt    time.Time // 저장소 목록을 읽은 시각 (이후 생긴 행은 건드리지 않음)
+	Retention   time.Duration
+}
+
+// imageGCDeletion : 지울 파일
+type imageGCDeletion struct {
+	Key    string
+	Size   int64
+	Reason string
+}
+
+// imageGCPlan : 정리 계획
+type imageGCPlan struct {
+	Deletions  []imageGCDeletion
+	ClearRefs  []imageRef // 경로를 비울 행 (파일 삭제 대상 또는 파일 없음)
+	Missing    int        // ClearRefs 중 파일이 이미 없는 행
+	StaleIndex []string   // 파일이 없는 색인 키
+	Backfill   []ImageObject
+}
+
+// RunImageGC : 이미지 정리 작업 실행 (dryRun이면 삭제 없이 리포트만)
+func RunImageGC(dryRun bool) (*ImageGCReport, error) {
+	if !imageGCMu.TryLock() {
+		return nil, fmt.Errorf("이미지 정리 작업이 이미 실행 중입니다")
+	}
+	defer imageGCMu.Unlock()
+
+	report := &ImageGCReport{DryRun: dryRun, StartedAt: time.Now()}
+
+	input, err := loadImageGCInput()
+	if err != nil {
+		return nil, err
+	}
+	for _, object := range input.Objects {
+		report.ScannedObjects++
+		report.ScannedBytes += object.Size
+	}
+
+	plan := planImageGC(input)
+	for _, deletion := range plan.Deletions {
+		bucket := &report.Orphaned
+		switch deletion.Reason {
+		case gcReasonExpired:
+			bucket = &report.Expired
+		case gcReasonDeletedUser:
+			bucket = &report.DeletedUsers
+		}
+		bucket.Count++
+		bucket.Bytes += deletion.Size
+		if len(bucket.Keys) < gcReportKeyLimit {
+			bucket.Keys = append(bucket.Keys, deletion.Key)
+		}
+	}
+	report.MissingFiles = plan.Missing
+	report.StaleIndexRows = len(plan.StaleIndex)
+	report.Backfilled = len(plan.Backfill)
+
+	if !dryRun {
+		report.FreedBytes, report.Errors = applyImageGCPlan(plan)
+	}
+
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+	return report, nil
+}
+
+// StartImageGC : 주기적으로 정리 작업 실행 (IMAGE_GC_INTERVAL_HOURS가 0이면 실행 안 함)
+func StartImageGC() {
+	if config.ImageGCIntervalHours <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(config.ImageGCIntervalHours) * time.Hour)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			report, err := RunImageGC(false)
+			if err != nil {
+				println("⚠️ 이미지 정리 실패:", err.Error())
+				continue
+			}
+			deleted := report.Expired.Count + report.DeletedUsers.Count + report.Orphaned.Count
+			if deleted > 0 || report.MissingFiles > 0 {
+				println("🧹 이미지 정리:", deleted, "개 삭제,", report.FreedBytes, "바이트 확보, 경로 정리", report.MissingFiles, "건")
+			}
+		}
+	}()
+	println("✅ 이미지 정리 작업 예약:", config.ImageGCIntervalHours, "시간마다")
+}
+
+// loadImageGCInput : 저장소 목록과 참조 행 조회
+// 목록을 먼저 읽어서, 그 사이 새로 저장된 파일의 행이 "파일 없음"으로 처리되지 않게 함
+func loadImageGCInput() (*imageGCInput, error) {
+	input := &imageGCInput{
+		Indexed:     make(map[string]time.Time),
+		ActiveUsers: make(map[uint]bool),
+		ListedAt:    time.Now(),
+		Retention:   time.Duration(config.ImageRetentionDays) * 24 * time.Hour,
+	}
+
+	objects, err := imageStore.List("")
+	if err != nil {
+		return nil, err
+	}
+	input.Objects = objects
+
+	var userIDs []uint
+	config.DB.Model(&models.User{}).Pluck("id", &userIDs)
+	for _, id := range userIDs {
+		input.ActiveUsers[id] = true
+	}
+
+	var confirmedIDs []uint
+	config.DB.Model(&models.CaffeineLog{}).
+		Where("recognition_log_id IS NOT NULL").
+		Distinct().
+		Pluck("recognition_log_id", &confirmedIDs)
+	confirmed := make(map[uint]bool, len(confirmedIDs))
+	for _, id := range confirmedIDs {
+		confirmed[id] = true
+	}
+
+	var beverageImages []models.BeverageImage
+	config.DB.Select("id, image_path, uploaded_by_user, created_at").
+		Where("image_path <> ?", "").
+		Find(&beverageImages)
+	for _, image := range beverageImages {
+		input.Refs = append(input.Refs, imageRef{
+			Table:     "beverage_images",
+			ID:        image.ID,
+			Key:       image.ImagePath,
+			UserID:    image.UploadedByUser,
+			CreatedAt: image.CreatedAt,
+		})
+	}
+
+	var recognitionLogs []models.RecognitionLog
+	config.DB.Select("id, image_path, user_id, created_at").
+		Where("image_path <> ?", "").
+		Find(&recognitionLogs)
+	for _, log := range recognitionLogs {
+		input.Refs = append(input.Refs, imageRef{
+			Table:     "recognition_logs",
+			ID:        log.ID,
+			Key:       log.ImagePath,
+			UserID:    log.UserID,
+			CreatedAt: log.CreatedAt,
+			Confirmed: confirmed[log.ID],
+		})
+	}
+
+	var indexed []models.StoredImage
+	config.DB.Select("storage_key, created_at").Find(&indexed)
+	for _, row := range indexed {
+		input.Indexed[row.StorageKey] = row.CreatedAt
+	}
+
+	return input, nil
+}
+
+// planImageGC : 현재 상태로 지울 파일과 정리할 행 결정 (DB/저장소를 건드리지 않음)
+func planImageGC(input *imageGCInput) *imageGCPlan {
+	plan := &imageGCPlan{}
+
+	objects := make(map[string]ImageObject, len(input.Objects))
+	for _, object := range input.Objects {
+		objects[object.Key] = object
+	}
+
+	userGone := func(userID uint) bool {
+		return userID != 0 && !input.ActiveUsers[userID] // 0은 시스템/초기 데이터
+	}
+
+	// 1. 참조되는 키마다 보관 여부 결정 (하나라도 보관할 이유가 있으면 보관)
+	refsByKey := make(map[string][]imageRef)
+	for _, ref := range input.Refs {
+		refsByKey[ref.Key] = append(refsByKey[ref.Key], ref)
+	}
+
+	kept := make(map[string]bool)
+	deleted := make(map[string]string) // 키 → 삭제 사유
+	for key, refs := range refsByKey {
+		keep := false
+		allUsersGone := true
+		for _, ref := range refs {
+			if userGone(ref.UserID) {
+				continue
+			}
+			allUsersGone = false
+			if ref.Table == "beverage_images" || ref.Confirmed ||
+				input.Retention <= 0 || input.ListedAt.Sub(ref.CreatedAt) < input.Retention {
+				keep = true
+				break
+			}
+		}
+
+		object, exists := objects[key]
+		if keep {
+			if exists {
+				kept[key] = true
+				continue
+			}
+			// 파일이 없어진 행 (목록 조회 후 생긴 행은 제외)
+			for _, ref := range refs {
+				if ref.CreatedAt.Before(input.ListedAt) {
+					plan.ClearRefs = append(plan.ClearRefs, ref)
+					plan.Missing++
+				}
+			}
+			continue
+		}
+
+		reason := gcReasonExpired
+		if allUsersGone {
+			reason = gcReasonDeletedUser
+		}
+		if exists {
+			plan.Deletions = append(plan.Deletions, imageGCDeletion{Key: key, Size: object.Size, Reason: reason})
+			deleted[key] = reason
+		} else {
+			plan.Missing += len(refs)
+		}
+		plan.ClearRefs = append(plan.ClearRefs, refs...)
+	}
+
+	// 2. 참조 없는 파일 (썸네일은 원본을 따라감)
+	for _, object := range input.Objects {
+		if kept[object.Key] || deleted[object.Key] != "" {
+			continue
+		}
+
+		if original, ok := thumbnailOriginalKey(object.Key); ok {
+			if kept[original] {
+				kept[object.Key] = true
+				continue
+			}
+			if reason := deleted[original]; reason != "" {
+				plan.Deletions = append(plan.Deletions, imageGCDeletion{Key: object.Key, Size: object.Size, Reason: reason})
+				deleted[object.Key] = reason
+				continue
+			}
+		}
+
+		if input.ListedAt.Sub(object.ModTime) < orphanImageGracePeriod {
+			kept[object.Key] = true
+			continue
+		}
+		plan.Deletions = append(plan.Deletions, imageGCDeletion{Key: object.Key, Size: object.Size, Reason: gcReasonOrphaned})
+		deleted[object.Key] = gcReasonOrphaned
+	}
+
+	// 3. 색인 정리 (파일이 없는 색인 행 삭제, 색인에 없는 파일 추가)
+	for key, createdAt := range input.Indexed {
+		if _, exists := objects[key]; (!exists && createdAt.Before(input.ListedAt)) || deleted[key] != "" {
+			plan.StaleIndex = append(plan.StaleIndex, key)
+		}
+	}
+	for _, object := range input.Objects {
+		if _, indexed := input.Indexed[object.Key]; kept[object.Key] && !indexed {
+			plan.Backfill = append(plan.Backfill, object)
+		}
+	}
+
+	return plan
+}
+
+// thumbnailOriginalKey : 썸네일 키면 원본 키 반환
+func thumbnailOriginalKey(key string) (string, bool) {
+	if !strings.HasSuffix(key, "_thumb.jpg") {
+		return "", false
+	}
+	return strings.TrimSuffix(key, "_thumb.jpg") + ".jpg", true
+}
+
+// applyImageGCPlan : 계획대로 파일 삭제 및 행 정리 (확보한 바이트와 오류 목록 반환)
+func applyImageGCPlan(plan *imageGCPlan) (int64, []string) {
+	var freed int64
+	var errs []string
+	failed := make(map[string]bool)
+
+	for _, deletion := range plan.Deletions {
+		if err := imageStore.Delete(deletion.Key); err != nil {
+			failed[deletion.Key] = true
+			if len(errs) < gcReportKeyLimit {
+				errs = append(errs, fmt.Sprintf("%s: %v", deletion.Key, err))
+			}
+			continue
+		}
+		freed += deletion.Size
+	}
+
+	// 삭제에 실패한 파일의 행은 그대로 둠 (다음 실행에서 다시 시도)
+	idsByTable := make(map[string][]uint)
+	for _, ref := range plan.ClearRefs {
+		if failed[ref.Key] {
+			continue
+		}
+		idsByTable[ref.Table] = append(idsByTable[ref.Table], ref.ID)
+	}
+	for table, ids := range idsByTable {
+		for start := 0; start < len(ids); start += imageGCBatchSize {
+			batch := ids[start:min(start+imageGCBatchSize, len(ids))]
+			config.DB.Table(table).Where("id IN ?", batch).Update("image_path", "")
+		}
+	}
+
+	var stale []string
+	for _, key := range plan.StaleIndex {
+		if !failed[key] {
+			stale = append(stale, key)
+		}
+	}
+	for start := 0; start < len(stale); start += imageGCBatchSize {
+		batch := stale[start:min(start+imageGCBatchSize, len(stale))]
+		config.DB.Unscoped().Where("storage_key IN ?", batch).Delete(&models.StoredImage{})
+	}
+
+	for _, object := range plan.Backfill {
+		recordStoredImage(object.Key, imageKeyUserID(object.Key), object.Size, storedImageKind(object.Key))
+	}
+
+	return freed, errs
+}
+
+// imageKeyUserID : 키의 첫 구간({userID}/...)에서 사용자 ID 추출 (형식이 다르면 0)
+func imageKeyUserID(key string) uint {
+	first, _, found := strings.Cut(key, "/")
+	if !found {
+		return 0
+	}
+	id, err := strconv.ParseUint(first, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return uint(id)
+}
+
+// storedImageKind : 키로 원본/썸네일 구분
+func storedImageKind(key string) string {
+	if _, ok := thumbnailOriginalKey(key); ok {
+		return models.StoredImageThumbnail
+	}
+	return models.StoredImageOriginal
+}
@@ -1,40 +1,56 @@
 package services
 
 import (
+	"caffy-backend/config"
+	"caffy-backend/models"
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
-	"io"
-	"os"
-	"path/filepath"
+	"net/http"
 	"strings"
 	"time"
 )
 
-// GetImageUploadPath : 이미지 저장 경로 (환경변수에서 가져옴)
-func GetImageUploadPath() string {
-	// 사용자 요청: D드라이브 caffy 이미지 폴더
-	return "D:\\caffy\\images"
-}
+// imageStore : 현재 사용 중인 이미지 저장소 (InitImageStorage에서 설정)
+var imageStore ImageStore
 
-// InitImageStorage : 이미지 저장 폴더 초기화
+// InitImageStorage : 설정(IMAGE_STORE)에 맞는 이미지 저장소 초기화
+// "local"은 UPLOAD_PATH 폴더 (공개 경로 없음, 인증된 API로만 조회), "s3"는 S3 호환 스토리지
 func InitImageStorage() error {
-	return os.MkdirAll(GetImageUploadPath(), 0755)
+	switch config.ImageStoreType {
+	case "s3":
+		store, err := NewS3ImageStore(config.S3Endpoint, config.S3Region, config.S3Bucket,
+			config.S3AccessKey, config.S3SecretKey, config.S3UsePathStyle, config.S3PublicURL)
+		if err != nil {
+			return err
+		}
+		imageStore = store
+	case "local", "":
+		store, err := NewLocalImageStore(config.UploadPath, "")
+		if err != nil {
+			return err
+		}
+		imageStore = store
+	default:
+		return fmt.Errorf("지원하지 않는 IMAGE_STORE: %s (local, s3)", config.ImageStoreType)
+	}
+
+	migrateLegacyImagePaths()
+	return nil
 }
 
-// SaveImage : 이미지 파일 저장
+// SaveImage : 이미지 저장 후 저장소 키 반환
+// 키 형식: {userID}/{YYYYMMDD_HHMMSS}_{음료명}_{해시 8자}.jpg
+// 사용자 저장 용량이 모자라면 오래된 미확정 사진을 먼저 정리하고, 그래도 모자라면 ErrImageQuotaExceeded
 func SaveImage(imageData []byte, userID uint, drinkName string) (string, error) {
-	// 기본 경로 가져오기
-	basePath := GetImageUploadPath()
-
-	// 유저별 폴더 경로: D:\caffy\images\{userID}
-	userDir := filepath.Join(basePath, fmt.Sprintf("%d", userID))
-
-	// 폴더 생성 확인
-	if err := os.MkdirAll(userDir, 0755); err != nil {
-		return "", fmt.Errorf("폴더 생성 실패: %v", err)
+	if err := ensureImageQuota(userID, int64(len(imageData))); err != nil {
+		return "", err
 	}
+	return putImage(imageData, userID, drinkName)
+}
 
+// putImage : 용량 확인 없이 저장하고 색인에 기록
+func putImage(imageData []byte, userID uint, drinkName string) (string, error) {
 	// 파일명 생성 (YYYYMMDD_HHMMSS_DrinkName.jpg)
 	// 음료명에 파일시스템에 사용할 수 없는 문자가 있을 수 있으므로 치환
 	safeDrinkName := strings.ReplaceAll(drinkName, " ", "_")
@@ -47,21 +63,101 @@ func SaveImage(imageData []byte, userID uint, drinkName string) (string, error)
 	safeDrinkName = strings.ReplaceAll(safeDrinkName, "<", "")
 	safeDrinkName = strings.ReplaceAll(safeDrinkName, ">", "")
 	safeDrinkName = strings.ReplaceAll(safeDrinkName, "|", "")
+	safeDrinkName = strings.ReplaceAll(safeDrinkName, "..", "")
 
 	if safeDrinkName == "" {
 		safeDrinkName = "unknown"
 	}
 
+	// 같은 초에 같은 음료를 저장해도 덮어쓰지 않도록 내용 해시를 붙임
 	timestamp := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("%s_%s.jpg", timestamp, safeDrinkName)
-	filePath := filepath.Join(userDir, filename)
+	key := fmt.Sprintf("%d/%s_%s_%s.jpg", userID, timestamp, safeDrinkName, CalculateImageHash(imageData)[:8])
+
+	if err := imageStore.Put(key, imageData, http.DetectContentType(imageData)); err != nil {
+		return "", err
+	}
+	recordStoredImage(key, userID, int64(len(imageData)), models.StoredImageOriginal)
+
+	return key, nil
+}
+
+// SaveProcessedImage : 전처리된 이미지와 썸네일 저장 후 원본 키 반환
+// 썸네일은 ThumbnailKey(key)에 저장
+func SaveProcessedImage(image *PreprocessedImage, userID uint, drinkName string) (string, error) {
+	if err := ensureImageQuota(userID, int64(len(image.Data)+len(image.Thumbnail))); err != nil {
+		println("⚠️ 이미지 저장 생략:", err.Error())
+		return "", err
+	}
+
+	key, err := putImage(image.Data, userID, drinkName)
+	if err != nil {
+		return "", err
+	}
+	if len(image.Thumbnail) > 0 {
+		if err := imageStore.Put(ThumbnailKey(key), image.Thumbnail, "image/jpeg"); err != nil {
+			println("⚠️ 썸네일 저장 실패:", err.Error())
+		} else {
+			recordStoredImage(ThumbnailKey(key), userID, int64(len(image.Thumbnail)), models.StoredImageThumbnail)
+		}
+	}
+	return key, nil
+}
+
+// ThumbnailKey : 이미지 키에 대응하는 썸네일 키
+func ThumbnailKey(key string) string {
+	return strings.TrimSuffix(key, ".jpg") + "_thumb.jpg"
+}
 
-	// 파일 저장
-	if err := os.WriteFile(filePath, imageData, 0644); err != nil {
-		return "", fmt.Errorf("파일 저장 실패: %v", err)
+// ImageURL : 저장소 키의 공개 URL (공개 접근이 불가능하면 "")
+func ImageURL(key string) string {
+	return imageStore.URL(key)
+}
+
+// migrateLegacyImagePaths : 예전에 저장된 절대 경로(D:\caffy\images\12\파일.jpg)를 저장소 키(12/파일.jpg)로 변환
+// 예전 SaveImage는 항상 {기본폴더}/{userID}/{파일명}으로 저장했으므로 마지막 두 구간이 키가 됨
+func migrateLegacyImagePaths() {
+	if config.DB == nil {
+		return
 	}
 
-	return filePath, nil
+	tables := []interface{}{&models.BeverageImage{}, &models.RecognitionLog{}}
+	for _, table := range tables {
+		var rows []struct {
+			ID        uint
+			ImagePath string
+		}
+		config.DB.Model(table).
+			Where("image_path LIKE ? OR image_path LIKE ? OR image_path LIKE ?", "%\\\\%", "/%", "_:%").
+			Select("id, image_path").
+			Find(&rows)
+
+		migrated := 0
+		for _, row := range rows {
+			key, ok := legacyImageKey(row.ImagePath)
+			if !ok {
+				continue
+			}
+			if config.DB.Model(table).Where("id = ?", row.ID).Update("image_path", key).Error == nil {
+				migrated++
+			}
+		}
+		if migrated > 0 {
+			println("🔁 이미지 경로를 저장소 키로 변환:", migrated, "건")
+		}
+	}
+}
+
+// legacyImageKey : 절대 경로에서 {userID}/{파일명} 키 추출
+func legacyImageKey(imagePath string) (string, bool) {
+	parts := strings.FieldsFunc(imagePath, func(r rune) bool { return r == '/' || r == '\\' })
+	if len(parts) < 2 {
+		return "", false
+	}
+	key := parts[len(parts)-2] + "/" + parts[len(parts)-1]
+	if _, err := cleanImageKey(key); err != nil {
+		return "", false
+	}
+	return key, true
 }
 
 // CalculateImageHash : 이미지의 MD5 해시 계산 (간단한 중복 체크용)
@@ -71,18 +167,12 @@ func CalculateImageHash(imageData []byte) string {
 	return hex.EncodeToString(hash[:])
 }
 
-// DeleteImage : 이미지 파일 삭제
-func DeleteImage(filePath string) error {
-	return os.Remove(filePath)
+// DeleteImage : 저장소에서 이미지 삭제
+func DeleteImage(key string) error {
+	return imageStore.Delete(key)
 }
 
-// GetImageData : 이미지 파일 읽기
-func GetImageData(filePath string) ([]byte, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	return io.ReadAll(file)
+// GetImageData : 저장소에서 이미지 읽기
+func GetImageData(key string) ([]byte, error) {
+	return imageStore.Get(key)
 }
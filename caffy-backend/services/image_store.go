@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ========================================
+// 이미지 저장소 (로컬 디렉터리 / S3 호환)
+// ========================================
+
+var (
+	ErrImageNotFound   = errors.New("이미지를 찾을 수 없습니다")
+	ErrInvalidImageKey = errors.New("잘못된 이미지 키입니다")
+)
+
+// ImageStore : 이미지 저장소
+// 키는 저장소와 무관한 "/" 구분 상대 경로 (예: "12/20250101_120000_아메리카노_ab12cd34.jpg")
+type ImageStore interface {
+	Put(key string, data []byte, contentType string) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+	URL(key string) string // 공개 URL (공개 접근이 불가능하면 "")
+	List(prefix string) ([]ImageObject, error)
+}
+
+// ImageObject : 저장소에 있는 파일 정보 (정리 작업용)
+type ImageObject struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// cleanImageKey : 키 정규화 및 검증 (절대 경로, 상위 디렉터리 탈출 방지)
+func cleanImageKey(key string) (string, error) {
+	key = strings.ReplaceAll(key, "\\", "/")
+	if key == "" || strings.HasPrefix(key, "/") {
+		return "", ErrInvalidImageKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidImageKey
+	}
+	return cleaned, nil
+}
+
+// ========================================
+// 로컬 파일시스템 저장소
+// ========================================
+
+// LocalImageStore : 설정된 업로드 폴더에 저장
+// 사용자 사진이므로 보통 공개 경로 없이 쓰고 (URLPrefix ""), 조회는 인증된 API가 Get으로 처리
+type LocalImageStore struct {
+	Root      string // 저장 루트 (config.UploadPath)
+	URLPrefix string // 정적 서빙 경로 (비우면 공개 URL 없음)
+}
+
+// NewLocalImageStore : 로컬 저장소 생성 (루트 폴더가 없으면 생성)
+func NewLocalImageStore(root string, urlPrefix string) (*LocalImageStore, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("이미지 폴더 생성 실패: %v", err)
+	}
+	return &LocalImageStore{Root: root, URLPrefix: strings.TrimSuffix(urlPrefix, "/")}, nil
+}
+
+func (s *LocalImageStore) filePath(key string) (string, error) {
+	cleaned, err := cleanImageKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.Root, filepath.FromSlash(cleaned)), nil
+}
+
+// Put : 파일 저장 (하위 폴더 자동 생성)
+func (s *LocalImageStore) Put(key string, data []byte, contentType string) error {
+	filePath, err := s.filePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("폴더 생성 실패: %v", err)
+	}
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return fmt.Errorf("파일 저장 실패: %v", err)
+	}
+	return nil
+}
+
+// Get : 파일 읽기
+func (s *LocalImageStore) Get(key string) ([]byte, error) {
+	filePath, err := s.filePath(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrImageNotFound
+	}
+	return data, err
+}
+
+// Delete : 파일 삭제 (이미 없으면 성공으로 취급)
+func (s *LocalImageStore) Delete(key string) error {
+	filePath, err := s.filePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// URL : 정적 서빙 URL
+func (s *LocalImageStore) URL(key string) string {
+	cleaned, err := cleanImageKey(key)
+	if err != nil || s.URLPrefix == "" {
+		return ""
+	}
+	return s.URLPrefix + "/" + cleaned
+}
+
+// List : prefix로 시작하는 모든 파일 (""이면 전체)
+func (s *LocalImageStore) List(prefix string) ([]ImageObject, error) {
+	var objects []ImageObject
+	err := filepath.WalkDir(s.Root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(s.Root, filePath)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil // 조회 중 삭제된 파일
+		}
+		objects = append(objects, ImageObject{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("이미지 폴더 조회 실패: %v", err)
+	}
+	return objects, nil
+}
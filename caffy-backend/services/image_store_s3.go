@@ -0,0 +1,357 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ========================================
+// S3 호환 저장소 (AWS S3, MinIO 등)
+// 외부 SDK 없이 Signature V4로 직접 서명
+// ========================================
+
+// S3ImageStore : S3 호환 오브젝트 스토리지
+type S3ImageStore struct {
+	Endpoint     string // 예: "https://s3.ap-northeast-2.amazonaws.com", "http://localhost:9000"
+	Region       string
+	Bucket       string
+	AccessKey    string
+	SecretKey    string
+	UsePathStyle bool   // MinIO는 보통 path-style (endpoint/bucket/key)
+	PublicURL    string // 공개 읽기 URL 접두사 (비워두면 URL() == "")
+
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3ImageStore : S3 저장소 생성
+func NewS3ImageStore(endpoint, region, bucket, accessKey, secretKey string, usePathStyle bool, publicURL string) (*S3ImageStore, error) {
+	if endpoint == "" || bucket == "" || accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("S3 설정이 부족합니다 (S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY, S3_SECRET_KEY)")
+	}
+	if _, err := url.Parse(endpoint); err != nil {
+		return nil, fmt.Errorf("S3_ENDPOINT 형식 오류: %v", err)
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &S3ImageStore{
+		Endpoint:     strings.TrimSuffix(endpoint, "/"),
+		Region:       region,
+		Bucket:       bucket,
+		AccessKey:    accessKey,
+		SecretKey:    secretKey,
+		UsePathStyle: usePathStyle,
+		PublicURL:    strings.TrimSuffix(publicURL, "/"),
+		client:       &http.Client{Timeout: 30 * time.Second},
+		now:          time.Now,
+	}, nil
+}
+
+// Put : 오브젝트 업로드
+func (s *S3ImageStore) Put(key string, data []byte, contentType string) error {
+	resp, err := s.do(http.MethodPut, key, data, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return s3Error("업로드", resp)
+	}
+	return nil
+}
+
+// Get : 오브젝트 다운로드
+func (s *S3ImageStore) Get(key string) ([]byte, error) {
+	resp, err := s.do(http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrImageNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, s3Error("다운로드", resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Delete : 오브젝트 삭제 (S3는 없는 키 삭제도 성공으로 응답)
+func (s *S3ImageStore) Delete(key string) error {
+	resp, err := s.do(http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return s3Error("삭제", resp)
+	}
+	return nil
+}
+
+// URL : 공개 URL (PublicURL이 설정된 경우만)
+func (s *S3ImageStore) URL(key string) string {
+	cleaned, err := cleanImageKey(key)
+	if err != nil || s.PublicURL == "" {
+		return ""
+	}
+	return s.PublicURL + "/" + s3EncodePath(cleaned)
+}
+
+// s3ListResult : ListObjectsV2 응답
+type s3ListResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List : prefix로 시작하는 모든 오브젝트 (ListObjectsV2, 1000개 단위로 이어서 조회)
+func (s *S3ImageStore) List(prefix string) ([]ImageObject, error) {
+	var objects []ImageObject
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		target, err := s.bucketURL()
+		if err != nil {
+			return nil, err
+		}
+		target.RawQuery = canonicalQuery(query)
+
+		req, err := http.NewRequest(http.MethodGet, target.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		signS3Request(req, nil, s.AccessKey, s.SecretKey, s.Region, s.now().UTC())
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("S3 요청 실패: %v", err)
+		}
+		if resp.StatusCode/100 != 2 {
+			err := s3Error("목록 조회", resp)
+			resp.Body.Close()
+			return nil, err
+		}
+
+		var page s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("S3 목록 응답 파싱 실패: %v", err)
+		}
+
+		for _, item := range page.Contents {
+			objects = append(objects, ImageObject{Key: item.Key, Size: item.Size, ModTime: item.LastModified})
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// do : 서명된 요청 전송
+func (s *S3ImageStore) do(method string, key string, body []byte, contentType string) (*http.Response, error) {
+	cleaned, err := cleanImageKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.objectURL(cleaned)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	signS3Request(req, body, s.AccessKey, s.SecretKey, s.Region, s.now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("S3 요청 실패: %v", err)
+	}
+	return resp, nil
+}
+
+// objectURL : 버킷/키에 해당하는 요청 URL
+func (s *S3ImageStore) objectURL(key string) (*url.URL, error) {
+	base, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	objectPath := "/" + key
+	if s.UsePathStyle {
+		objectPath = "/" + s.Bucket + objectPath
+	} else {
+		base.Host = s.Bucket + "." + base.Host
+	}
+	base.Path = objectPath
+	base.RawPath = s3EncodePath(objectPath)
+	return base, nil
+}
+
+// bucketURL : 버킷 자체에 대한 요청 URL (목록 조회용)
+func (s *S3ImageStore) bucketURL() (*url.URL, error) {
+	base, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if s.UsePathStyle {
+		base.Path = "/" + s.Bucket + "/"
+	} else {
+		base.Host = s.Bucket + "." + base.Host
+		base.Path = "/"
+	}
+	base.RawPath = s3EncodePath(base.Path)
+	return base, nil
+}
+
+// s3Error : 오류 응답 본문을 포함한 에러
+func s3Error(action string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("S3 %s 실패 (HTTP %d): %s", action, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// ========================================
+// AWS Signature Version 4
+// ========================================
+
+// signS3Request : 요청에 SigV4 Authorization 헤더 추가
+// host, x-amz-*, content-type, range 헤더를 서명에 포함
+func signS3Request(req *http.Request, body []byte, accessKey, secretKey, region string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// 서명할 헤더 (소문자, 정렬)
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" || lower == "range" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+secretKey), date)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature,
+	))
+}
+
+// canonicalQuery : 정렬 및 인코딩된 쿼리 문자열
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		vals := append([]string(nil), values[key]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, s3Encode(key, true)+"="+s3Encode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3EncodePath : 경로 인코딩 ("/"는 유지)
+func s3EncodePath(p string) string {
+	return s3Encode(p, false)
+}
+
+// s3Encode : AWS 규칙의 URI 인코딩 (unreserved 문자만 그대로)
+func s3Encode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"errors"
+	"sync"
+)
+
+// ========================================
+// 동일 요청 병합 (in-flight coalescing)
+// ========================================
+
+// errInflightPanicked : 먼저 실행한 호출이 panic으로 끝나 공유할 결과가 없음
+var errInflightPanicked = errors.New("동일 요청을 처리하던 호출이 비정상 종료되었습니다")
+
+// inflightCall : 진행 중인 호출 하나
+type inflightCall struct {
+	wg   sync.WaitGroup
+	val  interface{}
+	err  error
+	dups int // 결과를 공유받는 중복 호출 수
+}
+
+// inflightGroup : 같은 키로 동시에 들어온 호출을 하나로 합침
+// 먼저 들어온 호출만 fn을 실행하고, 나머지는 그 결과를 기다렸다가 공유받음
+type inflightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*inflightCall
+}
+
+// Do : key에 대해 fn을 한 번만 실행 (shared=true면 다른 호출의 결과를 공유받음)
+func (g *inflightGroup) Do(key string, fn func() (interface{}, error)) (val interface{}, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*inflightCall)
+	}
+	if call, ok := g.calls[key]; ok {
+		call.dups++
+		g.mu.Unlock()
+		call.wg.Wait()
+		return call.val, call.err, true
+	}
+
+	call := &inflightCall{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	// fn이 panic으로 끝나도 기다리는 호출이 빈 결과를 받지 않도록 에러를 채워서 깨움
+	// dups는 중복 호출이 잠금 안에서 늘리므로 잠금 안에서 읽음
+	normalReturn := false
+	defer func() {
+		if !normalReturn {
+			call.val, call.err = nil, errInflightPanicked
+		}
+		g.mu.Lock()
+		shared = call.dups > 0
+		delete(g.calls, key)
+		g.mu.Unlock()
+		call.wg.Done()
+	}()
+
+	call.val, call.err = fn()
+	normalReturn = true
+	return call.val, call.err, false
+}
+
+// waiting : key에 대해 결과를 기다리는 중복 호출 수
+func (g *inflightGroup) waiting(key string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if call, ok := g.calls[key]; ok {
+		return call.dups
+	}
+	return 0
+}
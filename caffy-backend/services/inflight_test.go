@@ -0,0 +1,267 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 같은 이미지 해시로 동시에 들어온 요청은 프로바이더를 한 번만 호출해야 함
+func TestRecognizeCoalescedSharesSingleProviderCall(t *testing.T) {
+	const concurrent = 8
+	const imageHash = "coalesce-test-hash"
+
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	original := llmRecognize
+	llmRecognize = func(imageBase64 string, mimeType string) (*LLMRecognitionResult, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+		}
+		<-release
+		return &LLMRecognitionResult{DrinkName: "아메리카노", CaffeineAmount: 150, Confidence: 0.9}, nil
+	}
+	defer func() { llmRecognize = original }()
+
+	var wg sync.WaitGroup
+	results := make([]*LLMRecognitionResult, concurrent)
+	errs := make([]error, concurrent)
+	run := func(i int) {
+		defer wg.Done()
+		results[i], errs[i] = recognizeCoalesced(imageHash, "aW1hZ2U=", "image/jpeg")
+	}
+
+	wg.Add(1)
+	go run(0)
+	<-started
+
+	for i := 1; i < concurrent; i++ {
+		wg.Add(1)
+		go run(i)
+	}
+
+	// 나머지 요청이 모두 진행 중인 호출에 합류할 때까지 대기
+	deadline := time.Now().Add(2 * time.Second)
+	for recognitionFlights.waiting(imageHash) < concurrent-1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("중복 요청 합류 대기 시간 초과: %d/%d", recognitionFlights.waiting(imageHash), concurrent-1)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("프로바이더 호출 횟수 = %d, want 1", got)
+	}
+	for i := 0; i < concurrent; i++ {
+		if errs[i] != nil {
+			t.Fatalf("요청 %d 실패: %v", i, errs[i])
+		}
+		if results[i].DrinkName != "아메리카노" || results[i].CaffeineAmount != 150 {
+			t.Fatalf("요청 %d 결과 불일치: %+v", i, results[i])
+		}
+	}
+
+	// 호출자마다 독립된 복사본을 받아야 함
+	results[0].DrinkName = "변경됨"
+	if results[1].DrinkName != "아메리카노" {
+		t.Fatalf("공유 결과가 호출자 간에 복사되지 않음")
+	}
+}
+
+// 동시에 합류한 호출자들이 각자 음료 목록을 고쳐도 서로의 결과에 섞이지 않아야 함 (-race로 확인)
+func TestRecognizeCoalescedReturnsIndependentDrinks(t *testing.T) {
+	const concurrent = 8
+	const imageHash = "coalesce-drinks-hash"
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+
+	original := llmRecognize
+	llmRecognize = func(imageBase64 string, mimeType string) (*LLMRecognitionResult, error) {
+		once.Do(func() { close(started) })
+		<-release
+		sharedID := uint(1)
+		drinks := []DetectedDrink{
+			{DrinkName: "아메리카노", CaffeineAmount: 150, BeverageID: &sharedID, Region: &RegionHint{X: 0.1, Y: 0.1, Width: 0.5, Height: 0.5}},
+			{DrinkName: "레드불", CaffeineAmount: 62},
+		}
+		return &LLMRecognitionResult{
+			DrinkName:  "아메리카노",
+			Drinks:     drinks,
+			Candidates: []RecognitionCandidate{{DrinkName: "아메리카노", BeverageID: &sharedID, Drinks: drinks}},
+		}, nil
+	}
+	defer func() { llmRecognize = original }()
+
+	var wg sync.WaitGroup
+	results := make([]*LLMRecognitionResult, concurrent)
+	run := func(i int) {
+		defer wg.Done()
+		result, err := recognizeCoalesced(imageHash, "aW1hZ2U=", "image/jpeg")
+		if err != nil {
+			t.Errorf("요청 %d 실패: %v", i, err)
+			return
+		}
+		// SmartRecognizeDrink처럼 호출자마다 자기 음료 ID를 채움
+		for j := range result.Drinks {
+			id := uint(100*(i+1) + j)
+			result.Drinks[j].BeverageID = &id
+		}
+		result.Drinks[0].Region.X = float64(i)
+		*result.Candidates[0].BeverageID = uint(1000 + i)
+		result.Candidates[0].Drinks[1].DrinkName = "변경됨"
+		results[i] = result
+	}
+
+	wg.Add(1)
+	go run(0)
+	<-started
+	for i := 1; i < concurrent; i++ {
+		wg.Add(1)
+		go run(i)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for recognitionFlights.waiting(imageHash) < concurrent-1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("중복 요청 합류 대기 시간 초과: %d/%d", recognitionFlights.waiting(imageHash), concurrent-1)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	for i, result := range results {
+		if result == nil {
+			t.Fatalf("요청 %d 결과 없음", i)
+		}
+		for j, drink := range result.Drinks {
+			if want := uint(100*(i+1) + j); drink.BeverageID == nil || *drink.BeverageID != want {
+				t.Fatalf("요청 %d 음료 %d의 BeverageID = %v, want %d", i, j, drink.BeverageID, want)
+			}
+		}
+		if result.Drinks[0].Region.X != float64(i) {
+			t.Fatalf("요청 %d 영역이 다른 호출자와 공유됨: %+v", i, result.Drinks[0].Region)
+		}
+		if *result.Candidates[0].BeverageID != uint(1000+i) {
+			t.Fatalf("요청 %d 후보 BeverageID가 다른 호출자와 공유됨: %d", i, *result.Candidates[0].BeverageID)
+		}
+		if result.Drinks[1].DrinkName != "레드불" {
+			t.Fatalf("요청 %d 후보 음료 목록이 대표 음료 목록과 공유됨", i)
+		}
+	}
+}
+
+// 진행 중인 호출이 끝나면 같은 해시라도 다시 프로바이더를 호출해야 함
+func TestRecognizeCoalescedCallsAgainAfterCompletion(t *testing.T) {
+	var calls int32
+
+	original := llmRecognize
+	llmRecognize = func(imageBase64 string, mimeType string) (*LLMRecognitionResult, error) {
+		atomic.AddInt32(&calls, 1)
+		return &LLMRecognitionResult{DrinkName: "레드불", CaffeineAmount: 62}, nil
+	}
+	defer func() { llmRecognize = original }()
+
+	for i := 0; i < 2; i++ {
+		if _, err := recognizeCoalesced("sequential-hash", "aW1hZ2U=", "image/jpeg"); err != nil {
+			t.Fatalf("인식 실패: %v", err)
+		}
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("프로바이더 호출 횟수 = %d, want 2", got)
+	}
+}
+
+// 먼저 실행한 호출이 panic으로 끝나면 기다리던 호출은 빈 결과 대신 에러를 받아야 함
+func TestRecognizeCoalescedOwnerPanic(t *testing.T) {
+	const concurrent = 4
+	const imageHash = "coalesce-panic-hash"
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+
+	original := llmRecognize
+	llmRecognize = func(imageBase64 string, mimeType string) (*LLMRecognitionResult, error) {
+		once.Do(func() { close(started) })
+		<-release
+		panic("프로바이더 응답 처리 실패")
+	}
+	defer func() { llmRecognize = original }()
+
+	var wg sync.WaitGroup
+	errs := make([]error, concurrent)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() {
+			if recover() == nil {
+				t.Error("먼저 실행한 호출의 panic이 전파되지 않음")
+			}
+		}()
+		recognizeCoalesced(imageHash, "aW1hZ2U=", "image/jpeg")
+	}()
+	<-started
+	for i := 1; i < concurrent; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = recognizeCoalesced(imageHash, "aW1hZ2U=", "image/jpeg")
+		}(i)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for recognitionFlights.waiting(imageHash) < concurrent-1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("중복 요청 합류 대기 시간 초과: %d/%d", recognitionFlights.waiting(imageHash), concurrent-1)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	for i := 1; i < concurrent; i++ {
+		if !errors.Is(errs[i], errInflightPanicked) {
+			t.Errorf("요청 %d 에러 = %v, want errInflightPanicked", i, errs[i])
+		}
+	}
+}
+
+// 대표 후보의 음료 목록은 결과의 음료 목록과 따로 수정할 수 있어야 함
+func TestLinkRecognitionCandidatesCopiesDrinks(t *testing.T) {
+	beverageID := uint(4)
+	result := &LLMRecognitionResult{
+		DrinkName: "아메리카노",
+		Drinks:    []DetectedDrink{{DrinkName: "아메리카노", BeverageID: &beverageID, Region: &RegionHint{X: 0.1}}},
+		Candidates: []RecognitionCandidate{
+			{Provider: "gemini", DrinkName: "아메리카노"},
+			{Provider: "openai", DrinkName: "라떼"}, // 브랜드가 없으면 음료 DB를 찾지 않음
+		},
+	}
+	shared := result.Candidates
+
+	linkRecognitionCandidates(result, 1)
+	if got := result.Candidates[0].BeverageID; got == nil || *got != 4 {
+		t.Fatalf("대표 후보 BeverageID = %v, want 4", got)
+	}
+	if shared[0].BeverageID != nil {
+		t.Error("원래 후보 목록을 수정함")
+	}
+
+	*result.Drinks[0].BeverageID = 99
+	result.Drinks[0].Region.X = 0.9
+	result.Drinks[0].DrinkName = "바뀜"
+	drink := result.Candidates[0].Drinks[0]
+	if *drink.BeverageID != 4 || drink.Region.X != 0.1 || drink.DrinkName != "아메리카노" {
+		t.Errorf("결과의 음료를 바꾸면 후보도 바뀜: %+v", drink)
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ========================================
+// 인식 결과 → 섭취 기록 연결 서비스
+// ========================================
+
+var ErrNothingRecognized = errors.New("음료를 인식하지 못했습니다. 카페인량을 직접 입력해 주세요")
+
+// RecognizeAndLogOptions : 인식과 동시에 기록할 때 사용자가 조정한 값
+type RecognizeAndLogOptions struct {
+	DrinkIndex int        // 사진 속 음료 중 기록할 음료 (0: 대표 음료)
+	DrinkName  *string    // 음료 이름 직접 입력
+	Amount     *float64   // 카페인량 직접 입력 (mg, 원래 양)
+	Ratio      *float64   // 실제 마신 비율 (0.0~1.0)
+	IntakeAt   *time.Time // 마신 시간 (기본: 지금)
+}
+
+// RecognizeAndLog : 사진 인식 후 결과로 섭취 기록까지 한 번에 생성
+// 인식에 실패해도 카페인량을 직접 입력했다면 기록은 남김
+func RecognizeAndLog(userID uint, imageBase64 string, opts RecognizeAndLogOptions) (*SmartRecognitionResult, *models.CaffeineLog, error) {
+	result, err := SmartRecognizeDrink(imageBase64, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	drink := DetectedDrink{
+		DrinkName:      result.DrinkName,
+		CaffeineAmount: result.CaffeineAmount,
+		BeverageID:     result.PendingBeverageID,
+	}
+	if opts.DrinkIndex < 0 || (opts.DrinkIndex > 0 && opts.DrinkIndex >= len(result.Drinks)) {
+		return result, nil, fmt.Errorf("drink_index가 범위를 벗어났습니다 (인식된 음료 %d개)", len(result.Drinks))
+	}
+	if len(result.Drinks) > 0 {
+		drink = result.Drinks[opts.DrinkIndex]
+	}
+
+	if !result.Found && opts.Amount == nil {
+		return result, nil, ErrNothingRecognized
+	}
+
+	log := models.CaffeineLog{
+		UserID:           userID,
+		DrinkName:        drink.DrinkName,
+		OriginalAmount:   float64(drink.CaffeineAmount),
+		ConsumedRatio:    1.0,
+		IntakeAt:         time.Now(),
+		BeverageID:       drink.BeverageID,
+		RecognitionLogID: nonZeroID(result.RecognitionLogID),
+		BeverageImageID:  nonZeroID(result.ImageID),
+		DrinkIndex:       opts.DrinkIndex,
+	}
+	if opts.DrinkName != nil && *opts.DrinkName != "" {
+		log.DrinkName = *opts.DrinkName
+	}
+	if opts.Amount != nil {
+		log.OriginalAmount = *opts.Amount
+		log.AmountOverridden = true
+	}
+	if opts.Ratio != nil {
+		log.ConsumedRatio = *opts.Ratio
+	}
+	if opts.IntakeAt != nil && !opts.IntakeAt.IsZero() {
+		log.IntakeAt = *opts.IntakeAt
+	}
+	log.Amount = log.OriginalAmount * log.ConsumedRatio
+
+	if err := config.DB.Create(&log).Error; err != nil {
+		return result, nil, fmt.Errorf("기록 저장 실패: %v", err)
+	}
+	return result, &log, nil
+}
+
+// FindUserRecognitionLog : 사용자의 인식 로그 조회 (기존 기록 API에서 출처 연결용)
+func FindUserRecognitionLog(userID uint, logID uint) (*models.RecognitionLog, error) {
+	var log models.RecognitionLog
+	if err := config.DB.Where("id = ? AND user_id = ?", logID, userID).First(&log).Error; err != nil {
+		return nil, ErrRecognitionLogNotFound
+	}
+	return &log, nil
+}
+
+// applyCorrectionToIntakeLogs : 정정된 음료를 인식 로그에 연결된 섭취 기록에 반영
+// 피드백은 대표 음료에 대한 것이므로 같은 사진의 다른 음료 기록은 그대로 둠
+// 사용자가 카페인량을 직접 입력한 기록은 이름과 음료 연결만 바꾸고 양은 유지
+func applyCorrectionToIntakeLogs(tx *gorm.DB, recognitionLogID uint, corrected *models.Beverage) (int64, error) {
+	linked := tx.Model(&models.CaffeineLog{}).Where("recognition_log_id = ? AND drink_index = ?", recognitionLogID, 0).Session(&gorm.Session{})
+
+	renamed := linked.
+		Where("amount_overridden = ?", true).
+		Updates(map[string]interface{}{
+			"beverage_id": corrected.ID,
+			"drink_name":  corrected.Name,
+		})
+	if renamed.Error != nil {
+		return 0, renamed.Error
+	}
+
+	recalculated := linked.
+		Where("amount_overridden = ?", false).
+		Updates(map[string]interface{}{
+			"beverage_id":     corrected.ID,
+			"drink_name":      corrected.Name,
+			"original_amount": corrected.CaffeineAmount,
+			"amount":          gorm.Expr("? * consumed_ratio", corrected.CaffeineAmount),
+		})
+	if recalculated.Error != nil {
+		return 0, recalculated.Error
+	}
+
+	return renamed.RowsAffected + recalculated.RowsAffected, nil
+}
+
+// nonZeroID : 0이면 nil (저장되지 않은 행 참조 방지)
+func nonZeroID(id uint) *uint {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,604 @@
+package services
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ========================================
+// 영양성분표 카페인 파서
+// ========================================
+
+// 카페인 함량 기준
+const (
+	LabelBasisUnknown   = ""
+	LabelBasisPer100ML  = "per_100ml" // 100ml당
+	LabelBasisServing   = "serving"   // 1회 제공량당
+	LabelBasisContainer = "container" // 총 내용량(1병, 1캔)당
+)
+
+// LabelCaffeineInfo : 영양성분표(OCR 텍스트)에서 추출한 카페인 정보
+type LabelCaffeineInfo struct {
+	Found         bool    `json:"found"`          // 카페인 함량을 찾았는지
+	ProductName   string  `json:"product_name"`   // 추정 제품명
+	PerHundredML  float64 `json:"per_100ml"`      // 100ml당 카페인 (mg)
+	PerServing    float64 `json:"per_serving"`    // 1회 제공량당 카페인 (mg)
+	PerContainer  float64 `json:"per_container"`  // 총 내용량당 카페인 (mg)
+	ServingML     float64 `json:"serving_ml"`     // 1회 제공량 (ml)
+	VolumeML      float64 `json:"volume_ml"`      // 총 내용량 (ml)
+	TotalCaffeine float64 `json:"total_caffeine"` // 제품 전체 카페인 (mg, 계산 불가 시 0)
+	Basis         string  `json:"basis"`          // TotalCaffeine을 계산한 기준
+	IsRange       bool    `json:"is_range"`       // "30~40mg"처럼 범위로 표기되었는지
+}
+
+// labelQuantity : 텍스트 속 수치 하나 (예: "32mg", "30~40mg", "355ml")
+type labelQuantity struct {
+	value   float64 // 범위면 중간값
+	isRange bool
+	unit    string // "mg", "ml", "g", "kcal", "%", "" (단위 없음)
+	start   int    // 라인 내 시작 바이트 위치
+	end     int    // 라인 내 끝 바이트 위치
+}
+
+// labelBasisMarker : 기준 표기 하나 (예: "100ml당", "1회 제공량(250ml)당", "1캔당")
+type labelBasisMarker struct {
+	basis string
+	ml    float64 // 표기에 포함된 용량 (있으면)
+	start int
+}
+
+var (
+	labelQuantityPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)(?:\s*(?:~|-|∼|〜)\s*(\d+(?:\.\d+)?))?\s*(mg|ml|kcal|l|g|%)?`)
+	labelThousandsComma  = regexp.MustCompile(`(\d),(\d{3})`)
+
+	// "/100ml", "100ml당", "per 100ml" 처럼 mg 뒤에 붙는 용량 기준
+	labelPerMLSuffix = regexp.MustCompile(`^\s*(?:/|per)\s*(\d+(?:\.\d+)?)\s*(ml|l)\b`)
+
+	labelPer100Pattern    = regexp.MustCompile(`100\s*ml\s*(?:당|기준|에|중)|per\s*100\s*ml|/\s*100\s*ml`)
+	labelServingPattern   = regexp.MustCompile(`1\s*회\s*(?:제공량|분량|분)(?:\s*\(?\s*(\d+(?:\.\d+)?)\s*(ml|l)\s*\)?)?\s*(?:당|기준)?|per\s*serving|serving\s*size`)
+	labelContainerPattern = regexp.MustCompile(`총\s*내용량\s*(?:\(?\s*\d+(?:\.\d+)?\s*(?:ml|l)\s*\)?\s*)?(?:당|기준)|총\s*카페인|총\s*함량|(?:1\s*)?(?:병|캔|팩|컵|잔|개|포)\s*당|1\s*(?:병|캔|팩|컵|잔|포)|/\s*1?\s*(?:병|캔|팩|컵|잔|개|포)|per\s*(?:can|bottle|container|pack|cup)`)
+
+	labelVolumePattern   = regexp.MustCompile(`(?:총\s*)?내용량|용량|net\s*(?:vol|volume|contents|wt)?|contents|volume`)
+	labelServingsPattern = regexp.MustCompile(`총\s*(\d+)\s*회\s*(?:제공량|분량|분)`)
+
+	// 제품명 후보에서 제외할 영양성분표/포장 문구
+	labelNonNameKeywords = []string{
+		"영양", "성분", "열량", "나트륨", "탄수화물", "당류", "지방", "단백질", "콜레스테롤", "카페인",
+		"내용량", "원재료", "원산지", "제조", "유통", "소비기한", "보관", "섭취", "주의", "어린이", "임산부",
+		"기준치", "제공량", "kcal", "nutrition", "caffeine", "ingredients", "serving", "calories",
+		"sodium", "sugar", "protein", "fat", "www", "http", "고객", "반품", "교환", "바코드",
+	}
+)
+
+// ParseCaffeineLabel : OCR 텍스트에서 100ml당/1회 제공량당/총 내용량당 카페인과 용량을 해석
+func ParseCaffeineLabel(ocrText string) *LabelCaffeineInfo {
+	info := &LabelCaffeineInfo{}
+	if strings.TrimSpace(ocrText) == "" {
+		return info
+	}
+
+	lines := splitLabelLines(ocrText)
+	info.ProductName = guessProductName(ocrText)
+
+	parseLabelVolumes(lines, info)
+	parseLabelCaffeine(lines, info)
+	info.computeTotal()
+
+	return info
+}
+
+// splitLabelLines : 정규화 후 줄 단위로 분리 (빈 줄 제거)
+func splitLabelLines(text string) []string {
+	normalized := normalizeLabelText(text)
+	var lines []string
+	for _, line := range strings.Split(normalized, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// normalizeLabelText : 전각 문자, 단위 기호, 천 단위 쉼표 등을 파싱하기 쉬운 형태로 변환
+func normalizeLabelText(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r >= '０' && r <= '９':
+			b.WriteRune('0' + (r - '０'))
+		case r >= 'Ａ' && r <= 'Ｚ':
+			b.WriteRune('a' + (r - 'Ａ'))
+		case r >= 'ａ' && r <= 'ｚ':
+			b.WriteRune('a' + (r - 'ａ'))
+		case r == '㎎':
+			b.WriteString("mg")
+		case r == '㎖':
+			b.WriteString("ml")
+		case r == 'ℓ':
+			b.WriteString("l")
+		case r == '／':
+			b.WriteRune('/')
+		case r == '（':
+			b.WriteRune('(')
+		case r == '）':
+			b.WriteRune(')')
+		case r == '：':
+			b.WriteRune(':')
+		case r == '～':
+			b.WriteRune('~')
+		case r == '．':
+			b.WriteRune('.')
+		case r == '，':
+			b.WriteRune(',')
+		case r == '\t' || r == '|' || r == '│':
+			b.WriteRune(' ')
+		case r == '\r':
+			// 무시
+		default:
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+
+	normalized := b.String()
+	normalized = strings.ReplaceAll(normalized, "밀리그램", "mg")
+	normalized = strings.ReplaceAll(normalized, "밀리리터", "ml")
+	normalized = strings.ReplaceAll(normalized, "m l", "ml")
+	normalized = strings.ReplaceAll(normalized, "m g", "mg")
+	normalized = strings.ReplaceAll(normalized, "caffein ", "caffeine ")
+	normalized = labelThousandsComma.ReplaceAllString(normalized, "$1$2")
+	return normalized
+}
+
+// findLabelQuantities : 라인에서 수치와 단위 추출
+func findLabelQuantities(line string) []labelQuantity {
+	var quantities []labelQuantity
+	for _, m := range labelQuantityPattern.FindAllStringSubmatchIndex(line, -1) {
+		// 앞에 글자가 붙은 숫자 (예: "b12", "hot6")는 수치가 아님
+		if m[0] > 0 {
+			prev, _ := utf8.DecodeLastRuneInString(line[:m[0]])
+			if unicode.IsLetter(prev) && prev < utf8.RuneSelf {
+				continue
+			}
+		}
+
+		value, _ := strconv.ParseFloat(line[m[2]:m[3]], 64)
+		q := labelQuantity{value: value, start: m[0], end: m[1]}
+
+		if m[4] >= 0 {
+			upper, _ := strconv.ParseFloat(line[m[4]:m[5]], 64)
+			if upper > value {
+				q.value = (value + upper) / 2
+				q.isRange = true
+			}
+		}
+
+		if m[6] >= 0 {
+			unit := line[m[6]:m[7]]
+			// 단위 뒤에 영문이 이어지면 단위가 아님 (예: "5 large", "3 great")
+			if next, _ := utf8.DecodeRuneInString(line[m[7]:]); next < utf8.RuneSelf && unicode.IsLetter(next) {
+				unit = ""
+				q.end = m[6]
+			}
+			q.unit = unit
+		}
+
+		if q.unit == "l" {
+			q.value *= 1000
+			q.unit = "ml"
+		}
+
+		quantities = append(quantities, q)
+	}
+	return quantities
+}
+
+// findBasisMarkers : 라인에서 기준 표기를 위치 순서대로 추출
+func findBasisMarkers(line string) []labelBasisMarker {
+	var markers []labelBasisMarker
+
+	for _, m := range labelPer100Pattern.FindAllStringIndex(line, -1) {
+		markers = append(markers, labelBasisMarker{basis: LabelBasisPer100ML, ml: 100, start: m[0]})
+	}
+	for _, m := range labelServingPattern.FindAllStringSubmatchIndex(line, -1) {
+		marker := labelBasisMarker{basis: LabelBasisServing, start: m[0]}
+		if m[2] >= 0 {
+			ml, _ := strconv.ParseFloat(line[m[2]:m[3]], 64)
+			if line[m[4]:m[5]] == "l" {
+				ml *= 1000
+			}
+			marker.ml = ml
+		}
+		markers = append(markers, marker)
+	}
+	for _, m := range labelContainerPattern.FindAllStringIndex(line, -1) {
+		markers = append(markers, labelBasisMarker{basis: LabelBasisContainer, start: m[0]})
+	}
+
+	// 위치 순 정렬 (열 순서 = 표기 순서)
+	for i := 1; i < len(markers); i++ {
+		for j := i; j > 0 && markers[j].start < markers[j-1].start; j-- {
+			markers[j], markers[j-1] = markers[j-1], markers[j]
+		}
+	}
+	return markers
+}
+
+// isCaffeineLine : 카페인 언급이 있는 라인인지 (디카페인 문구 제외 안 함 - 0mg도 정보)
+func isCaffeineLine(line string) bool {
+	return strings.Contains(line, "카페인") || strings.Contains(line, "caffeine") || strings.Contains(line, "카페안")
+}
+
+// caffeineKeywordEnd : 카페인 키워드가 끝나는 위치
+func caffeineKeywordEnd(line string) int {
+	for _, keyword := range []string{"카페인", "caffeine", "카페안"} {
+		if idx := strings.Index(line, keyword); idx >= 0 {
+			return idx + len(keyword)
+		}
+	}
+	return 0
+}
+
+// isOtherNutrientLine : 카페인 외 다른 영양성분 항목인지
+func isOtherNutrientLine(line string) bool {
+	for _, keyword := range []string{"나트륨", "탄수화물", "당류", "지방", "단백질", "콜레스테롤", "열량", "sodium", "sugar", "protein", "fat", "calories"} {
+		if strings.Contains(line, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLabelVolumes : 총 내용량과 1회 제공량 추출
+func parseLabelVolumes(lines []string, info *LabelCaffeineInfo) {
+	var fallbackVolume, inlineVolume float64
+	servings := 0.0
+
+	for _, line := range lines {
+		if m := labelServingsPattern.FindStringSubmatch(line); m != nil {
+			servings, _ = strconv.ParseFloat(m[1], 64)
+		}
+
+		markers := findBasisMarkers(line)
+		for _, marker := range markers {
+			if marker.basis == LabelBasisServing && marker.ml > 0 && info.ServingML == 0 {
+				info.ServingML = marker.ml
+			}
+		}
+
+		quantities := mlQuantities(line, markers)
+		if len(quantities) == 0 {
+			continue
+		}
+
+		switch {
+		case labelVolumePattern.MatchString(line):
+			if info.VolumeML == 0 {
+				info.VolumeML = quantities[0]
+			}
+		case labelServingsPattern.MatchString(line):
+			// "총 2회 제공량(500ml)" → 전체 용량
+			if info.VolumeML == 0 {
+				info.VolumeML = quantities[0]
+			}
+		case isOtherNutrientLine(line):
+			// 성분 라인 속 용량은 기준 표기일 가능성이 높음
+		case isCaffeineLine(line):
+			// 한 줄로 읽힌 라벨 문구 (예: "카페인 32mg/100ml 355ml")
+			if inlineVolume == 0 {
+				inlineVolume = quantities[0]
+			}
+		default:
+			if fallbackVolume == 0 {
+				fallbackVolume = quantities[0]
+			}
+		}
+	}
+
+	if info.VolumeML == 0 {
+		info.VolumeML = fallbackVolume
+	}
+	if info.VolumeML == 0 {
+		info.VolumeML = inlineVolume
+	}
+	if info.ServingML == 0 && servings > 0 && info.VolumeML > 0 {
+		info.ServingML = info.VolumeML / servings
+	}
+}
+
+// mlQuantities : 기준 표기(100ml당 등)에 속하지 않은 ml 수치만 반환
+func mlQuantities(line string, markers []labelBasisMarker) []float64 {
+	var values []float64
+	for _, q := range findLabelQuantities(line) {
+		if q.unit != "ml" || q.value <= 0 || q.isRange {
+			continue
+		}
+		if partOfMarker(line, q, markers) {
+			continue
+		}
+		// "250ml x 6" 같은 묶음 표기는 낱개 용량만 사용
+		values = append(values, q.value)
+	}
+	return values
+}
+
+// partOfMarker : 수치가 기준 표기 안에 포함된 것인지
+func partOfMarker(line string, q labelQuantity, markers []labelBasisMarker) bool {
+	for _, marker := range markers {
+		if marker.ml <= 0 || math.Abs(marker.ml-q.value) > 0.01 {
+			continue
+		}
+		if q.start >= marker.start && q.start-marker.start < 40 {
+			return true
+		}
+	}
+	// "/100ml", "per 100ml" 처럼 mg 뒤에 붙은 용량
+	before := strings.TrimRight(line[:q.start], " ")
+	return strings.HasSuffix(before, "/") || strings.HasSuffix(before, "per") || strings.HasSuffix(before, "mg")
+}
+
+// parseLabelCaffeine : 카페인 라인에서 기준별 함량 추출
+func parseLabelCaffeine(lines []string, info *LabelCaffeineInfo) {
+	// 표 머리글의 기준 표기 (다단 표: "100ml당  총 내용량당")
+	var headerMarkers []labelBasisMarker
+
+	for i, line := range lines {
+		markers := findBasisMarkers(line)
+		if !isCaffeineLine(line) {
+			if len(markers) > 0 && !isOtherNutrientLine(line) {
+				headerMarkers = markers
+			}
+			continue
+		}
+
+		keywordEnd := caffeineKeywordEnd(line)
+		values := caffeineQuantities(line, keywordEnd)
+
+		// 값이 다음 줄로 밀린 경우 (OCR이 표의 열을 줄로 분리)
+		if len(values) == 0 {
+			for j := i + 1; j < len(lines) && j <= i+2; j++ {
+				if isOtherNutrientLine(lines[j]) || isCaffeineLine(lines[j]) {
+					break
+				}
+				if values = caffeineQuantities(lines[j], 0); len(values) > 0 {
+					if len(markers) == 0 {
+						markers = findBasisMarkers(lines[j])
+					}
+					line = lines[j]
+					break
+				}
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		for idx := range values {
+			basis, perML := resolveValueBasis(line, values, idx, markers, headerMarkers)
+			info.assign(basis, perML, values[idx])
+		}
+	}
+}
+
+// caffeineQuantities : 카페인 키워드 뒤의 mg 수치 (퍼센트, 다른 단위 제외)
+func caffeineQuantities(line string, from int) []labelQuantity {
+	var values []labelQuantity
+	all := findLabelQuantities(line)
+	for _, q := range all {
+		if q.start < from {
+			continue
+		}
+		switch q.unit {
+		case "mg":
+			values = append(values, q)
+		case "":
+			// 단위 없는 숫자는 키워드 바로 뒤에 하나만 있을 때 mg로 간주 (예: "caffeine 80")
+			if len(values) == 0 && from > 0 && q.value > 0 && q.value < 1000 &&
+				strings.TrimSpace(strings.Trim(line[from:q.start], " :()")) == "" &&
+				!followedByBasis(line, q) {
+				values = append(values, q)
+			}
+		}
+	}
+	return values
+}
+
+// followedByBasis : 단위 없는 숫자 뒤에 "회 제공량" 등이 이어지는지 (예: "1회")
+func followedByBasis(line string, q labelQuantity) bool {
+	rest := strings.TrimSpace(line[q.end:])
+	return strings.HasPrefix(rest, "회") || strings.HasPrefix(rest, "병") || strings.HasPrefix(rest, "캔")
+}
+
+// resolveValueBasis : 수치 하나의 기준 결정
+// 우선순위: 값 바로 뒤 "/100ml", "(1캔당)" → 같은 줄에서 값 앞의 기준 표기 → 표 머리글의 열 순서 → 미상
+func resolveValueBasis(line string, values []labelQuantity, index int, markers []labelBasisMarker, headerMarkers []labelBasisMarker) (string, float64) {
+	q := values[index]
+	rest := line[q.end:]
+
+	// 1. "32mg/100ml", "80mg per 250ml"
+	if m := labelPerMLSuffix.FindStringSubmatch(rest); m != nil {
+		ml, _ := strconv.ParseFloat(m[1], 64)
+		if m[2] == "l" {
+			ml *= 1000
+		}
+		if ml == 100 {
+			return LabelBasisPer100ML, 100
+		}
+		return LabelBasisServing, ml
+	}
+
+	// 2. "150mg (1캔당)", "80 mg per can" - 값 바로 뒤의 표기이고 뒤에 다른 값이 없을 때만
+	for _, marker := range markers {
+		offset := marker.start - q.end
+		if offset >= 0 && offset <= 3 && !hasValueAfter(values, marker.start) {
+			return marker.basis, marker.ml
+		}
+	}
+
+	// 3. "100ml당 32mg", "1회 제공량당 카페인 80mg" - 이전 값과 이 값 사이의 가장 가까운 표기
+	from := 0
+	if index > 0 {
+		from = values[index-1].end
+	}
+	var nearest *labelBasisMarker
+	for i := range markers {
+		if markers[i].start >= from && markers[i].start < q.start {
+			nearest = &markers[i]
+		}
+	}
+	if nearest != nil {
+		return nearest.basis, nearest.ml
+	}
+
+	// 4. 다단 표: 값 순서 = 머리글 기준 표기 순서
+	if len(headerMarkers) == len(values) {
+		return headerMarkers[index].basis, headerMarkers[index].ml
+	}
+	if len(values) == 1 && len(headerMarkers) == 1 {
+		return headerMarkers[0].basis, headerMarkers[0].ml
+	}
+	return LabelBasisUnknown, 0
+}
+
+// hasValueAfter : 위치 뒤에 다른 mg 값이 있는지
+func hasValueAfter(values []labelQuantity, pos int) bool {
+	for _, q := range values {
+		if q.start > pos {
+			return true
+		}
+	}
+	return false
+}
+
+// assign : 기준별 함량 저장 (먼저 찾은 값 우선)
+func (info *LabelCaffeineInfo) assign(basis string, perML float64, q labelQuantity) {
+	if q.value < 0 || q.value > 2000 {
+		return
+	}
+	info.Found = true
+	if q.isRange {
+		info.IsRange = true
+	}
+
+	switch basis {
+	case LabelBasisPer100ML:
+		if info.PerHundredML == 0 {
+			info.PerHundredML = q.value
+		}
+	case LabelBasisServing:
+		if perML > 0 && info.ServingML == 0 {
+			info.ServingML = perML
+		}
+		if info.PerServing == 0 {
+			info.PerServing = q.value
+		}
+	case LabelBasisContainer:
+		if info.PerContainer == 0 {
+			info.PerContainer = q.value
+		}
+	default:
+		// 기준 표기 없는 단일 값은 제품 전체 함량으로 간주 (고카페인 표시 관행)
+		if info.PerContainer == 0 && info.PerServing == 0 && info.PerHundredML == 0 {
+			info.PerContainer = q.value
+		}
+	}
+}
+
+// computeTotal : 총 내용량 기준 카페인 계산
+func (info *LabelCaffeineInfo) computeTotal() {
+	switch {
+	case info.PerContainer > 0 || (info.Found && info.PerServing == 0 && info.PerHundredML == 0):
+		info.TotalCaffeine = info.PerContainer
+		info.Basis = LabelBasisContainer
+	case info.PerServing > 0 && info.ServingML > 0 && info.VolumeML > 0:
+		info.TotalCaffeine = info.PerServing * info.VolumeML / info.ServingML
+		info.Basis = LabelBasisServing
+	case info.PerHundredML > 0 && info.VolumeML > 0:
+		info.TotalCaffeine = info.PerHundredML * info.VolumeML / 100
+		info.Basis = LabelBasisPer100ML
+	case info.PerServing > 0:
+		// 용량 정보가 없으면 1회 제공량을 한 병으로 간주
+		info.TotalCaffeine = info.PerServing
+		info.Basis = LabelBasisServing
+	case info.PerHundredML > 0:
+		// 총 용량을 모르면 전체 함량 계산 불가
+		info.Basis = LabelBasisPer100ML
+	}
+
+	// 다른 기준 값끼리도 서로 채워줌
+	if info.PerHundredML == 0 && info.TotalCaffeine > 0 && info.VolumeML > 0 {
+		info.PerHundredML = info.TotalCaffeine * 100 / info.VolumeML
+	}
+	info.TotalCaffeine = math.Round(info.TotalCaffeine*10) / 10
+	info.PerHundredML = math.Round(info.PerHundredML*10) / 10
+}
+
+// guessProductName : 제품명으로 보이는 줄 선택
+// "제품명:" 표기가 있으면 우선, 없으면 상단에서 영양성분/포장 문구가 아닌 첫 줄
+func guessProductName(ocrText string) string {
+	lines := strings.Split(ocrText, "\n")
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		for _, prefix := range []string{"제품명", "품명", "product name"} {
+			lower := strings.ToLower(trimmed)
+			if strings.HasPrefix(lower, prefix) {
+				name := strings.TrimSpace(strings.TrimLeft(trimmed[len(prefix):], " :：)"))
+				if name != "" {
+					return truncateRunes(name, 100)
+				}
+			}
+		}
+	}
+
+	for i, line := range lines {
+		if i >= 8 {
+			break
+		}
+		trimmed := strings.TrimSpace(line)
+		if isProductNameCandidate(trimmed) {
+			return truncateRunes(trimmed, 100)
+		}
+	}
+	return ""
+}
+
+// isProductNameCandidate : 제품명 후보가 될 수 있는 줄인지
+func isProductNameCandidate(line string) bool {
+	length := utf8.RuneCountInString(line)
+	if length < 2 || length > 40 {
+		return false
+	}
+
+	lower := strings.ToLower(line)
+	for _, keyword := range labelNonNameKeywords {
+		if strings.Contains(lower, keyword) {
+			return false
+		}
+	}
+
+	letters, digits := 0, 0
+	for _, r := range line {
+		switch {
+		case unicode.IsLetter(r):
+			letters++
+		case unicode.IsDigit(r):
+			digits++
+		}
+	}
+	// 숫자 위주 줄 (용량, 바코드, 날짜) 제외
+	return letters >= 2 && digits <= letters
+}
+
+// truncateRunes : 문자 단위로 자르기 (한글이 깨지지 않도록)
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit])
+}
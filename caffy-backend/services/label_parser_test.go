@@ -0,0 +1,406 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+// 실제 음료 라벨 OCR 텍스트 모음 (Vision API TEXT_DETECTION 출력 형태)
+func TestParseCaffeineLabel(t *testing.T) {
+	tests := []struct {
+		name         string
+		ocr          string
+		wantFound    bool
+		wantTotal    float64
+		wantBasis    string
+		wantPer100   float64
+		wantServing  float64
+		wantVolume   float64
+		wantRange    bool
+		wantProduct  string
+		checkProduct bool
+	}{
+		{
+			name:        "에너지드링크 - 기준 없는 단일 함량",
+			ocr:         "Red Bull\nENERGY DRINK\n250ml\n영양정보\n카페인 62.5mg",
+			wantFound:   true,
+			wantTotal:   62.5,
+			wantBasis:   LabelBasisContainer,
+			wantVolume:  250,
+			wantPer100:  25,
+			wantProduct: "Red Bull", checkProduct: true,
+		},
+		{
+			name:       "100ml당 슬래시 표기",
+			ocr:        "MONSTER ENERGY\n355ml\n카페인 32mg/100ml",
+			wantFound:  true,
+			wantTotal:  113.6,
+			wantBasis:  LabelBasisPer100ML,
+			wantPer100: 32,
+			wantVolume: 355,
+		},
+		{
+			name:       "100ml당 접두 표기",
+			ocr:        "몬스터 에너지 울트라\n내용량 355ml\n카페인(100ml당) 30mg",
+			wantFound:  true,
+			wantTotal:  106.5,
+			wantBasis:  LabelBasisPer100ML,
+			wantPer100: 30,
+			wantVolume: 355,
+		},
+		{
+			name:        "1회 제공량당 + 총 내용량",
+			ocr:         "핫식스\n총 내용량 500ml (1회 제공량 250ml)\n1회 제공량당\n카페인 80mg",
+			wantFound:   true,
+			wantTotal:   160,
+			wantBasis:   LabelBasisServing,
+			wantServing: 80,
+			wantVolume:  500,
+			wantPer100:  32,
+		},
+		{
+			name:        "1회 제공량(용량)당 같은 줄",
+			ocr:         "콜드브루 블랙\n1회 제공량(250ml)당 카페인 50mg\n총 내용량 500ml",
+			wantFound:   true,
+			wantTotal:   100,
+			wantBasis:   LabelBasisServing,
+			wantServing: 50,
+			wantVolume:  500,
+		},
+		{
+			name:        "총 N회 제공량",
+			ocr:         "아메리카노 블랙\n총 2회 제공량(500ml)\n1회 제공량당 카페인 40mg",
+			wantFound:   true,
+			wantTotal:   80,
+			wantBasis:   LabelBasisServing,
+			wantServing: 40,
+			wantVolume:  500,
+		},
+		{
+			name:        "1회 제공량당 - 용량 모름",
+			ocr:         "핫식스 더킹\n1회 제공량당 카페인 60mg",
+			wantFound:   true,
+			wantTotal:   60,
+			wantBasis:   LabelBasisServing,
+			wantServing: 60,
+		},
+		{
+			name:       "다단 표 - 100ml당 / 총 내용량당",
+			ocr:        "영양정보\n100ml당 총 내용량(355ml)당\n열량 46kcal 163kcal\n나트륨 80mg 284mg\n카페인 32mg 114mg",
+			wantFound:  true,
+			wantTotal:  114,
+			wantBasis:  LabelBasisContainer,
+			wantPer100: 32,
+			wantVolume: 355,
+		},
+		{
+			name:        "다단 표 - 1회 제공량당 / 기준치 비율",
+			ocr:         "영양정보 총 내용량 240ml\n1회 제공량당 1일 영양성분 기준치에 대한 비율\n당류 20g 20%\n카페인 80mg -",
+			wantFound:   true,
+			wantTotal:   80,
+			wantBasis:   LabelBasisServing,
+			wantServing: 80,
+			wantVolume:  240,
+		},
+		{
+			name:       "다단 표 - 값이 다음 줄로 분리",
+			ocr:        "영양정보\n100ml당\n카페인\n15mg\n내용량 1.5L",
+			wantFound:  true,
+			wantTotal:  225,
+			wantBasis:  LabelBasisPer100ML,
+			wantPer100: 15,
+			wantVolume: 1500,
+		},
+		{
+			name:        "다단 표 - 1회 제공량(ml)당 / 총 내용량(ml)당",
+			ocr:         "영양정보\n1회 제공량(240ml)당 총 내용량(480ml)당\n카페인 65mg 130mg\n당류 12g 24g",
+			wantFound:   true,
+			wantTotal:   130,
+			wantBasis:   LabelBasisContainer,
+			wantServing: 65,
+			wantVolume:  480,
+		},
+		{
+			name:      "1캔(용량)당 접두 표기",
+			ocr:       "레쓰비 마일드\n카페인 함량 1캔(250ml)당 80mg",
+			wantFound: true,
+			wantTotal: 80,
+			wantBasis: LabelBasisContainer,
+		},
+		{
+			name:        "1회 제공량 후치 표기",
+			ocr:         "조지아 크래프트\n내용량 470ml\n카페인: 45 mg/ 1회 제공량\n총 2회 제공량",
+			wantFound:   true,
+			wantTotal:   90,
+			wantBasis:   LabelBasisServing,
+			wantServing: 45,
+			wantVolume:  470,
+		},
+		{
+			name:       "고카페인 여러 줄",
+			ocr:        "고카페인 함유\n총 카페인 함량 180mg\n내용량 473ml\n어린이, 임산부 및 카페인 민감자는 섭취에 주의",
+			wantFound:  true,
+			wantTotal:  180,
+			wantBasis:  LabelBasisContainer,
+			wantVolume: 473,
+		},
+		{
+			name:      "영문 fl oz 용량",
+			ocr:       "Caffeine content: 160mg/16 fl oz",
+			wantFound: true,
+			wantTotal: 160,
+			wantBasis: LabelBasisContainer,
+		},
+		{
+			name:      "범위 표기",
+			ocr:       "에스프레소 샷\n카페인 30~40mg",
+			wantFound: true,
+			wantTotal: 35,
+			wantBasis: LabelBasisContainer,
+			wantRange: true,
+		},
+		{
+			name:       "범위 표기 + 100ml당",
+			ocr:        "녹차 라떼\n내용량 300ml\n100ml당 카페인 10-20mg",
+			wantFound:  true,
+			wantTotal:  45,
+			wantBasis:  LabelBasisPer100ML,
+			wantPer100: 15,
+			wantVolume: 300,
+			wantRange:  true,
+		},
+		{
+			name:      "고카페인 총 함량 표기",
+			ocr:       "고카페인 함유 총 카페인 함량 150mg\n어린이, 임산부, 카페인 민감자는 섭취에 주의하여 주시기 바랍니다.",
+			wantFound: true,
+			wantTotal: 150,
+			wantBasis: LabelBasisContainer,
+		},
+		{
+			name:      "1캔당 후치 표기",
+			ocr:       "카페인 함량 : 150mg / 1캔",
+			wantFound: true,
+			wantTotal: 150,
+			wantBasis: LabelBasisContainer,
+		},
+		{
+			name:      "괄호 후치 표기",
+			ocr:       "카페인 95mg (1병당)\n내용량 270ml",
+			wantFound: true,
+			wantTotal: 95,
+			wantBasis: LabelBasisContainer,
+		},
+		{
+			name:       "총 함량과 100ml당 함께 표기",
+			ocr:        "카페인 총 함량 200mg (100ml당 40mg)\n내용량 500ml",
+			wantFound:  true,
+			wantTotal:  200,
+			wantBasis:  LabelBasisContainer,
+			wantPer100: 40,
+			wantVolume: 500,
+		},
+		{
+			name:      "영문 per can",
+			ocr:       "NUTRITION FACTS\nCaffeine: 80 mg per can",
+			wantFound: true,
+			wantTotal: 80,
+			wantBasis: LabelBasisContainer,
+		},
+		{
+			name:        "영문 serving size",
+			ocr:         "Nutrition Facts\nServing size 1 can (250ml)\nCalories 110\nCaffeine 80mg",
+			wantFound:   true,
+			wantTotal:   80,
+			wantBasis:   LabelBasisServing,
+			wantServing: 80,
+			wantVolume:  250,
+		},
+		{
+			name:       "영문 per 100ml",
+			ocr:        "COLD BREW COFFEE\nNet Vol. 330ml\nCaffeine 40mg per 100ml",
+			wantFound:  true,
+			wantTotal:  132,
+			wantBasis:  LabelBasisPer100ML,
+			wantPer100: 40,
+			wantVolume: 330,
+		},
+		{
+			name:      "단위 없는 숫자",
+			ocr:       "caffeine 80",
+			wantFound: true,
+			wantTotal: 80,
+			wantBasis: LabelBasisContainer,
+		},
+		{
+			name:       "전각 문자와 단위 기호",
+			ocr:        "카페인 ３２㎎／１００㎖\n내용량 ２５０㎖",
+			wantFound:  true,
+			wantTotal:  80,
+			wantBasis:  LabelBasisPer100ML,
+			wantPer100: 32,
+			wantVolume: 250,
+		},
+		{
+			name:       "mℓ 표기",
+			ocr:        "스타벅스 더블샷\n200mℓ\n카페인 132㎎",
+			wantFound:  true,
+			wantTotal:  132,
+			wantBasis:  LabelBasisContainer,
+			wantVolume: 200,
+		},
+		{
+			name:      "디카페인 0mg",
+			ocr:       "디카페인 아메리카노\n카페인 0mg",
+			wantFound: true,
+			wantTotal: 0,
+			wantBasis: LabelBasisContainer,
+		},
+		{
+			name:       "100ml당만 있고 용량 없음",
+			ocr:        "카페인 32mg/100ml",
+			wantFound:  true,
+			wantTotal:  0,
+			wantBasis:  LabelBasisPer100ML,
+			wantPer100: 32,
+		},
+		{
+			name:      "카페인 언급 없음",
+			ocr:       "오렌지 주스\n내용량 250ml\n열량 110kcal\n당류 22g",
+			wantFound: false,
+		},
+		{
+			name:      "빈 텍스트",
+			ocr:       "",
+			wantFound: false,
+		},
+		{
+			name:        "제품명 표기 우선",
+			ocr:         "영양정보\n제품명: 스타벅스 더블샷 에스프레소\n카페인 132mg",
+			wantFound:   true,
+			wantTotal:   132,
+			wantBasis:   LabelBasisContainer,
+			wantProduct: "스타벅스 더블샷 에스프레소", checkProduct: true,
+		},
+		{
+			name:        "제품명 - 영양정보 줄 건너뛰기",
+			ocr:         "영양정보\n총 내용량 250ml\n칸타타 아메리카노\n카페인 1캔당 104mg",
+			wantFound:   true,
+			wantTotal:   104,
+			wantBasis:   LabelBasisContainer,
+			wantVolume:  250,
+			wantProduct: "칸타타 아메리카노", checkProduct: true,
+		},
+		{
+			name:        "제품명 - 숫자 포함 브랜드",
+			ocr:         "hot6\nThe King\n카페인 100mg",
+			wantFound:   true,
+			wantTotal:   100,
+			wantBasis:   LabelBasisContainer,
+			wantProduct: "hot6", checkProduct: true,
+		},
+		{
+			name:      "천 단위 쉼표 무시",
+			ocr:       "카페인 함량 1,200mg/1000ml\n내용량 1,000ml",
+			wantFound: true,
+			wantTotal: 1200,
+			wantBasis: LabelBasisServing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ParseCaffeineLabel(tt.ocr)
+
+			if info.Found != tt.wantFound {
+				t.Fatalf("Found = %v, want %v (%+v)", info.Found, tt.wantFound, info)
+			}
+			if !tt.wantFound {
+				return
+			}
+			if !approxEqual(info.TotalCaffeine, tt.wantTotal) {
+				t.Errorf("TotalCaffeine = %v, want %v (%+v)", info.TotalCaffeine, tt.wantTotal, info)
+			}
+			if info.Basis != tt.wantBasis {
+				t.Errorf("Basis = %q, want %q (%+v)", info.Basis, tt.wantBasis, info)
+			}
+			if tt.wantPer100 != 0 && !approxEqual(info.PerHundredML, tt.wantPer100) {
+				t.Errorf("PerHundredML = %v, want %v", info.PerHundredML, tt.wantPer100)
+			}
+			if tt.wantServing != 0 && !approxEqual(info.PerServing, tt.wantServing) {
+				t.Errorf("PerServing = %v, want %v", info.PerServing, tt.wantServing)
+			}
+			if tt.wantVolume != 0 && !approxEqual(info.VolumeML, tt.wantVolume) {
+				t.Errorf("VolumeML = %v, want %v", info.VolumeML, tt.wantVolume)
+			}
+			if info.IsRange != tt.wantRange {
+				t.Errorf("IsRange = %v, want %v", info.IsRange, tt.wantRange)
+			}
+			if tt.checkProduct && info.ProductName != tt.wantProduct {
+				t.Errorf("ProductName = %q, want %q", info.ProductName, tt.wantProduct)
+			}
+		})
+	}
+}
+
+// 기존 호출부 호환: ExtractCaffeineInfo는 전체 함량과 제품명을 반환
+func TestExtractCaffeineInfoUsesLabelParser(t *testing.T) {
+	caffeine, name := ExtractCaffeineInfo("영양정보\n몬스터 에너지\n355ml\n카페인 32mg/100ml")
+	if !approxEqual(caffeine, 113.6) {
+		t.Errorf("caffeine = %v, want 113.6", caffeine)
+	}
+	if name != "몬스터 에너지" {
+		t.Errorf("productName = %q, want %q", name, "몬스터 에너지")
+	}
+}
+
+// LLM이 읽은 라벨 문구와 추정치가 크게 다르면 라벨 값을 사용
+func TestCrossCheckDrinkWithLabel(t *testing.T) {
+	tests := []struct {
+		name       string
+		drink      DetectedDrink
+		wantAmount int
+		wantSource string
+	}{
+		{
+			name:       "라벨 값으로 교정",
+			drink:      DetectedDrink{DrinkName: "몬스터", CaffeineAmount: 160, LabelText: "카페인 32mg/100ml 355ml"},
+			wantAmount: 114,
+			wantSource: CaffeineSourceLabel,
+		},
+		{
+			name:       "허용 오차 이내면 유지",
+			drink:      DetectedDrink{DrinkName: "레드불", CaffeineAmount: 62, LabelText: "카페인 62.5mg"},
+			wantAmount: 62,
+			wantSource: CaffeineSourceLabel,
+		},
+		{
+			name:       "라벨 문구 없음",
+			drink:      DetectedDrink{DrinkName: "아메리카노", CaffeineAmount: 150},
+			wantAmount: 150,
+			wantSource: CaffeineSourceEstimate,
+		},
+		{
+			name:       "100ml당만 읽힘 - 전체 계산 불가",
+			drink:      DetectedDrink{DrinkName: "콜드브루", CaffeineAmount: 200, LabelText: "카페인 40mg/100ml"},
+			wantAmount: 200,
+			wantSource: CaffeineSourceEstimate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drink := tt.drink
+			drink.crossCheckWithLabel()
+			if drink.CaffeineAmount != tt.wantAmount {
+				t.Errorf("CaffeineAmount = %d, want %d", drink.CaffeineAmount, tt.wantAmount)
+			}
+			if drink.CaffeineSource != tt.wantSource {
+				t.Errorf("CaffeineSource = %q, want %q", drink.CaffeineSource, tt.wantSource)
+			}
+		})
+	}
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 0.11
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ========================================
+// LLM 응답 스키마 / 검증 / 복구 재시도
+// 지원하는 프로바이더에는 구조화된 JSON 출력을 요청하고,
+// 그래도 형식이 틀리면 문제점을 알려주고 정해진 횟수만큼 다시 요청
+// ========================================
+
+// ErrLLMNotConfigured : API 키가 설정되지 않은 프로바이더
+var ErrLLMNotConfigured = errors.New("LLM API 키가 설정되지 않았습니다")
+
+// maxLLMRepairAttempts : 잘못된 응답에 대해 고쳐 달라고 다시 요청하는 최대 횟수
+const maxLLMRepairAttempts = 2
+
+// 응답 값 허용 범위
+const (
+	maxCaffeineAmountMG = 1000
+	minConfidence       = 0.0
+	maxConfidence       = 1.0
+)
+
+// LLMOutputError : 복구 재시도 후에도 스키마에 맞지 않는 LLM 응답
+type LLMOutputError struct {
+	Provider string   // "gemini", "openai"
+	Attempts int      // 총 요청 횟수
+	Problems []string // 마지막 응답의 문제점
+	Raw      string   // 마지막 응답 원문
+	Usage    LLMUsage // 실패하기까지 쓴 토큰
+}
+
+func (e *LLMOutputError) Error() string {
+	return fmt.Sprintf("%s 응답 형식 오류 (%d회 시도): %s", e.Provider, e.Attempts, strings.Join(e.Problems, "; "))
+}
+
+// withLLMUsage : 형식 오류로 끝난 요청에 그동안 쓴 토큰 사용량을 붙임
+func withLLMUsage(err error, usage LLMUsage) error {
+	var outputErr *LLMOutputError
+	if errors.As(err, &outputErr) {
+		outputErr.Usage = usage
+	}
+	return err
+}
+
+// llmTurn : 복구 요청 시 이어 붙이는 대화 (이전 응답과 수정 요청)
+type llmTurn struct {
+	FromModel bool
+	Text      string
+}
+
+// requestValidJSON : 응답이 검증을 통과할 때까지 최대 maxLLMRepairAttempts번 고쳐 달라고 다시 요청
+// call은 이어 붙일 대화를 받아 응답 텍스트를 반환, check는 응답을 파싱하고 문제점을 반환
+// 네트워크/API 오류는 재시도하지 않고 그대로 반환
+func requestValidJSON(provider string, call func(extra []llmTurn) (string, error), check func(text string) []string) error {
+	var extra []llmTurn
+	for attempt := 1; ; attempt++ {
+		text, err := call(extra)
+		if err != nil {
+			return err
+		}
+
+		problems := check(text)
+		if len(problems) == 0 {
+			return nil
+		}
+		println("⚠️", provider, "응답 형식 오류 (시도", attempt, "):", strings.Join(problems, "; "))
+
+		if attempt > maxLLMRepairAttempts {
+			return &LLMOutputError{Provider: provider, Attempts: attempt, Problems: problems, Raw: text}
+		}
+		extra = append(extra,
+			llmTurn{FromModel: true, Text: text},
+			llmTurn{Text: repairPrompt(problems)},
+		)
+	}
+}
+
+// repairPrompt : 이전 응답의 문제점을 알려주는 수정 요청
+func repairPrompt(problems []string) string {
+	return "직전 응답이 요구한 JSON 형식에 맞지 않습니다.\n문제점:\n- " +
+		strings.Join(problems, "\n- ") +
+		"\n같은 내용을 형식에 맞게 고친 JSON만 다시 응답하세요 (다른 텍스트 없이)."
+}
+
+// parseLLMJSON : 응답 텍스트에서 JSON 블록을 꺼내 파싱 (```json 코드 블록 허용)
+func parseLLMJSON(text string, v interface{}) error {
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, "```json")
+	text = strings.TrimPrefix(text, "```")
+	text = strings.TrimSuffix(text, "```")
+	text = extractJSON(strings.TrimSpace(text))
+	return json.Unmarshal([]byte(text), v)
+}
+
+// validatable : 파싱 후 스스로 검증할 수 있는 응답
+type validatable interface {
+	validate() []string
+}
+
+// checkLLMJSON : 응답 텍스트를 v로 파싱하고 검증 (문제가 없으면 nil)
+func checkLLMJSON(text string, v validatable) []string {
+	if err := parseLLMJSON(text, v); err != nil {
+		return []string{fmt.Sprintf("JSON 파싱 실패: %v", err)}
+	}
+	return v.validate()
+}
+
+// checkRange : 값이 범위를 벗어나면 문제점 추가
+func checkRange(problems []string, field string, value, lo, hi float64) []string {
+	if value < lo || value > hi {
+		problems = append(problems, fmt.Sprintf("%s는 %g~%g 사이여야 합니다 (받은 값: %g)", field, lo, hi, value))
+	}
+	return problems
+}
+
+// validate : 이미지 인식 응답 검증 (음료가 없으면 drinks는 빈 배열이 정상)
+func (r *LLMRecognitionResult) validate() []string {
+	var problems []string
+	for i, drink := range r.Drinks {
+		field := fmt.Sprintf("drinks[%d]", i)
+		if strings.TrimSpace(drink.DrinkName) == "" {
+			problems = append(problems, field+".drink_name은 필수입니다")
+		}
+		problems = checkRange(problems, field+".caffeine_amount", float64(drink.CaffeineAmount), 0, maxCaffeineAmountMG)
+		problems = checkRange(problems, field+".confidence", drink.Confidence, minConfidence, maxConfidence)
+	}
+	return problems
+}
+
+// validate : 텍스트 추정 응답 검증
+func (r *TextRecognitionResult) validate() []string {
+	var problems []string
+	if strings.TrimSpace(r.DrinkName) == "" {
+		problems = append(problems, "drink_name은 필수입니다")
+	}
+	problems = checkRange(problems, "caffeine_amount", float64(r.CaffeineAmount), 0, maxCaffeineAmountMG)
+	problems = checkRange(problems, "confidence", r.Confidence, minConfidence, maxConfidence)
+	return problems
+}
+
+// ========================================
+// 응답 스키마 (한 번 정의해서 프로바이더별 형식으로 변환)
+// ========================================
+
+// outputField : 응답 JSON 필드 정의
+type outputField struct {
+	Name     string
+	Type     string // "string", "integer", "number", "object", "array"
+	Optional bool   // 생략 가능 (OpenAI strict 모드는 모든 필드가 필수라 null 허용으로 표현)
+	Min, Max *float64
+	Enum     []string
+	Fields   []outputField // object의 필드
+	Items    *outputField  // array의 원소
+}
+
+func bound(v float64) *float64 { return &v }
+
+// drinkCategories : 음료 분류
+var drinkCategories = []string{"커피", "에너지드링크", "차", "탄산음료", "기타"}
+
+// recognitionSchema : 이미지 인식 응답 스키마
+var recognitionSchema = outputField{
+	Type: "object",
+	Fields: []outputField{
+		{Name: "drinks", Type: "array", Items: &outputField{
+			Type: "object",
+			Fields: []outputField{
+				{Name: "drink_name", Type: "string"},
+				{Name: "caffeine_amount", Type: "integer", Min: bound(0), Max: bound(maxCaffeineAmountMG)},
+				{Name: "confidence", Type: "number", Min: bound(minConfidence), Max: bound(maxConfidence)},
+				{Name: "brand", Type: "string"},
+				{Name: "category", Type: "string", Enum: drinkCategories},
+				{Name: "label_text", Type: "string"},
+				{Name: "region", Type: "object", Optional: true, Fields: []outputField{
+					{Name: "x", Type: "number"},
+					{Name: "y", Type: "number"},
+					{Name: "width", Type: "number"},
+					{Name: "height", Type: "number"},
+				}},
+			},
+		}},
+		{Name: "description", Type: "string", Optional: true},
+	},
+}
+
+// textEstimateSchema : 텍스트 카페인 추정 응답 스키마
+var textEstimateSchema = outputField{
+	Type: "object",
+	Fields: []outputField{
+		{Name: "drink_name", Type: "string"},
+		{Name: "caffeine_amount", Type: "integer", Min: bound(0), Max: bound(maxCaffeineAmountMG)},
+		{Name: "confidence", Type: "number", Min: bound(minConfidence), Max: bound(maxConfidence)},
+		{Name: "brand", Type: "string", Optional: true},
+		{Name: "category", Type: "string", Optional: true, Enum: drinkCategories},
+	},
+}
+
+// geminiSchema : Gemini responseSchema (OpenAPI 부분집합) 형식
+func (f outputField) geminiSchema() map[string]interface{} {
+	schema := map[string]interface{}{"type": strings.ToUpper(f.Type)}
+	if f.Optional {
+		schema["nullable"] = true
+	}
+	if f.Min != nil {
+		schema["minimum"] = *f.Min
+	}
+	if f.Max != nil {
+		schema["maximum"] = *f.Max
+	}
+	if len(f.Enum) > 0 {
+		schema["enum"] = f.Enum
+	}
+	if f.Items != nil {
+		schema["items"] = f.Items.geminiSchema()
+	}
+	if len(f.Fields) > 0 {
+		properties := make(map[string]interface{}, len(f.Fields))
+		var required, order []string
+		for _, field := range f.Fields {
+			properties[field.Name] = field.geminiSchema()
+			order = append(order, field.Name)
+			if !field.Optional {
+				required = append(required, field.Name)
+			}
+		}
+		schema["properties"] = properties
+		schema["required"] = required
+		schema["propertyOrdering"] = order
+	}
+	return schema
+}
+
+// openAISchema : OpenAI Structured Outputs (strict JSON Schema) 형식
+// strict 모드는 범위 키워드를 지원하지 않으므로 범위는 검증 단계에서 확인
+func (f outputField) openAISchema() map[string]interface{} {
+	var schema map[string]interface{}
+	if f.Optional {
+		schema = map[string]interface{}{"type": []string{f.Type, "null"}}
+	} else {
+		schema = map[string]interface{}{"type": f.Type}
+	}
+	if len(f.Enum) > 0 {
+		enum := stringsToInterfaces(f.Enum)
+		if f.Optional {
+			enum = append(enum, nil)
+		}
+		schema["enum"] = enum
+	}
+	if f.Items != nil {
+		schema["items"] = f.Items.openAISchema()
+	}
+	if f.Type == "object" {
+		properties := make(map[string]interface{}, len(f.Fields))
+		required := []string{}
+		for _, field := range f.Fields {
+			properties[field.Name] = field.openAISchema()
+			required = append(required, field.Name)
+		}
+		schema["properties"] = properties
+		schema["required"] = required
+		schema["additionalProperties"] = false
+	}
+	return schema
+}
+
+func stringsToInterfaces(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
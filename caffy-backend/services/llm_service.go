@@ -5,67 +5,289 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 )
 
 // LLMRecognitionResult : LLM 음료 인식 결과
+// 최상위 필드는 대표 음료(가장 확신도 높은 음료), Drinks는 사진 속 모든 음료
 type LLMRecognitionResult struct {
-	DrinkName      string  `json:"drink_name"`
-	CaffeineAmount int     `json:"caffeine_amount"`
-	Confidence     float64 `json:"confidence"`
-	Description    string  `json:"description"`
-	Brand          string  `json:"brand"`
-	Category       string  `json:"category"`
+	DrinkName      string                 `json:"drink_name"`
+	CaffeineAmount int                    `json:"caffeine_amount"`
+	Confidence     float64                `json:"confidence"`
+	Description    string                 `json:"description"`
+	Brand          string                 `json:"brand"`
+	Category       string                 `json:"category"`
+	Drinks         []DetectedDrink        `json:"drinks"`
+	PromptVersion  string                 `json:"prompt_version,omitempty"` // 사용한 프롬프트 (예: "drinks.v2.ko")
+	Usage          LLMUsage               `json:"-"`                        // 복구 재시도를 포함한 토큰 사용량
+	Provider       string                 `json:"-"`                        // 답을 낸 프로바이더 (인식 체인에서 채움)
+	Policy         string                 `json:"-"`                        // 인식 정책 판정 (PolicyAccepted 등)
+	Candidates     []RecognitionCandidate `json:"-"`                        // 프로바이더별 후보 (첫 번째가 대표)
+}
+
+// clone : 음료 목록과 포인터 필드까지 복사한 결과 (동시 요청끼리 공유한 결과를 호출자별로 나눌 때 사용)
+func (r *LLMRecognitionResult) clone() *LLMRecognitionResult {
+	result := *r
+	result.Drinks = cloneDetectedDrinks(r.Drinks)
+	if r.Candidates != nil {
+		result.Candidates = make([]RecognitionCandidate, len(r.Candidates))
+		for i, candidate := range r.Candidates {
+			candidate.BeverageID = cloneUintPtr(candidate.BeverageID)
+			candidate.Drinks = cloneDetectedDrinks(candidate.Drinks)
+			result.Candidates[i] = candidate
+		}
+	}
+	return &result
+}
+
+func cloneDetectedDrinks(drinks []DetectedDrink) []DetectedDrink {
+	if drinks == nil {
+		return nil
+	}
+	cloned := make([]DetectedDrink, len(drinks))
+	for i, drink := range drinks {
+		if drink.Region != nil {
+			region := *drink.Region
+			drink.Region = &region
+		}
+		drink.BeverageID = cloneUintPtr(drink.BeverageID)
+		cloned[i] = drink
+	}
+	return cloned
+}
+
+func cloneUintPtr(p *uint) *uint {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// DetectedDrink : 사진 속 음료 하나
+type DetectedDrink struct {
+	DrinkName      string      `json:"drink_name"`
+	CaffeineAmount int         `json:"caffeine_amount"`
+	Confidence     float64     `json:"confidence"`
+	Brand          string      `json:"brand"`
+	Category       string      `json:"category"`
+	Region         *RegionHint `json:"region,omitempty"`      // 이미지 내 위치 (크롭 힌트)
+	BeverageID     *uint       `json:"beverage_id,omitempty"` // 연결된 음료 ID
+	LabelText      string      `json:"label_text,omitempty"`  // LLM이 읽은 라벨의 카페인/용량 문구
+	CaffeineSource string      `json:"caffeine_source"`       // 카페인 값 출처: "label", "estimate"
+}
+
+// 카페인 값 출처
+const (
+	CaffeineSourceLabel    = "label"    // 라벨 표기에서 계산
+	CaffeineSourceEstimate = "estimate" // LLM 추정
+)
+
+// 라벨 값과 LLM 추정치가 이 이상 차이나면 라벨 값을 사용
+const (
+	labelToleranceMG    = 10.0
+	labelToleranceRatio = 0.15
+)
+
+// crossCheckWithLabel : LLM이 읽은 라벨 문구를 파싱해 카페인 추정치를 검증
+// 라벨에서 전체 함량을 계산할 수 있고 추정치와 크게 다르면 라벨 값으로 교정
+func (d *DetectedDrink) crossCheckWithLabel() {
+	d.CaffeineSource = CaffeineSourceEstimate
+	if strings.TrimSpace(d.LabelText) == "" {
+		return
+	}
+
+	info := ParseCaffeineLabel(d.LabelText)
+	if !info.Found || info.TotalCaffeine <= 0 {
+		return
+	}
+
+	d.CaffeineSource = CaffeineSourceLabel
+	diff := math.Abs(info.TotalCaffeine - float64(d.CaffeineAmount))
+	if diff > math.Max(labelToleranceMG, info.TotalCaffeine*labelToleranceRatio) {
+		println("🏷️ 라벨 값으로 카페인 교정:", d.DrinkName, d.CaffeineAmount, "→", int(math.Round(info.TotalCaffeine)))
+		d.CaffeineAmount = int(math.Round(info.TotalCaffeine))
+	}
+}
+
+// RegionHint : 이미지 내 음료 영역 (좌상단 기준 0~1 정규화 좌표)
+type RegionHint struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// clamp : 좌표를 이미지 범위 안으로 제한
+func (h *RegionHint) clamp() {
+	h.X = math.Max(0, math.Min(1, h.X))
+	h.Y = math.Max(0, math.Min(1, h.Y))
+	h.Width = math.Max(0, math.Min(1-h.X, h.Width))
+	h.Height = math.Max(0, math.Min(1-h.Y, h.Height))
+}
+
+// normalizeDrinks : Drinks 목록과 대표 음료 필드를 서로 맞춤
+// 확신도 높은 순으로 정렬하고, 대표 음료는 첫 번째 음료로 설정
+func (r *LLMRecognitionResult) normalizeDrinks() {
+	// 이름 없는 항목 제거, 영역 좌표는 0~1로 제한
+	drinks := r.Drinks[:0]
+	for _, drink := range r.Drinks {
+		if strings.TrimSpace(drink.DrinkName) == "" {
+			continue
+		}
+		if drink.Region != nil {
+			drink.Region.clamp()
+		}
+		drink.crossCheckWithLabel()
+		drinks = append(drinks, drink)
+	}
+	r.Drinks = drinks
+
+	if len(r.Drinks) == 0 {
+		if r.DrinkName != "" {
+			r.Drinks = []DetectedDrink{{
+				DrinkName:      r.DrinkName,
+				CaffeineAmount: r.CaffeineAmount,
+				Confidence:     r.Confidence,
+				Brand:          r.Brand,
+				Category:       r.Category,
+				CaffeineSource: CaffeineSourceEstimate,
+			}}
+		}
+		return
+	}
+
+	sort.SliceStable(r.Drinks, func(i, j int) bool {
+		return r.Drinks[i].Confidence > r.Drinks[j].Confidence
+	})
+
+	primary := r.Drinks[0]
+	r.DrinkName = primary.DrinkName
+	r.CaffeineAmount = primary.CaffeineAmount
+	r.Confidence = primary.Confidence
+	r.Brand = primary.Brand
+	r.Category = primary.Category
+}
+
+// Gemini/OpenAI API 주소 (테스트에서 교체할 수 있도록 변수로 둠)
+var (
+	geminiEndpoint = "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.5-flash:generateContent"
+	openAIEndpoint = "https://api.openai.com/v1/chat/completions"
+)
+
+// LLMUsage : 프로바이더가 알려준 토큰 사용량
+type LLMUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+func (u *LLMUsage) add(other LLMUsage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+}
+
+// llmPrice : 100만 토큰당 가격 (USD)
+type llmPrice struct {
+	Input  float64
+	Output float64
+}
+
+// llmPrices : 프로바이더별 모델 가격 (gemini-2.5-flash, gpt-4o 공개 가격 기준, 바뀌면 함께 수정)
+var llmPrices = map[string]llmPrice{
+	"gemini": {Input: 0.30, Output: 2.50},
+	"openai": {Input: 2.50, Output: 10.00},
+}
+
+// CostUSD : 사용량의 예상 비용 (가격을 모르는 프로바이더는 0)
+func (u LLMUsage) CostUSD(provider string) float64 {
+	price := llmPrices[provider]
+	return (float64(u.InputTokens)*price.Input + float64(u.OutputTokens)*price.Output) / 1e6
 }
 
 // RecognizeDrinkWithLLM : Gemini Vision API로 음료 인식
-func RecognizeDrinkWithLLM(imageBase64 string) (*LLMRecognitionResult, error) {
+// mimeType은 전처리된 이미지의 실제 형식 (예: "image/jpeg")
+// 응답이 복구 재시도 후에도 스키마에 맞지 않으면 *LLMOutputError
+func RecognizeDrinkWithLLM(imageBase64 string, mimeType string) (*LLMRecognitionResult, error) {
 	apiKey := os.Getenv("GEMINI_API_KEY")
 	if apiKey == "" {
 		println("❌ GEMINI_API_KEY가 설정되지 않음")
-		return nil, fmt.Errorf("GEMINI_API_KEY not set")
+		return nil, fmt.Errorf("%w (GEMINI_API_KEY)", ErrLLMNotConfigured)
 	}
 	println("🔑 Gemini API 호출 시작...")
 
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/gemini-2.5-flash:generateContent?key=%s", apiKey)
+	prompt, err := renderPrompt(PromptDrinkRecognition, promptData{})
+	if err != nil {
+		return nil, err
+	}
+
+	parts := []map[string]interface{}{
+		{"text": prompt.Text},
+		{
+			"inline_data": map[string]string{
+				"mime_type": mimeType,
+				"data":      imageBase64,
+			},
+		},
+	}
 
-	prompt := `이 이미지에서 음료를 분석해주세요.
-다음 JSON 형식으로만 응답하세요 (다른 텍스트 없이):
-{
-  "drink_name": "음료 이름 (한글)",
-  "caffeine_amount": 카페인량(mg, 숫자만),
-  "confidence": 확신도(0.0~1.0),
+	var result LLMRecognitionResult
+	var usage LLMUsage
+	err = requestValidJSON("gemini", func(extra []llmTurn) (string, error) {
+		text, callUsage, err := callGemini(apiKey, parts, extra, recognitionSchema)
+		usage.add(callUsage)
+		return text, err
+	}, func(text string) []string {
+		result = LLMRecognitionResult{}
+		return checkLLMJSON(text, &result)
+	})
+	if err != nil {
+		return nil, withLLMUsage(err, usage)
+	}
+
+	result.PromptVersion = prompt.Version
+	result.Usage = usage
+	result.normalizeDrinks()
+	return &result, nil
 }
-음료가 아니거나 인식 불가능하면 caffeine_amount를 0으로 설정하세요.`
+
+// callGemini : Gemini에 스키마에 맞는 JSON 응답을 요청하고 응답 텍스트와 토큰 사용량 반환
+// extra는 복구 요청 시 이어 붙이는 대화
+func callGemini(apiKey string, parts []map[string]interface{}, extra []llmTurn, schema outputField) (string, LLMUsage, error) {
+	contents := []map[string]interface{}{
+		{"role": "user", "parts": parts},
+	}
+	for _, turn := range extra {
+		role := "user"
+		if turn.FromModel {
+			role = "model"
+		}
+		contents = append(contents, map[string]interface{}{
+			"role":  role,
+			"parts": []map[string]interface{}{{"text": turn.Text}},
+		})
+	}
 
 	requestBody := map[string]interface{}{
-		"contents": []map[string]interface{}{
-			{
-				"parts": []map[string]interface{}{
-					{"text": prompt},
-					{
-						"inline_data": map[string]string{
-							"mime_type": "image/jpeg",
-							"data":      imageBase64,
-						},
-					},
-				},
-			},
-		},
+		"contents": contents,
 		"generationConfig": map[string]interface{}{
-			"temperature":     0.1,
-			"maxOutputTokens": 1000,
+			"temperature":      0.1,
+			"maxOutputTokens":  1000,
+			"responseMimeType": "application/json",
+			"responseSchema":   schema.geminiSchema(),
 		},
 	}
 
+	url := fmt.Sprintf("%s?key=%s", geminiEndpoint, apiKey)
 	jsonBody, _ := json.Marshal(requestBody)
 	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonBody))
 	if err != nil {
 		println("❌ Gemini API 호출 실패:", err.Error())
-		return nil, fmt.Errorf("Gemini API 호출 실패: %v", err)
+		return "", LLMUsage{}, fmt.Errorf("Gemini API 호출 실패: %v", err)
 	}
 	defer resp.Body.Close()
 
@@ -81,6 +303,11 @@ func RecognizeDrinkWithLLM(imageBase64 string) (*LLMRecognitionResult, error) {
 				} `json:"parts"`
 			} `json:"content"`
 		} `json:"candidates"`
+		UsageMetadata struct {
+			PromptTokenCount     int `json:"promptTokenCount"`
+			CandidatesTokenCount int `json:"candidatesTokenCount"`
+			ThoughtsTokenCount   int `json:"thoughtsTokenCount"`
+		} `json:"usageMetadata"`
 		Error *struct {
 			Message string `json:"message"`
 		} `json:"error"`
@@ -89,44 +316,27 @@ func RecognizeDrinkWithLLM(imageBase64 string) (*LLMRecognitionResult, error) {
 	if err := json.Unmarshal(body, &geminiResp); err != nil {
 		println("❌ 응답 파싱 실패:", err.Error())
 		println("📄 원본 응답:", string(body))
-		return nil, fmt.Errorf("응답 파싱 실패: %v", err)
+		return "", LLMUsage{}, fmt.Errorf("응답 파싱 실패: %v", err)
 	}
 
 	if geminiResp.Error != nil {
 		println("❌ Gemini API 에러:", geminiResp.Error.Message)
-		return nil, fmt.Errorf("Gemini API 에러: %s", geminiResp.Error.Message)
+		return "", LLMUsage{}, fmt.Errorf("Gemini API 에러: %s", geminiResp.Error.Message)
 	}
 
+	// 생각(thinking) 토큰도 출력 토큰으로 과금됨
+	usage := LLMUsage{
+		InputTokens:  geminiResp.UsageMetadata.PromptTokenCount,
+		OutputTokens: geminiResp.UsageMetadata.CandidatesTokenCount + geminiResp.UsageMetadata.ThoughtsTokenCount,
+	}
 	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
 		println("❌ Gemini 응답 없음, 원본:", string(body))
-		return nil, fmt.Errorf("Gemini 응답 없음")
+		return "", usage, fmt.Errorf("Gemini 응답 없음")
 	}
 
 	responseText := geminiResp.Candidates[0].Content.Parts[0].Text
 	println("✅ Gemini 응답:", responseText)
-
-	// JSON 추출 (```json ... ``` 제거)
-	responseText = strings.TrimPrefix(responseText, "```json")
-	responseText = strings.TrimPrefix(responseText, "```")
-	responseText = strings.TrimSuffix(responseText, "```")
-	responseText = strings.TrimSpace(responseText)
-
-	// JSON 블록만 추출
-	responseText = extractJSON(responseText)
-
-	var result LLMRecognitionResult
-	if err := json.Unmarshal([]byte(responseText), &result); err != nil {
-		// JSON 파싱 실패 시 기본값
-		return &LLMRecognitionResult{
-			DrinkName:      "알 수 없는 음료",
-			CaffeineAmount: 0,
-			Confidence:     0,
-			Description:    responseText,
-			Category:       "기타",
-		}, nil
-	}
-
-	return &result, nil
+	return responseText, usage, nil
 }
 
 // extractJSON : 텍스트에서 JSON 블록만 추출
@@ -140,46 +350,83 @@ func extractJSON(text string) string {
 }
 
 // RecognizeDrinkWithOpenAI : OpenAI GPT-4o Vision (대안)
-func RecognizeDrinkWithOpenAI(imageBase64 string) (*LLMRecognitionResult, error) {
+func RecognizeDrinkWithOpenAI(imageBase64 string, mimeType string) (*LLMRecognitionResult, error) {
 	apiKey := os.Getenv("OPENAI_API_KEY")
 	if apiKey == "" {
-		return nil, fmt.Errorf("OPENAI_API_KEY not set")
+		return nil, fmt.Errorf("%w (OPENAI_API_KEY)", ErrLLMNotConfigured)
+	}
+
+	prompt, err := renderPrompt(PromptDrinkRecognition, promptData{})
+	if err != nil {
+		return nil, err
+	}
+
+	content := []map[string]interface{}{
+		{"type": "text", "text": prompt.Text},
+		{
+			"type": "image_url",
+			"image_url": map[string]string{
+				"url": fmt.Sprintf("data:%s;base64,%s", mimeType, imageBase64),
+			},
+		},
 	}
 
-	url := "https://api.openai.com/v1/chat/completions"
+	var result LLMRecognitionResult
+	var usage LLMUsage
+	err = requestValidJSON("openai", func(extra []llmTurn) (string, error) {
+		text, callUsage, err := callOpenAI(apiKey, content, extra, "drink_recognition", recognitionSchema)
+		usage.add(callUsage)
+		return text, err
+	}, func(text string) []string {
+		result = LLMRecognitionResult{}
+		return checkLLMJSON(text, &result)
+	})
+	if err != nil {
+		return nil, withLLMUsage(err, usage)
+	}
+
+	result.PromptVersion = prompt.Version
+	result.Usage = usage
+	result.normalizeDrinks()
+	return &result, nil
+}
 
-	prompt := `이 이미지에서 음료를 분석해주세요. 
-	무조건 JSON 형식으로만 응답:
-{"drink_name": "음료명", "caffeine_amount": mg숫자, "confidence": 0.0-1.0}`
+// callOpenAI : OpenAI에 Structured Outputs(json_schema)로 응답을 요청하고 응답 텍스트와 토큰 사용량 반환
+func callOpenAI(apiKey string, content []map[string]interface{}, extra []llmTurn, schemaName string, schema outputField) (string, LLMUsage, error) {
+	messages := []map[string]interface{}{
+		{"role": "user", "content": content},
+	}
+	for _, turn := range extra {
+		role := "user"
+		if turn.FromModel {
+			role = "assistant"
+		}
+		messages = append(messages, map[string]interface{}{"role": role, "content": turn.Text})
+	}
 
 	requestBody := map[string]interface{}{
-		"model": "gpt-4o",
-		"messages": []map[string]interface{}{
-			{
-				"role": "user",
-				"content": []map[string]interface{}{
-					{"type": "text", "text": prompt},
-					{
-						"type": "image_url",
-						"image_url": map[string]string{
-							"url": fmt.Sprintf("data:image/jpeg;base64,%s", imageBase64),
-						},
-					},
-				},
+		"model":      "gpt-4o",
+		"messages":   messages,
+		"max_tokens": 1000,
+		"response_format": map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   schemaName,
+				"strict": true,
+				"schema": schema.openAISchema(),
 			},
 		},
-		"max_tokens": 1000,
 	}
 
 	jsonBody, _ := json.Marshal(requestBody)
-	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
+	req, _ := http.NewRequest("POST", openAIEndpoint, bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return "", LLMUsage{}, err
 	}
 	defer resp.Body.Close()
 
@@ -189,33 +436,36 @@ func RecognizeDrinkWithOpenAI(imageBase64 string) (*LLMRecognitionResult, error)
 		Choices []struct {
 			Message struct {
 				Content string `json:"content"`
+				Refusal string `json:"refusal"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
 	}
 
 	if err := json.Unmarshal(body, &openaiResp); err != nil {
-		return nil, err
+		return "", LLMUsage{}, err
 	}
 
-	if len(openaiResp.Choices) == 0 {
-		return nil, fmt.Errorf("OpenAI 응답 없음")
+	if openaiResp.Error != nil {
+		return "", LLMUsage{}, fmt.Errorf("OpenAI API 에러: %s", openaiResp.Error.Message)
 	}
 
-	responseText := openaiResp.Choices[0].Message.Content
-	responseText = extractJSON(responseText)
-
-	var result LLMRecognitionResult
-	if err := json.Unmarshal([]byte(responseText), &result); err != nil {
-		return &LLMRecognitionResult{
-			DrinkName:      "알 수 없는 음료",
-			CaffeineAmount: 0,
-			Confidence:     0,
-			Description:    responseText,
-			Category:       "기타",
-		}, nil
+	usage := LLMUsage{InputTokens: openaiResp.Usage.PromptTokens, OutputTokens: openaiResp.Usage.CompletionTokens}
+	if len(openaiResp.Choices) == 0 {
+		return "", usage, fmt.Errorf("OpenAI 응답 없음")
 	}
 
-	return &result, nil
+	message := openaiResp.Choices[0].Message
+	if message.Refusal != "" {
+		return "", usage, fmt.Errorf("OpenAI 응답 거부: %s", message.Refusal)
+	}
+	return message.Content, usage, nil
 }
 
 // TextRecognitionResult : 텍스트 기반 카페인 추정 결과
@@ -228,118 +478,41 @@ type TextRecognitionResult struct {
 	Category       string  `json:"category"`
 	Size           string  `json:"size"`
 	SizeML         int     `json:"size_ml"`
+	PromptVersion  string  `json:"prompt_version,omitempty"`   // 사용한 프롬프트 (예: "text_estimate.v2.ko")
+	Source         string  `json:"source"`                     // 답을 낸 곳: "catalog", "cache", "llm"
+	BeverageID     *uint   `json:"beverage_id,omitempty"`      // 음료 DB에서 찾았거나 제안으로 등록된 음료
+	BeverageSizeID *uint   `json:"beverage_size_id,omitempty"` // 음료 DB에서 고른 사이즈 (용량으로 환산한 경우 nil)
+	IsVerified     bool    `json:"is_verified"`                // 검증된 음료 데이터인지
 }
 
-// EstimateCaffeineByText : 음료명+사이즈로 카페인 추정 (Gemini)
-func EstimateCaffeineByText(drinkName string, size string, sizeML int, userID uint) (*TextRecognitionResult, error) {
+// estimateCaffeineWithLLM : 음료명+사이즈로 카페인 추정 (Gemini)
+func estimateCaffeineWithLLM(drinkName string, size string, sizeML int) (*TextRecognitionResult, error) {
 	apiKey := os.Getenv("GEMINI_API_KEY")
 	if apiKey == "" {
 		println("❌ GEMINI_API_KEY가 설정되지 않음")
-		return nil, fmt.Errorf("GEMINI_API_KEY not set")
+		return nil, fmt.Errorf("%w (GEMINI_API_KEY)", ErrLLMNotConfigured)
 	}
 	println("🔑 Gemini 텍스트 추정 시작...")
 
-	// 사이즈 정보 구성
-	sizeInfo := ""
-	if size != "" {
-		sizeInfo = fmt.Sprintf("사이즈: %s", size)
-	}
-	if sizeML > 0 {
-		sizeInfo = fmt.Sprintf("용량: %dml", sizeML)
-	}
-
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/gemini-2.5-flash:generateContent?key=%s", apiKey)
-
-	prompt := fmt.Sprintf(`사용자가 입력한 음료의 카페인 함량을 추정해주세요.
-
-입력 정보:
-- 음료: %s
-- %s
-
-무조건 다음 JSON 형식으로만 응답하세요 (다른 텍스트 없이):
-{
-  "drink_name": "정확한 음료 이름 (한글)",
-  "caffeine_amount": 카페인량(mg, 숫자만),
-  "confidence": 확신도(0.0~1.0)
-}
-`, drinkName, sizeInfo)
-
-	requestBody := map[string]interface{}{
-		"contents": []map[string]interface{}{
-			{
-				"parts": []map[string]interface{}{
-					{"text": prompt},
-				},
-			},
-		},
-		"generationConfig": map[string]interface{}{
-			"temperature":     0.1,
-			"maxOutputTokens": 1000,
-		},
-	}
-
-	jsonBody, _ := json.Marshal(requestBody)
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonBody))
+	prompt, err := renderPrompt(PromptTextEstimate, promptData{DrinkName: drinkName, Size: size, SizeML: sizeML})
 	if err != nil {
-		println("❌ Gemini API 호출 실패:", err.Error())
-		return nil, fmt.Errorf("Gemini API 호출 실패: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	println("📥 Gemini 응답 상태:", resp.StatusCode)
-
-	// 응답 파싱
-	var geminiResp struct {
-		Candidates []struct {
-			Content struct {
-				Parts []struct {
-					Text string `json:"text"`
-				} `json:"parts"`
-			} `json:"content"`
-		} `json:"candidates"`
-		Error *struct {
-			Message string `json:"message"`
-		} `json:"error"`
-	}
-
-	if err := json.Unmarshal(body, &geminiResp); err != nil {
-		println("❌ 응답 파싱 실패:", err.Error())
-		return nil, fmt.Errorf("응답 파싱 실패: %v", err)
-	}
-
-	if geminiResp.Error != nil {
-		println("❌ Gemini API 에러:", geminiResp.Error.Message)
-		return nil, fmt.Errorf("Gemini API 에러: %s", geminiResp.Error.Message)
+		return nil, err
 	}
 
-	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
-		println("❌ Gemini 응답 없음")
-		return nil, fmt.Errorf("Gemini 응답 없음")
-	}
-
-	responseText := geminiResp.Candidates[0].Content.Parts[0].Text
-	println("✅ Gemini 응답:", responseText)
-
-	// JSON 추출
-	responseText = strings.TrimPrefix(responseText, "```json")
-	responseText = strings.TrimPrefix(responseText, "```")
-	responseText = strings.TrimSuffix(responseText, "```")
-	responseText = strings.TrimSpace(responseText)
-	responseText = extractJSON(responseText)
+	parts := []map[string]interface{}{{"text": prompt.Text}}
 
 	var result TextRecognitionResult
-	if err := json.Unmarshal([]byte(responseText), &result); err != nil {
-		println("❌ JSON 파싱 실패:", err.Error())
-		// 기본값 반환
-		return &TextRecognitionResult{
-			DrinkName:      drinkName,
-			CaffeineAmount: 100, // 기본값
-			Confidence:     0.3,
-			Description:    "추정 실패, 기본값 사용",
-			Category:       "기타",
-		}, nil
+	err = requestValidJSON("gemini", func(extra []llmTurn) (string, error) {
+		text, _, err := callGemini(apiKey, parts, extra, textEstimateSchema)
+		return text, err
+	}, func(text string) []string {
+		result = TextRecognitionResult{}
+		return checkLLMJSON(text, &result)
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	result.PromptVersion = prompt.Version
 	return &result, nil
 }
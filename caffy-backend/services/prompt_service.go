@@ -0,0 +1,214 @@
+package services
+
+import (
+	"bytes"
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// ========================================
+// 프롬프트 템플릿 (버전/언어별, services/prompts/{이름}.{버전}.{언어}.tmpl)
+// 인식 로그에 사용한 버전을 남겨 프롬프트 수정 전후 정확도를 비교할 수 있게 함
+// ========================================
+
+//go:embed prompts/*.tmpl
+var promptFiles embed.FS
+
+// 프롬프트 이름
+const (
+	PromptDrinkRecognition = "drinks"        // 사진 속 음료 인식
+	PromptTextEstimate     = "text_estimate" // 음료명으로 카페인 추정
+)
+
+// defaultPromptLocale : 요청한 언어의 템플릿이 없을 때 사용하는 언어
+const defaultPromptLocale = "ko"
+
+// knownBrandsLimit : 프롬프트에 넣는 브랜드 최대 개수
+const knownBrandsLimit = 30
+
+// knownBrandsTTL : 브랜드 목록 캐시 시간
+const knownBrandsTTL = 10 * time.Minute
+
+// promptCupSize : 컵 사이즈 표 한 줄
+type promptCupSize struct {
+	Brand    string
+	Size     string
+	VolumeML int
+}
+
+// cupSizeTable : 주요 카페 컵 사이즈별 용량
+var cupSizeTable = []promptCupSize{
+	{"스타벅스", "Short", 237},
+	{"스타벅스", "Tall", 355},
+	{"스타벅스", "Grande", 473},
+	{"스타벅스", "Venti", 591},
+	{"투썸플레이스", "Regular", 355},
+	{"투썸플레이스", "Large", 414},
+	{"이디야", "Regular", 414},
+	{"이디야", "Extra", 650},
+	{"메가커피", "Mega", 710},
+	{"빽다방", "Regular", 591},
+}
+
+// promptData : 템플릿 변수
+type promptData struct {
+	Locale      string
+	KnownBrands []string
+	SizeTable   []promptCupSize
+	DrinkName   string // 텍스트 추정용 입력
+	Size        string // 사이즈 이름 (예: "tall")
+	SizeML      int    // 용량 (ml, 있으면 Size보다 우선)
+}
+
+// renderedPrompt : 렌더링된 프롬프트와 버전 식별자 (예: "drinks.v2.ko")
+type renderedPrompt struct {
+	Text    string
+	Version string
+}
+
+// promptSet : 이름 → 버전 → 언어 → 템플릿
+type promptSet map[string]map[string]map[string]*template.Template
+
+var promptTemplates = mustLoadPrompts()
+
+// mustLoadPrompts : 내장된 템플릿 파싱 (파일명이나 문법이 틀리면 시작 시 바로 실패)
+func mustLoadPrompts() promptSet {
+	set := make(promptSet)
+	funcs := template.FuncMap{"join": strings.Join}
+
+	files, err := fs.Glob(promptFiles, "prompts/*.tmpl")
+	if err != nil {
+		panic(err)
+	}
+	for _, file := range files {
+		base := strings.TrimSuffix(strings.TrimPrefix(file, "prompts/"), ".tmpl")
+		parts := strings.Split(base, ".")
+		if len(parts) != 3 || promptVersionNumber(parts[1]) < 0 {
+			panic(fmt.Sprintf("프롬프트 파일명 형식 오류: %s ({이름}.v{번호}.{언어}.tmpl)", file))
+		}
+		name, version, locale := parts[0], parts[1], parts[2]
+
+		content, err := promptFiles.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		tmpl := template.Must(template.New(base).Funcs(funcs).Parse(string(content)))
+
+		if set[name] == nil {
+			set[name] = make(map[string]map[string]*template.Template)
+		}
+		if set[name][version] == nil {
+			set[name][version] = make(map[string]*template.Template)
+		}
+		set[name][version][locale] = tmpl
+	}
+	return set
+}
+
+// promptVersionNumber : "v12" → 12 (형식이 다르면 -1)
+func promptVersionNumber(version string) int {
+	if !strings.HasPrefix(version, "v") {
+		return -1
+	}
+	n, err := strconv.Atoi(version[1:])
+	if err != nil || n < 0 {
+		return -1
+	}
+	return n
+}
+
+// PromptVersions : 이름별 사용 가능한 버전 목록 (오래된 순)
+func PromptVersions(name string) []string {
+	var versions []string
+	for version := range promptTemplates[name] {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return promptVersionNumber(versions[i]) < promptVersionNumber(versions[j])
+	})
+	return versions
+}
+
+// renderPrompt : 설정된 버전(PROMPT_VERSION, 비우면 최신)과 언어(PROMPT_LOCALE)로 프롬프트 렌더링
+// 해당 언어의 템플릿이 없으면 기본 언어(ko)를 사용
+func renderPrompt(name string, data promptData) (*renderedPrompt, error) {
+	versions := PromptVersions(name)
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("프롬프트 템플릿이 없습니다: %s", name)
+	}
+
+	version := versions[len(versions)-1]
+	if config.PromptVersion != "" {
+		if _, ok := promptTemplates[name][config.PromptVersion]; !ok {
+			return nil, fmt.Errorf("프롬프트 버전이 없습니다: %s.%s (사용 가능: %s)", name, config.PromptVersion, strings.Join(versions, ", "))
+		}
+		version = config.PromptVersion
+	}
+
+	locale := config.PromptLocale
+	tmpl, ok := promptTemplates[name][version][locale]
+	if !ok {
+		locale = defaultPromptLocale
+		if tmpl, ok = promptTemplates[name][version][locale]; !ok {
+			return nil, fmt.Errorf("프롬프트 템플릿이 없습니다: %s.%s.%s", name, version, config.PromptLocale)
+		}
+	}
+
+	data.Locale = locale
+	if data.KnownBrands == nil {
+		data.KnownBrands = knownBrands()
+	}
+	if data.SizeTable == nil {
+		data.SizeTable = cupSizeTable
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("프롬프트 렌더링 실패 (%s.%s.%s): %v", name, version, locale, err)
+	}
+	return &renderedPrompt{
+		Text:    strings.TrimSpace(buf.String()),
+		Version: fmt.Sprintf("%s.%s.%s", name, version, locale),
+	}, nil
+}
+
+// knownBrandsCache : 자주 등록된 브랜드 목록 (매 요청마다 조회하지 않도록 캐시)
+var knownBrandsCache struct {
+	sync.Mutex
+	brands    []string
+	expiresAt time.Time
+}
+
+// knownBrands : 음료가 많이 등록된 브랜드 순으로 최대 knownBrandsLimit개
+func knownBrands() []string {
+	if config.DB == nil {
+		return []string{}
+	}
+
+	knownBrandsCache.Lock()
+	defer knownBrandsCache.Unlock()
+	if time.Now().Before(knownBrandsCache.expiresAt) {
+		return knownBrandsCache.brands
+	}
+
+	brands := []string{}
+	config.DB.Model(&models.Beverage{}).
+		Where("brand <> ? AND status = ?", "", models.BeverageStatusActive).
+		Group("brand").
+		Order("COUNT(*) DESC, brand ASC").
+		Limit(knownBrandsLimit).
+		Pluck("brand", &brands)
+
+	knownBrandsCache.brands = brands
+	knownBrandsCache.expiresAt = time.Now().Add(knownBrandsTTL)
+	return brands
+}
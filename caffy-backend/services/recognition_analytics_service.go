@@ -0,0 +1,429 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"errors"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ========================================
+// 인식 분석
+// 기간/사용자/프로바이더별 캐시 적중률, 정정률(피드백에서 틀렸다고 한 비율),
+// 신뢰도 보정(신뢰도 구간별 실제 정답률), 가장 많이 정정된 음료
+// ========================================
+
+var ErrInvalidAnalyticsRange = errors.New("조회 기간이 올바르지 않습니다 (from < to, 최대 366일)")
+
+// 집계 단위
+const (
+	AnalyticsIntervalHour = "hour"
+	AnalyticsIntervalDay  = "day"
+	AnalyticsIntervalWeek = "week"
+)
+
+const (
+	defaultAnalyticsDays = 30  // 기간을 주지 않으면 최근 30일
+	maxAnalyticsDays     = 366 // 한 번에 조회할 수 있는 최대 기간
+	maxHourlyDays        = 14  // 시간 단위 집계는 최대 14일
+	calibrationBins      = 10  // 신뢰도 구간 수 (0.1 단위)
+	mostCorrectedLimit   = 10
+)
+
+// recognitionProviderExpr : 답을 낸 곳 (API를 호출했으면 프로바이더, 아니면 캐시/바코드 등 source)
+// provider 컬럼이 생기기 전 LLM 로그는 "llm"으로 집계됨
+const recognitionProviderExpr = "COALESCE(NULLIF(provider, ''), source)"
+
+// recognitionMetricsSelect : RecognitionMetrics 집계 컬럼
+const recognitionMetricsSelect = "COUNT(*) AS recognitions, " +
+	"SUM(CASE WHEN source = 'database' THEN 1 ELSE 0 END) AS cache_hits, " +
+	"SUM(CASE WHEN source = 'barcode' THEN 1 ELSE 0 END) AS barcode_hits, " +
+	"SUM(CASE WHEN source IN ('llm', 'vision') THEN 1 ELSE 0 END) AS provider_calls, " +
+	"SUM(CASE WHEN is_correct IS NOT NULL THEN 1 ELSE 0 END) AS feedbacks, " +
+	"SUM(CASE WHEN is_correct = FALSE THEN 1 ELSE 0 END) AS corrections, " +
+	"AVG(confidence) AS avg_confidence, " +
+	"AVG(processing_time) AS avg_processing_time"
+
+// RecognitionAnalyticsFilter : 분석 조건 (From/To가 비어 있으면 최근 30일)
+type RecognitionAnalyticsFilter struct {
+	From          time.Time
+	To            time.Time // 이 시각 직전까지
+	Interval      string    // "hour", "day", "week" (비우면 day)
+	UserID        uint      // 0이면 전체 사용자
+	Source        string
+	Provider      string // "gemini", "openai", "vision", "database", "barcode", "llm"
+	PromptVersion string
+}
+
+// RecognitionMetrics : 인식 지표
+// 캐시 적중 = 같은 사진을 저장된 결과로 답함 (source=database), 프로바이더 호출 = LLM/Vision API 호출 (비용 발생)
+type RecognitionMetrics struct {
+	Recognitions      int64   `json:"recognitions"`
+	CacheHits         int64   `json:"cache_hits"`
+	BarcodeHits       int64   `json:"barcode_hits"`
+	ProviderCalls     int64   `json:"provider_calls"`
+	Feedbacks         int64   `json:"feedbacks"`
+	Corrections       int64   `json:"corrections"` // 틀렸다는 피드백
+	AvgConfidence     float64 `json:"avg_confidence"`
+	AvgProcessingTime float64 `json:"avg_processing_time_ms"`
+	CacheHitRate      float64 `json:"cache_hit_rate" gorm:"-"`
+	FeedbackRate      float64 `json:"feedback_rate" gorm:"-"`   // 피드백을 받은 비율
+	CorrectionRate    float64 `json:"correction_rate" gorm:"-"` // 피드백 중 틀렸다는 비율
+}
+
+func (m *RecognitionMetrics) computeRates() {
+	if m.Recognitions > 0 {
+		m.CacheHitRate = float64(m.CacheHits) / float64(m.Recognitions)
+		m.FeedbackRate = float64(m.Feedbacks) / float64(m.Recognitions)
+	}
+	if m.Feedbacks > 0 {
+		m.CorrectionRate = float64(m.Corrections) / float64(m.Feedbacks)
+	}
+}
+
+// RecognitionMetricsGroup : 기간/프로바이더/프롬프트별 지표
+type RecognitionMetricsGroup struct {
+	Key string `json:"key"`
+	RecognitionMetrics
+}
+
+// CalibrationBin : 신뢰도 구간별 실제 정답률 (피드백이 있는 인식만)
+type CalibrationBin struct {
+	Bin           int     `json:"-"`
+	From          float64 `json:"from" gorm:"-"`
+	To            float64 `json:"to" gorm:"-"`
+	Count         int64   `json:"count"`
+	Correct       int64   `json:"correct"`
+	AvgConfidence float64 `json:"avg_confidence"`
+	Accuracy      float64 `json:"accuracy" gorm:"-"`
+	Gap           float64 `json:"gap" gorm:"-"` // 평균 신뢰도 - 정답률 (양수면 과신)
+}
+
+// RecognitionCalibration : 신뢰도 보정 결과
+type RecognitionCalibration struct {
+	Samples int64            `json:"samples"`
+	ECE     float64          `json:"ece"` // 기대 보정 오차 (구간별 |신뢰도-정답률|의 가중 평균)
+	Bins    []CalibrationBin `json:"bins"`
+}
+
+// CorrectedDrink : 인식 결과가 틀렸다고 자주 정정된 음료
+type CorrectedDrink struct {
+	BeverageID     uint    `json:"beverage_id"`
+	Name           string  `json:"name"`
+	Recognitions   int64   `json:"recognitions"`
+	Corrections    int64   `json:"corrections"`
+	CorrectionRate float64 `json:"correction_rate"`
+	CorrectedToID  *uint   `json:"corrected_to_id,omitempty"` // 가장 많이 고친 음료
+	CorrectedTo    string  `json:"corrected_to,omitempty"`
+}
+
+// RecognitionAnalytics : 인식 분석 결과
+type RecognitionAnalytics struct {
+	From            time.Time                 `json:"from"`
+	To              time.Time                 `json:"to"`
+	Interval        string                    `json:"interval"`
+	UserID          uint                      `json:"user_id,omitempty"`
+	Totals          RecognitionMetrics        `json:"totals"`
+	Timeline        []RecognitionMetricsGroup `json:"timeline"`
+	ByProvider      []RecognitionMetricsGroup `json:"by_provider"`
+	ByPromptVersion []RecognitionMetricsGroup `json:"by_prompt_version"`
+	Calibration     RecognitionCalibration    `json:"calibration"`
+	MostCorrected   []CorrectedDrink          `json:"most_corrected"`
+}
+
+// normalize : 기본 기간/단위 채우고 범위 검사
+func (f *RecognitionAnalyticsFilter) normalize() error {
+	if f.To.IsZero() {
+		f.To = time.Now()
+	}
+	if f.From.IsZero() {
+		f.From = f.To.AddDate(0, 0, -defaultAnalyticsDays)
+	}
+	if f.Interval == "" {
+		f.Interval = AnalyticsIntervalDay
+	}
+
+	span := f.To.Sub(f.From)
+	if span <= 0 || span > maxAnalyticsDays*24*time.Hour {
+		return ErrInvalidAnalyticsRange
+	}
+	switch f.Interval {
+	case AnalyticsIntervalDay, AnalyticsIntervalWeek:
+	case AnalyticsIntervalHour:
+		if span > maxHourlyDays*24*time.Hour {
+			return ErrInvalidAnalyticsRange
+		}
+	default:
+		return ErrInvalidAnalyticsRange
+	}
+	return nil
+}
+
+// query : 조건이 적용된 인식 로그 쿼리 (호출할 때마다 새로 만듦)
+func (f *RecognitionAnalyticsFilter) query() *gorm.DB {
+	query := config.DB.Model(&models.RecognitionLog{}).
+		Where("created_at >= ? AND created_at < ?", f.From, f.To)
+	if f.UserID != 0 {
+		query = query.Where("user_id = ?", f.UserID)
+	}
+	if f.Source != "" {
+		query = query.Where("source = ?", f.Source)
+	}
+	if f.Provider != "" {
+		query = query.Where(recognitionProviderExpr+" = ?", f.Provider)
+	}
+	if f.PromptVersion != "" {
+		query = query.Where("prompt_version = ?", f.PromptVersion)
+	}
+	return query
+}
+
+// bucketExpr : 집계 단위별 기간 키 (주 단위는 월요일 날짜)
+func (f *RecognitionAnalyticsFilter) bucketExpr() string {
+	switch f.Interval {
+	case AnalyticsIntervalHour:
+		return "DATE_FORMAT(created_at, '%Y-%m-%d %H:00')"
+	case AnalyticsIntervalWeek:
+		return "DATE_FORMAT(DATE_SUB(created_at, INTERVAL WEEKDAY(created_at) DAY), '%Y-%m-%d')"
+	default:
+		return "DATE_FORMAT(created_at, '%Y-%m-%d')"
+	}
+}
+
+// GetRecognitionAnalytics : 조건에 맞는 인식 로그 분석
+func GetRecognitionAnalytics(filter RecognitionAnalyticsFilter) (*RecognitionAnalytics, error) {
+	if err := filter.normalize(); err != nil {
+		return nil, err
+	}
+	analytics := &RecognitionAnalytics{
+		From:     filter.From,
+		To:       filter.To,
+		Interval: filter.Interval,
+		UserID:   filter.UserID,
+	}
+
+	if err := filter.query().Select(recognitionMetricsSelect).Scan(&analytics.Totals).Error; err != nil {
+		return nil, err
+	}
+	analytics.Totals.computeRates()
+
+	var err error
+	if analytics.Timeline, err = groupRecognitionMetrics(filter.query(), filter.bucketExpr()); err != nil {
+		return nil, err
+	}
+	if analytics.ByProvider, err = groupRecognitionMetrics(filter.query(), recognitionProviderExpr); err != nil {
+		return nil, err
+	}
+	promptQuery := filter.query().Where("prompt_version <> ?", "")
+	if analytics.ByPromptVersion, err = groupRecognitionMetrics(promptQuery, "prompt_version"); err != nil {
+		return nil, err
+	}
+	if analytics.Calibration, err = recognitionCalibration(filter.query()); err != nil {
+		return nil, err
+	}
+	if analytics.MostCorrected, err = mostCorrectedDrinks(&filter); err != nil {
+		return nil, err
+	}
+	return analytics, nil
+}
+
+// groupRecognitionMetrics : keyExpr별 지표 (키 오름차순)
+func groupRecognitionMetrics(query *gorm.DB, keyExpr string) ([]RecognitionMetricsGroup, error) {
+	groups := []RecognitionMetricsGroup{}
+	err := query.
+		Select(keyExpr + " AS `key`, " + recognitionMetricsSelect).
+		Group("`key`").
+		Order("`key`").
+		Scan(&groups).Error
+	for i := range groups {
+		groups[i].computeRates()
+	}
+	return groups, err
+}
+
+// recognitionCalibration : 신뢰도 구간별 평균 신뢰도와 피드백 기준 정답률
+func recognitionCalibration(query *gorm.DB) (RecognitionCalibration, error) {
+	var rows []CalibrationBin
+	err := query.
+		Select("LEAST(FLOOR(confidence * ?), ?) AS bin, COUNT(*) AS count, "+
+			"SUM(CASE WHEN is_correct = TRUE THEN 1 ELSE 0 END) AS correct, "+
+			"AVG(confidence) AS avg_confidence", calibrationBins, calibrationBins-1).
+		Where("is_correct IS NOT NULL").
+		Group("bin").
+		Scan(&rows).Error
+	if err != nil {
+		return RecognitionCalibration{}, err
+	}
+
+	calibration := RecognitionCalibration{Bins: make([]CalibrationBin, calibrationBins)}
+	for i := range calibration.Bins {
+		calibration.Bins[i] = CalibrationBin{
+			Bin:  i,
+			From: float64(i) / calibrationBins,
+			To:   float64(i+1) / calibrationBins,
+		}
+	}
+	for _, row := range rows {
+		bin := &calibration.Bins[max(0, min(row.Bin, calibrationBins-1))]
+		bin.Count += row.Count
+		bin.Correct += row.Correct
+		bin.AvgConfidence = row.AvgConfidence
+		calibration.Samples += row.Count
+	}
+
+	for i := range calibration.Bins {
+		bin := &calibration.Bins[i]
+		if bin.Count == 0 {
+			continue
+		}
+		bin.Accuracy = float64(bin.Correct) / float64(bin.Count)
+		bin.Gap = bin.AvgConfidence - bin.Accuracy
+		calibration.ECE += float64(bin.Count) / float64(calibration.Samples) * math.Abs(bin.Gap)
+	}
+	return calibration, nil
+}
+
+// mostCorrectedDrinks : 틀렸다는 피드백을 많이 받은 인식 음료와 주로 고친 음료
+func mostCorrectedDrinks(filter *RecognitionAnalyticsFilter) ([]CorrectedDrink, error) {
+	drinks := []CorrectedDrink{}
+	err := filter.query().
+		Select("recognized_id AS beverage_id, COUNT(*) AS recognitions, " +
+			"SUM(CASE WHEN is_correct = FALSE THEN 1 ELSE 0 END) AS corrections").
+		Where("recognized_id IS NOT NULL").
+		Group("recognized_id").
+		Having("corrections > 0").
+		Order("corrections DESC, recognitions DESC").
+		Limit(mostCorrectedLimit).
+		Scan(&drinks).Error
+	if err != nil || len(drinks) == 0 {
+		return drinks, err
+	}
+
+	ids := make([]uint, 0, len(drinks))
+	for _, drink := range drinks {
+		ids = append(ids, drink.BeverageID)
+	}
+
+	// 음료마다 가장 많이 고친 대상 (횟수 내림차순이므로 처음 나온 것)
+	var targets []struct {
+		RecognizedID uint
+		CorrectedID  uint
+		Count        int64
+	}
+	err = filter.query().
+		Select("recognized_id, corrected_id, COUNT(*) AS count").
+		Where("is_correct = ? AND corrected_id IS NOT NULL AND recognized_id IN ?", false, ids).
+		Group("recognized_id, corrected_id").
+		Order("count DESC, corrected_id").
+		Scan(&targets).Error
+	if err != nil {
+		return nil, err
+	}
+	topTarget := map[uint]uint{}
+	for _, target := range targets {
+		if _, ok := topTarget[target.RecognizedID]; !ok {
+			topTarget[target.RecognizedID] = target.CorrectedID
+			ids = append(ids, target.CorrectedID)
+		}
+	}
+
+	// 병합/삭제된 음료도 이름은 보여줌
+	var beverages []models.Beverage
+	if err := config.DB.Unscoped().Select("id", "name").Where("id IN ?", ids).Find(&beverages).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(beverages))
+	for _, beverage := range beverages {
+		names[beverage.ID] = beverage.Name
+	}
+
+	for i := range drinks {
+		drink := &drinks[i]
+		drink.Name = names[drink.BeverageID]
+		drink.CorrectionRate = float64(drink.Corrections) / float64(drink.Recognitions)
+		if target, ok := topTarget[drink.BeverageID]; ok {
+			drink.CorrectedToID = &target
+			drink.CorrectedTo = names[target]
+		}
+	}
+	return drinks, nil
+}
+
+// ========================================
+// 내 인식 기록
+// ========================================
+
+// RecognitionHistoryItem : 사용자에게 보여주는 인식 기록 하나
+type RecognitionHistoryItem struct {
+	ID             uint      `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Source         string    `json:"source"`
+	Provider       string    `json:"provider,omitempty"`
+	Confidence     float64   `json:"confidence"`
+	ProcessingTime int       `json:"processing_time"`
+	RecognizedID   *uint     `json:"recognized_id"`
+	RecognizedName string    `json:"recognized_name,omitempty"`
+	IsCorrect      *bool     `json:"is_correct"`
+	CorrectedID    *uint     `json:"corrected_id"`
+	CorrectedName  string    `json:"corrected_name,omitempty"`
+}
+
+// ListRecognitionHistory : 사용자의 인식 기록 (최근순)과 전체 개수
+func ListRecognitionHistory(userID uint, page int, limit int) ([]RecognitionHistoryItem, int64, error) {
+	limit = clampLimit(limit, 20, 100)
+	page = max(page, 1)
+
+	query := config.DB.Model(&models.RecognitionLog{}).Where("user_id = ?", userID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var logs []models.RecognitionLog
+	if err := query.Order("id DESC").Limit(limit).Offset((page - 1) * limit).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var ids []uint
+	for _, log := range logs {
+		if log.RecognizedID != nil {
+			ids = append(ids, *log.RecognizedID)
+		}
+		if log.CorrectedID != nil {
+			ids = append(ids, *log.CorrectedID)
+		}
+	}
+	names := map[uint]string{}
+	if len(ids) > 0 {
+		var beverages []models.Beverage
+		config.DB.Unscoped().Select("id", "name").Where("id IN ?", ids).Find(&beverages)
+		for _, beverage := range beverages {
+			names[beverage.ID] = beverage.Name
+		}
+	}
+
+	items := make([]RecognitionHistoryItem, 0, len(logs))
+	for _, log := range logs {
+		item := RecognitionHistoryItem{
+			ID:             log.ID,
+			CreatedAt:      log.CreatedAt,
+			Source:         log.Source,
+			Provider:       log.Provider,
+			Confidence:     log.Confidence,
+			ProcessingTime: log.ProcessingTime,
+			RecognizedID:   log.RecognizedID,
+			IsCorrect:      log.IsCorrect,
+			CorrectedID:    log.CorrectedID,
+		}
+		if log.RecognizedID != nil {
+			item.RecognizedName = names[*log.RecognizedID]
+		}
+		if log.CorrectedID != nil {
+			item.CorrectedName = names[*log.CorrectedID]
+		}
+		items = append(items, item)
+	}
+	return items, total, nil
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ========================================
+// 비동기 인식 작업 (작업 제출 → 워커 처리 → 폴링/스트리밍)
+// ========================================
+
+var (
+	ErrRecognitionJobNotFound = errors.New("인식 작업을 찾을 수 없습니다")
+	ErrRecognitionQueueFull   = errors.New("인식 대기열이 가득 찼습니다. 잠시 후 다시 시도해 주세요")
+)
+
+// maxJobAttempts : 처리 중 서버가 재시작된 작업을 다시 시도하는 최대 횟수
+const maxJobAttempts = 3
+
+// janitorInterval : 만료 작업 정리 주기
+const janitorInterval = time.Minute
+
+// jobLease : 처리를 시작한 지 이 시간이 지나도 끝나지 않은 작업은 처리하던 인스턴스가 멈춘 것으로 봄
+// 여러 인스턴스가 같은 DB를 쓰므로 다른 인스턴스가 처리 중인 작업은 이 시간 전에는 건드리지 않음
+const jobLease = 10 * time.Minute
+
+// RecognitionJobStatus : 작업 조회 응답
+type RecognitionJobStatus struct {
+	ID         uint                    `json:"id"`
+	Status     string                  `json:"status"`
+	Result     *SmartRecognitionResult `json:"result,omitempty"`
+	Error      string                  `json:"error,omitempty"`
+	CreatedAt  time.Time               `json:"created_at"`
+	StartedAt  *time.Time              `json:"started_at,omitempty"`
+	FinishedAt *time.Time              `json:"finished_at,omitempty"`
+	ExpiresAt  time.Time               `json:"expires_at"`
+}
+
+// Finished : 더 이상 상태가 바뀌지 않는 작업인지
+func (s *RecognitionJobStatus) Finished() bool {
+	return s.Status == models.JobStatusDone || s.Status == models.JobStatusFailed
+}
+
+// jobQueue : 처리 대기 중인 작업 ID
+var jobQueue chan uint
+
+// jobWatchers : 작업 상태 변경을 기다리는 구독자 (SSE)
+var jobWatchers = &jobWatcherGroup{subs: make(map[uint]map[chan struct{}]struct{})}
+
+// StartRecognitionWorkers : 워커 풀과 만료 작업 정리기 시작
+// 재시작 전에 끝나지 않은 작업은 다시 대기열에 넣음
+func StartRecognitionWorkers() {
+	jobQueue = make(chan uint, config.RecognitionQueueSize)
+
+	for i := 0; i < config.RecognitionWorkers; i++ {
+		go recognitionWorker()
+	}
+	go recognitionJobJanitor()
+
+	requeueUnfinishedJobs()
+	println("✅ 인식 워커 시작:", config.RecognitionWorkers, "개")
+}
+
+// SubmitRecognitionJob : 인식 작업 생성 후 대기열에 추가 (즉시 반환)
+func SubmitRecognitionJob(userID uint, imageBase64 string) (*RecognitionJobStatus, error) {
+	job := models.RecognitionJob{
+		UserID:      userID,
+		Status:      models.JobStatusQueued,
+		ImageBase64: imageBase64,
+		ExpiresAt:   time.Now().Add(time.Duration(config.RecognitionJobTTLMinutes) * time.Minute),
+	}
+	if err := config.DB.Create(&job).Error; err != nil {
+		return nil, fmt.Errorf("인식 작업 생성 실패: %v", err)
+	}
+
+	select {
+	case jobQueue <- job.ID:
+	default:
+		finishJob(&job, nil, ErrRecognitionQueueFull)
+		return nil, ErrRecognitionQueueFull
+	}
+
+	return jobStatus(&job), nil
+}
+
+// GetRecognitionJob : 사용자의 인식 작업 조회 (만료된 작업은 없는 것으로 취급)
+func GetRecognitionJob(userID uint, jobID uint) (*RecognitionJobStatus, error) {
+	var job models.RecognitionJob
+	err := config.DB.Omit("image_base64").
+		Where("id = ? AND user_id = ? AND expires_at > ?", jobID, userID, time.Now()).
+		First(&job).Error
+	if err != nil {
+		return nil, ErrRecognitionJobNotFound
+	}
+	return jobStatus(&job), nil
+}
+
+// WatchRecognitionJob : 작업 상태가 바뀔 때마다 신호를 받는 채널 (cancel로 구독 해제)
+func WatchRecognitionJob(jobID uint) (<-chan struct{}, func()) {
+	return jobWatchers.subscribe(jobID)
+}
+
+// recognitionWorker : 대기열에서 작업을 꺼내 처리
+func recognitionWorker() {
+	for jobID := range jobQueue {
+		processRecognitionJob(jobID)
+	}
+}
+
+// processRecognitionJob : 작업 하나 처리
+// 다른 워커가 이미 가져간 작업은 건너뜀 (queued → processing 전환에 성공한 워커만 처리)
+func processRecognitionJob(jobID uint) {
+	now := time.Now()
+	claimed := config.DB.Model(&models.RecognitionJob{}).
+		Where("id = ? AND status = ?", jobID, models.JobStatusQueued).
+		Updates(map[string]interface{}{
+			"status":     models.JobStatusProcessing,
+			"started_at": now,
+			"attempts":   gorm.Expr("attempts + ?", 1),
+		})
+	if claimed.Error != nil || claimed.RowsAffected == 0 {
+		return
+	}
+
+	var job models.RecognitionJob
+	if err := config.DB.First(&job, jobID).Error; err != nil {
+		return
+	}
+	jobWatchers.notify(jobID)
+
+	var result *SmartRecognitionResult
+	var err error
+	func() {
+		// 인식 중 패닉이 나도 워커는 계속 동작하고 작업은 실패로 기록
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("인식 중 오류: %v", r)
+			}
+		}()
+		result, err = SmartRecognizeDrink(job.ImageBase64, job.UserID)
+	}()
+
+	finishJob(&job, result, err)
+}
+
+// finishJob : 작업 결과 저장 (이미지는 더 필요 없으므로 비움)
+func finishJob(job *models.RecognitionJob, result *SmartRecognitionResult, err error) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":       models.JobStatusDone,
+		"image_base64": "",
+		"finished_at":  now,
+	}
+	if err != nil {
+		updates["status"] = models.JobStatusFailed
+		updates["error"] = truncateRunes(err.Error(), 500)
+	} else {
+		resultJSON, _ := json.Marshal(result)
+		updates["result"] = string(resultJSON)
+	}
+
+	config.DB.Model(job).Updates(updates)
+	jobWatchers.notify(job.ID)
+}
+
+// requeueUnfinishedJobs : 재시작 전에 끝나지 않은 작업을 다시 대기열에 넣음
+// 처리가 멈춘 작업(jobLease 경과)은 시도 횟수가 남아 있으면 queued로 되돌림
+func requeueUnfinishedJobs() {
+	requeueStaleJobs()
+
+	var jobIDs []uint
+	config.DB.Model(&models.RecognitionJob{}).
+		Where("status = ? AND expires_at > ?", models.JobStatusQueued, time.Now()).
+		Order("id ASC").
+		Pluck("id", &jobIDs)
+	if len(jobIDs) == 0 {
+		return
+	}
+
+	println("🔁 미완료 인식 작업 재개:", len(jobIDs), "개")
+	enqueueJobs(jobIDs)
+}
+
+// requeueStaleJobs : 처리를 시작한 지 jobLease가 지난 작업을 queued로 되돌리고 ID 반환
+// 시도 횟수를 다 쓴 작업은 실패로 기록
+func requeueStaleJobs() []uint {
+	cutoff := time.Now().Add(-jobLease)
+	config.DB.Model(&models.RecognitionJob{}).
+		Where("status = ? AND started_at < ? AND attempts >= ?", models.JobStatusProcessing, cutoff, maxJobAttempts).
+		Updates(map[string]interface{}{
+			"status":       models.JobStatusFailed,
+			"error":        "처리가 여러 번 중단되어 작업을 중단했습니다",
+			"image_base64": "",
+			"finished_at":  time.Now(),
+		})
+
+	var jobIDs []uint
+	config.DB.Model(&models.RecognitionJob{}).
+		Where("status = ? AND (started_at IS NULL OR started_at < ?)", models.JobStatusProcessing, cutoff).
+		Pluck("id", &jobIDs)
+	if len(jobIDs) == 0 {
+		return nil
+	}
+	// 조회와 갱신 사이에 끝난 작업은 되돌리지 않음
+	config.DB.Model(&models.RecognitionJob{}).
+		Where("id IN ? AND status = ?", jobIDs, models.JobStatusProcessing).
+		Update("status", models.JobStatusQueued)
+	return jobIDs
+}
+
+// enqueueJobs : 작업 ID를 대기열에 추가
+// 대기열보다 많을 수 있으므로 별도 고루틴에서 밀어 넣음 (이미 다른 워커가 가져간 작업은 처리 시 건너뜀)
+func enqueueJobs(jobIDs []uint) {
+	go func() {
+		for _, id := range jobIDs {
+			jobQueue <- id
+		}
+	}()
+}
+
+// recognitionJobJanitor : 보관 시간이 지난 작업 삭제, 멈춘 작업 재개
+func recognitionJobJanitor() {
+	ticker := time.NewTicker(janitorInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		// 처리하던 인스턴스가 멈춘 작업은 이 인스턴스가 이어서 처리
+		if jobIDs := requeueStaleJobs(); len(jobIDs) > 0 {
+			println("🔁 멈춘 인식 작업 재개:", len(jobIDs), "개")
+			enqueueJobs(jobIDs)
+		}
+
+		deleted := config.DB.Unscoped().
+			Where("expires_at <= ?", time.Now()).
+			Delete(&models.RecognitionJob{})
+		if deleted.Error == nil && deleted.RowsAffected > 0 {
+			println("🧹 만료된 인식 작업 삭제:", deleted.RowsAffected, "개")
+		}
+	}
+}
+
+// jobStatus : DB 행을 응답 형태로 변환
+func jobStatus(job *models.RecognitionJob) *RecognitionJobStatus {
+	status := &RecognitionJobStatus{
+		ID:         job.ID,
+		Status:     job.Status,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+		ExpiresAt:  job.ExpiresAt,
+	}
+	if job.Result != "" {
+		var result SmartRecognitionResult
+		if json.Unmarshal([]byte(job.Result), &result) == nil {
+			status.Result = &result
+		}
+	}
+	return status
+}
+
+// jobWatcherGroup : 작업 ID별 상태 변경 구독자
+type jobWatcherGroup struct {
+	mu   sync.Mutex
+	subs map[uint]map[chan struct{}]struct{}
+}
+
+// subscribe : 작업 상태 변경 구독
+func (g *jobWatcherGroup) subscribe(jobID uint) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	g.mu.Lock()
+	if g.subs[jobID] == nil {
+		g.subs[jobID] = make(map[chan struct{}]struct{})
+	}
+	g.subs[jobID][ch] = struct{}{}
+	g.mu.Unlock()
+
+	cancel := func() {
+		g.mu.Lock()
+		delete(g.subs[jobID], ch)
+		if len(g.subs[jobID]) == 0 {
+			delete(g.subs, jobID)
+		}
+		g.mu.Unlock()
+	}
+	return ch, cancel
+}
+
+// notify : 구독자에게 상태 변경 알림 (이미 알림이 쌓여 있으면 건너뜀)
+func (g *jobWatcherGroup) notify(jobID uint) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for ch := range g.subs[jobID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
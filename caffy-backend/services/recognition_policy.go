@@ -0,0 +1,128 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"math"
+)
+
+// ========================================
+// 인식 정책 (신뢰도 기반 재확인, 프로바이더 합의)
+// 첫 프로바이더의 대표 음료 신뢰도가 기준보다 낮으면 다음 프로바이더에도 물어보고,
+// 음료나 카페인 값이 다르면 두 후보를 모두 돌려줘 사용자가 고르게 함
+// 캐시(BeverageImage)에는 합의한 결과와 사용자가 확인한 결과만 저장
+// ========================================
+
+// 정책 판정 결과
+const (
+	PolicyAccepted     = "accepted"     // 신뢰도가 기준 이상 (프로바이더 하나의 답)
+	PolicyConsensus    = "consensus"    // 두 프로바이더가 같은 음료/카페인으로 답함
+	PolicyDisagreement = "disagreement" // 답이 달라 사용자가 골라야 함
+	PolicyUnconfirmed  = "unconfirmed"  // 신뢰도가 낮은데 물어볼 다른 프로바이더가 없거나 실패
+)
+
+// consensusCaffeineRatio : 카페인 허용 오차 비율 (RecognitionCaffeineToleranceMG와 둘 중 큰 값)
+const consensusCaffeineRatio = 0.15
+
+// RecognitionCandidate : 프로바이더 하나가 낸 인식 후보
+type RecognitionCandidate struct {
+	Provider       string          `json:"provider"`
+	DrinkName      string          `json:"drink_name"`
+	CaffeineAmount int             `json:"caffeine_amount"`
+	Confidence     float64         `json:"confidence"`
+	Brand          string          `json:"brand"`
+	Category       string          `json:"category"`
+	BeverageID     *uint           `json:"beverage_id,omitempty"`
+	Drinks         []DetectedDrink `json:"drinks"` // 이 프로바이더가 찾은 사진 속 모든 음료
+}
+
+func candidateFromResult(result *LLMRecognitionResult) RecognitionCandidate {
+	return RecognitionCandidate{
+		Provider:       result.Provider,
+		DrinkName:      result.DrinkName,
+		CaffeineAmount: result.CaffeineAmount,
+		Confidence:     result.Confidence,
+		Brand:          result.Brand,
+		Category:       result.Category,
+		Drinks:         result.Drinks,
+	}
+}
+
+// Cacheable : 인식 결과를 바로 캐시에 저장해도 되는지 (합의한 결과만)
+func (r *LLMRecognitionResult) Cacheable() bool {
+	return r.Policy == PolicyConsensus
+}
+
+// RecognizeWithPolicy : 인식 체인을 정책에 따라 호출하고 프로바이더별 시도 내역도 반환
+// 결과의 Policy에 판정, Candidates에 프로바이더별 후보 (불일치면 신뢰도 높은 순)
+func RecognizeWithPolicy(imageBase64 string, mimeType string) (*LLMRecognitionResult, []ProviderAttempt, error) {
+	primary, attempts, next, err := recognizeWithChain(recognitionProviders, imageBase64, mimeType)
+	if err != nil {
+		return nil, attempts, err
+	}
+	primary.Candidates = []RecognitionCandidate{candidateFromResult(primary)}
+	if primary.Confidence >= config.RecognitionEscalateBelow {
+		primary.Policy = PolicyAccepted
+		return primary, attempts, nil
+	}
+
+	println("🤔", primary.Provider, "신뢰도 낮음 (", primary.Confidence, ") - 다른 프로바이더에 재확인")
+	second, more, _, err := recognizeWithChain(recognitionProviders[next:], imageBase64, mimeType)
+	attempts = append(attempts, more...)
+	if err != nil {
+		primary.Policy = PolicyUnconfirmed
+		return primary, attempts, nil
+	}
+	return decideConsensus(primary, second), attempts, nil
+}
+
+// decideConsensus : 두 프로바이더의 답을 비교해 대표 결과와 판정 결정
+// 더 확신하는 쪽을 대표로 하고, 합의했으면 신뢰도는 둘 중 높은 값
+func decideConsensus(primary, second *LLMRecognitionResult) *LLMRecognitionResult {
+	chosen, other := primary, second
+	if second.Confidence > primary.Confidence {
+		chosen, other = second, primary
+	}
+	chosen.Candidates = []RecognitionCandidate{candidateFromResult(chosen), candidateFromResult(other)}
+	chosen.Usage.add(other.Usage)
+
+	if sameRecognizedDrink(primary, second) && caffeineAgrees(primary.CaffeineAmount, second.CaffeineAmount) {
+		println("🤝 프로바이더 합의:", chosen.DrinkName)
+		chosen.Policy = PolicyConsensus
+		return chosen
+	}
+
+	println("⚖️ 프로바이더 불일치:", primary.Provider, primary.DrinkName, primary.CaffeineAmount, "/", second.Provider, second.DrinkName, second.CaffeineAmount)
+	chosen.Policy = PolicyDisagreement
+	return chosen
+}
+
+// sameRecognizedDrink : 두 결과의 대표 음료가 같은 음료인지
+// 별칭이 같은 음료를 가리키거나, 브랜드가 다르지 않으면서 이름이 같거나 브랜드를 뺀 이름이 비슷함
+func sameRecognizedDrink(a, b *LLMRecognitionResult) bool {
+	if idA, ok := aliasBeverageID(a.DrinkName); ok {
+		if idB, ok := aliasBeverageID(b.DrinkName); ok && idA == idB {
+			return true
+		}
+	}
+
+	entries := newDuplicateEntries([]models.Beverage{
+		{Name: a.DrinkName, Brand: a.Brand},
+		{Name: b.DrinkName, Brand: b.Brand},
+	}, aliasSnapshot().terms)
+	x, y := &entries[0], &entries[1]
+	if x.brand != "" && y.brand != "" && x.brand != y.brand {
+		return false
+	}
+	if aliasKey(a.DrinkName) == aliasKey(b.DrinkName) {
+		return true
+	}
+	score, _ := scoreDuplicatePair(x, y) // 이름이 충분히 비슷하지 않으면 0
+	return score > 0
+}
+
+// caffeineAgrees : 두 카페인 값이 허용 오차 안인지
+func caffeineAgrees(a, b int) bool {
+	tolerance := math.Max(float64(config.RecognitionCaffeineToleranceMG), consensusCaffeineRatio*float64(max(a, b)))
+	return math.Abs(float64(a-b)) <= tolerance
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCaffeineAgrees(t *testing.T) {
+	saved := config.RecognitionCaffeineToleranceMG
+	config.RecognitionCaffeineToleranceMG = 20
+	defer func() { config.RecognitionCaffeineToleranceMG = saved }()
+
+	// 허용 오차는 20mg와 큰 값의 15% 중 큰 쪽
+	tests := []struct {
+		a, b int
+		want bool
+	}{
+		{300, 340, true},  // 15% = 51mg
+		{100, 125, false}, // 20mg > 18.75mg
+		{100, 120, true},  // 정확히 20mg
+		{0, 20, true},
+		{0, 21, false},
+		{400, 470, true},  // 70.5mg
+		{400, 480, false}, // 72mg
+		{480, 400, false}, // 순서 무관
+		{150, 150, true},
+	}
+
+	for _, tt := range tests {
+		if got := caffeineAgrees(tt.a, tt.b); got != tt.want {
+			t.Errorf("caffeineAgrees(%d, %d) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// withAliasIndex : 테스트 동안 별칭 캐시를 주어진 별칭으로 바꿈 (DB 없이 별칭 매칭 확인)
+func withAliasIndex(t *testing.T, aliases []models.BeverageAlias) {
+	t.Helper()
+	var rows []models.BeverageAlias
+	for alias, term := range builtinTermAliases {
+		rows = append(rows, models.BeverageAlias{Alias: alias, Normalized: aliasKey(alias), TargetType: models.AliasTargetTerm, Term: term, Weight: 1})
+	}
+	rows = append(rows, aliases...)
+
+	aliasCache.Lock()
+	savedIndex, savedExpires := aliasCache.index, aliasCache.expiresAt
+	aliasCache.index, aliasCache.expiresAt = buildAliasIndex(rows), time.Now().Add(time.Hour)
+	aliasCache.Unlock()
+	t.Cleanup(func() {
+		aliasCache.Lock()
+		aliasCache.index, aliasCache.expiresAt = savedIndex, savedExpires
+		aliasCache.Unlock()
+	})
+}
+
+func TestDecideConsensus(t *testing.T) {
+	saved := config.RecognitionCaffeineToleranceMG
+	config.RecognitionCaffeineToleranceMG = 20
+	defer func() { config.RecognitionCaffeineToleranceMG = saved }()
+
+	bearID := uint(7)
+	withAliasIndex(t, []models.BeverageAlias{
+		{Alias: "북극곰 라떼", Normalized: aliasKey("북극곰 라떼"), TargetType: models.AliasTargetBeverage, BeverageID: &bearID, Weight: 1},
+		{Alias: "polar bear latte", Normalized: aliasKey("polar bear latte"), TargetType: models.AliasTargetBeverage, BeverageID: &bearID, Weight: 1},
+	})
+
+	type answer struct {
+		name     string
+		brand    string
+		caffeine int
+		conf     float64
+	}
+	tests := []struct {
+		name          string
+		primary       answer
+		second        answer
+		wantPolicy    string
+		wantProvider  string // 대표로 고른 프로바이더
+		wantDrinkName string
+	}{
+		{
+			name:          "같은 이름, 카페인 허용 오차 안",
+			primary:       answer{"아메리카노", "스타벅스", 150, 0.5},
+			second:        answer{"아메리카노", "스타벅스", 160, 0.7},
+			wantPolicy:    PolicyConsensus,
+			wantProvider:  "openai",
+			wantDrinkName: "아메리카노",
+		},
+		{
+			name:          "영문/한글 표기",
+			primary:       answer{"Starbucks Americano", "Starbucks", 150, 0.55},
+			second:        answer{"스타벅스 아메리카노", "스타벅스", 150, 0.5},
+			wantPolicy:    PolicyConsensus,
+			wantProvider:  "gemini",
+			wantDrinkName: "Starbucks Americano",
+		},
+		{
+			name:          "별칭이 같은 음료를 가리킴",
+			primary:       answer{"북극곰 라떼", "", 80, 0.4},
+			second:        answer{"Polar Bear Latte", "", 90, 0.45},
+			wantPolicy:    PolicyConsensus,
+			wantProvider:  "openai",
+			wantDrinkName: "Polar Bear Latte",
+		},
+		{
+			name:          "브랜드가 다르면 이름이 같아도 불일치",
+			primary:       answer{"아메리카노", "스타벅스", 150, 0.5},
+			second:        answer{"아메리카노", "이디야", 150, 0.4},
+			wantPolicy:    PolicyDisagreement,
+			wantProvider:  "gemini",
+			wantDrinkName: "아메리카노",
+		},
+		{
+			name:          "브랜드 포함 이름이 다름",
+			primary:       answer{"스타벅스 아메리카노", "", 150, 0.5},
+			second:        answer{"이디야 아메리카노", "이디야", 150, 0.4},
+			wantPolicy:    PolicyDisagreement,
+			wantProvider:  "gemini",
+			wantDrinkName: "스타벅스 아메리카노",
+		},
+		{
+			name:          "같은 음료, 카페인 차이가 큼",
+			primary:       answer{"콜드브루", "스타벅스", 100, 0.3},
+			second:        answer{"콜드브루", "스타벅스", 125, 0.5},
+			wantPolicy:    PolicyDisagreement,
+			wantProvider:  "openai",
+			wantDrinkName: "콜드브루",
+		},
+		{
+			name:          "다른 음료",
+			primary:       answer{"아메리카노", "", 150, 0.5},
+			second:        answer{"카페라떼", "", 150, 0.5},
+			wantPolicy:    PolicyDisagreement,
+			wantProvider:  "gemini", // 신뢰도가 같으면 첫 프로바이더
+			wantDrinkName: "아메리카노",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &LLMRecognitionResult{
+				Provider: "gemini", DrinkName: tt.primary.name, Brand: tt.primary.brand,
+				CaffeineAmount: tt.primary.caffeine, Confidence: tt.primary.conf, Usage: LLMUsage{InputTokens: 100, OutputTokens: 10},
+			}
+			second := &LLMRecognitionResult{
+				Provider: "openai", DrinkName: tt.second.name, Brand: tt.second.brand,
+				CaffeineAmount: tt.second.caffeine, Confidence: tt.second.conf, Usage: LLMUsage{InputTokens: 200, OutputTokens: 20},
+			}
+
+			chosen := decideConsensus(primary, second)
+			if chosen.Policy != tt.wantPolicy {
+				t.Errorf("Policy = %q, want %q", chosen.Policy, tt.wantPolicy)
+			}
+			if chosen.Provider != tt.wantProvider || chosen.DrinkName != tt.wantDrinkName {
+				t.Errorf("대표 = %s %q, want %s %q", chosen.Provider, chosen.DrinkName, tt.wantProvider, tt.wantDrinkName)
+			}
+			if len(chosen.Candidates) != 2 || chosen.Candidates[0].Provider != tt.wantProvider || chosen.Candidates[1].Provider == tt.wantProvider {
+				t.Errorf("후보 순서 = %+v (대표가 첫 번째여야 함)", chosen.Candidates)
+			}
+			if chosen.Usage.InputTokens != 300 || chosen.Usage.OutputTokens != 30 {
+				t.Errorf("Usage = %+v, 두 프로바이더 합이어야 함", chosen.Usage)
+			}
+			if chosen.Cacheable() != (tt.wantPolicy == PolicyConsensus) {
+				t.Errorf("Cacheable = %v, 합의한 결과만 바로 캐시", chosen.Cacheable())
+			}
+		})
+	}
+}
+
+func TestPickRecognitionCandidate(t *testing.T) {
+	beverageID := uint(5)
+	candidates := []RecognitionCandidate{
+		{Provider: "gemini", DrinkName: "아메리카노", CaffeineAmount: 150, Confidence: 0.5, BeverageID: &beverageID},
+		{Provider: "openai", DrinkName: "콜드브루", CaffeineAmount: 200, Confidence: 0.4}, // 음료 DB와 연결되지 않은 후보
+	}
+
+	for _, index := range []int{-1, 2} {
+		if _, err := pickRecognitionCandidate(candidates, index); err != ErrInvalidCandidate {
+			t.Errorf("index %d: err = %v, want ErrInvalidCandidate", index, err)
+		}
+	}
+	if _, err := pickRecognitionCandidate(nil, 0); err != ErrInvalidCandidate {
+		t.Errorf("후보 없음: err = %v, want ErrInvalidCandidate", err)
+	}
+
+	picked, err := pickRecognitionCandidate(candidates, 1)
+	if err != nil || picked.DrinkName != "콜드브루" {
+		t.Fatalf("picked = %+v, err = %v", picked, err)
+	}
+	picked.DrinkName = "바뀜"
+	if candidates[1].DrinkName != "콜드브루" {
+		t.Error("고른 후보를 바꿔도 후보 목록은 그대로여야 함")
+	}
+}
+
+func TestConfirmedRecognitionImage(t *testing.T) {
+	beverageID := uint(5)
+	candidates := []RecognitionCandidate{
+		{Provider: "gemini", DrinkName: "아메리카노", CaffeineAmount: 150, Confidence: 0.55, BeverageID: &beverageID,
+			Drinks: []DetectedDrink{{DrinkName: "아메리카노", CaffeineAmount: 150, BeverageID: &beverageID}}},
+		{Provider: "openai", DrinkName: "콜드브루", CaffeineAmount: 200, Confidence: 0.4,
+			Drinks: []DetectedDrink{{DrinkName: "콜드브루", CaffeineAmount: 200}}},
+	}
+	log := &models.RecognitionLog{UserID: 3, ImageHash: "abc", ImagePath: "3/a.jpg"}
+
+	// 음료 DB에 없는 후보를 골라도 후보 값으로 캐시 (정정 음료 없음)
+	picked, _ := pickRecognitionCandidate(candidates, 1)
+	image := confirmedRecognitionImage(log, candidates, false, nil, picked)
+	if image == nil {
+		t.Fatal("연결되지 않은 후보를 골라도 캐시에 저장해야 함")
+	}
+	if image.BeverageID != nil || image.DrinkName != "콜드브루" || image.CaffeineAmount != 200 ||
+		image.Confidence != UserCorrectedConfidence || image.Source != "user" || image.UploadedByUser != 3 {
+		t.Errorf("후보 선택 이미지 = %+v", image)
+	}
+	if image.Detections == "" || image.ImageHash != "abc" || image.ImagePath != "3/a.jpg" {
+		t.Errorf("이미지 정보 = %+v", image)
+	}
+
+	// 대표 결과 확인: 신뢰도에 확인 가산점
+	image = confirmedRecognitionImage(log, candidates, true, nil, nil)
+	if image == nil || image.DrinkName != "아메리카노" || *image.BeverageID != beverageID {
+		t.Fatalf("대표 확인 이미지 = %+v", image)
+	}
+	if want := 0.55 + ConfirmationBonus; image.Confidence < want-1e-9 || image.Confidence > want+1e-9 {
+		t.Errorf("Confidence = %v, want %v", image.Confidence, want)
+	}
+
+	// 다른 음료로 정정: 정정한 음료 값
+	corrected := models.Beverage{Name: "카페라떼", CaffeineAmount: 74.6}
+	corrected.ID = 9
+	image = confirmedRecognitionImage(log, candidates, false, &corrected, nil)
+	if image == nil || *image.BeverageID != 9 || image.DrinkName != "카페라떼" || image.CaffeineAmount != 75 {
+		t.Errorf("정정 이미지 = %+v", image)
+	}
+
+	// "틀림"만 표시하면 저장하지 않음
+	if image := confirmedRecognitionImage(log, candidates, false, nil, nil); image != nil {
+		t.Errorf("틀림만 표시했는데 저장함: %+v", image)
+	}
+	// 후보가 없으면 저장하지 않음
+	if image := confirmedRecognitionImage(log, nil, true, nil, nil); image != nil {
+		t.Errorf("후보 없이 저장함: %+v", image)
+	}
+}
+
+func TestCheckCorrectionTarget(t *testing.T) {
+	const userID = 7
+	tests := []struct {
+		name       string
+		beverage   models.Beverage
+		wantShared bool
+		wantErr    bool
+	}{
+		{name: "승인된 음료", beverage: models.Beverage{Status: models.BeverageStatusActive, CreatedByUser: 3}, wantShared: true},
+		{name: "본인의 승인 대기 음료", beverage: models.Beverage{Status: models.BeverageStatusPending, CreatedByUser: userID}},
+		{name: "다른 사용자의 승인 대기 음료", beverage: models.Beverage{Status: models.BeverageStatusPending, CreatedByUser: 3}, wantErr: true},
+		{name: "본인의 거절된 음료", beverage: models.Beverage{Status: models.BeverageStatusRejected, CreatedByUser: userID}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shared, err := checkCorrectionTarget(&tt.beverage, userID)
+			if (err != nil) != tt.wantErr || shared != tt.wantShared {
+				t.Errorf("checkCorrectionTarget = (%v, %v), want (%v, err=%v)", shared, err, tt.wantShared, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUndoFeedbackOnImage(t *testing.T) {
+	yes, no := true, false
+	recognizedID, correctedID := uint(1), uint(2)
+	corrected := &models.Beverage{Name: "카페라떼", CaffeineAmount: 75}
+	corrected.ID = correctedID
+	recognized := models.BeverageImage{BeverageID: &recognizedID, DrinkName: "아메리카노", CaffeineAmount: 150, Confidence: 0.7}
+
+	tests := []struct {
+		name      string
+		previous  models.RecognitionLog
+		isCorrect bool
+		corrected *models.Beverage
+		wantID    uint
+		wantConf  float64
+	}{
+		{
+			// "맞음" 다음 정정 → 보너스는 사라지고 정정한 음료로 연결
+			name:      "맞음 후 정정",
+			previous:  models.RecognitionLog{IsCorrect: &yes},
+			corrected: corrected,
+			wantID:    correctedID,
+			wantConf:  UserCorrectedConfidence,
+		},
+		{
+			name:     "맞음 후 틀림",
+			previous: models.RecognitionLog{IsCorrect: &yes},
+			wantID:   recognizedID,
+			wantConf: 0.7 * ContradictionPenalty,
+		},
+		{
+			name:      "틀림 후 맞음",
+			previous:  models.RecognitionLog{IsCorrect: &no},
+			isCorrect: true,
+			wantID:    recognizedID,
+			wantConf:  0.7 + ConfirmationBonus,
+		},
+		{
+			// 정정을 취소하고 "맞음" → 처음 인식한 음료와 신뢰도로 돌아간 뒤 보너스
+			name:      "정정 후 맞음",
+			previous:  models.RecognitionLog{IsCorrect: &no, CorrectedID: &correctedID, RecognizedID: &recognizedID, Confidence: 0.7},
+			isCorrect: true,
+			wantID:    recognizedID,
+			wantConf:  0.7 + ConfirmationBonus,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 이전 피드백이 반영된 상태의 이미지
+			image := recognized
+			var previousCorrection *models.Beverage
+			if tt.previous.CorrectedID != nil {
+				previousCorrection = corrected
+			}
+			applyFeedbackToImage(&image, *tt.previous.IsCorrect, previousCorrection)
+
+			undoFeedbackOnImage(&image, &tt.previous, recognized.DrinkName, recognized.CaffeineAmount)
+			applyFeedbackToImage(&image, tt.isCorrect, tt.corrected)
+
+			if image.BeverageID == nil || *image.BeverageID != tt.wantID {
+				t.Errorf("BeverageID = %v, want %d", image.BeverageID, tt.wantID)
+			}
+			if math.Abs(image.Confidence-tt.wantConf) > 1e-9 {
+				t.Errorf("Confidence = %v, want %v", image.Confidence, tt.wantConf)
+			}
+			if tt.wantID == recognizedID && (image.DrinkName != "아메리카노" || image.CaffeineAmount != 150) {
+				t.Errorf("처음 인식한 값으로 돌아가지 않음: %s %d", image.DrinkName, image.CaffeineAmount)
+			}
+		})
+	}
+}
+
+func TestSameFeedback(t *testing.T) {
+	yes, no := true, false
+	id := uint(3)
+	beverage := &models.Beverage{}
+	beverage.ID = id
+	other := &models.Beverage{}
+	other.ID = 4
+
+	tests := []struct {
+		name      string
+		previous  models.RecognitionLog
+		isCorrect bool
+		corrected *models.Beverage
+		want      bool
+	}{
+		{name: "같은 맞음", previous: models.RecognitionLog{IsCorrect: &yes}, isCorrect: true, want: true},
+		{name: "맞음에서 틀림", previous: models.RecognitionLog{IsCorrect: &yes}, want: false},
+		{name: "같은 정정", previous: models.RecognitionLog{IsCorrect: &no, CorrectedID: &id}, corrected: beverage, want: true},
+		{name: "다른 음료로 정정", previous: models.RecognitionLog{IsCorrect: &no, CorrectedID: &id}, corrected: other, want: false},
+		{name: "틀림에서 정정", previous: models.RecognitionLog{IsCorrect: &no}, corrected: beverage, want: false},
+		{name: "피드백 없음", previous: models.RecognitionLog{}, isCorrect: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameFeedback(&tt.previous, tt.isCorrect, tt.corrected); got != tt.want {
+				t.Errorf("sameFeedback = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
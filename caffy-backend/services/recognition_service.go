@@ -4,6 +4,7 @@ import (
 	"caffy-backend/config"
 	"caffy-backend/models"
 	"encoding/json"
+	"math"
 	"strings"
 	"time"
 )
@@ -18,6 +19,9 @@ type RecognitionResult struct {
 	DetectedLabels []string         `json:"detected_labels"` // 감지된 라벨
 	DetectedLogos  []string         `json:"detected_logos"`  // 감지된 로고
 	IsNewBeverage  bool             `json:"is_new_beverage"` // 새로 등록된 음료인지
+
+	LabelCaffeine   *LabelCaffeineInfo `json:"label_caffeine,omitempty"` // 영양성분표에서 읽은 카페인 정보
+	LabelMismatched bool               `json:"label_mismatched"`         // 라벨 값과 DB 값이 크게 다른지
 }
 
 // RecognizeBeverage : 이미지로 음료 인식
@@ -25,9 +29,15 @@ func RecognizeBeverage(imageData []byte, userID uint) (*RecognitionResult, error
 	startTime := time.Now()
 	result := &RecognitionResult{}
 
-	// 1. 이미지 해시 계산
+	// 1. 이미지 해시 계산 (원본 기준)
 	imageHash := CalculateImageHash(imageData)
 
+	// 회전/축소, 메타데이터 제거 → Vision API와 저장에는 전처리 결과 사용
+	processed, err := PreprocessImage(imageData)
+	if err != nil {
+		return nil, err
+	}
+
 	// 2. DB에서 해시로 먼저 검색 (빠른 매칭)
 	var existingImage models.BeverageImage
 	if err := config.DB.Where("image_hash = ?", imageHash).
@@ -46,7 +56,7 @@ func RecognizeBeverage(imageData []byte, userID uint) (*RecognitionResult, error
 	}
 
 	// 3. DB에서 못 찾았으면 Vision API 호출
-	visionResult, err := AnalyzeImage(imageData)
+	visionResult, err := AnalyzeImage(processed.Data)
 	if err != nil {
 		return nil, err
 	}
@@ -61,20 +71,35 @@ func RecognizeBeverage(imageData []byte, userID uint) (*RecognitionResult, error
 	}
 
 	// 4. OCR 텍스트와 로고로 DB 검색
-	beverage := findBeverageByVisionResult(visionResult)
+	beverage := findBeverageByVisionResult(visionResult, userID)
+
+	// 라벨에서 카페인을 읽을 수 있으면 DB 값 검증에 사용
+	labelInfo := ParseCaffeineLabel(visionResult.FullText)
+	if labelInfo.Found {
+		result.LabelCaffeine = labelInfo
+	}
 
 	if beverage != nil {
 		result.Found = true
 		result.Beverage = beverage
 		result.Confidence = 0.8 // Vision API 결과는 약간 낮은 신뢰도
 
+		// 라벨 값과 DB 값이 크게 다르면 다른 음료로 매칭됐을 가능성이 있음
+		if labelInfo.TotalCaffeine > 0 {
+			diff := math.Abs(labelInfo.TotalCaffeine - beverage.CaffeineAmount)
+			if diff > math.Max(labelToleranceMG, labelInfo.TotalCaffeine*labelToleranceRatio) {
+				result.LabelMismatched = true
+				result.Confidence = 0.5
+			}
+		}
+
 		// 이 이미지를 해당 음료에 연결하여 저장 (학습)
-		saveNewBeverageImage(beverage.ID, imageHash, imageData, visionResult, userID, beverage.Name)
+		saveNewBeverageImage(beverage, imageHash, processed, visionResult, userID)
 
-		logRecognition(userID, "", &beverage.ID, 0.8, true, int(time.Since(startTime).Milliseconds()))
+		logRecognition(userID, "", &beverage.ID, result.Confidence, true, int(time.Since(startTime).Milliseconds()))
 	} else {
 		// 5. DB에 없으면 새 음료 등록
-		newBeverage := createNewBeverageFromVision(visionResult)
+		newBeverage := createNewBeverageFromVision(visionResult, userID)
 		if newBeverage != nil {
 			result.Found = true
 			result.Beverage = newBeverage
@@ -82,7 +107,7 @@ func RecognizeBeverage(imageData []byte, userID uint) (*RecognitionResult, error
 			result.IsNewBeverage = true
 
 			// 이미지도 저장
-			saveNewBeverageImage(newBeverage.ID, imageHash, imageData, visionResult, userID, newBeverage.Name)
+			saveNewBeverageImage(newBeverage, imageHash, processed, visionResult, userID)
 
 			logRecognition(userID, "", &newBeverage.ID, 0.5, true, int(time.Since(startTime).Milliseconds()))
 		} else {
@@ -95,57 +120,37 @@ func RecognizeBeverage(imageData []byte, userID uint) (*RecognitionResult, error
 }
 
 // findBeverageByVisionResult : Vision API 결과로 DB에서 음료 검색
-func findBeverageByVisionResult(vision *VisionResult) *models.Beverage {
+func findBeverageByVisionResult(vision *VisionResult, userID uint) *models.Beverage {
 	var beverage models.Beverage
 
-	// 1. 로고로 브랜드 검색
-	for _, logo := range vision.Logos {
-		if err := config.DB.Where("LOWER(brand) LIKE ?", "%"+strings.ToLower(logo)+"%").
-			First(&beverage).Error; err == nil {
+	// 1. OCR 텍스트와 로고를 음료 이름/별칭/브랜드와 매칭해 가장 점수가 높은 음료
+	match := MatchBeverageVision(vision.FullText, vision.Logos)
+	if best := match.Best(); best != nil {
+		if err := config.DB.First(&beverage, best.BeverageID).Error; err == nil {
+			println("🏷️ 음료 매칭:", best.Name, "점수:", best.Score, strings.Join(best.MatchedBy, ","))
 			return &beverage
 		}
 	}
 
-	// 2. OCR 텍스트에서 음료 이름 검색
-	ocrLower := strings.ToLower(vision.FullText)
-
-	// 알려진 음료 패턴 검색
-	knownPatterns := []string{
-		"아메리카노", "americano",
-		"라떼", "latte",
-		"카푸치노", "cappuccino",
-		"에스프레소", "espresso",
-		"콜드브루", "cold brew",
-		"모카", "mocha",
-		"마끼아또", "macchiato",
-		"레드불", "red bull",
-		"몬스터", "monster",
-		"핫식스", "hot6",
+	// 2. 이미지의 OCR 텍스트로 기존 이미지 검색 (텍스트가 없으면 아무 이미지나 걸리므로 건너뜀)
+	if vision.FullText == "" {
+		return nil
 	}
-
-	for _, pattern := range knownPatterns {
-		if strings.Contains(ocrLower, pattern) {
-			if err := config.DB.Where("LOWER(name) LIKE ?", "%"+pattern+"%").
-				First(&beverage).Error; err == nil {
-				return &beverage
-			}
-		}
-	}
-
-	// 3. 이미지의 OCR 텍스트로 기존 이미지 검색
 	var existingImage models.BeverageImage
 	if err := config.DB.Where("ocr_text LIKE ?", "%"+vision.FullText[:min(50, len(vision.FullText))]+"%").
-		First(&existingImage).Error; err == nil {
-		config.DB.First(&beverage, existingImage.BeverageID)
-		return &beverage
+		First(&existingImage).Error; err == nil && existingImage.BeverageID != nil {
+		if err := config.DB.Scopes(VisibleBeverages(userID)).First(&beverage, *existingImage.BeverageID).Error; err == nil {
+			return &beverage
+		}
 	}
 
 	return nil
 }
 
 // createNewBeverageFromVision : Vision 결과로 새 음료 생성
-func createNewBeverageFromVision(vision *VisionResult) *models.Beverage {
-	caffeineAmount, productName := ExtractCaffeineInfo(vision.FullText)
+func createNewBeverageFromVision(vision *VisionResult, userID uint) *models.Beverage {
+	labelInfo := ParseCaffeineLabel(vision.FullText)
+	caffeineAmount, productName := labelInfo.TotalCaffeine, labelInfo.ProductName
 
 	if productName == "" {
 		// 라벨에서 음료 관련 키워드 찾기
@@ -169,8 +174,11 @@ func createNewBeverageFromVision(vision *VisionResult) *models.Beverage {
 		brand = vision.Logos[0]
 	}
 
-	// 카테고리 추정
-	category := guessCategoryFromLabels(vision.Labels)
+	// 카테고리 추정 (분류 별칭이 있으면 우선)
+	category := MatchBeverageText(vision.FullText, productName).Category
+	if category == "" {
+		category = guessCategoryFromLabels(vision.Labels)
+	}
 
 	// 기본 카페인 함량 설정 (추출 못했을 경우)
 	if caffeineAmount == 0 {
@@ -181,17 +189,25 @@ func createNewBeverageFromVision(vision *VisionResult) *models.Beverage {
 		Name:           productName,
 		Brand:          brand,
 		CaffeineAmount: caffeineAmount,
+		Volume:         labelInfo.VolumeML,
 		Category:       category,
 		IsVerified:     false, // 사용자 제보이므로 미검증
+		Status:         models.BeverageStatusPending,
+		CreatedByUser:  userID,
 	}
 
-	config.DB.Create(&newBeverage)
+	// 관리자가 승인하기 전까지는 찍은 사용자에게만 보임
+	if err := config.DB.Create(&newBeverage).Error; err != nil {
+		return nil
+	}
+	submitRecognizedBeverage(&newBeverage, ChangeSourceRecognition)
 	return &newBeverage
 }
 
 // saveNewBeverageImage : 새 이미지를 음료에 연결하여 저장
-func saveNewBeverageImage(beverageID uint, imageHash string, imageData []byte, vision *VisionResult, userID uint, drinkName string) {
-	imagePath, err := SaveImage(imageData, userID, drinkName)
+// 같은 해시의 이미지가 이미 있거나 저장에 실패하면 방금 쓴 파일은 지움
+func saveNewBeverageImage(beverage *models.Beverage, imageHash string, processed *PreprocessedImage, vision *VisionResult, userID uint) {
+	imagePath, err := SaveProcessedImage(processed, userID, beverage.Name)
 	if err != nil {
 		return
 	}
@@ -200,24 +216,41 @@ func saveNewBeverageImage(beverageID uint, imageHash string, imageData []byte, v
 	logos := strings.Join(vision.Logos, ",")
 
 	image := models.BeverageImage{
-		BeverageID:     &beverageID,
+		BeverageID:     &beverage.ID,
 		ImageHash:      imageHash,
 		ImagePath:      imagePath,
+		DrinkName:      beverage.Name,
+		CaffeineAmount: int(beverage.CaffeineAmount + 0.5),
 		OCRText:        vision.FullText,
 		Labels:         string(labelsJSON),
 		Logos:          logos,
 		Confidence:     0.8,
+		Source:         "vision",
+		UsageCount:     1,
 		UploadedByUser: userID,
 	}
 
-	config.DB.Create(&image)
+	_, isNew, err := storeBeverageImage(&image)
+	if err != nil {
+		println("⚠️ Vision 이미지 캐시 저장 실패:", err.Error())
+	}
+	if !isNew {
+		DeleteImage(imagePath)
+	}
 }
 
 // logRecognition : 인식 로그 저장
 func logRecognition(userID uint, imagePath string, recognizedID *uint, confidence float64, visionUsed bool, processingTime int) {
+	source, provider := "database", ""
+	if visionUsed {
+		source, provider = "vision", "vision"
+	}
+
 	log := models.RecognitionLog{
 		UserID:         userID,
 		ImagePath:      imagePath,
+		Source:         source,
+		Provider:       provider,
 		RecognizedID:   recognizedID,
 		Confidence:     confidence,
 		VisionAPIUsed:  visionUsed,
@@ -4,9 +4,14 @@ import (
 	"caffy-backend/config"
 	"caffy-backend/models"
 	"crypto/sha256"
-	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // SmartRecognitionResult : 스마트 인식 결과
@@ -15,24 +20,47 @@ type SmartRecognitionResult struct {
 	DrinkName      string  `json:"drink_name"`
 	CaffeineAmount int     `json:"caffeine_amount"`
 	Confidence     float64 `json:"confidence"`
-	Source         string  `json:"source"` // "database", "llm", "manual"
+	Source         string  `json:"source"` // "database", "barcode", "llm", "manual"
 	Description    string  `json:"description"`
 	Brand          string  `json:"brand"`
 	Category       string  `json:"category"`
 	ImageID        uint    `json:"image_id,omitempty"` // 저장된 이미지 ID
 	IsNew          bool    `json:"is_new"`             // 새로 학습된 데이터인지
+
+	Drinks []DetectedDrink `json:"drinks"` // 사진 속 모든 음료 (첫 번째가 대표 음료)
+
+	RecognitionLogID uint `json:"recognition_log_id"` // 피드백 제출 시 참조할 인식 로그 ID
+
+	Barcode           string `json:"barcode,omitempty"`             // 사진에서 읽은 바코드
+	PendingBeverageID *uint  `json:"pending_beverage_id,omitempty"` // 모르는 바코드로 생성된 확인 대기 음료 ID
+
+	Policy            string                 `json:"policy,omitempty"`     // 인식 정책 판정 (LLM을 호출한 경우)
+	NeedsConfirmation bool                   `json:"needs_confirmation"`   // 사용자가 확인하거나 후보 중 골라야 함 (확인해야 캐시에 저장)
+	Candidates        []RecognitionCandidate `json:"candidates,omitempty"` // 프로바이더 답이 다를 때 고를 후보 (피드백의 candidate로 선택)
 }
 
 // SmartRecognizeDrink : DB 우선 검색 → LLM 폴백 → 결과 저장
 func SmartRecognizeDrink(imageBase64 string, userID uint) (*SmartRecognitionResult, error) {
+	startTime := time.Now()
 	result := &SmartRecognitionResult{}
 
-	// 1. 이미지 해시 계산
+	// 1. 이미지 해시 계산 (같은 사진 재업로드를 찾도록 원본 기준)
 	imageHash := calculateHash(imageBase64)
 
+	// 실제 형식 확인, 회전/축소, 메타데이터 제거 → 인식과 저장 모두 전처리 결과 사용
+	rawImage, err := DecodeImageBase64(imageBase64)
+	if err != nil {
+		return nil, err
+	}
+	processed, err := PreprocessImage(rawImage)
+	if err != nil {
+		return nil, err
+	}
+
 	// 2. DB에서 해시로 검색 (정확히 일치하는 이미지)
+	// 피드백으로 신뢰도가 떨어진 이미지는 캐시로 쓰지 않고 다시 인식
 	var existingImage models.BeverageImage
-	if err := config.DB.Where("image_hash = ?", imageHash).First(&existingImage).Error; err == nil {
+	if err := config.DB.Where("image_hash = ? AND confidence >= ?", imageHash, MinCacheConfidence).First(&existingImage).Error; err == nil {
 		// DB에서 찾음! (비용 0)
 		existingImage.UsageCount++
 		config.DB.Save(&existingImage)
@@ -53,75 +81,401 @@ func SmartRecognizeDrink(imageBase64 string, userID uint) (*SmartRecognitionResu
 				result.Category = beverage.Category
 			}
 		}
+		result.Drinks = cachedDetections(&existingImage, result)
 
-		// 사용자 요청: 이미지를 로컬에 저장 (히스토리용)
-		decodedImage, _ := base64.StdEncoding.DecodeString(imageBase64)
-		SaveImage(decodedImage, userID, result.DrinkName)
+		// 사용자 요청: 이미지를 저장 (히스토리용, 섭취 기록으로 이어지지 않으면 보관 기간 후 정리)
+		imagePath, _ := SaveProcessedImage(processed, userID, result.DrinkName)
 
-		logSmartRecognition(userID, imageHash, "database", result.CaffeineAmount, result.Confidence)
+		result.RecognitionLogID = logSmartRecognition(userID, imageHash, imagePath, "database", &existingImage, result.Confidence, nil, startTime)
 		return result, nil
 	}
 
-	// 3. DB에서 못 찾음 → LLM 호출 (비용 발생)
-	llmResult, err := RecognizeDrinkWithLLM(imageBase64)
+	// 3. 바코드가 보이면 바코드로 먼저 조회 (등록된 음료면 LLM 호출 없이 확정)
+	barcode, barcodeFormat, barcodeErr := decodeBarcodeImage(processed.decoded)
+	var pendingBeverage *models.Beverage
+	if barcodeErr == nil {
+		println("🏷️ 바코드 인식:", barcode)
+		result.Barcode = barcode
+		if beverage, err := FindBeverageByBarcode(barcode); err == nil {
+			if beverage.Status == models.BeverageStatusActive {
+				return recognizeByBarcode(result, beverage, processed, imageHash, userID, startTime), nil
+			}
+			pendingBeverage = beverage
+		}
+	}
+
+	// 4. DB에서 못 찾음 → LLM 호출 (비용 발생)
+	// 같은 이미지가 동시에 들어오면 (더블탭, 재시도) 한 번만 호출하고 결과를 공유
+	llmResult, err := recognizeCoalesced(imageHash, processed.Base64(), processed.MIME)
 	if err != nil {
-		// LLM 실패 시 OpenAI로 폴백 시도
-		llmResult, err = RecognizeDrinkWithOpenAI(imageBase64)
-		if err != nil {
+		if barcodeErr != nil {
 			return nil, err
 		}
+		// 바코드는 읽었으므로 이름 없는 확인 대기 음료라도 남겨 사용자가 채우도록 함
+		if pendingBeverage == nil {
+			pendingBeverage, _ = CreatePendingBeverage(barcode, barcodeFormat, nil, userID)
+		}
+		if pendingBeverage != nil {
+			result.PendingBeverageID = &pendingBeverage.ID
+		}
+		result.Source = "barcode"
+		result.Description = "바코드는 인식했지만 등록되지 않은 상품입니다. 음료 정보를 입력해 주세요."
+		return result, nil
 	}
 
-	// 4. LLM 결과를 DB에 저장 (학습)
+	// 모르는 바코드 → 대표 음료를 확인 대기 음료로 등록
+	if barcodeErr == nil {
+		var guess *DetectedDrink
+		if len(llmResult.Drinks) > 0 {
+			guess = &llmResult.Drinks[0]
+		}
+		if pendingBeverage == nil {
+			pendingBeverage, _ = CreatePendingBeverage(barcode, barcodeFormat, guess, userID)
+		}
+		if pendingBeverage != nil {
+			result.PendingBeverageID = &pendingBeverage.ID
+			if guess != nil {
+				guess.BeverageID = &pendingBeverage.ID
+			}
+		}
+	}
+
+	// 5. 음료마다 Beverage 테이블에서 찾거나 생성 (브랜드가 있는 경우)
+	for i := range llmResult.Drinks {
+		if llmResult.Drinks[i].Brand == "" || llmResult.Drinks[i].BeverageID != nil {
+			continue
+		}
+		if beverage := findOrCreateBeverage(&llmResult.Drinks[i], userID); beverage != nil {
+			llmResult.Drinks[i].BeverageID = &beverage.ID
+		}
+	}
+	linkRecognitionCandidates(llmResult, userID)
+
+	result.DrinkName = llmResult.DrinkName
+	result.CaffeineAmount = llmResult.CaffeineAmount
+	result.Confidence = llmResult.Confidence
+	result.Source = "llm"
+	result.Description = llmResult.Description
+	result.Brand = llmResult.Brand
+	result.Category = llmResult.Category
+	result.Drinks = llmResult.Drinks
+	result.Found = llmResult.CaffeineAmount > 0 || len(llmResult.Drinks) > 1
+	result.Policy = llmResult.Policy
+	if len(llmResult.Candidates) > 1 && llmResult.Policy == PolicyDisagreement {
+		result.Candidates = llmResult.Candidates
+	}
+
+	// 사용자 요청: 이미지를 저장
+	imagePath, _ := SaveProcessedImage(processed, userID, llmResult.DrinkName)
+
+	// 6. 합의한 결과만 바로 캐시에 저장 (학습)
+	// 나머지(프로바이더 하나의 답 포함)는 후보를 인식 로그에 남겨 두고 사용자가 확인하면 캐시에 저장 (ApplyRecognitionFeedback)
+	if !llmResult.Cacheable() {
+		result.NeedsConfirmation = result.Found || llmResult.Policy == PolicyDisagreement
+		result.RecognitionLogID = logSmartRecognition(userID, imageHash, imagePath, "llm", nil, result.Confidence, llmResult, startTime)
+		return result, nil
+	}
+
+	detectionsJSON, _ := json.Marshal(llmResult.Drinks)
 	newImage := models.BeverageImage{
 		ImageHash:      imageHash,
 		DrinkName:      llmResult.DrinkName,
 		CaffeineAmount: llmResult.CaffeineAmount,
 		Confidence:     llmResult.Confidence,
+		Detections:     string(detectionsJSON),
 		Source:         "llm",
 		UsageCount:     1,
 		UploadedByUser: userID,
 	}
-
-	// 사용자 요청: 이미지를 로컬에 저장
-	decodedImage, _ := base64.StdEncoding.DecodeString(imageBase64)
-	imagePath, _ := SaveImage(decodedImage, userID, llmResult.DrinkName)
+	if len(llmResult.Drinks) > 0 {
+		newImage.BeverageID = llmResult.Drinks[0].BeverageID
+	}
 	newImage.ImagePath = imagePath
 
-	// 브랜드가 있으면 Beverage 테이블에서 찾거나 생성
-	if llmResult.Brand != "" {
-		var beverage models.Beverage
-		if err := config.DB.Where("name = ? OR brand = ?", llmResult.DrinkName, llmResult.Brand).First(&beverage).Error; err == nil {
-			newImage.BeverageID = &beverage.ID
-		} else {
-			// 새 음료 생성
-			newBeverage := models.Beverage{
-				Name:           llmResult.DrinkName,
-				Brand:          llmResult.Brand,
-				CaffeineAmount: float64(llmResult.CaffeineAmount),
-				Category:       llmResult.Category,
-				IsVerified:     false,
-			}
-			config.DB.Create(&newBeverage)
-			newImage.BeverageID = &newBeverage.ID
+	// 동시에 들어온 중복 요청이 이미 저장했을 수 있으므로 충돌 시 기존 행을 사용
+	storedImage, isNew, err := storeBeverageImage(&newImage)
+	if err != nil {
+		println("⚠️ 인식 이미지 캐시 저장 실패:", err.Error())
+	} else {
+		result.ImageID = storedImage.ID
+		result.IsNew = isNew
+	}
+
+	result.RecognitionLogID = logSmartRecognition(userID, imageHash, imagePath, "llm", storedImage, result.Confidence, llmResult, startTime)
+	return result, nil
+}
+
+// recognizeByBarcode : 바코드로 찾은 음료로 결과 구성
+// 같은 사진이 다시 들어오면 해시 캐시로 바로 찾도록 이미지도 저장
+func recognizeByBarcode(result *SmartRecognitionResult, beverage *models.Beverage, processed *PreprocessedImage, imageHash string, userID uint, startTime time.Time) *SmartRecognitionResult {
+	caffeine := int(beverage.CaffeineAmount + 0.5)
+	drink := DetectedDrink{
+		DrinkName:      beverage.Name,
+		CaffeineAmount: caffeine,
+		Confidence:     BarcodeRecognitionConfidence,
+		Brand:          beverage.Brand,
+		Category:       beverage.Category,
+		BeverageID:     &beverage.ID,
+	}
+	detectionsJSON, _ := json.Marshal([]DetectedDrink{drink})
+
+	imagePath, _ := SaveProcessedImage(processed, userID, beverage.Name)
+	newImage := models.BeverageImage{
+		BeverageID:     &beverage.ID,
+		ImageHash:      imageHash,
+		ImagePath:      imagePath,
+		DrinkName:      beverage.Name,
+		CaffeineAmount: caffeine,
+		Confidence:     BarcodeRecognitionConfidence,
+		Detections:     string(detectionsJSON),
+		Source:         "barcode",
+		UsageCount:     1,
+		UploadedByUser: userID,
+	}
+	storedImage, isNew, err := storeBeverageImage(&newImage)
+	if err != nil {
+		println("⚠️ 바코드 이미지 캐시 저장 실패:", err.Error())
+	}
+
+	result.Found = true
+	result.DrinkName = beverage.Name
+	result.CaffeineAmount = caffeine
+	result.Confidence = BarcodeRecognitionConfidence
+	result.Source = "barcode"
+	result.Brand = beverage.Brand
+	result.Category = beverage.Category
+	if storedImage != nil {
+		result.ImageID = storedImage.ID
+		result.IsNew = isNew
+	}
+	result.Drinks = []DetectedDrink{drink}
+
+	result.RecognitionLogID = logSmartRecognition(userID, imageHash, imagePath, "barcode", storedImage, result.Confidence, nil, startTime)
+	return result
+}
+
+// RecognitionProvider : 이미지 인식 프로바이더 (체인 앞에서부터 시도)
+type RecognitionProvider struct {
+	Name      string
+	Recognize func(imageBase64 string, mimeType string) (*LLMRecognitionResult, error)
+}
+
+// recognitionProviders : 이미지 인식 체인 (Gemini 실패 시 OpenAI 폴백)
+var recognitionProviders = []RecognitionProvider{
+	{Name: "gemini", Recognize: RecognizeDrinkWithLLM},
+	{Name: "openai", Recognize: RecognizeDrinkWithOpenAI},
+}
+
+// ProviderAttempt : 체인에서 프로바이더 하나를 호출한 결과
+type ProviderAttempt struct {
+	Provider  string   `json:"provider"`
+	LatencyMS int64    `json:"latency_ms"`
+	Usage     LLMUsage `json:"usage"`
+	CostUSD   float64  `json:"cost_usd"`
+	Error     string   `json:"error,omitempty"`
+	Skipped   bool     `json:"skipped,omitempty"` // API 키가 없어 호출하지 않음
+}
+
+// recognizeWithChain : 프로바이더를 차례로 호출해 처음 성공한 결과와 시도 내역 반환
+// next는 답을 낸 프로바이더 다음 위치 (재확인할 때 이어서 물어봄)
+// 모두 실패하면 마지막 오류 (뒤 프로바이더가 설정되지 않았으면 앞의 오류를 그대로 알림)
+func recognizeWithChain(providers []RecognitionProvider, imageBase64 string, mimeType string) (*LLMRecognitionResult, []ProviderAttempt, int, error) {
+	var attempts []ProviderAttempt
+	var firstErr, lastErr error
+	for i, provider := range providers {
+		started := time.Now()
+		result, err := provider.Recognize(imageBase64, mimeType)
+		attempt := ProviderAttempt{Provider: provider.Name, LatencyMS: time.Since(started).Milliseconds()}
+		if err == nil {
+			result.Provider = provider.Name
+			attempt.Usage = result.Usage
+			attempt.CostUSD = result.Usage.CostUSD(provider.Name)
+			return result, append(attempts, attempt), i + 1, nil
+		}
+
+		var outputErr *LLMOutputError
+		if errors.As(err, &outputErr) {
+			attempt.Usage = outputErr.Usage
+			attempt.CostUSD = outputErr.Usage.CostUSD(provider.Name)
+		}
+		attempt.Error = err.Error()
+		attempt.Skipped = errors.Is(err, ErrLLMNotConfigured)
+		attempts = append(attempts, attempt)
+
+		if firstErr == nil {
+			firstErr = err
 		}
+		if !attempt.Skipped {
+			lastErr = err
+		}
+	}
+	if lastErr == nil {
+		lastErr = firstErr
 	}
+	if lastErr == nil {
+		lastErr = ErrLLMNotConfigured
+	}
+	return nil, attempts, len(providers), lastErr
+}
 
-	config.DB.Create(&newImage)
+// llmRecognize : 인식 정책에 따라 프로바이더 호출 (재확인/합의 포함)
+// 테스트에서 실제 API 대신 교체할 수 있도록 변수로 둠
+var llmRecognize = func(imageBase64 string, mimeType string) (*LLMRecognitionResult, error) {
+	result, _, err := RecognizeWithPolicy(imageBase64, mimeType)
+	return result, err
+}
 
-	result.Found = llmResult.CaffeineAmount > 0
-	result.DrinkName = llmResult.DrinkName
-	result.CaffeineAmount = llmResult.CaffeineAmount
-	result.Confidence = llmResult.Confidence
-	result.Source = "llm"
-	result.Description = llmResult.Description
-	result.Brand = llmResult.Brand
-	result.Category = llmResult.Category
-	result.ImageID = newImage.ID
-	result.IsNew = true
+// recognitionFlights : 이미지 해시별 진행 중인 LLM 호출
+var recognitionFlights inflightGroup
 
-	logSmartRecognition(userID, imageHash, "llm", result.CaffeineAmount, result.Confidence)
-	return result, nil
+// recognizeCoalesced : 같은 해시의 동시 요청은 프로바이더 호출 하나를 공유
+func recognizeCoalesced(imageHash string, imageBase64 string, mimeType string) (*LLMRecognitionResult, error) {
+	val, err, shared := recognitionFlights.Do(imageHash, func() (interface{}, error) {
+		return llmRecognize(imageBase64, mimeType)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		println("🔁 동일 이미지 동시 요청 - LLM 결과 공유:", imageHash[:min(12, len(imageHash))])
+	}
+
+	result, ok := val.(*LLMRecognitionResult)
+	if !ok || result == nil {
+		return nil, errInflightPanicked
+	}
+
+	// 공유된 결과를 호출자끼리 수정하지 않도록 복사본 반환 (호출자가 음료 ID를 채움)
+	return result.clone(), nil
+}
+
+// findOrCreateBeverage : 인식된 음료에 해당하는 음료를 찾거나 새로 생성
+// 이름에 unique 제약이 있으므로 동시에 생성해도 한 행만 남음
+// 새로 만든 음료는 관리자가 승인하기 전까지 인식한 사용자에게만 보임
+func findOrCreateBeverage(llmResult *DetectedDrink, userID uint) *models.Beverage {
+	var beverage models.Beverage
+	if err := config.DB.Scopes(VisibleBeverages(userID)).
+		Where("name = ?", llmResult.DrinkName).
+		First(&beverage).Error; err == nil {
+		return &beverage
+	}
+	// 이름/별칭 매칭 점수로 찾음 (브랜드가 다른 음료는 제외, 브랜드만 같은 음료는 연결하지 않음)
+	match := MatchBeverageText(llmResult.Brand, llmResult.DrinkName)
+	if best := recognizedBeverageMatch(match, llmResult.Brand, aliasSnapshot().terms); best != nil {
+		if err := config.DB.Scopes(VisibleBeverages(userID)).First(&beverage, best.BeverageID).Error; err == nil {
+			return &beverage
+		}
+	}
+	// 다른 음료로 병합된 이름이면 병합 대상 사용 (다시 만들지 않음)
+	if merged := mergedBeverageByName(llmResult.DrinkName, userID); merged != nil {
+		return merged
+	}
+
+	// 새 음료 생성
+	beverage = models.Beverage{
+		Name:           llmResult.DrinkName,
+		Brand:          llmResult.Brand,
+		CaffeineAmount: float64(llmResult.CaffeineAmount),
+		Category:       llmResult.Category,
+		IsVerified:     false,
+		Status:         models.BeverageStatusPending,
+		CreatedByUser:  userID,
+	}
+	created := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&beverage)
+	if created.Error != nil {
+		return nil
+	}
+	if created.RowsAffected == 0 {
+		// 다른 요청이 먼저 생성함 (다른 사용자의 승인 대기 음료면 연결하지 않음)
+		var existing models.Beverage
+		if err := config.DB.Scopes(VisibleBeverages(userID)).Where("name = ?", llmResult.DrinkName).First(&existing).Error; err != nil {
+			return nil
+		}
+		return &existing
+	}
+	submitRecognizedBeverage(&beverage, ChangeSourceRecognition)
+	return &beverage
+}
+
+// recognizedBeverageMatch : 인식된 음료와 같은 음료로 볼 수 있는 가장 점수가 높은 후보 (없으면 nil)
+// 매칭 점수는 이름/별칭이 맞아야 생기므로 같은 브랜드의 다른 음료는 후보가 되지 않음
+func recognizedBeverageMatch(match *BeverageMatchResult, brand string, terms map[string]string) *BeverageMatch {
+	brandKey := compactQuery(normalizeWithTerms(brand, terms))
+	for i := range match.Candidates {
+		candidate := &match.Candidates[i]
+		if candidate.Score < minBeverageMatchScore {
+			break
+		}
+		candidateBrand := compactQuery(normalizeWithTerms(candidate.Brand, terms))
+		if brandKey != "" && candidateBrand != "" &&
+			!strings.Contains(brandKey, candidateBrand) && !strings.Contains(candidateBrand, brandKey) {
+			continue
+		}
+		return candidate
+	}
+	return nil
+}
+
+// storeBeverageImage : 이미지 인식 결과 저장 (image_hash 중복 시 기존 행 반환)
+// 기존 행이 피드백으로 신뢰도를 잃은 상태라면 새 인식 결과로 덮어씀
+// 새로 저장했으면 true, 저장도 기존 행 조회도 실패하면 에러
+func storeBeverageImage(image *models.BeverageImage) (*models.BeverageImage, bool, error) {
+	created := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(image)
+	if created.Error == nil && created.RowsAffected > 0 {
+		return image, true, nil
+	}
+
+	var existing models.BeverageImage
+	if err := config.DB.Where("image_hash = ?", image.ImageHash).First(&existing).Error; err != nil {
+		if created.Error != nil {
+			return nil, false, created.Error
+		}
+		return nil, false, err
+	}
+
+	if existing.Confidence < MinCacheConfidence {
+		config.DB.Model(&existing).Updates(map[string]interface{}{
+			"beverage_id":     image.BeverageID,
+			"drink_name":      image.DrinkName,
+			"caffeine_amount": image.CaffeineAmount,
+			"confidence":      image.Confidence,
+			"detections":      image.Detections,
+			"source":          image.Source,
+			"usage_count":     gorm.Expr("usage_count + ?", 1),
+		})
+		return &existing, false, nil
+	}
+
+	config.DB.Model(&existing).UpdateColumn("usage_count", gorm.Expr("usage_count + ?", 1))
+	return &existing, false, nil
+}
+
+// cachedDetections : 캐시 이미지에 저장된 음료 목록 복원
+// 대표 음료는 피드백으로 정정되었을 수 있으므로 이미지 행의 값을 우선함
+func cachedDetections(image *models.BeverageImage, primary *SmartRecognitionResult) []DetectedDrink {
+	var drinks []DetectedDrink
+	if image.Detections != "" {
+		json.Unmarshal([]byte(image.Detections), &drinks)
+	}
+	for i := range drinks {
+		drinks[i].BeverageID = resolveBeverageRef(drinks[i].BeverageID) // 저장 후 병합된 음료
+	}
+
+	head := DetectedDrink{
+		DrinkName:      primary.DrinkName,
+		CaffeineAmount: primary.CaffeineAmount,
+		Confidence:     primary.Confidence,
+		Brand:          primary.Brand,
+		Category:       primary.Category,
+		BeverageID:     image.BeverageID,
+	}
+	if len(drinks) == 0 {
+		return []DetectedDrink{head}
+	}
+
+	head.Region = drinks[0].Region
+	drinks[0] = head
+	return drinks
 }
 
 // calculateHash : 이미지 Base64의 SHA256 해시
@@ -130,16 +484,64 @@ func calculateHash(imageBase64 string) string {
 	return hex.EncodeToString(hash[:])
 }
 
-// logSmartRecognition : 인식 로그 저장
-func logSmartRecognition(userID uint, imageHash string, source string, caffeineAmount int, confidence float64) {
+// logSmartRecognition : 인식 로그 저장 (생성된 로그 ID 반환)
+// llmResult는 LLM을 호출한 경우에만 (캐시/바코드는 nil)
+// 캐시에 저장하지 않은 결과(image가 nil)는 나중에 확인할 수 있도록 후보를 함께 남김
+func logSmartRecognition(userID uint, imageHash string, imagePath string, source string, image *models.BeverageImage, confidence float64, llmResult *LLMRecognitionResult, startTime time.Time) uint {
 	log := models.RecognitionLog{
 		UserID:         userID,
-		ImagePath:      imageHash,
+		ImagePath:      imagePath,
+		ImageHash:      imageHash,
+		Source:         source,
 		Confidence:     confidence,
 		VisionAPIUsed:  source == "llm",
-		ProcessingTime: int(time.Now().UnixMilli() % 10000), // 간단한 타임스탬프
+		ProcessingTime: int(time.Since(startTime).Milliseconds()),
+	}
+	if llmResult != nil {
+		log.PromptVersion = llmResult.PromptVersion
+		log.Provider = llmResult.Provider
+		log.Policy = llmResult.Policy
+	}
+	if image != nil && image.ID != 0 {
+		log.BeverageImageID = &image.ID
+		log.RecognizedID = image.BeverageID
+	} else if llmResult != nil {
+		if len(llmResult.Drinks) > 0 {
+			log.RecognizedID = llmResult.Drinks[0].BeverageID
+		}
+		candidates, _ := json.Marshal(llmResult.Candidates)
+		log.Candidates = string(candidates)
 	}
 	config.DB.Create(&log)
+	return log.ID
+}
+
+// linkRecognitionCandidates : 후보마다 대표 음료를 음료 DB와 연결
+// 대표 결과는 이미 찾거나 만든 음료를 쓰고, 다른 후보는 이미 있는 음료만 연결 (틀린 추측으로 음료를 만들지 않음)
+// 동시 요청끼리 공유한 결과일 수 있으므로 후보는 복사해서 수정
+func linkRecognitionCandidates(llmResult *LLMRecognitionResult, userID uint) {
+	llmResult.Candidates = append([]RecognitionCandidate(nil), llmResult.Candidates...)
+	for i := range llmResult.Candidates {
+		candidate := &llmResult.Candidates[i]
+		if i == 0 && len(llmResult.Drinks) > 0 {
+			candidate.Drinks = cloneDetectedDrinks(llmResult.Drinks)
+			candidate.BeverageID = cloneUintPtr(candidate.Drinks[0].BeverageID)
+			continue
+		}
+		if candidate.Brand == "" {
+			continue
+		}
+		var beverage models.Beverage
+		if err := config.DB.Scopes(VisibleBeverages(userID)).
+			Where("name = ?", candidate.DrinkName).
+			First(&beverage).Error; err == nil {
+			candidate.BeverageID = &beverage.ID
+			if len(candidate.Drinks) > 0 {
+				candidate.Drinks = append([]DetectedDrink(nil), candidate.Drinks...)
+				candidate.Drinks[0].BeverageID = &beverage.ID
+			}
+		}
+	}
 }
 
 // GetRecognitionStats : 인식 통계
@@ -0,0 +1,426 @@
+package services
+
+import (
+	"caffy-backend/config"
+	"caffy-backend/models"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========================================
+// 텍스트 카페인 추정 (음료 DB → 캐시 → LLM 순)
+// ========================================
+
+// 텍스트 추정 결과 출처
+const (
+	TextSourceCatalog = "catalog" // 음료 DB에서 찾음
+	TextSourceCache   = "cache"   // 이전 LLM 추정 결과 재사용
+	TextSourceLLM     = "llm"     // 이번에 LLM이 추정
+)
+
+// proposeMinConfidence : LLM 추정 결과를 미검증 음료로 등록 제안하는 최소 확신도
+const proposeMinConfidence = 0.7
+
+// catalogCandidateLimit : 음료 DB 매칭 시 살펴볼 후보 수
+const catalogCandidateLimit = 200
+
+// drinkSizeAliases : 사이즈 표기 → 정규화된 사이즈 키
+var drinkSizeAliases = map[string]string{
+	"short": "short", "숏": "short",
+	"tall": "tall", "톨": "tall",
+	"grande": "grande", "그란데": "grande", "그랑데": "grande",
+	"venti": "venti", "벤티": "venti",
+	"trenta": "trenta", "트렌타": "trenta",
+	"small": "small", "스몰": "small",
+	"medium": "medium", "미디엄": "medium",
+	"regular": "regular", "레귤러": "regular",
+	"large": "large", "라지": "large",
+	"extra": "extra", "엑스트라": "extra",
+}
+
+// volumeTokenPattern : "355ml", "355" 같은 용량 표기
+var volumeTokenPattern = regexp.MustCompile(`^(\d{2,4})(ml|미리|밀리)?$`)
+
+// EstimateCaffeineByText : 음료명+사이즈로 카페인 추정
+// 음료 DB(정규화된 이름/별칭)에서 먼저 찾고, 없으면 캐시된 LLM 추정, 그래도 없으면 LLM 호출
+// 응답의 Source로 어디서 답했는지 알려줌
+func EstimateCaffeineByText(drinkName string, size string, sizeML int, userID uint) (*TextRecognitionResult, error) {
+	name, querySize, queryML := splitDrinkQuery(NormalizeDrinkQuery(drinkName))
+	if name == "" {
+		return nil, fmt.Errorf("음료 이름을 알 수 없습니다")
+	}
+	if size == "" {
+		size = querySize
+	}
+	if sizeML <= 0 {
+		sizeML = queryML
+	}
+	sizeKey := normalizeSizeKey(size, sizeML)
+
+	// 1. 음료 DB
+	if result := estimateFromCatalog(name, sizeKey); result != nil {
+		result.Size, result.SizeML = size, sizeML
+		println("📚 음료 DB에서 찾음:", result.DrinkName, result.CaffeineAmount, "mg")
+		return result, nil
+	}
+
+	// 2. 이전 LLM 추정 캐시
+	if result := estimateFromCache(name, sizeKey); result != nil {
+		result.Size, result.SizeML = size, sizeML
+		println("💾 추정 캐시 사용:", result.DrinkName, result.CaffeineAmount, "mg")
+		return result, nil
+	}
+
+	// 3. LLM
+	result, err := estimateCaffeineWithLLM(drinkName, size, sizeML)
+	if err != nil {
+		return nil, err
+	}
+	result.Source = TextSourceLLM
+	result.Size, result.SizeML = size, sizeML
+
+	if config.TextEstimateProposeBeverages {
+		if beverage := proposeBeverageFromEstimate(result, size, sizeML, userID); beverage != nil {
+			result.BeverageID = &beverage.ID
+		}
+	}
+	storeTextEstimate(name, sizeKey, result)
+	return result, nil
+}
+
+// NormalizeDrinkQuery : 음료 검색어 정규화 (소문자, 문장부호 제거, 공백 정리, 별칭 치환)
+// 치환할 별칭은 별칭 테이블의 term 항목 (BeverageAlias)
+func NormalizeDrinkQuery(query string) string {
+	return normalizeWithTerms(query, aliasSnapshot().terms)
+}
+
+// normalizeWithTerms : 주어진 치환표로 검색어 정규화
+func normalizeWithTerms(query string, terms map[string]string) string {
+	tokens := strings.Fields(stripPunctuation(query))
+	// "red bull"처럼 띄어 쓴 별칭도 치환
+	for i := 0; i+1 < len(tokens); i++ {
+		if term, ok := terms[tokens[i]+tokens[i+1]]; ok {
+			tokens = append(tokens[:i], append([]string{term}, tokens[i+2:]...)...)
+		}
+	}
+	for i, token := range tokens {
+		if term, ok := terms[token]; ok {
+			tokens[i] = term
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+// stripPunctuation : 소문자로 바꾸고 글자/숫자가 아닌 문자는 공백으로
+func stripPunctuation(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	return b.String()
+}
+
+// splitDrinkQuery : 정규화된 검색어에서 사이즈/용량 표기를 분리
+// 예: "스타벅스 아메리카노 tall" → ("스타벅스 아메리카노", "tall", 0)
+func splitDrinkQuery(normalized string) (name string, size string, sizeML int) {
+	var rest []string
+	tokens := strings.Fields(normalized)
+	for i, token := range tokens {
+		if key, ok := drinkSizeAliases[token]; ok && size == "" {
+			size = key
+			continue
+		}
+		if token == "ml" && i > 0 && sizeML > 0 {
+			continue
+		}
+		// 첫 토큰이 숫자면 음료 이름의 일부일 수 있음 (예: "2% 부족할때")
+		if m := volumeTokenPattern.FindStringSubmatch(token); m != nil && i > 0 && sizeML == 0 {
+			if m[2] != "" || (i+1 < len(tokens) && tokens[i+1] == "ml") {
+				sizeML, _ = strconv.Atoi(m[1])
+				continue
+			}
+		}
+		rest = append(rest, token)
+	}
+	return strings.Join(rest, " "), size, sizeML
+}
+
+// normalizeSizeKey : 사이즈 표기를 캐시/비교용 키로 변환 (용량이 있으면 "355ml")
+func normalizeSizeKey(size string, sizeML int) string {
+	if sizeML > 0 {
+		return fmt.Sprintf("%dml", sizeML)
+	}
+	size = strings.ToLower(strings.TrimSpace(size))
+	if key, ok := drinkSizeAliases[size]; ok {
+		return key
+	}
+	if m := volumeTokenPattern.FindStringSubmatch(strings.ReplaceAll(size, " ", "")); m != nil {
+		return m[1] + "ml"
+	}
+	return size
+}
+
+// requestedVolumeML : 사이즈 키의 용량 (ml 표기 또는 브랜드 컵 사이즈 표, 모르면 0)
+func requestedVolumeML(brand string, sizeKey string) float64 {
+	if strings.HasSuffix(sizeKey, "ml") {
+		ml, _ := strconv.Atoi(strings.TrimSuffix(sizeKey, "ml"))
+		return float64(ml)
+	}
+	brand = NormalizeDrinkQuery(brand)
+	for _, cup := range cupSizeTable {
+		if NormalizeDrinkQuery(cup.Brand) == brand && strings.ToLower(cup.Size) == sizeKey {
+			return float64(cup.VolumeML)
+		}
+	}
+	return 0
+}
+
+// compactQuery : 비교용으로 공백 제거
+func compactQuery(s string) string {
+	return strings.ReplaceAll(s, " ", "")
+}
+
+// estimateFromCatalog : 음료 DB에서 이름이 맞는 음료 찾기
+// 요청한 사이즈와 다르면 용량 비례로 환산하고, 환산할 수 없으면 nil (LLM에 맡김)
+func estimateFromCatalog(name string, sizeKey string) *TextRecognitionResult {
+	if config.DB == nil {
+		return nil
+	}
+
+	// 별칭이 가리키는 음료가 있으면 바로 사용 (예: "아아" → 등록된 아이스 아메리카노)
+	if beverage := findBeverageByAlias(name); beverage != nil {
+		return catalogResult(beverage, sizeKey)
+	}
+
+	tokens := strings.Fields(name)
+	longest := ""
+	for _, token := range tokens {
+		if len([]rune(token)) > len([]rune(longest)) {
+			longest = token
+		}
+	}
+	if len([]rune(longest)) < 2 {
+		return nil
+	}
+
+	var candidates []models.Beverage
+	config.DB.Preload("Sizes").
+		Where("status = ?", models.BeverageStatusActive).
+		Where("LOWER(name) LIKE ? OR LOWER(brand) LIKE ?", "%"+longest+"%", "%"+longest+"%").
+		Limit(catalogCandidateLimit).
+		Find(&candidates)
+
+	query := compactQuery(name)
+	var best *models.Beverage
+	bestScore := 0
+	for i := range candidates {
+		candidate := &candidates[i]
+		nameOnly := compactQuery(NormalizeDrinkQuery(candidate.Name))
+		full := compactQuery(NormalizeDrinkQuery(candidate.Brand + " " + candidate.Name))
+
+		score := 0
+		if query == nameOnly || query == full {
+			score = 100
+		} else {
+			matched := true
+			for _, token := range tokens {
+				if !strings.Contains(full, token) {
+					matched = false
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+			// 검색어에 없는 글자가 적을수록 가까운 음료
+			score = 50 - min(40, (len([]rune(full))-len([]rune(query)))/2)
+		}
+		if sizeKey != "" && catalogHasSize(candidate, sizeKey) {
+			score += 20
+		}
+		if candidate.IsVerified {
+			score += 5
+		}
+		if score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return catalogResult(best, sizeKey)
+}
+
+// catalogResult : 음료 DB 음료로 추정 결과 생성 (요청한 사이즈의 값을 모르면 nil)
+func catalogResult(best *models.Beverage, sizeKey string) *TextRecognitionResult {
+	choice := catalogSizeChoice(best, sizeKey)
+	if choice == nil {
+		return nil
+	}
+
+	confidence := 0.8
+	if best.IsVerified {
+		confidence = 0.95
+	}
+	description := "음료 DB에서 찾은 값입니다"
+	if choice.Interpolated {
+		confidence -= 0.15
+		description = fmt.Sprintf("음료 DB의 사이즈별 값으로 %gml 기준 환산했습니다", choice.VolumeML)
+	}
+
+	return &TextRecognitionResult{
+		DrinkName:      best.Name,
+		CaffeineAmount: int(math.Round(choice.CaffeineAmount)),
+		Confidence:     confidence,
+		Description:    description,
+		Brand:          best.Brand,
+		Category:       best.Category,
+		Source:         TextSourceCatalog,
+		BeverageID:     &best.ID,
+		BeverageSizeID: choice.SizeID,
+		IsVerified:     best.IsVerified,
+	}
+}
+
+// catalogHasSize : 음료에 요청한 사이즈가 등록되어 있는지 (사이즈 목록이 없으면 음료의 Size로 판단)
+func catalogHasSize(beverage *models.Beverage, sizeKey string) bool {
+	if len(beverage.Sizes) == 0 {
+		return normalizeSizeKey(beverage.Size, 0) == sizeKey
+	}
+	if strings.HasSuffix(sizeKey, "ml") {
+		volume := requestedVolumeML(beverage.Brand, sizeKey)
+		for _, size := range beverage.Sizes {
+			if math.Abs(size.VolumeML-volume) <= sameVolumeToleranceML {
+				return true
+			}
+		}
+		return false
+	}
+	return findSizeByLabel(beverage.Sizes, sizeKey) != nil
+}
+
+// catalogSizeChoice : 음료 DB 음료에서 요청한 사이즈의 값
+// 사이즈 이름이 등록되어 있지 않으면 컵 사이즈 표의 용량으로 보간, 그것도 안 되면 nil (LLM에 맡김)
+func catalogSizeChoice(beverage *models.Beverage, sizeKey string) *SizeChoice {
+	var choice *SizeChoice
+	var err error
+	switch {
+	case sizeKey == "":
+		choice, err = ResolveBeverageSize(beverage, nil, "", 0)
+	case strings.HasSuffix(sizeKey, "ml"):
+		choice, err = ResolveBeverageSize(beverage, nil, "", requestedVolumeML(beverage.Brand, sizeKey))
+	default:
+		choice, err = ResolveBeverageSize(beverage, nil, sizeKey, 0)
+		if errors.Is(err, ErrBeverageSizeNotFound) {
+			if volume := requestedVolumeML(beverage.Brand, sizeKey); volume > 0 {
+				choice, err = ResolveBeverageSize(beverage, nil, "", volume)
+			}
+		}
+	}
+	if err != nil {
+		return nil
+	}
+	return choice
+}
+
+// estimateFromCache : 같은 이름+사이즈의 이전 LLM 추정 결과 (TEXT_ESTIMATE_CACHE_DAYS 이내)
+func estimateFromCache(name string, sizeKey string) *TextRecognitionResult {
+	if config.DB == nil {
+		return nil
+	}
+
+	var cached models.TextEstimateCache
+	err := config.DB.
+		Where("normalized_name = ? AND size_key = ?", name, sizeKey).
+		Where("updated_at > ?", time.Now().AddDate(0, 0, -config.TextEstimateCacheDays)).
+		First(&cached).Error
+	if err != nil {
+		return nil
+	}
+	config.DB.Model(&cached).UpdateColumn("hit_count", gorm.Expr("hit_count + ?", 1))
+
+	return &TextRecognitionResult{
+		DrinkName:      cached.DrinkName,
+		CaffeineAmount: cached.CaffeineAmount,
+		Confidence:     cached.Confidence,
+		Description:    "이전에 추정한 값입니다",
+		Brand:          cached.Brand,
+		Category:       cached.Category,
+		PromptVersion:  cached.PromptVersion,
+		Source:         TextSourceCache,
+		BeverageID:     cached.BeverageID,
+	}
+}
+
+// storeTextEstimate : LLM 추정 결과를 캐시에 저장 (기간이 지난 같은 키는 덮어씀)
+func storeTextEstimate(name string, sizeKey string, result *TextRecognitionResult) {
+	if config.DB == nil {
+		return
+	}
+
+	entry := models.TextEstimateCache{
+		NormalizedName: name,
+		SizeKey:        sizeKey,
+		DrinkName:      result.DrinkName,
+		Brand:          result.Brand,
+		Category:       result.Category,
+		CaffeineAmount: result.CaffeineAmount,
+		Confidence:     result.Confidence,
+		PromptVersion:  result.PromptVersion,
+		BeverageID:     result.BeverageID,
+	}
+	config.DB.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{
+			"drink_name", "brand", "category", "caffeine_amount", "confidence",
+			"prompt_version", "beverage_id", "updated_at",
+		}),
+	}).Create(&entry)
+}
+
+// proposeBeverageFromEstimate : 확신도 높은 LLM 추정 결과를 승인 대기 음료로 등록
+// 같은 이름의 음료가 이미 있으면 새로 만들지 않음
+func proposeBeverageFromEstimate(result *TextRecognitionResult, size string, sizeML int, userID uint) *models.Beverage {
+	if result.Brand == "" || result.Confidence < proposeMinConfidence {
+		return nil
+	}
+
+	beverage := models.Beverage{
+		Name:           result.DrinkName,
+		Brand:          result.Brand,
+		CaffeineAmount: float64(result.CaffeineAmount),
+		Size:           displaySize(size),
+		Volume:         float64(sizeML),
+		Category:       result.Category,
+		IsVerified:     false,
+		Status:         models.BeverageStatusPending,
+		CreatedByUser:  userID,
+	}
+	created := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&beverage)
+	if created.Error != nil || created.RowsAffected == 0 {
+		return nil
+	}
+	submitRecognizedBeverage(&beverage, ChangeSourceTextEstimate)
+	return &beverage
+}
+
+// displaySize : 사이즈 키를 음료 DB 표기로 (tall → Tall)
+func displaySize(size string) string {
+	key := normalizeSizeKey(size, 0)
+	if key == "" || strings.HasSuffix(key, "ml") {
+		return key
+	}
+	return strings.ToUpper(key[:1]) + key[1:]
+}
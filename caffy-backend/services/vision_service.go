@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 )
 
 // GetVisionAPIKey : 환경변수에서 API 키 가져오기
@@ -136,43 +135,10 @@ func AnalyzeImage(imageData []byte) (*VisionResult, error) {
 }
 
 // ExtractCaffeineInfo : OCR 텍스트에서 카페인 정보 추출
+// 100ml당/1회 제공량당 표기는 감지된 용량으로 제품 전체 함량을 계산 (ParseCaffeineLabel 참고)
 func ExtractCaffeineInfo(ocrText string) (caffeineAmount float64, productName string) {
-	lines := strings.Split(ocrText, "\n")
-
-	// 카페인 함량 패턴 찾기 (예: "카페인 150mg", "caffeine 150mg")
-	for _, line := range lines {
-		lower := strings.ToLower(line)
-
-		// 카페인/caffeine 키워드 찾기
-		if strings.Contains(lower, "카페인") || strings.Contains(lower, "caffeine") {
-			// 숫자 추출 시도
-			var mg float64
-			if _, err := fmt.Sscanf(lower, "%*s %f", &mg); err == nil && mg > 0 {
-				caffeineAmount = mg
-			}
-			// mg 앞의 숫자 찾기
-			for i, c := range lower {
-				if c >= '0' && c <= '9' {
-					var num float64
-					fmt.Sscanf(lower[i:], "%f", &num)
-					if num > 0 && num < 1000 { // 합리적인 범위
-						caffeineAmount = num
-						break
-					}
-				}
-			}
-		}
-	}
-
-	// 제품명은 첫 몇 줄에서 추출 (보통 상단에 있음)
-	if len(lines) > 0 {
-		productName = strings.TrimSpace(lines[0])
-		if len(productName) > 100 {
-			productName = productName[:100]
-		}
-	}
-
-	return caffeineAmount, productName
+	info := ParseCaffeineLabel(ocrText)
+	return info.TotalCaffeine, info.ProductName
 }
 
 // SetVisionAPIKey : API 키 설정 (테스트용)